@@ -0,0 +1,43 @@
+package sqleditor
+
+import "DBHS/utils"
+
+const (
+	// the settings only last until the end of the transaction of the query, the role drops the
+	// privileges of the platform user for the statements of the editor
+	SET_QUERY_LIMITS = `SELECT set_config('statement_timeout', $1, true), set_config('application_name', $2, true),
+        set_config('role', $3, true)`
+
+	// the editor role is shared by the project databases but only holds privileges on the objects of
+	// the user schemas of each one. the default privileges cover the schemas and tables created later
+	// by the platform user, the internal schema, the server files and the server administration stay
+	// out of reach of the role
+	ENSURE_EDITOR_ROLE = `DO $editor$
+        DECLARE
+            schema_name text;
+        BEGIN
+            IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '` + editorRole + `') THEN
+                BEGIN
+                    CREATE ROLE ` + editorRole + ` NOLOGIN NOINHERIT;
+                EXCEPTION WHEN duplicate_object OR unique_violation THEN
+                    NULL;
+                END;
+            END IF;
+            FOR schema_name IN SELECT n.nspname FROM pg_namespace n WHERE ` + utils.UserSchemasFilter + ` LOOP
+                EXECUTE format('GRANT USAGE ON SCHEMA %I TO ` + editorRole + `', schema_name);
+                EXECUTE format('GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA %I TO ` + editorRole + `', schema_name);
+                EXECUTE format('GRANT USAGE, SELECT, UPDATE ON ALL SEQUENCES IN SCHEMA %I TO ` + editorRole + `', schema_name);
+            END LOOP;
+            ALTER DEFAULT PRIVILEGES GRANT USAGE ON SCHEMAS TO ` + editorRole + `;
+            ALTER DEFAULT PRIVILEGES GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO ` + editorRole + `;
+            ALTER DEFAULT PRIVILEGES GRANT USAGE, SELECT, UPDATE ON SEQUENCES TO ` + editorRole + `;
+        END
+        $editor$`
+
+	CANCEL_BACKEND = `SELECT COALESCE((SELECT pg_cancel_backend(pid) FROM pg_stat_activity
+        WHERE pid = $1 AND application_name = $2 AND state = 'active'), false)`
+
+	// the type names are read like in a cast, an unknown type is NULL and an empty name is left to the database
+	RESOLVE_PARAM_TYPES = `SELECT CASE WHEN name <> '' THEN to_regtype(name)::oid END
+        FROM unnest($1::text[]) WITH ORDINALITY AS t(name, n) ORDER BY n`
+)
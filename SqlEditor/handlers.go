@@ -5,7 +5,9 @@ import (
 	"DBHS/response"
 	"DBHS/utils"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -13,27 +15,38 @@ import (
 // ResponseBodySwagger is a swagger documentation model for ResponseBody
 // @Description SQL query execution response with results and metadata
 type ResponseBodySwagger struct {
-	// The JSON result of the query execution
-	Result json.RawMessage `json:"result" swaggertype:"string" example:"[{\"id\":1,\"name\":\"test\"}]"`
-	// Names of columns in the result set
-	ColumnNames []string `json:"column_names" example:"[\"id\",\"name\"]"`
+	// Columns of the result set in their order, with the names of their Postgres types
+	Columns []ResultColumn `json:"columns"`
+	// Rows of the result set, one value per column. booleans, numbers and json keep their type, other values are given as text
+	Rows [][]any `json:"rows" swaggertype:"array,object" example:"[[1,\"test\"]]"`
+	// The command tag of the statement
+	CommandTag string `json:"command_tag" example:"SELECT 1"`
+	// Rows changed by a data-modifying statement or returned by a SELECT
+	RowsAffected int64 `json:"rows_affected" example:"1"`
 	// Query execution time in milliseconds
 	ExecutionTime float64 `json:"execution_time" example:"10.45"`
+	// True when the query returned more rows than the row limit
+	Truncated bool `json:"truncated" example:"false"`
+	// The query id given in the request
+	QueryID string `json:"query_id,omitempty" example:"3f6c1a52-8d4e-4c1b-9a7e-2b1f0c9d8e7a"`
 }
 
 // RunSqlQuery godoc
 // @Summary Execute SQL query on project database
-// @Description Execute a dynamic SQL query against a specific project's PostgreSQL database and return structured JSON results with metadata including column names and execution time
+// @Description Execute a dynamic SQL query against a specific project's PostgreSQL database and return its result set with its columns in order and their Postgres types, its command tag and the rows changed by a data-modifying statement, whose RETURNING rows are returned as its result set. The query is parsed with the PostgreSQL parser: only SELECT, INSERT, UPDATE and DELETE statements are run, server administration and file access functions are denied and the system schemas can't be modified. In explain mode the plan tree of the statement is returned with its costs and row estimates, and with its timings and buffers when analyze is set, along with its hotspots: large sequential scans, row estimate misses and sorts or hashes spilling to disk. An analyzed data-modifying statement is rolled back. Queries are stopped by the statement timeout and return at most the row limit of the platform, max_rows and timeout_ms can only lower them. A query_id chosen by the client, a UUID, lets it cancel the query while it runs. With an Accept header of application/x-ndjson every row is streamed instead, without the row limit: a line with the columns, a line per row and a last line with the summary of the statement or the error that stopped it. A script of several statements returns the result set, command tag, timing and error of each statement it ran, in their order, and marks the statements it did not run after it halted or was cancelled as skipped. Its statements run in transactions of their own, or in a single transaction when transaction is set where a failing statement is rolled back to a savepoint. With stop_on_error the script halts at its first failing statement, whose error is located by line and column in the script, and a single transaction is rolled back. Values are bound to the $n placeholders of the query through params, in their order, or to its :name placeholders when they are named. A parameter is given as a JSON value with an optional Postgres type, a string is read as the text of a value of the type of its placeholder, and there must be a parameter for every placeholder
 // @Tags sqlEditor
 // @Accept json
 // @Produce json
+// @Produce application/x-ndjson
 // @Param project_id path string true "Project ID (OID)"
 // @Param query body sqleditor.RequestBody true "SQL query to execute"
 // @Security BearerAuth
-// @Success 200 {object} response.SuccessResponse{data=sqleditor.ResponseBodySwagger} "Query executed successfully with results, column names, and execution time"
-// @Failure 400 {object} response.ErrorResponse "Project ID missing, invalid request body, empty query, or dangerous SQL operations detected"
+// @Success 200 {object} response.SuccessResponse{data=sqleditor.ResponseBodySwagger} "Query executed successfully with its columns, rows and command tag, a script returns a sqleditor.ScriptResponse"
+// @Failure 400 {object} response.ErrorResponse "Project ID missing, invalid request body, empty query, invalid query ID, SQL syntax error, statement rejected by the editor policy, parameters not matching the placeholders, or script explained or streamed"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized access"
 // @Failure 404 {object} response.ErrorResponse "Project not found or referenced table/column does not exist"
+// @Failure 408 {object} response.ErrorResponse "Query exceeded the statement timeout"
+// @Failure 409 {object} response.ErrorResponse "A query with the same query id is already running"
 // @Failure 500 {object} response.ErrorResponse "Internal server error, query execution failed, or error parsing results"
 // @Router /projects/{project_id}/sqlEditor/run-query [post]
 func RunSqlQuery(app *config.Application) http.HandlerFunc {
@@ -56,16 +69,85 @@ func RunSqlQuery(app *config.Application) http.HandlerFunc {
 			response.BadRequest(w, r, "Query is required", nil)
 			return
 		}
+		if RequestBody.QueryID != "" {
+			if err := ValidateQueryID(RequestBody.QueryID); err != nil {
+				response.BadRequest(w, r, err.Error(), err)
+				return
+			}
+		}
+
+		// Named parameters are bound to positional placeholders
+		query, params, err := BindNamedParams(RequestBody.Query, RequestBody.Params)
+		if err != nil {
+			response.BadRequest(w, r, err.Error(), err)
+			return
+		}
+		RequestBody.Query, RequestBody.Params = query, params
+
+		// Parse the query and check it against the editor policy
+		statements, err := ValidateQuery(RequestBody.Query)
+		if err != nil {
+			var syntaxErr *utils.SQLSyntaxError
+			if errors.As(err, &syntaxErr) {
+				response.BadRequest(w, r, "Invalid SQL syntax", err)
+				return
+			}
+			response.BadRequest(w, r, err.Error(), err)
+			return
+		}
+		if err := CheckParams(statements, RequestBody.Params); err != nil {
+			response.BadRequest(w, r, err.Error(), err)
+			return
+		}
+		if RequestBody.Mode != "" && RequestBody.Mode != RunMode && RequestBody.Mode != ExplainMode {
+			response.BadRequest(w, r, "Mode must be run or explain", nil)
+			return
+		}
+
+		streamed := strings.Contains(r.Header.Get("Accept"), NDJSONContentType)
+		if len(statements) > 1 {
+			if RequestBody.Mode == ExplainMode || streamed {
+				response.BadRequest(w, r, "Only a single statement can be explained or streamed at a time", nil)
+				return
+			}
+			script, apiErr := GetScriptResponse(r.Context(), config.DB, projectOid, RequestBody.Query, statements, RequestBody)
+			if apiErr.Error() != nil {
+				utils.ResponseHandler(w, r, apiErr)
+				return
+			}
+			response.OK(w, r, "Script executed successfully", script)
+			config.App.InfoLog.Println("Script executed successfully for project:", projectOid)
+			return
+		}
 
-		// Validate query for dangerous operations
-		isValid, err := ValidateQuery(RequestBody.Query)
-		if !isValid {
-			response.BadRequest(w, r, err.Error(), nil)
+		if RequestBody.Mode == ExplainMode {
+			explained, apiErr := GetExplainResponse(r.Context(), config.DB, projectOid, statements[0], RequestBody)
+			if apiErr.Error() != nil {
+				utils.ResponseHandler(w, r, apiErr)
+				return
+			}
+			response.OK(w, r, "Query explained successfully", explained)
+			return
+		}
+
+		if streamed {
+			stream := NewNDJSONWriter(w)
+			apiErr := StreamQueryResponse(r.Context(), config.DB, projectOid, statements[0], RequestBody, stream)
+			if apiErr.Error() != nil {
+				// once the rows are streamed the error can only be reported on a line of its own
+				if stream.Started() {
+					stream.Fail(apiErr.Message)
+					return
+				}
+				utils.ResponseHandler(w, r, apiErr)
+				return
+			}
+			config.App.InfoLog.Println("Query streamed successfully for project:", projectOid)
 			return
 		}
 
 		// Get the query response
-		queryResponse, apiErr := GetQueryResponse(r.Context(), config.DB, projectOid, RequestBody.Query)
+		queryResponse, apiErr := GetQueryResponse(r.Context(), config.DB, projectOid, statements[0], RequestBody)
 		if apiErr.Error() != nil {
 			utils.ResponseHandler(w, r, apiErr)
 			return
@@ -75,3 +157,39 @@ func RunSqlQuery(app *config.Application) http.HandlerFunc {
 		config.App.InfoLog.Println("Query executed successfully for project:", projectOid)
 	}
 }
+
+// CancelSqlQuery godoc
+// @Summary Cancel a running SQL query
+// @Description Cancel a query of the user still running on the project database, by the query id given when it was run
+// @Tags sqlEditor
+// @Produce json
+// @Param project_id path string true "Project ID (OID)"
+// @Param query_id path string true "Query ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Query cancellation requested"
+// @Failure 400 {object} response.ErrorResponse "Project ID or query ID missing, or query ID not a UUID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized access"
+// @Failure 404 {object} response.ErrorResponse "Project not found or query not running"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/sqlEditor/queries/{query_id}/cancel [post]
+func CancelSqlQuery(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		projectOid, queryID := urlVariables["project_id"], urlVariables["query_id"]
+		if projectOid == "" || queryID == "" {
+			response.BadRequest(w, r, "Project Id and Query Id are required", nil)
+			return
+		}
+		if err := ValidateQueryID(queryID); err != nil {
+			response.BadRequest(w, r, err.Error(), err)
+			return
+		}
+
+		if apiErr := CancelQuery(r.Context(), config.DB, projectOid, queryID); apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Query cancellation requested", nil)
+	}
+}
@@ -1,13 +1,167 @@
 package sqleditor
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RunMode     = "run"
+	ExplainMode = "explain"
+)
 
 type RequestBody struct {
 	Query string `json:"query"`
+	// Mode runs the query, the default, or explains it
+	Mode string `json:"mode,omitempty" enums:"run,explain"`
+	// Analyze runs the explained statement to time its plan, data-modifying statements are rolled back
+	Analyze bool `json:"analyze,omitempty"`
+	// QueryID is a UUID chosen by the client to cancel the query while it runs
+	QueryID string `json:"query_id,omitempty"`
+	// MaxRows and TimeoutMs lower the row limit and the statement timeout of the platform
+	MaxRows   int `json:"max_rows,omitempty"`
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+	// Transaction runs the statements of a script in a single transaction
+	Transaction bool `json:"transaction,omitempty"`
+	// StopOnError halts a script at its first failing statement
+	StopOnError bool `json:"stop_on_error,omitempty"`
+	// Params are bound to the $n placeholders of the query in their order, or to its :name
+	// placeholders when they are named
+	Params []QueryParam `json:"params,omitempty"`
+}
+
+// QueryParam is the value of a placeholder. a string is bound as the text Postgres reads for a value
+// of the type of the placeholder, and arrays and objects as their JSON text. Type is the Postgres
+// type of the placeholder, such as integer or timestamptz, it is inferred by the database when empty
+type QueryParam struct {
+	Name  string          `json:"name,omitempty"`
+	Value json.RawMessage `json:"value" swaggertype:"string" example:"42"`
+	Type  string          `json:"type,omitempty" example:"integer"`
+}
+
+// ResultColumn is a column of a result set, Type is the name of its Postgres type
+type ResultColumn struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	TypeOID uint32 `json:"type_oid"`
+}
+
+// StatementSummary is the outcome of a statement. RowsAffected is the number of rows changed by a
+// data-modifying statement or the number of rows returned by a SELECT
+type StatementSummary struct {
+	CommandTag    string  `json:"command_tag"`
+	RowsAffected  int64   `json:"rows_affected"`
+	ExecutionTime float64 `json:"execution_time"`
+	// Truncated is true when the statement returned more rows than the row limit
+	Truncated bool   `json:"truncated"`
+	QueryID   string `json:"query_id,omitempty"`
 }
 
+// ResponseBody is the result set of a statement with its rows in the order of its columns, the rows
+// of a data-modifying statement are the ones of its RETURNING clause
 type ResponseBody struct {
-	Result        json.RawMessage `json:"result"`
-	ColumnNames   []string        `json:"column_names"`
-	ExecutionTime float64         `json:"execution_time"`
+	Columns []ResultColumn `json:"columns"`
+	Rows    [][]any        `json:"rows"`
+	StatementSummary
+}
+
+// StatementError is the error of a statement of a script, Line and Column locate it in the script
+type StatementError struct {
+	Message string `json:"message"`
+	// Code is the SQLSTATE of the error, empty when it wasn't reported by the database
+	Code   string `json:"code,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// StatementResult is the result of a statement of a script, Line and Column locate the statement in the script
+type StatementResult struct {
+	SQL    string `json:"sql"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	ResponseBody
+	Error *StatementError `json:"error,omitempty"`
+	// Skipped is true when the script halted before the statement was run
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// ScriptResponse lists the results of the statements of a script in their order. Stopped is true
+// when the script halted at a failing statement or was cancelled, the statements it didn't run are
+// skipped, and RolledBack is true when the changes of a script run in a transaction were rolled back
+type ScriptResponse struct {
+	Statements    []StatementResult `json:"statements"`
+	ExecutionTime float64           `json:"execution_time"`
+	Stopped       bool              `json:"stopped"`
+	RolledBack    bool              `json:"rolled_back"`
+	QueryID       string            `json:"query_id,omitempty"`
+}
+
+// QueryExecution bounds a query of the editor and identifies it for its cancellation
+type QueryExecution struct {
+	QueryID    string
+	OwnerID    int64
+	ProjectOid string
+	Timeout    time.Duration
+	MaxRows    int
+	Params     []QueryParam
+}
+
+// key identifies the execution among the running queries
+func (e QueryExecution) key() queryKey {
+	return queryKey{ownerID: e.OwnerID, projectOid: e.ProjectOid, queryID: e.QueryID}
+}
+
+// PlanNode is a node of a plan printed by EXPLAIN (FORMAT JSON), the keys are the ones of PostgreSQL
+// so the plan can be given to the usual plan visualizers. the actual values are only set by ANALYZE
+type PlanNode struct {
+	// NodeID numbers the nodes depth first from 1, it is not printed by PostgreSQL
+	NodeID              int        `json:"Node Id"`
+	NodeType            string     `json:"Node Type"`
+	ParentRelationship  string     `json:"Parent Relationship,omitempty"`
+	RelationName        string     `json:"Relation Name,omitempty"`
+	Schema              string     `json:"Schema,omitempty"`
+	Alias               string     `json:"Alias,omitempty"`
+	IndexName           string     `json:"Index Name,omitempty"`
+	JoinType            string     `json:"Join Type,omitempty"`
+	Strategy            string     `json:"Strategy,omitempty"`
+	Filter              string     `json:"Filter,omitempty"`
+	IndexCond           string     `json:"Index Cond,omitempty"`
+	SortKey             []string   `json:"Sort Key,omitempty"`
+	SortMethod          string     `json:"Sort Method,omitempty"`
+	SortSpaceUsed       int64      `json:"Sort Space Used,omitempty"`
+	SortSpaceType       string     `json:"Sort Space Type,omitempty"`
+	HashBatches         int64      `json:"Hash Batches,omitempty"`
+	StartupCost         float64    `json:"Startup Cost"`
+	TotalCost           float64    `json:"Total Cost"`
+	PlanRows            float64    `json:"Plan Rows"`
+	PlanWidth           int        `json:"Plan Width"`
+	ActualStartupTime   float64    `json:"Actual Startup Time,omitempty"`
+	ActualTotalTime     float64    `json:"Actual Total Time,omitempty"`
+	ActualRows          float64    `json:"Actual Rows,omitempty"`
+	ActualLoops         float64    `json:"Actual Loops,omitempty"`
+	RowsRemovedByFilter float64    `json:"Rows Removed by Filter,omitempty"`
+	SharedHitBlocks     int64      `json:"Shared Hit Blocks,omitempty"`
+	SharedReadBlocks    int64      `json:"Shared Read Blocks,omitempty"`
+	TempReadBlocks      int64      `json:"Temp Read Blocks,omitempty"`
+	TempWrittenBlocks   int64      `json:"Temp Written Blocks,omitempty"`
+	Plans               []PlanNode `json:"Plans,omitempty"`
+}
+
+// Hotspot is a node of a plan worth a look, Node is the Node Id of the plan node
+type Hotspot struct {
+	Node     int    `json:"node"`
+	NodeType string `json:"node_type"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+}
+
+// ExplainResponse is the plan of an explained statement. Analyzed is true when the statement was run
+// and RolledBack when its changes were rolled back
+type ExplainResponse struct {
+	Plan          PlanNode  `json:"plan"`
+	PlanningTime  float64   `json:"planning_time"`
+	ExecutionTime float64   `json:"execution_time"`
+	Analyzed      bool      `json:"analyzed"`
+	RolledBack    bool      `json:"rolled_back"`
+	Hotspots      []Hotspot `json:"hotspots"`
 }
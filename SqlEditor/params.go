@@ -0,0 +1,158 @@
+package sqleditor
+
+import (
+	"DBHS/utils"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ParamError is returned when the parameters of a query don't match its placeholders
+type ParamError struct {
+	Reason string
+}
+
+func (e *ParamError) Error() string {
+	return e.Reason
+}
+
+// BoundParams are the values of the placeholders of a statement in the text format with the oids of
+// their types, an oid is zero when the type is inferred by the database
+type BoundParams struct {
+	Values [][]byte
+	OIDs   []uint32
+}
+
+// upTo returns the first n parameters, the ones of a statement whose highest placeholder is $n
+func (b BoundParams) upTo(n int) BoundParams {
+	return BoundParams{Values: b.Values[:n], OIDs: b.OIDs[:n]}
+}
+
+// BindNamedParams rewrites the :name placeholders of a query into positional ones, numbered in the
+// order the names first appear, and returns the parameters in that order. the query is read with
+// the PostgreSQL scanner so casts and the content of strings and comments are left alone, and so is
+// a colon inside square brackets, which slices an array. positional parameters are returned as they are
+func BindNamedParams(query string, params []QueryParam) (string, []QueryParam, error) {
+	byName := make(map[string]QueryParam, len(params))
+	for _, param := range params {
+		if param.Name == "" {
+			continue
+		}
+		if _, ok := byName[param.Name]; ok {
+			return "", nil, &ParamError{Reason: fmt.Sprintf("parameter :%s is given twice", param.Name)}
+		}
+		byName[param.Name] = param
+	}
+	if len(byName) == 0 {
+		return query, params, nil
+	}
+	if len(byName) != len(params) {
+		return "", nil, &ParamError{Reason: "parameters must be all named or all positional"}
+	}
+
+	scanned, err := pg_query.Scan(query)
+	if err != nil {
+		// the parser reports the syntax error with its position
+		return query, params, nil
+	}
+
+	var rewritten strings.Builder
+	var ordered []QueryParam
+	numbers := make(map[string]int, len(params))
+	last, brackets := 0, 0
+	tokens := scanned.Tokens
+	for i, token := range tokens {
+		switch token.Token {
+		case pg_query.Token_ASCII_91:
+			brackets++
+		case pg_query.Token_ASCII_93:
+			brackets--
+		}
+		if token.Token != pg_query.Token_ASCII_58 || brackets > 0 || i+1 == len(tokens) || tokens[i+1].Start != token.End {
+			continue
+		}
+		nameToken := tokens[i+1]
+		name := query[nameToken.Start:nameToken.End]
+		if nameToken.Token != pg_query.Token_IDENT && nameToken.KeywordKind == pg_query.KeywordKind_NO_KEYWORD {
+			continue
+		}
+		param, ok := byName[name]
+		if !ok {
+			return "", nil, &ParamError{Reason: fmt.Sprintf("placeholder :%s has no parameter", name)}
+		}
+		number, ok := numbers[name]
+		if !ok {
+			ordered = append(ordered, param)
+			number = len(ordered)
+			numbers[name] = number
+		}
+		rewritten.WriteString(query[last:token.Start])
+		fmt.Fprintf(&rewritten, "$%d", number)
+		last = int(nameToken.End)
+	}
+	rewritten.WriteString(query[last:])
+
+	for _, param := range params {
+		if _, ok := numbers[param.Name]; !ok {
+			return "", nil, &ParamError{Reason: fmt.Sprintf("parameter :%s is not used in the query", param.Name)}
+		}
+	}
+	return rewritten.String(), ordered, nil
+}
+
+// Placeholders returns the number of the highest $n placeholder of a statement, zero when it has none
+func Placeholders(statement utils.ParsedStatement) int {
+	highest := 0
+	utils.WalkParseTree(statement.Node.ProtoReflect(), func(message protoreflect.ProtoMessage) bool {
+		if ref, ok := message.(*pg_query.ParamRef); ok {
+			highest = max(highest, int(ref.Number))
+		}
+		return true
+	})
+	return highest
+}
+
+// CheckParams checks that a parameter is given for every placeholder of a query, the $n placeholder
+// of every statement of a script is bound to the nth parameter
+func CheckParams(statements []utils.ParsedStatement, params []QueryParam) error {
+	highest := 0
+	for _, statement := range statements {
+		highest = max(highest, Placeholders(statement))
+	}
+	if highest != len(params) {
+		return &ParamError{Reason: fmt.Sprintf("the query has %d placeholders but %d parameters were given", highest, len(params))}
+	}
+	return nil
+}
+
+// ParamText returns the text format of the value of a parameter, nil for a JSON null
+func ParamText(value json.RawMessage) ([]byte, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 || string(value) == "null" {
+		return nil, nil
+	}
+	if !json.Valid(value) {
+		return nil, fmt.Errorf("invalid JSON value %s", value)
+	}
+	switch value[0] {
+	case '"':
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			return nil, err
+		}
+		return []byte(text), nil
+	case '{', '[':
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, value); err != nil {
+			return nil, err
+		}
+		return compacted.Bytes(), nil
+	}
+	// numbers and booleans are written the way Postgres reads them
+	return slices.Clone(value), nil
+}
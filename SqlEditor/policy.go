@@ -0,0 +1,159 @@
+package sqleditor
+
+import (
+	"DBHS/utils"
+	"fmt"
+	"slices"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// QueryPolicy decides which queries the SQL editor runs. it is enforced on the PostgreSQL parse tree
+// so casts, dollar quoting, CTEs and subqueries are checked like any other part of a statement
+type QueryPolicy struct {
+	// AllowedStatements are the parse tree names of the statements that can be run, such as SelectStmt
+	AllowedStatements []string
+	// DeniedFunctions can't be called from any part of a statement, whatever schema they are qualified with
+	DeniedFunctions []string
+	// ProtectedSchemas can be read but not modified, an unqualified pg_ relation is in pg_catalog
+	ProtectedSchemas []string
+	// HiddenSchemas can't be referenced at all
+	HiddenSchemas []string
+}
+
+// DefaultPolicy lets the editor read and modify the rows of the project tables
+var DefaultPolicy = QueryPolicy{
+	AllowedStatements: []string{"SelectStmt", "InsertStmt", "UpdateStmt", "DeleteStmt"},
+	DeniedFunctions:   utils.UnsafeFunctions,
+	ProtectedSchemas:  []string{"pg_catalog", "information_schema", "pg_toast"},
+	HiddenSchemas:     []string{utils.InternalSchema},
+}
+
+// PolicyError is returned when a statement is rejected by the policy of the SQL editor
+type PolicyError struct {
+	Reason string
+	Line   int
+}
+
+func (e *PolicyError) Error() string {
+	if e.Line == 0 {
+		return e.Reason
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// ValidateQuery parses a query with the PostgreSQL parser and checks its statements against the default policy
+func ValidateQuery(sqlQuery string) ([]utils.ParsedStatement, error) {
+	return DefaultPolicy.Validate(sqlQuery)
+}
+
+// Validate parses a query with the PostgreSQL parser and checks every statement against the policy,
+// a syntax error is returned as a *utils.SQLSyntaxError and a rejected statement as a *PolicyError
+func (p QueryPolicy) Validate(sqlQuery string) ([]utils.ParsedStatement, error) {
+	statements, err := utils.ParseSQLStatements(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return nil, &PolicyError{Reason: "query does not contain any statement"}
+	}
+
+	for _, statement := range statements {
+		if reason := p.checkStatement(statement.Node); reason != "" {
+			return nil, &PolicyError{Reason: reason, Line: statement.Line}
+		}
+	}
+	return statements, nil
+}
+
+// checkStatement returns why a statement is rejected, or an empty string when it is allowed
+func (p QueryPolicy) checkStatement(node *pg_query.Node) string {
+	top := utils.InnerNode(node)
+	if top == nil {
+		return "query does not contain any statement"
+	}
+	kind := string(top.ProtoReflect().Descriptor().Name())
+	if !slices.Contains(p.AllowedStatements, kind) {
+		return fmt.Sprintf("%s statements are not allowed", statementLabel(kind))
+	}
+
+	var reason string
+	utils.WalkParseTree(node.ProtoReflect(), func(message protoreflect.ProtoMessage) bool {
+		switch n := message.(type) {
+		case *pg_query.SelectStmt:
+			if n.IntoClause != nil {
+				reason = "SELECT INTO creates a table and is not allowed"
+			}
+		case *pg_query.InsertStmt:
+			reason = p.checkModification(n, top, n.Relation)
+		case *pg_query.UpdateStmt:
+			reason = p.checkModification(n, top, n.Relation)
+		case *pg_query.DeleteStmt:
+			reason = p.checkModification(n, top, n.Relation)
+		case *pg_query.MergeStmt:
+			reason = p.checkModification(n, top, n.Relation)
+		case *pg_query.RangeVar:
+			if slices.Contains(p.HiddenSchemas, n.Schemaname) {
+				reason = fmt.Sprintf("schema %s can't be accessed", n.Schemaname)
+			}
+		case *pg_query.FuncCall:
+			reason = p.checkFunction(n)
+		}
+		return reason == ""
+	})
+	return reason
+}
+
+// checkModification rejects the data-modifying statements nested in a SELECT, a query that reads as
+// a SELECT has to be one, and the modification of the relations of the protected and hidden schemas
+func (p QueryPolicy) checkModification(stmt, top protoreflect.ProtoMessage, relation *pg_query.RangeVar) string {
+	if _, read := top.(*pg_query.SelectStmt); read && stmt != top {
+		return "data-modifying statements can't be nested in a SELECT"
+	}
+	if relation == nil {
+		return ""
+	}
+	schema := relation.Schemaname
+	if schema == "" && strings.HasPrefix(strings.ToLower(relation.Relname), "pg_") {
+		schema = "pg_catalog"
+	}
+	if slices.Contains(p.ProtectedSchemas, schema) || slices.Contains(p.HiddenSchemas, schema) {
+		return fmt.Sprintf("cannot modify protected schema %s", schema)
+	}
+	return ""
+}
+
+// checkFunction rejects the denied functions and the functions of the hidden schemas
+func (p QueryPolicy) checkFunction(call *pg_query.FuncCall) string {
+	names := make([]string, len(call.Funcname))
+	for i, name := range call.Funcname {
+		names[i] = name.GetString_().GetSval()
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	function := strings.ToLower(names[len(names)-1])
+	if slices.Contains(p.DeniedFunctions, function) {
+		return fmt.Sprintf("function %s is not allowed", function)
+	}
+	if len(names) > 1 && slices.Contains(p.HiddenSchemas, names[0]) {
+		return fmt.Sprintf("schema %s can't be accessed", names[0])
+	}
+	return ""
+}
+
+// statementLabel turns a statement node name into the words of the statement, CreateTableAsStmt into CREATE TABLE AS
+func statementLabel(kind string) string {
+	kind = strings.TrimSuffix(kind, "Stmt")
+	var words []string
+	start := 0
+	for i := 1; i <= len(kind); i++ {
+		if i == len(kind) || (kind[i] >= 'A' && kind[i] <= 'Z') {
+			words = append(words, strings.ToUpper(kind[start:i]))
+			start = i
+		}
+	}
+	return strings.Join(words, " ")
+}
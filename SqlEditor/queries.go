@@ -0,0 +1,85 @@
+package sqleditor
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrQueryIDInUse   = errors.New("a query with the same id is already running")
+	ErrQueryNotFound  = errors.New("query not found or already finished")
+	ErrInvalidQueryID = errors.New("query id must be a UUID")
+)
+
+// queryKey identifies a running query, the id is chosen by the client so it only names a query
+// among the queries of its owner on a project
+type queryKey struct {
+	ownerID    int64
+	projectOid string
+	queryID    string
+}
+
+// runningQuery is a query of the editor running on a backend of a project database
+type runningQuery struct {
+	pid       uint32
+	cancelled bool
+}
+
+// runningQueries are the queries that can be cancelled by their id
+var runningQueries = struct {
+	sync.Mutex
+	byKey map[queryKey]*runningQuery
+}{byKey: make(map[queryKey]*runningQuery)}
+
+// ValidateQueryID checks that a query id is a UUID in its canonical form, so the application name
+// of the backend running the query stays within the identifier length
+func ValidateQueryID(queryID string) error {
+	if len(queryID) != 36 {
+		return ErrInvalidQueryID
+	}
+	if _, err := uuid.Parse(queryID); err != nil {
+		return ErrInvalidQueryID
+	}
+	return nil
+}
+
+func registerQuery(key queryKey, query *runningQuery) error {
+	runningQueries.Lock()
+	defer runningQueries.Unlock()
+	if _, ok := runningQueries.byKey[key]; ok {
+		return ErrQueryIDInUse
+	}
+	runningQueries.byKey[key] = query
+	return nil
+}
+
+// unregisterQuery forgets a finished query and reports whether it was cancelled
+func unregisterQuery(key queryKey) bool {
+	runningQueries.Lock()
+	defer runningQueries.Unlock()
+	query, ok := runningQueries.byKey[key]
+	delete(runningQueries.byKey, key)
+	return ok && query.cancelled
+}
+
+// markCancelled flags a running query of a user as cancelled and returns the pid of its backend
+func markCancelled(key queryKey) (uint32, error) {
+	runningQueries.Lock()
+	defer runningQueries.Unlock()
+	query, ok := runningQueries.byKey[key]
+	if !ok {
+		return 0, ErrQueryNotFound
+	}
+	query.cancelled = true
+	return query.pid, nil
+}
+
+// queryCancelled reports whether a running query was cancelled
+func queryCancelled(key queryKey) bool {
+	runningQueries.Lock()
+	defer runningQueries.Unlock()
+	query, ok := runningQueries.byKey[key]
+	return ok && query.cancelled
+}
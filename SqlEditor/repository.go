@@ -1,70 +1,474 @@
 package sqleditor
 
 import (
+	"DBHS/utils"
 	api "DBHS/utils/apiError"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/pingcap/tidb/parser"
-	// "github.com/pingcap/tidb/parser/ast"
-	_ "github.com/pingcap/tidb/parser/test_driver"
 )
 
-// validateSQLWithParser validates SQL syntax using TiDB SQL parser
-func validateSQLWithParser(query string) error {
-	p := parser.New()
+// errQueryCancelled is returned when a query was stopped through the cancel endpoint
+var errQueryCancelled = errors.New("query was cancelled")
 
-	// Parse the SQL statement
-	stmts, _, err := p.Parse(query, "", "")
+// timeoutGrace leaves the database the time to report its statement timeout before the request gives up
+const timeoutGrace = 5 * time.Second
+
+// editorRole runs the statements of the editor, it can only read and modify the rows of the project tables
+const editorRole = "dbhs_sqleditor"
+
+// editorRoleGranted holds the projects whose database granted its objects to the editor role since the start
+var editorRoleGranted sync.Map
+
+const (
+	// editorCursor reads the rows of a SELECT of the editor
+	editorCursor = "dbhs_editor_cursor"
+	// fetchBatchRows is the number of rows fetched at a time from the cursor of a streamed SELECT
+	fetchBatchRows = 500
+)
+
+// RunStatement runs a statement of the editor in a transaction of its own and hands its result set to sink
+func RunStatement(ctx context.Context, conn *pgxpool.Pool, statement utils.ParsedStatement, execution QueryExecution, sink resultSink) (StatementSummary, error) {
+	var summary StatementSummary
+	err := runBounded(ctx, conn, execution, true, func(tx pgx.Tx) error {
+		params, err := bindParams(ctx, tx, execution.Params)
+		if err != nil {
+			return err
+		}
+		summary, err = ExecuteStatement(ctx, tx, statement, params.upTo(Placeholders(statement)), execution.MaxRows, sink)
+		return err
+	})
+	return summary, err
+}
+
+// ExecuteStatement runs a statement of the editor in tx with its parameters and hands at most maxRows
+// rows of its result set to sink, every row when maxRows is zero. the values are read in the text
+// format so every type can be returned. a SELECT is read through a cursor so the rows past the limit
+// are never fetched, the RETURNING rows of the other statements past the limit are read and dropped
+func ExecuteStatement(ctx context.Context, tx pgx.Tx, statement utils.ParsedStatement, params BoundParams, maxRows int, sink resultSink) (StatementSummary, error) {
+	startTime := time.Now()
+	var summary StatementSummary
+	var err error
+	if statement.Node.GetSelectStmt() != nil {
+		summary, err = fetchSelect(ctx, tx.Conn().PgConn(), statement.SQL, params, maxRows, sink)
+	} else {
+		summary, err = execStatement(ctx, tx.Conn().PgConn(), statement.SQL, params, maxRows, sink)
+	}
+	summary.ExecutionTime = float64(time.Since(startTime).Nanoseconds()) / 1e6 // Convert to milliseconds as float64
+	return summary, err
+}
+
+// fetchSelect reads a SELECT through a cursor, in a single fetch of one row past the limit or in
+// batches until the cursor is exhausted when every row is read
+func fetchSelect(ctx context.Context, conn *pgconn.PgConn, query string, params BoundParams, maxRows int, sink resultSink) (StatementSummary, error) {
+	// the query is closed on a new line in case it ends with a comment
+	declare := "DECLARE " + editorCursor + " NO SCROLL CURSOR FOR "
+	if _, err := conn.ExecParams(ctx, declare+query+"\n", params.Values, params.OIDs, nil, nil).Close(); err != nil {
+		return StatementSummary{}, shiftErrorPosition(err, len(declare))
+	}
+
+	batch, keep := fetchBatchRows, int64(-1)
+	if maxRows > 0 {
+		batch, keep = maxRows+1, int64(maxRows)
+	}
+	fetch := fmt.Sprintf("FETCH %d FROM %s", batch, editorCursor)
+	var returned int64
+	truncated := false
+	for first := true; ; first = false {
+		read, _, err := readRows(conn.ExecParams(ctx, fetch, nil, nil, nil, nil), sink, first, keep)
+		if err != nil {
+			return StatementSummary{}, err
+		}
+		if keep >= 0 {
+			truncated = read > keep
+			returned = min(read, keep)
+			break
+		}
+		returned += read
+		if read < int64(batch) {
+			break
+		}
+	}
+
+	if _, err := conn.ExecParams(ctx, "CLOSE "+editorCursor, nil, nil, nil, nil).Close(); err != nil {
+		return StatementSummary{}, err
+	}
+	return StatementSummary{CommandTag: fmt.Sprintf("SELECT %d", returned), RowsAffected: returned, Truncated: truncated}, nil
+}
+
+// execStatement runs a statement other than a SELECT and reads the rows of its RETURNING clause
+func execStatement(ctx context.Context, conn *pgconn.PgConn, query string, params BoundParams, maxRows int, sink resultSink) (StatementSummary, error) {
+	keep := int64(-1)
+	if maxRows > 0 {
+		keep = int64(maxRows)
+	}
+	read, tag, err := readRows(conn.ExecParams(ctx, query, params.Values, params.OIDs, nil, nil), sink, true, keep)
 	if err != nil {
-		return fmt.Errorf("SQL syntax error: %v", err)
+		return StatementSummary{}, err
 	}
+	return StatementSummary{CommandTag: tag.String(), RowsAffected: tag.RowsAffected(), Truncated: keep >= 0 && read > keep}, nil
+}
 
-	// Check if we have any statements
-	if len(stmts) == 0 {
-		return errors.New("empty SQL statement")
+// readRows hands the columns, when sendColumns is set, and the first keep rows of a result to sink,
+// every row when keep is negative. the result is closed once read, it returns the number of rows
+// read and the command tag of the statement
+func readRows(result *pgconn.ResultReader, sink resultSink, sendColumns bool, keep int64) (int64, pgconn.CommandTag, error) {
+	fields := result.FieldDescriptions()
+	if sendColumns && len(fields) > 0 {
+		if err := sink.Columns(ResultColumns(fields)); err != nil {
+			result.Close()
+			return 0, pgconn.CommandTag{}, err
+		}
 	}
 
-	return nil
+	var read int64
+	for result.NextRow() {
+		read++
+		if keep >= 0 && read > keep {
+			continue
+		}
+		if err := sink.Row(ResultRow(fields, result.Values())); err != nil {
+			result.Close()
+			return read, pgconn.CommandTag{}, err
+		}
+	}
+	tag, err := result.Close()
+	return read, tag, err
 }
 
-func FetchQueryData(ctx context.Context, conn *pgxpool.Pool, query string) (ResponseBody, api.ApiError) {
-	// Validate SQL syntax using parser
-	if err := validateSQLWithParser(query); err != nil {
-		return ResponseBody{}, *api.NewApiError("Invalid SQL syntax", 400, err)
+// bindParams turns the parameters of a query into the text format and resolves the oids of their types
+func bindParams(ctx context.Context, db pgxscan.Querier, params []QueryParam) (BoundParams, error) {
+	bound := BoundParams{Values: make([][]byte, len(params)), OIDs: make([]uint32, len(params))}
+	typeNames := make([]string, len(params))
+	typed := false
+	for i, param := range params {
+		value, err := ParamText(param.Value)
+		if err != nil {
+			return BoundParams{}, &ParamError{Reason: fmt.Sprintf("parameter %d has an invalid value: %s", i+1, err)}
+		}
+		bound.Values[i] = value
+		typeNames[i] = strings.TrimSpace(param.Type)
+		typed = typed || typeNames[i] != ""
+	}
+	if !typed {
+		return bound, nil
 	}
 
-	wrappedQuery := WrapQueryWithJSONAgg(query)
+	rows, err := db.Query(ctx, RESOLVE_PARAM_TYPES, typeNames)
+	if err != nil {
+		return BoundParams{}, err
+	}
+	oids, err := pgx.CollectRows(rows, pgx.RowTo[*uint32])
+	if err != nil {
+		return BoundParams{}, err
+	}
+	for i, oid := range oids {
+		if typeNames[i] == "" {
+			continue
+		}
+		if oid == nil {
+			return BoundParams{}, &ParamError{Reason: fmt.Sprintf("parameter %d has an unknown type %s", i+1, typeNames[i])}
+		}
+		bound.OIDs[i] = *oid
+	}
+	return bound, nil
+}
 
-	var result string
+// RunScript runs the statements of a script in their order, each one in a transaction of its own or
+// all of them in a single transaction, where a failing statement is rolled back to a savepoint. the
+// script halts at its first failing statement when stopOnError is set, a single transaction is then
+// rolled back. a cancelled script or one that ran out of time halts at the statement that was running
+// or before the next one, the statements that weren't run are reported as skipped
+func RunScript(ctx context.Context, conn *pgxpool.Pool, script string, statements []utils.ParsedStatement, execution QueryExecution, transaction, stopOnError bool) (ScriptResponse, error) {
+	response := ScriptResponse{Statements: []StatementResult{}}
 	startTime := time.Now()
-	err := conn.QueryRow(ctx, wrappedQuery).Scan(&result)
+
+	// record adds the result of a statement to the response and reports whether the script halts
+	record := func(ctx context.Context, statement utils.ParsedStatement, result *bufferedResult, summary StatementSummary, err error) bool {
+		if err != nil {
+			// the rows read before a failure are dropped
+			result, summary = &bufferedResult{}, StatementSummary{ExecutionTime: summary.ExecutionTime}
+		}
+		statementResult := StatementResult{SQL: statement.SQL, Line: statement.Line, Column: statement.Column, ResponseBody: result.response(summary)}
+		if err != nil {
+			statementResult.Error = statementError(err, statement, script, execution)
+			response.Stopped = stopOnError || ctx.Err() != nil || queryCancelled(execution.key())
+		}
+		response.Statements = append(response.Statements, statementResult)
+		return response.Stopped
+	}
+
+	// halted reports whether the script was cancelled or ran out of time before its next statement
+	halted := func(ctx context.Context) bool {
+		if ctx.Err() != nil || queryCancelled(execution.key()) {
+			response.Stopped = true
+		}
+		return response.Stopped
+	}
+
+	// skip adds the statements the script didn't run to the response
+	skip := func(remaining []utils.ParsedStatement) {
+		for _, statement := range remaining {
+			response.Statements = append(response.Statements, StatementResult{SQL: statement.SQL, Line: statement.Line,
+				Column: statement.Column, ResponseBody: (&bufferedResult{}).response(StatementSummary{}), Skipped: true})
+		}
+	}
+
+	budget := execution.Timeout * time.Duration(len(statements))
+	err := runOnBackend(ctx, conn, execution, budget, func(ctx context.Context, backend *pgxpool.Conn) error {
+		params, err := bindParams(ctx, backend, execution.Params)
+		if err != nil {
+			return err
+		}
+
+		if !transaction {
+			for i, statement := range statements {
+				if halted(ctx) {
+					skip(statements[i:])
+					break
+				}
+				result := &bufferedResult{}
+				var summary StatementSummary
+				err := runInTransaction(ctx, backend, execution, true, func(tx pgx.Tx) error {
+					var err error
+					summary, err = ExecuteStatement(ctx, tx, statement, params.upTo(Placeholders(statement)), execution.MaxRows, result)
+					return err
+				})
+				if record(ctx, statement, result, summary, err) {
+					skip(statements[i+1:])
+					break
+				}
+			}
+			return nil
+		}
+
+		return runInTransaction(ctx, backend, execution, false, func(tx pgx.Tx) error {
+			for i, statement := range statements {
+				if halted(ctx) {
+					skip(statements[i:])
+					response.RolledBack = true
+					return nil
+				}
+				result := &bufferedResult{}
+				summary, err := executeInSavepoint(ctx, tx, statement, params.upTo(Placeholders(statement)), execution.MaxRows, result, !stopOnError)
+				if record(ctx, statement, result, summary, err) {
+					skip(statements[i+1:])
+					response.RolledBack = true
+					return nil
+				}
+			}
+			return tx.Commit(ctx)
+		})
+	})
 	if err != nil {
-		if strings.Contains(err.Error(), "does not exist") {
-			return ResponseBody{}, *api.NewApiError("Table/Column not found", 404, errors.New("Table or column does not exist: "+err.Error()))
+		return ScriptResponse{}, err
+	}
+
+	response.ExecutionTime = float64(time.Since(startTime).Nanoseconds()) / 1e6
+	return response, nil
+}
+
+// executeInSavepoint runs a statement of a script in the transaction of the script, behind a
+// savepoint when savepoint is set so a failure only rolls back the statement
+func executeInSavepoint(ctx context.Context, tx pgx.Tx, statement utils.ParsedStatement, params BoundParams, maxRows int, sink resultSink, savepoint bool) (StatementSummary, error) {
+	if !savepoint {
+		return ExecuteStatement(ctx, tx, statement, params, maxRows, sink)
+	}
+	nested, err := tx.Begin(ctx)
+	if err != nil {
+		return StatementSummary{}, err
+	}
+	summary, err := ExecuteStatement(ctx, nested, statement, params, maxRows, sink)
+	if err != nil {
+		if rollbackErr := nested.Rollback(ctx); rollbackErr != nil {
+			return summary, errors.Join(err, rollbackErr)
 		}
-		if strings.Contains(err.Error(), "cannot scan NULL into *string") {
-			return ResponseBody{}, *api.NewApiError("There is no data", 200, errors.New("No data found for the query"))
+		return summary, err
+	}
+	return summary, nested.Commit(ctx)
+}
+
+// statementError describes the error of a statement of a script, located in the script at the
+// position reported by the database or else at the statement
+func statementError(err error, statement utils.ParsedStatement, script string, execution QueryExecution) *StatementError {
+	statementErr := &StatementError{Message: err.Error(), Line: statement.Line, Column: statement.Column}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		statementErr.Message, statementErr.Code = pgErr.Message, pgErr.Code
+		if pgErr.Position > 0 {
+			statementErr.Line, statementErr.Column = statement.ScriptPosition(script, int(pgErr.Position))
 		}
-		return ResponseBody{}, *api.NewApiError("Query execution failed", 500, errors.New("failed to execute query: "+err.Error()))
 	}
+	if queryCancelled(execution.key()) {
+		statementErr.Message = "Query was cancelled"
+	} else if isQueryCanceled(err) || errors.Is(err, context.DeadlineExceeded) {
+		statementErr.Message = fmt.Sprintf("Query exceeded the statement timeout of %s", execution.Timeout)
+	}
+	return statementErr
+}
 
-	// Extract column names from the JSON result
-	columnNames, err := ExtractColumnNames(result)
+// shiftErrorPosition moves the position of a database error back by the characters of a prefix added
+// to a statement, so it points into the statement of the user
+func shiftErrorPosition(err error, prefix int) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && int(pgErr.Position) > prefix {
+		shifted := *pgErr
+		shifted.Position -= int32(prefix)
+		return &shifted
+	}
+	return err
+}
 
+// ExplainStatement returns the EXPLAIN (FORMAT JSON) output of a statement. it runs in a transaction
+// that is always rolled back, so an analyzed data-modifying statement leaves no change behind
+func ExplainStatement(ctx context.Context, conn *pgxpool.Pool, statement utils.ParsedStatement, analyze bool, execution QueryExecution) ([]byte, error) {
+	var output []byte
+	err := runBounded(ctx, conn, execution, false, func(tx pgx.Tx) error {
+		params, err := bindParams(ctx, tx, execution.Params)
+		if err != nil {
+			return err
+		}
+		params = params.upTo(Placeholders(statement))
+		result := tx.Conn().PgConn().ExecParams(ctx, BuildExplainQuery(statement.SQL, analyze), params.Values, params.OIDs, nil, nil).Read()
+		if result.Err != nil {
+			return result.Err
+		}
+		if len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+			return errors.New("EXPLAIN returned no plan")
+		}
+		output = result.Rows[0][0]
+		return nil
+	})
+	return output, err
+}
+
+// CancelBackend asks a backend of the project database to cancel the statement of a query. the
+// application name of the backend is checked since it may have moved on to another query
+func CancelBackend(ctx context.Context, conn *pgxpool.Pool, pid uint32, queryID string) (bool, error) {
+	var cancelled bool
+	err := conn.QueryRow(ctx, CANCEL_BACKEND, int64(pid), queryApplicationName(queryID)).Scan(&cancelled)
+	if err != nil {
+		return false, err
+	}
+	return cancelled, nil
+}
+
+// queryApplicationName is the application name of a backend while it runs a query of the editor
+func queryApplicationName(queryID string) string {
+	return "dbhs-sqleditor:" + queryID
+}
+
+// runBounded runs fn in a transaction limited by the statement timeout of the execution, on a
+// connection of its own whose backend is registered under the query id so it can be cancelled.
+// the transaction is committed when commit is true and rolled back otherwise
+func runBounded(ctx context.Context, conn *pgxpool.Pool, execution QueryExecution, commit bool, fn func(pgx.Tx) error) error {
+	return runOnBackend(ctx, conn, execution, execution.Timeout, func(ctx context.Context, backend *pgxpool.Conn) error {
+		return runInTransaction(ctx, backend, execution, commit, fn)
+	})
+}
+
+// runOnBackend runs fn on a connection of its own whose backend is registered under the query id so
+// it can be cancelled, fn is given budget and the grace of the timeout to finish
+func runOnBackend(ctx context.Context, conn *pgxpool.Pool, execution QueryExecution, budget time.Duration, fn func(context.Context, *pgxpool.Conn) error) error {
+	ctx, cancel := context.WithTimeout(ctx, budget+timeoutGrace)
+	defer cancel()
+
+	backend, err := conn.Acquire(ctx)
 	if err != nil {
-		return ResponseBody{}, *api.NewApiError("Internal server error", 500, errors.New("failed to extract column names: "+err.Error()))
+		return err
 	}
+	defer backend.Release()
 
-	executionTime := time.Since(startTime)
-	return ResponseBody{
-		Result:        json.RawMessage(result),
-		ColumnNames:   columnNames,
-		ExecutionTime: float64(executionTime.Nanoseconds()) / 1e6, // Convert to milliseconds as float64
-	}, api.ApiError{} // Return empty ApiError to indicate success
+	if err := ensureEditorRole(ctx, backend, execution.ProjectOid); err != nil {
+		return err
+	}
+
+	if execution.QueryID != "" {
+		query := &runningQuery{pid: backend.Conn().PgConn().PID()}
+		if err := registerQuery(execution.key(), query); err != nil {
+			return err
+		}
+	}
+
+	err = fn(ctx, backend)
+	if execution.QueryID != "" && unregisterQuery(execution.key()) && isQueryCanceled(err) {
+		return errQueryCancelled
+	}
+	return err
+}
+
+// ensureEditorRole creates the editor role and grants it the objects of the project database once per project
+func ensureEditorRole(ctx context.Context, backend *pgxpool.Conn, projectOid string) error {
+	if _, ok := editorRoleGranted.Load(projectOid); ok {
+		return nil
+	}
+	if _, err := backend.Exec(ctx, ENSURE_EDITOR_ROLE); err != nil {
+		return fmt.Errorf("failed to grant the project to the editor role: %w", err)
+	}
+	editorRoleGranted.Store(projectOid, struct{}{})
+	return nil
+}
+
+func runInTransaction(ctx context.Context, backend *pgxpool.Conn, execution QueryExecution, commit bool, fn func(pgx.Tx) error) error {
+	// a query cancelled before its backend started it has nothing to cancel on the backend
+	if execution.QueryID != "" && queryCancelled(execution.key()) {
+		return errQueryCancelled
+	}
+	tx, err := backend.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, SET_QUERY_LIMITS, strconv.FormatInt(execution.Timeout.Milliseconds(), 10), queryApplicationName(execution.QueryID), editorRole); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if commit {
+		return tx.Commit(ctx)
+	}
+	return nil
+}
+
+// isQueryCanceled reports whether a statement was canceled by a timeout or by pg_cancel_backend
+func isQueryCanceled(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "57014"
+}
+
+// executionError maps the error of a query of the editor
+func executionError(err error, execution QueryExecution) api.ApiError {
+	if errors.Is(err, errQueryCancelled) {
+		return *api.NewApiError("Query was cancelled", 400, err)
+	}
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		return *api.NewApiError(paramErr.Reason, 400, err)
+	}
+	if errors.Is(err, ErrQueryIDInUse) {
+		return *api.NewApiError(err.Error(), 409, err)
+	}
+	if isQueryCanceled(err) || errors.Is(err, context.DeadlineExceeded) {
+		return *api.NewApiError(fmt.Sprintf("Query exceeded the statement timeout of %s", execution.Timeout), 408, err)
+	}
+	if strings.Contains(err.Error(), "does not exist") {
+		return *api.NewApiError("Table/Column not found", 404, errors.New("Table or column does not exist: "+err.Error()))
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && slices.Contains([]string{"22", "23", "42"}, pgErr.Code[:2]) {
+		return *api.NewApiError(pgErr.Message, 400, errors.New(err.Error()))
+	}
+	return *api.NewApiError("Query execution failed", 500, errors.New("failed to execute query: "+err.Error()))
 }
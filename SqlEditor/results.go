@@ -0,0 +1,116 @@
+package sqleditor
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// NDJSONContentType is the content type of a streamed result set
+const NDJSONContentType = "application/x-ndjson"
+
+// ndjsonFlushRows is the number of rows written to a stream between two flushes
+const ndjsonFlushRows = 100
+
+// resultSink receives a result set while it is read
+type resultSink interface {
+	Columns(columns []ResultColumn) error
+	Row(values []any) error
+}
+
+// bufferedResult keeps a result set to return it in a single response
+type bufferedResult struct {
+	columns []ResultColumn
+	rows    [][]any
+}
+
+func (b *bufferedResult) Columns(columns []ResultColumn) error {
+	b.columns = columns
+	return nil
+}
+
+func (b *bufferedResult) Row(values []any) error {
+	b.rows = append(b.rows, values)
+	return nil
+}
+
+func (b *bufferedResult) response(summary StatementSummary) ResponseBody {
+	body := ResponseBody{Columns: b.columns, Rows: b.rows, StatementSummary: summary}
+	if body.Columns == nil {
+		body.Columns = []ResultColumn{}
+	}
+	if body.Rows == nil {
+		body.Rows = [][]any{}
+	}
+	return body
+}
+
+// NDJSONWriter streams a result set as newline delimited JSON. the first line holds the columns,
+// {"columns": [...]}, then every row has a line, {"row": [...]}, and the last line is either the
+// summary of the statement, {"summary": {...}}, or the error that stopped it, {"error": "..."}.
+// the response is only started by the first line so an error found before it can still be
+// returned with its own status code
+type NDJSONWriter struct {
+	w       http.ResponseWriter
+	encoder *json.Encoder
+	started bool
+	pending int
+}
+
+func NewNDJSONWriter(w http.ResponseWriter) *NDJSONWriter {
+	return &NDJSONWriter{w: w, encoder: json.NewEncoder(w)}
+}
+
+// Started reports whether a line was written, the status code of the response is then sent
+func (s *NDJSONWriter) Started() bool {
+	return s.started
+}
+
+func (s *NDJSONWriter) Columns(columns []ResultColumn) error {
+	return s.write(struct {
+		Columns []ResultColumn `json:"columns"`
+	}{columns})
+}
+
+func (s *NDJSONWriter) Row(values []any) error {
+	if err := s.write(struct {
+		Row []any `json:"row"`
+	}{values}); err != nil {
+		return err
+	}
+	if s.pending++; s.pending >= ndjsonFlushRows {
+		s.flush()
+	}
+	return nil
+}
+
+func (s *NDJSONWriter) Summary(summary StatementSummary) error {
+	err := s.write(struct {
+		Summary StatementSummary `json:"summary"`
+	}{summary})
+	s.flush()
+	return err
+}
+
+func (s *NDJSONWriter) Fail(message string) error {
+	err := s.write(struct {
+		Error string `json:"error"`
+	}{message})
+	s.flush()
+	return err
+}
+
+func (s *NDJSONWriter) write(line any) error {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", NDJSONContentType)
+		s.w.Header().Set("X-Content-Type-Options", "nosniff")
+		s.w.WriteHeader(http.StatusOK)
+	}
+	return s.encoder.Encode(line)
+}
+
+// flush sends the buffered lines to the client, a writer that can't flush sends them when it fills up
+func (s *NDJSONWriter) flush() {
+	s.pending = 0
+	_ = http.NewResponseController(s.w).Flush()
+}
@@ -13,4 +13,8 @@ func DefineURLs() {
 	router.Handle("/run-query", middleware.Route(map[string]http.HandlerFunc{
 		http.MethodPost: RunSqlQuery(config.App),
 	}))
+
+	router.Handle("/queries/{query_id}/cancel", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: CancelSqlQuery(config.App),
+	}))
 }
@@ -2,6 +2,7 @@ package sqleditor
 
 import (
 	"DBHS/indexes"
+	"DBHS/utils"
 	api "DBHS/utils/apiError"
 	"context"
 	"errors"
@@ -9,28 +10,146 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func GetQueryResponse(ctx context.Context, db *pgxpool.Pool, projectOid, query string) (ResponseBody, api.ApiError) {
+// GetQueryResponse runs a statement and returns its result set, up to the row limit
+func GetQueryResponse(ctx context.Context, db *pgxpool.Pool, projectOid string, statement utils.ParsedStatement, request RequestBody) (ResponseBody, api.ApiError) {
+	result := &bufferedResult{}
+	summary, apiErr := runStatement(ctx, db, projectOid, statement, request, false, result)
+	if apiErr.Error() != nil {
+		return ResponseBody{}, apiErr
+	}
+	return result.response(summary), api.ApiError{}
+}
+
+// StreamQueryResponse runs a statement and streams every row of its result set, the row limit
+// doesn't apply since the rows aren't held in memory
+func StreamQueryResponse(ctx context.Context, db *pgxpool.Pool, projectOid string, statement utils.ParsedStatement, request RequestBody, stream *NDJSONWriter) api.ApiError {
+	summary, apiErr := runStatement(ctx, db, projectOid, statement, request, true, stream)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	if err := stream.Summary(summary); err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New("failed to write the result: "+err.Error()))
+	}
+	return api.ApiError{}
+}
+
+func runStatement(ctx context.Context, db *pgxpool.Pool, projectOid string, statement utils.ParsedStatement, request RequestBody, streamed bool, sink resultSink) (StatementSummary, api.ApiError) {
 	owner_id, ok := ctx.Value("user-id").(int64)
 	if !ok || owner_id == 0 {
-		return ResponseBody{}, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+		return StatementSummary{}, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
 	}
 
 	// ------------------------ Get the project pool connection ------------------------
 	conn, err := indexes.ProjectPoolConnection(ctx, db, owner_id, projectOid)
 	if err != nil {
 		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
-			return ResponseBody{}, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+			return StatementSummary{}, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
 		}
-		return ResponseBody{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		return StatementSummary{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
 	}
 	defer conn.Close()
 
-	// ------------------------ Fetch the query data ------------------------
+	// ------------------------ Run the statement ------------------------
 
-	requestBody, apiErr := FetchQueryData(ctx, conn, query)
-	if apiErr.Error() != nil {
-		return ResponseBody{}, apiErr
+	execution := NewQueryExecution(owner_id, projectOid, request)
+	if streamed {
+		execution.MaxRows = 0
+	}
+	summary, err := RunStatement(ctx, conn, statement, execution, sink)
+	if err != nil {
+		return StatementSummary{}, executionError(err, execution)
 	}
+	summary.QueryID = execution.QueryID
+	return summary, api.ApiError{}
+}
 
-	return requestBody, api.ApiError{}
+// GetScriptResponse runs the statements of a script and returns the result of every statement that was run
+func GetScriptResponse(ctx context.Context, db *pgxpool.Pool, projectOid, script string, statements []utils.ParsedStatement, request RequestBody) (ScriptResponse, api.ApiError) {
+	owner_id, ok := ctx.Value("user-id").(int64)
+	if !ok || owner_id == 0 {
+		return ScriptResponse{}, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := indexes.ProjectPoolConnection(ctx, db, owner_id, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return ScriptResponse{}, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return ScriptResponse{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	// ------------------------ Run the statements of the script ------------------------
+
+	execution := NewQueryExecution(owner_id, projectOid, request)
+	response, err := RunScript(ctx, conn, script, statements, execution, request.Transaction, request.StopOnError)
+	if err != nil {
+		return ScriptResponse{}, executionError(err, execution)
+	}
+	response.QueryID = execution.QueryID
+	return response, api.ApiError{}
+}
+
+func GetExplainResponse(ctx context.Context, db *pgxpool.Pool, projectOid string, statement utils.ParsedStatement, request RequestBody) (ExplainResponse, api.ApiError) {
+	owner_id, ok := ctx.Value("user-id").(int64)
+	if !ok || owner_id == 0 {
+		return ExplainResponse{}, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := indexes.ProjectPoolConnection(ctx, db, owner_id, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return ExplainResponse{}, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return ExplainResponse{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	// ------------------------ Explain the statement ------------------------
+
+	execution := NewQueryExecution(owner_id, projectOid, request)
+	output, err := ExplainStatement(ctx, conn, statement, request.Analyze, execution)
+	if err != nil {
+		return ExplainResponse{}, executionError(err, execution)
+	}
+
+	explained, err := ParseExplainOutput(output, request.Analyze)
+	if err != nil {
+		return ExplainResponse{}, *api.NewApiError("Internal server error", 500, errors.New("failed to parse the plan: "+err.Error()))
+	}
+	explained.RolledBack = request.Analyze && statement.Node.GetSelectStmt() == nil
+	return explained, api.ApiError{}
+}
+
+// CancelQuery cancels a running query of the user by its query id
+func CancelQuery(ctx context.Context, db *pgxpool.Pool, projectOid, queryID string) api.ApiError {
+	owner_id, ok := ctx.Value("user-id").(int64)
+	if !ok || owner_id == 0 {
+		return *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	pid, err := markCancelled(queryKey{ownerID: owner_id, projectOid: projectOid, queryID: queryID})
+	if err != nil {
+		return *api.NewApiError("Query not found", 404, err)
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := indexes.ProjectPoolConnection(ctx, db, owner_id, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	// ------------------------ Cancel the backend running the query ------------------------
+
+	// a script between two statements has no statement to cancel, it halts before its next one
+	if _, err := CancelBackend(ctx, conn, pid, queryID); err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New("failed to cancel query: "+err.Error()))
+	}
+	return api.ApiError{}
 }
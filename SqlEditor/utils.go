@@ -1,125 +1,181 @@
 package sqleditor
 
 import (
+	"DBHS/config"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// List of dangerous SQL keywords/operations
-var dangerousOperations = []string{
-	"CREATE",
-	"DROP",
-	"ALTER",
-	"TRUNCATE",
-	"GRANT",
-	"REVOKE",
-	"EXEC",
-	"EXECUTE",
-	"CALL",
-	"MERGE",
-	"REPLACE",
-	"RENAME",
-	"COMMENT",
+// NewQueryExecution bounds a query of a user by the limits of the platform, a request can only lower them
+func NewQueryExecution(ownerID int64, projectOid string, request RequestBody) QueryExecution {
+	timeout := time.Duration(config.SQL_EDITOR_STATEMENT_TIMEOUT_SECOND) * time.Second
+	if requested := time.Duration(request.TimeoutMs) * time.Millisecond; requested > 0 && requested < timeout {
+		timeout = requested
+	}
+	maxRows := config.SQL_EDITOR_MAX_ROWS
+	if request.MaxRows > 0 && request.MaxRows < maxRows {
+		maxRows = request.MaxRows
+	}
+	return QueryExecution{
+		QueryID:    request.QueryID,
+		OwnerID:    ownerID,
+		ProjectOid: projectOid,
+		Timeout:    timeout,
+		MaxRows:    maxRows,
+		Params:     request.Params,
+	}
 }
 
-// Additional check for dangerous patterns
-var dangerousPatterns = []string{
-	`\bINTO\s+OUTFILE\b`, // File operations
-	`\bLOAD_FILE\b`,      // File operations
-	`\bSYSTEM\b`,         // System commands
-	`\bSHELL\b`,          // Shell commands
+// ResultColumns describes the columns of a result set with the names of their Postgres types, a type
+// missing from the type map is named by its oid
+func ResultColumns(fields []pgconn.FieldDescription) []ResultColumn {
+	columns := make([]ResultColumn, len(fields))
+	for i, field := range fields {
+		typeName, ok := config.PgTypes[field.DataTypeOID]
+		if !ok {
+			typeName = strconv.FormatUint(uint64(field.DataTypeOID), 10)
+		}
+		columns[i] = ResultColumn{Name: field.Name, Type: typeName, TypeOID: field.DataTypeOID}
+	}
+	return columns
 }
 
-// System tables and schemas that should never be updated
-var protectedTables = []string{
-	"PG_DATABASE",
-	"PG_CLASS",
-	"PG_NAMESPACE",
-	"PG_TABLES",
-	"PG_ATTRIBUTE",
-	"INFORMATION_SCHEMA",
+// ResultRow turns a row read in the text format into JSON values
+func ResultRow(fields []pgconn.FieldDescription, raw [][]byte) []any {
+	values := make([]any, len(raw))
+	for i, value := range raw {
+		values[i] = ResultValue(fields[i].DataTypeOID, value)
+	}
+	return values
 }
 
-// ValidateQuery checks if the SQL query contains dangerous operations
-// Returns true if the query is safe (allows SELECT, INSERT, DELETE, and UPDATE), false if it contains dangerous operations
-func ValidateQuery(sqlQuery string) (bool, error) {
-	// Convert to uppercase for case-insensitive matching
-	upperQuery := strings.ToUpper(strings.TrimSpace(sqlQuery))
-
-	// Check for dangerous operations at the beginning of statements
-	// Split by semicolon to handle multiple statements
-	statements := strings.Split(upperQuery, ";")
-
-	for _, statement := range statements {
-		statement = strings.TrimSpace(statement)
-		if statement == "" {
-			continue
-		}
-
-		// Check if statement starts with any dangerous operation
-		for _, operation := range dangerousOperations {
-			// Use regex to match word boundaries to avoid false positives
-			pattern := fmt.Sprintf(`^\s*%s\b`, regexp.QuoteMeta(operation))
-			matched, _ := regexp.MatchString(pattern, statement)
-			if matched {
-				return false, fmt.Errorf("query contains forbidden operation: %s", operation)
-			}
+// ResultValue turns a value read in the text format into JSON. booleans, numbers and json documents
+// keep their type, the numbers JSON can't hold such as NaN and the values of every other type are
+// given as the text Postgres prints for them
+func ResultValue(typeOID uint32, raw []byte) any {
+	if raw == nil {
+		return nil
+	}
+	switch typeOID {
+	case pgtype.BoolOID:
+		return string(raw) == "t"
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.OIDOID, pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID:
+		if json.Valid(raw) {
+			return json.RawMessage(slices.Clone(raw))
 		}
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		return json.RawMessage(slices.Clone(raw))
+	}
+	return string(raw)
+}
 
-		for _, pattern := range dangerousPatterns {
-			matched, _ := regexp.MatchString(pattern, statement)
-			if matched {
-				return false, errors.New("query contains forbidden file or system operations")
-			}
-		}
+// BuildExplainQuery prefixes a statement with EXPLAIN, an analyzed plan also reports the buffers it used
+func BuildExplainQuery(statement string, analyze bool) string {
+	if analyze {
+		return "EXPLAIN (FORMAT JSON, ANALYZE, BUFFERS) " + statement
+	}
+	return "EXPLAIN (FORMAT JSON) " + statement
+}
 
-		// Check if UPDATE statement targets protected system tables
-		if strings.HasPrefix(statement, "UPDATE") {
-			for _, protectedTable := range protectedTables {
-				// Check if the statement contains references to protected tables
-				pattern := fmt.Sprintf(`\b%s`, regexp.QuoteMeta(protectedTable))
-				matched, _ := regexp.MatchString(pattern, statement)
-				if matched {
-					return false, fmt.Errorf("cannot update protected system table/schema: %s", protectedTable)
-				}
-			}
-		}
+// ParseExplainOutput reads the output of EXPLAIN (FORMAT JSON), numbers its nodes and finds its hotspots
+func ParseExplainOutput(output []byte, analyzed bool) (ExplainResponse, error) {
+	var plans []struct {
+		Plan          PlanNode `json:"Plan"`
+		PlanningTime  float64  `json:"Planning Time"`
+		ExecutionTime float64  `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(output, &plans); err != nil {
+		return ExplainResponse{}, err
+	}
+	if len(plans) == 0 {
+		return ExplainResponse{}, errors.New("EXPLAIN returned no plan")
 	}
 
-	return true, nil
+	explained := ExplainResponse{
+		Plan:          plans[0].Plan,
+		PlanningTime:  plans[0].PlanningTime,
+		ExecutionTime: plans[0].ExecutionTime,
+		Analyzed:      analyzed,
+	}
+	nextID := 1
+	numberPlanNodes(&explained.Plan, &nextID)
+	explained.Hotspots = FindHotspots(explained.Plan, analyzed)
+	return explained, nil
 }
 
-// WrapQueryWithJSONAgg takes a SQL query and wraps it with json_agg and row_to_json
-func WrapQueryWithJSONAgg(sqlQuery string) string {
-	// Clean the input query by removing leading/trailing whitespace and semicolon
-	cleanQuery := strings.TrimSuffix(strings.TrimSpace(sqlQuery), ";")
-	// Build the wrapped query
-	wrappedQuery := fmt.Sprintf(`SELECT json_agg(row_to_json(t))::text AS result FROM (%s) t;`, cleanQuery)
-	return wrappedQuery
+func numberPlanNodes(node *PlanNode, nextID *int) {
+	node.NodeID = *nextID
+	*nextID++
+	for i := range node.Plans {
+		numberPlanNodes(&node.Plans[i], nextID)
+	}
 }
 
-// extractColumnNames extracts column names from the JSON result
-func ExtractColumnNames(jsonResult string) ([]string, error) {
-	var data []map[string]interface{}
+const (
+	HotspotSeqScan      = "seq_scan"
+	HotspotEstimateMiss = "estimate_miss"
+	HotspotSortSpill    = "sort_spill"
+	HotspotHashSpill    = "hash_spill"
+
+	// a sequential scan reading fewer rows than this is cheap
+	seqScanHotspotRows = 1000
+	// a row estimate off by this factor and by at least estimateMissMinRows rows misleads the planner
+	estimateMissFactor  = 10
+	estimateMissMinRows = 100
+)
 
-	err := json.Unmarshal([]byte(jsonResult), &data)
-	if err != nil {
-		return nil, err
-	}
+// FindHotspots returns the nodes of a plan worth a look: sequential scans of many rows, row estimates
+// far from the actual rows and sorts or hashes spilling to disk. the last two need an analyzed plan
+func FindHotspots(plan PlanNode, analyzed bool) []Hotspot {
+	hotspots := []Hotspot{}
+	var walk func(node PlanNode)
+	walk = func(node PlanNode) {
+		hotspot := func(kind, message string) {
+			hotspots = append(hotspots, Hotspot{Node: node.NodeID, NodeType: node.NodeType, Kind: kind, Message: message})
+		}
 
-	// If no data, return empty slice
-	if len(data) == 0 {
-		return []string{}, nil
-	}
+		if node.NodeType == "Seq Scan" {
+			scanned := node.PlanRows
+			if analyzed {
+				scanned = (node.ActualRows + node.RowsRemovedByFilter) * max(node.ActualLoops, 1)
+			}
+			if scanned >= seqScanHotspotRows {
+				message := fmt.Sprintf("sequential scan of %s reads %.0f rows", node.RelationName, scanned)
+				if node.RowsRemovedByFilter > 0 {
+					message += fmt.Sprintf(" and removes %.0f of them by filter", node.RowsRemovedByFilter*max(node.ActualLoops, 1))
+				}
+				hotspot(HotspotSeqScan, message)
+			}
+		}
 
-	// Extract column names from the first row
-	var columnNames []string
-	for key := range data[0] {
-		columnNames = append(columnNames, key)
-	}
+		if analyzed && node.ActualLoops > 0 {
+			estimated, actual := node.PlanRows, node.ActualRows
+			if math.Abs(estimated-actual) >= estimateMissMinRows &&
+				max(estimated, actual) >= estimateMissFactor*max(min(estimated, actual), 1) {
+				hotspot(HotspotEstimateMiss, fmt.Sprintf("estimated %.0f rows, got %.0f", estimated, actual))
+			}
+		}
 
-	return columnNames, nil
+		if node.SortSpaceType == "Disk" || strings.HasPrefix(node.SortMethod, "external") {
+			hotspot(HotspotSortSpill, fmt.Sprintf("sort spilled %d kB to disk, raise work_mem or sort fewer rows", node.SortSpaceUsed))
+		}
+		if node.HashBatches > 1 {
+			hotspot(HotspotHashSpill, fmt.Sprintf("hash split into %d batches on disk, raise work_mem or hash fewer rows", node.HashBatches))
+		}
+
+		for _, child := range node.Plans {
+			walk(child)
+		}
+	}
+	walk(plan)
+	return hotspots
 }
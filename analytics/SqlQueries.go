@@ -40,4 +40,33 @@ const (
 	GET_LAST_EXECUTION_TIME_STATS = `SELECT created_at::text, COALESCE((data->>'total_time_ms')::numeric, 0), COALESCE((data->>'total_queries')::bigint, 0) FROM analytics WHERE type = 'ExecutionTimeStats' AND "projectId" = $1 ORDER BY created_at DESC LIMIT 1;`
 
 	GET_LAST_DATABASE_USAGE_STATS = `SELECT created_at::text, COALESCE((data->>'read_write_cost')::numeric, 0), COALESCE((data->>'cpu_cost')::numeric, 0), COALESCE((data->>'total_cost')::numeric, 0) FROM analytics WHERE type = 'DatabaseUsageStats' AND "projectId" = $1 ORDER BY created_at DESC LIMIT 1;`
+
+	// the index advisor looks at the slowest and the most frequent filtered statements of the project database
+	GET_ADVISOR_WORKLOAD = `
+		WITH workload AS (
+			SELECT pss.query, pss.calls, pss.total_exec_time, pss.mean_exec_time
+			FROM pg_stat_statements pss
+			JOIN pg_database pd ON pss.dbid = pd.oid
+			WHERE pd.datname = current_database()
+				AND pss.query ~* '^\s*(select|update|delete)\M' AND pss.query ~* '\mwhere\M'
+		)
+		SELECT query, calls, total_exec_time, mean_exec_time FROM (
+			(SELECT * FROM workload ORDER BY mean_exec_time DESC LIMIT $1)
+			UNION
+			(SELECT * FROM workload ORDER BY calls DESC LIMIT $1)
+		) w
+		ORDER BY total_exec_time DESC
+	`
+
+	// generic plans are planned without parameter values so the normalized statements can be explained,
+	// the GENERIC_PLAN option needs PostgreSQL 16
+	EXPLAIN_GENERIC_PLAN = `EXPLAIN (FORMAT JSON, VERBOSE, GENERIC_PLAN) `
+
+	GET_SERVER_VERSION_NUM = `SELECT current_setting('server_version_num')::int`
+
+	EXPLAIN_STATEMENT_TIMEOUT = `SET LOCAL statement_timeout = '5s'`
+
+	GET_TABLE_ROWS = `SELECT GREATEST(c.reltuples, 0)::bigint
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2`
 )
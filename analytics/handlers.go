@@ -120,3 +120,37 @@ func DatabaseUsage(app *config.Application) http.HandlerFunc {
 		response.OK(w, r, "Database usage statistics retrieved successfully", stats)
 	}
 }
+
+// IndexRecommendations godoc
+// @Summary Get index recommendations
+// @Description Explain the slowest and the most frequent statements recorded by pg_stat_statements and propose indexes for the selective sequential scans of large tables. Each advice has the estimated time saved and the body to send to the index creation endpoint
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID (OID)"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]analytics.IndexAdvice} "Index advice retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Project ID is missing"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Failure 501 {object} response.ErrorResponse "The project database runs a PostgreSQL version older than 16"
+// @Router /projects/{project_id}/analytics/index-advice [get]
+func IndexRecommendations(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		projectOid := urlVariables["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		advice, apiErr := AdviseIndexes(r.Context(), config.DB, projectOid)
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Index advice retrieved successfully", advice)
+	}
+}
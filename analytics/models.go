@@ -1,5 +1,7 @@
 package analytics
 
+import "DBHS/indexes"
+
 // ----------------------- Storage represents the structure of the storage information returned by the analytics API --------
 type Storage struct {
 	ManagementStorage string `json:"Management storage"`
@@ -42,4 +44,54 @@ type DatabaseUsageCostWithDates struct {
 	ReadWriteCost float64 `json:"read_write_cost"`
 	CPUCost       float64 `json:"cpu_cost"`
 	TotalCost     float64 `json:"total_cost"`
-}
\ No newline at end of file
+}
+
+// ------------------------------ index advisor ------------------------------
+
+// WorkloadStatement is a normalized statement of the project workload read from pg_stat_statements
+type WorkloadStatement struct {
+	Query       string  `json:"query" db:"query"`
+	Calls       int64   `json:"calls" db:"calls"`
+	TotalTimeMs float64 `json:"total_time_ms" db:"total_exec_time"`
+	MeanTimeMs  float64 `json:"mean_time_ms" db:"mean_exec_time"`
+}
+
+// PlanNode is a node of a plan printed by EXPLAIN (FORMAT JSON, VERBOSE)
+type PlanNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	Schema       string     `json:"Schema"`
+	Filter       string     `json:"Filter"`
+	PlanRows     float64    `json:"Plan Rows"`
+	TotalCost    float64    `json:"Total Cost"`
+	Plans        []PlanNode `json:"Plans"`
+}
+
+// IndexCandidate is an index that would replace a filtered sequential scan of a workload statement
+type IndexCandidate struct {
+	Schema      string
+	Table       string
+	Columns     []string
+	Query       string
+	Calls       int64
+	TableRows   int64
+	Selectivity float64
+	// TimeSavedMs is the share of the statement time spent in the scan that the index would save
+	TimeSavedMs float64
+}
+
+// IndexAdvice is a proposed index. Index is the request body of the index creation endpoint and
+// Statement the CREATE INDEX it runs, the benefit is an estimate from the planner costs and the
+// statement times recorded by pg_stat_statements
+type IndexAdvice struct {
+	Schema               string            `json:"schema"`
+	Table                string            `json:"table"`
+	Columns              []string          `json:"columns"`
+	Statement            string            `json:"statement"`
+	Index                indexes.IndexData `json:"index"`
+	TableRows            int64             `json:"table_rows"`
+	Selectivity          float64           `json:"selectivity"`
+	Calls                int64             `json:"calls"`
+	EstimatedTimeSavedMs float64           `json:"estimated_time_saved_ms"`
+	Queries              []string          `json:"queries"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -37,3 +38,49 @@ func GetLastDatabaseUsageRecord(ctx context.Context, db *pgxpool.Pool, projectId
 	}
 	return &record, nil
 }
+
+// --------------------- Index advisor -----------------------
+
+func GetAdvisorWorkload(ctx context.Context, conn *pgxpool.Pool, limit int) ([]WorkloadStatement, error) {
+	var workload []WorkloadStatement
+	if err := pgxscan.Select(ctx, conn, &workload, GET_ADVISOR_WORKLOAD, limit); err != nil {
+		return nil, err
+	}
+	return workload, nil
+}
+
+// GetServerVersionNum returns the version of the project database server, e.g. 160002 for 16.2
+func GetServerVersionNum(ctx context.Context, conn *pgxpool.Pool) (int, error) {
+	var version int
+	if err := conn.QueryRow(ctx, GET_SERVER_VERSION_NUM).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// ExplainGenericPlan plans a workload statement without running it. the statement is sent with the
+// simple protocol since its parameters have no values, inside a read only transaction that is rolled back
+func ExplainGenericPlan(ctx context.Context, conn *pgxpool.Pool, query string) ([]byte, error) {
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, EXPLAIN_STATEMENT_TIMEOUT); err != nil {
+		return nil, err
+	}
+	var plan []byte
+	if err := tx.QueryRow(ctx, EXPLAIN_GENERIC_PLAN+query, pgx.QueryExecModeSimpleProtocol).Scan(&plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func GetTableRows(ctx context.Context, conn *pgxpool.Pool, schema, table string) (int64, error) {
+	var rows int64
+	if err := conn.QueryRow(ctx, GET_TABLE_ROWS, schema, table).Scan(&rows); err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
@@ -21,4 +21,8 @@ func DefineURLs() {
 	router.Handle("/usage", middleware.Route(map[string]http.HandlerFunc{
 		http.MethodGet: DatabaseUsage(config.App),
 	}))
+
+	router.Handle("/index-advice", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: IndexRecommendations(config.App),
+	}))
 }
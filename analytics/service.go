@@ -7,7 +7,9 @@ import (
 
 	api "DBHS/utils/apiError"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -217,3 +219,82 @@ func GetALLDatabaseUsageStats(ctx context.Context, db *pgxpool.Pool, projectOid
 
 	return usageRecords, api.ApiError{} // Return empty ApiError to indicate success
 }
+
+// --------------------- Index advisor -----------------------
+
+// advisorWorkloadSize is the number of slowest and of most frequent statements the advisor explains
+const advisorWorkloadSize = 25
+
+// genericPlanVersion is the first server version supporting EXPLAIN (GENERIC_PLAN)
+const genericPlanVersion = 160000
+
+// AdviseIndexes explains the generic plans of the slowest and the most frequent statements of the
+// project and proposes an index for the selective sequential scans of its large tables
+func AdviseIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string) ([]IndexAdvice, api.ApiError) {
+	conn, apiErr := GetConnectionToAnalyticsPool(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer conn.Close()
+
+	version, err := GetServerVersionNum(ctx, conn)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New("failed to retrieve the server version: "+err.Error()))
+	}
+	if version < genericPlanVersion {
+		return nil, *api.NewApiError("The index advisor needs PostgreSQL 16 or later", 501, fmt.Errorf("the project database runs server version %d", version))
+	}
+
+	workload, err := GetAdvisorWorkload(ctx, conn, advisorWorkloadSize)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New("failed to retrieve the workload: "+err.Error()))
+	}
+
+	var candidates []IndexCandidate
+	tableRows := make(map[string]int64)
+	for _, statement := range workload {
+		if !IsAdvisableStatement(statement.Query) {
+			continue
+		}
+
+		// statements referencing dropped objects or timing out are left out of the advice
+		output, err := ExplainGenericPlan(ctx, conn, statement.Query)
+		if err != nil {
+			if !IsStatementExplainError(err) {
+				return nil, *api.NewApiError("Internal server error", 500, errors.New("failed to explain the workload: "+err.Error()))
+			}
+			config.App.InfoLog.Printf("Index advisor skipped a statement of project %s: %v", projectOid, err)
+			continue
+		}
+		var plans []struct {
+			Plan PlanNode `json:"Plan"`
+		}
+		if err := json.Unmarshal(output, &plans); err != nil {
+			return nil, *api.NewApiError("Internal server error", 500, errors.New("failed to decode the plan: "+err.Error()))
+		}
+		if len(plans) == 0 {
+			continue
+		}
+
+		for _, scan := range SequentialScans(plans[0].Plan) {
+			key := scan.Schema + "." + scan.RelationName
+			rows, ok := tableRows[key]
+			if !ok {
+				if rows, err = GetTableRows(ctx, conn, scan.Schema, scan.RelationName); err != nil {
+					return nil, *api.NewApiError("Internal server error", 500, errors.New("failed to estimate the table rows: "+err.Error()))
+				}
+				tableRows[key] = rows
+			}
+			if candidate, ok := ScanCandidate(statement, plans[0].Plan, scan, rows); ok {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	existing, err := indexes.GetIndexStats(ctx, conn, "")
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New("failed to retrieve the indexes: "+err.Error()))
+	}
+
+	return BuildIndexAdvice(candidates, existing), api.ApiError{}
+}
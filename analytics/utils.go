@@ -2,11 +2,17 @@ package analytics
 
 import (
 	"DBHS/indexes"
+	"DBHS/utils"
 	api "DBHS/utils/apiError"
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
 // CalculateCosts calculates the costs associated with database usage based on read/write queries and CPU time.
@@ -80,3 +86,280 @@ func CalculateDatabaseUsageDifference(current DatabaseUsageCost, lastRecord *Dat
 		TotalCost:     current.TotalCost - lastRecord.TotalCost,
 	}
 }
+
+// ------- functions for the index advisor -------
+
+const (
+	// AdvisorMinTableRows is the size under which a sequential scan is cheap enough to keep
+	AdvisorMinTableRows = 10000
+	// AdvisorMaxSelectivity is the largest share of the table rows a filter can return to be worth an index
+	AdvisorMaxSelectivity = 0.1
+	// advisorMaxQueries is the number of example statements kept on an advice
+	advisorMaxQueries = 5
+)
+
+// IsStatementExplainError reports whether an explain error only concerns its statement, e.g. a
+// statement referencing a dropped object, using a feature generic plans don't support or timing out
+func IsStatementExplainError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code[:2] {
+	case "22", "42", "54", "0A":
+		return true
+	}
+	return pgErr.Code == "57014"
+}
+
+// IsAdvisableStatement reports whether a workload statement is a single SELECT, UPDATE or DELETE
+// that can be explained without side effects on the data
+func IsAdvisableStatement(query string) bool {
+	statements, err := utils.ParseSQLStatements(query)
+	if err != nil || len(statements) != 1 {
+		return false
+	}
+	node := statements[0].Node
+	if node.GetSelectStmt() != nil {
+		return node.GetSelectStmt().GetIntoClause() == nil
+	}
+	return node.GetUpdateStmt() != nil || node.GetDeleteStmt() != nil
+}
+
+// SequentialScans returns the filtered sequential scans of a plan
+func SequentialScans(plan PlanNode) []PlanNode {
+	var scans []PlanNode
+	if plan.NodeType == "Seq Scan" && plan.Filter != "" && plan.RelationName != "" {
+		scans = append(scans, plan)
+	}
+	for _, child := range plan.Plans {
+		scans = append(scans, SequentialScans(child)...)
+	}
+	return scans
+}
+
+// FilterColumns returns the columns compared to a constant or a parameter in the conjunction of a
+// scan filter, the equality comparisons first and then the range comparisons
+func FilterColumns(filter string) (equality, ranges []string) {
+	tree, err := pg_query.Parse("SELECT 1 WHERE " + filter)
+	if err != nil || len(tree.Stmts) != 1 {
+		return nil, nil
+	}
+	where := tree.Stmts[0].Stmt.GetSelectStmt().GetWhereClause()
+
+	var walk func(node *pg_query.Node)
+	walk = func(node *pg_query.Node) {
+		if node == nil {
+			return
+		}
+		if boolExpr := node.GetBoolExpr(); boolExpr != nil {
+			// a disjunction can't be served by a single index scan
+			if boolExpr.Boolop == pg_query.BoolExprType_AND_EXPR {
+				for _, arg := range boolExpr.Args {
+					walk(arg)
+				}
+			}
+			return
+		}
+		expr := node.GetAExpr()
+		if expr == nil || len(expr.Name) != 1 {
+			return
+		}
+		operator := expr.Name[0].GetString_().GetSval()
+		switch expr.Kind {
+		case pg_query.A_Expr_Kind_AEXPR_OP:
+			column := comparedColumn(expr.Lexpr, expr.Rexpr)
+			if column == "" {
+				return
+			}
+			switch operator {
+			case "=":
+				equality = appendColumn(equality, column)
+			case "<", ">", "<=", ">=":
+				ranges = appendColumn(ranges, column)
+			}
+		case pg_query.A_Expr_Kind_AEXPR_OP_ANY:
+			if column := comparedColumn(expr.Lexpr, expr.Rexpr); column != "" && operator == "=" {
+				equality = appendColumn(equality, column)
+			}
+		case pg_query.A_Expr_Kind_AEXPR_BETWEEN:
+			if column := columnName(expr.Lexpr); column != "" {
+				ranges = appendColumn(ranges, column)
+			}
+		}
+	}
+	walk(where)
+
+	// a column compared for equality doesn't need a range key
+	filtered := ranges[:0]
+	for _, column := range ranges {
+		if !containsColumn(equality, column) {
+			filtered = append(filtered, column)
+		}
+	}
+	return equality, filtered
+}
+
+// comparedColumn returns the column of a comparison when exactly one of its sides is a column
+func comparedColumn(left, right *pg_query.Node) string {
+	leftColumn, rightColumn := columnName(left), columnName(right)
+	if (leftColumn == "") == (rightColumn == "") {
+		return ""
+	}
+	return leftColumn + rightColumn
+}
+
+// columnName returns the name of a column reference, a cast column is still the column
+func columnName(node *pg_query.Node) string {
+	for node.GetTypeCast() != nil {
+		node = node.GetTypeCast().Arg
+	}
+	ref := node.GetColumnRef()
+	if ref == nil || len(ref.Fields) == 0 {
+		return ""
+	}
+	return ref.Fields[len(ref.Fields)-1].GetString_().GetSval()
+}
+
+func appendColumn(columns []string, column string) []string {
+	if containsColumn(columns, column) {
+		return columns
+	}
+	return append(columns, column)
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanCandidate turns a filtered sequential scan of a workload statement into an index candidate.
+// the scan is kept when the table is large and the filter selective, the index keys are the
+// equality columns followed by the first range column
+func ScanCandidate(statement WorkloadStatement, plan, scan PlanNode, tableRows int64) (IndexCandidate, bool) {
+	if tableRows < AdvisorMinTableRows {
+		return IndexCandidate{}, false
+	}
+	selectivity := scan.PlanRows / float64(tableRows)
+	if selectivity > AdvisorMaxSelectivity {
+		return IndexCandidate{}, false
+	}
+
+	equality, ranges := FilterColumns(scan.Filter)
+	columns := equality
+	if len(ranges) > 0 {
+		columns = append(columns, ranges[0])
+	}
+	if len(columns) == 0 {
+		return IndexCandidate{}, false
+	}
+
+	// the scan takes its share of the plan cost from the statement time, an index scan reads
+	// about the selected share of it
+	share := 1.0
+	if plan.TotalCost > 0 && scan.TotalCost < plan.TotalCost {
+		share = scan.TotalCost / plan.TotalCost
+	}
+
+	schema := scan.Schema
+	if schema == "" {
+		schema = "public"
+	}
+	return IndexCandidate{
+		Schema:      schema,
+		Table:       scan.RelationName,
+		Columns:     columns,
+		Query:       statement.Query,
+		Calls:       statement.Calls,
+		TableRows:   tableRows,
+		Selectivity: selectivity,
+		TimeSavedMs: statement.TotalTimeMs * share * (1 - selectivity),
+	}, true
+}
+
+// BuildIndexAdvice merges the candidates proposing the same index and drops the ones already
+// served by an existing index, the advice is sorted by the estimated time saved
+func BuildIndexAdvice(candidates []IndexCandidate, existing []indexes.IndexStats) []IndexAdvice {
+	advice := []IndexAdvice{}
+	positions := make(map[string]int)
+	for _, candidate := range candidates {
+		if indexExists(candidate, existing) {
+			continue
+		}
+
+		key := candidate.Schema + "." + candidate.Table + "(" + strings.Join(candidate.Columns, ",") + ")"
+		if i, ok := positions[key]; ok {
+			advice[i].Calls += candidate.Calls
+			advice[i].EstimatedTimeSavedMs += candidate.TimeSavedMs
+			if candidate.Selectivity < advice[i].Selectivity {
+				advice[i].Selectivity = candidate.Selectivity
+			}
+			if len(advice[i].Queries) < advisorMaxQueries {
+				advice[i].Queries = append(advice[i].Queries, candidate.Query)
+			}
+			continue
+		}
+
+		index := indexes.IndexData{
+			IndexName:    adviceIndexName(candidate.Table, candidate.Columns),
+			IndexType:    "btree",
+			Columns:      candidate.Columns,
+			TableName:    candidate.Table,
+			Schema:       candidate.Schema,
+			Concurrently: true,
+		}
+		positions[key] = len(advice)
+		advice = append(advice, IndexAdvice{
+			Schema:               candidate.Schema,
+			Table:                candidate.Table,
+			Columns:              candidate.Columns,
+			Statement:            indexes.GenerateIndexQuery(index),
+			Index:                index,
+			TableRows:            candidate.TableRows,
+			Selectivity:          candidate.Selectivity,
+			Calls:                candidate.Calls,
+			EstimatedTimeSavedMs: candidate.TimeSavedMs,
+			Queries:              []string{candidate.Query},
+		})
+	}
+
+	sort.SliceStable(advice, func(i, j int) bool {
+		return advice[i].EstimatedTimeSavedMs > advice[j].EstimatedTimeSavedMs
+	})
+	return advice
+}
+
+// indexExists reports whether a valid btree index of the table already starts with the candidate columns
+func indexExists(candidate IndexCandidate, existing []indexes.IndexStats) bool {
+	for _, index := range existing {
+		if index.SchemaName != candidate.Schema || index.TableName != candidate.Table ||
+			index.IndexType != "btree" || !index.IsValid || index.Predicate != nil ||
+			len(index.Columns) < len(candidate.Columns) {
+			continue
+		}
+		covered := true
+		for i, column := range candidate.Columns {
+			if strings.Trim(index.Columns[i], `"`) != column {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// adviceIndexName names an advised index after its table and columns within the identifier length limit
+func adviceIndexName(table string, columns []string) string {
+	name := fmt.Sprintf("%s_%s_idx", table, strings.Join(columns, "_"))
+	if len(name) > 63 {
+		name = name[:59] + "_idx"
+	}
+	return name
+}
@@ -83,6 +83,7 @@ func Init(infoLog, errorLog *log.Logger) {
 	if !deploy {
 		loadEnv()
 	}
+	loadSettings()
 
 	App = &Application{
 		ErrorLog: errorLog,
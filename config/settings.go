@@ -1,7 +1,31 @@
 package config
 
+import (
+	"os"
+	"strconv"
+)
+
 var (
 	VERIFY_CODE_EXPIRY_MINUTE = 30
 	ACCESS_TOKEN_EXPIRY_HOUR  = 24
-	PgTypes                   map[uint32]string
+	// the queries of the SQL editor are stopped after SQL_EDITOR_STATEMENT_TIMEOUT_SECOND and return
+	// at most SQL_EDITOR_MAX_ROWS rows, requests can only lower these limits
+	SQL_EDITOR_STATEMENT_TIMEOUT_SECOND = 30
+	SQL_EDITOR_MAX_ROWS                 = 1000
+	PgTypes                             map[uint32]string
 )
+
+// loadSettings overrides the default settings with the environment variables of the same name
+func loadSettings() {
+	SQL_EDITOR_STATEMENT_TIMEOUT_SECOND = envInt("SQL_EDITOR_STATEMENT_TIMEOUT_SECOND", SQL_EDITOR_STATEMENT_TIMEOUT_SECOND)
+	SQL_EDITOR_MAX_ROWS = envInt("SQL_EDITOR_MAX_ROWS", SQL_EDITOR_MAX_ROWS)
+}
+
+// envInt reads a positive integer from the environment, fallback is returned when it is unset or invalid
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package functions
+
+import "DBHS/utils"
+
+// functions created by extensions belong to the extension and are not listed
+const functionColumns = `
+		SELECT p.oid::text AS oid, p.proname AS name, n.nspname AS schema,
+			CASE p.prokind WHEN 'p' THEN 'procedure' ELSE 'function' END AS kind,
+			pg_get_function_identity_arguments(p.oid) AS arguments,
+			COALESCE(pg_get_function_result(p.oid), '') AS returns,
+			l.lanname AS language,
+			pg_get_functiondef(p.oid) AS definition
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		JOIN pg_language l ON l.oid = p.prolang
+		WHERE p.prokind IN ('f', 'p') AND ` + utils.UserSchemasFilter + `
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_depend d
+				WHERE d.objid = p.oid AND d.classid = 'pg_proc'::regclass AND d.deptype = 'e'
+			)`
+
+// the timing, level and events are decoded from the bits of tgtype
+const triggerColumns = `
+		SELECT t.oid::text AS oid, t.tgname AS name, n.nspname AS schema, c.relname AS table_name,
+			CASE WHEN t.tgtype::int & 2 <> 0 THEN 'BEFORE' WHEN t.tgtype::int & 64 <> 0 THEN 'INSTEAD OF' ELSE 'AFTER' END AS timing,
+			array_remove(ARRAY[
+				CASE WHEN t.tgtype::int & 4 <> 0 THEN 'INSERT' END,
+				CASE WHEN t.tgtype::int & 16 <> 0 THEN 'UPDATE' END,
+				CASE WHEN t.tgtype::int & 8 <> 0 THEN 'DELETE' END,
+				CASE WHEN t.tgtype::int & 32 <> 0 THEN 'TRUNCATE' END
+			], NULL) AS events,
+			CASE WHEN t.tgtype::int & 1 <> 0 THEN 'ROW' ELSE 'STATEMENT' END AS level,
+			p.proname AS function_name, pn.nspname AS function_schema,
+			t.tgenabled <> 'D' AS enabled,
+			pg_get_triggerdef(t.oid, true) AS definition
+		FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_proc p ON p.oid = t.tgfoid
+		JOIN pg_namespace pn ON pn.oid = p.pronamespace
+		WHERE NOT t.tgisinternal AND ` + utils.UserSchemasFilter
+
+const (
+	// an empty $1 lists the functions of every user schema
+	SELECT_FUNCTIONS = functionColumns + ` AND ($1 = '' OR n.nspname = $1) ORDER BY n.nspname, p.proname, p.oid`
+
+	SELECT_FUNCTION = functionColumns + ` AND p.oid = $1::oid`
+
+	// an empty $1 or $2 doesn't filter on the schema or the table
+	SELECT_TRIGGERS = triggerColumns + ` AND ($1 = '' OR n.nspname = $1) AND ($2 = '' OR c.relname = $2) ORDER BY n.nspname, c.relname, t.tgname`
+
+	SELECT_TRIGGER = triggerColumns + ` AND t.oid = $1::oid`
+
+	TABLE_EXISTS = `SELECT EXISTS (
+						SELECT 1 FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+						WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('r', 'p')
+					)`
+
+	COLUMN_EXISTS = `SELECT EXISTS (
+						SELECT 1 FROM information_schema.columns
+						WHERE table_schema = $1 AND table_name = $2 AND column_name = $3
+					)`
+
+	CREATE_TRIGGER     = `CREATE %sTRIGGER %s %s %s ON %s FOR EACH %s%s EXECUTE FUNCTION %s(%s)`
+	TOGGLE_TRIGGER     = `ALTER TABLE %s %s TRIGGER %s`
+	DROP_TRIGGER       = `DROP TRIGGER %s ON %s`
+	DROP_FUNCTION      = `DROP %s %s(%s) %s`
+	CREATE_AUDIT_TABLE = `CREATE TABLE IF NOT EXISTS %s (
+		audit_id bigserial PRIMARY KEY,
+		operation text NOT NULL,
+		changed_at timestamptz NOT NULL DEFAULT now(),
+		changed_by text NOT NULL DEFAULT current_user,
+		old_row jsonb,
+		new_row jsonb
+	)`
+)
+
+// the template functions read the column or table they work on from the trigger arguments,
+// so one function per schema serves every table the template is attached to
+const (
+	SET_UPDATED_AT_BODY = `
+BEGIN
+	NEW := jsonb_populate_record(NEW, jsonb_build_object(TG_ARGV[0], now()));
+	RETURN NEW;
+END`
+
+	AUDIT_ROW_BODY = `
+BEGIN
+	EXECUTE format('INSERT INTO %I.%I (operation, old_row, new_row) VALUES ($1, $2, $3)', TG_TABLE_SCHEMA, TG_ARGV[0])
+		USING TG_OP,
+			CASE WHEN TG_OP IN ('UPDATE', 'DELETE') THEN to_jsonb(OLD) END,
+			CASE WHEN TG_OP IN ('INSERT', 'UPDATE') THEN to_jsonb(NEW) END;
+	RETURN NULL;
+END`
+
+	SOFT_DELETE_GUARD_BODY = `
+BEGIN
+	IF to_jsonb(OLD) ->> TG_ARGV[0] IS NULL THEN
+		RAISE EXCEPTION 'rows of % must be soft deleted by setting % before they are deleted', TG_TABLE_NAME, TG_ARGV[0]
+			USING ERRCODE = 'restrict_violation';
+	END IF;
+	RETURN OLD;
+END`
+
+	CREATE_TEMPLATE_FUNCTION = `CREATE OR REPLACE FUNCTION %s() RETURNS trigger LANGUAGE plpgsql AS %s`
+)
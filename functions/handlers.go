@@ -0,0 +1,386 @@
+package functions
+
+import (
+	"DBHS/config"
+	"DBHS/response"
+	"DBHS/utils"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ListFunctionsHandler godoc
+// @Summary List functions
+// @Description List the stored functions and procedures of the project database with their definitions. Functions of extensions are not listed
+// @Tags functions
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the functions of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]Function} "Functions retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/functions [get]
+func ListFunctionsHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		functions, apiErr := ListFunctions(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Functions retrieved successfully", functions)
+	}
+}
+
+// CreateFunctionHandler godoc
+// @Summary Create or replace a function
+// @Description Run a single CREATE [OR REPLACE] FUNCTION or PROCEDURE statement. Unqualified names resolve in the given schema, a schema qualified function name takes precedence over it. Only SQL and PL/pgSQL functions running with the privileges of their caller are allowed, their bodies can read and write rows but can't run dynamic SQL, server administration or file access functions, or reference the system schemas
+// @Tags functions
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param function body CreateFunctionRequest true "Function definition"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse "Function created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid definition"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 409 {object} response.ErrorResponse "A function with the same signature already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/functions [post]
+func CreateFunctionHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		var request CreateFunctionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		function, apiErr := CreateFunction(r.Context(), config.DB, projectOid, &request)
+		if apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to create function:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.Created(w, r, "Function created successfully", map[string]string{
+			"schema": function.Schema,
+			"name":   function.Name,
+		})
+	}
+}
+
+// GetFunctionHandler godoc
+// @Summary Get a function
+// @Description Get the signature and definition of a function or procedure
+// @Tags functions
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param function_oid path string true "Function ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=Function} "Function retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or function not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/functions/{function_oid} [get]
+func GetFunctionHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, functionOid, ok := objectVariables(w, r, "function_oid")
+		if !ok {
+			return
+		}
+
+		function, apiErr := GetProjectFunction(r.Context(), config.DB, projectOid, functionOid)
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Function retrieved successfully", function)
+	}
+}
+
+// DropFunctionHandler godoc
+// @Summary Drop a function
+// @Description Drop a function or procedure, with cascade the triggers that execute it are dropped as well
+// @Tags functions
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param function_oid path string true "Function ID"
+// @Param cascade query bool false "Drop the objects that depend on the function as well"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Function dropped successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or function not found"
+// @Failure 409 {object} response.ErrorResponse "Other objects depend on the function"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/functions/{function_oid} [delete]
+func DropFunctionHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, functionOid, ok := objectVariables(w, r, "function_oid")
+		if !ok {
+			return
+		}
+
+		cascade, err := utils.ParseBoolParameter(r, "cascade")
+		if err != nil {
+			response.BadRequest(w, r, "cascade must be a boolean", err)
+			return
+		}
+
+		if apiErr := DropFunction(r.Context(), config.DB, projectOid, functionOid, cascade); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to drop function:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Function dropped successfully", nil)
+	}
+}
+
+// ListTriggersHandler godoc
+// @Summary List triggers
+// @Description List the user triggers of the project database with their definitions and whether they are enabled
+// @Tags triggers
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the triggers of this schema"
+// @Param table query string false "Only list the triggers of this table"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]Trigger} "Triggers retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/triggers [get]
+func ListTriggersHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		query := r.URL.Query()
+		triggers, apiErr := ListTriggers(r.Context(), config.DB, projectOid, query.Get("schema"), query.Get("table"))
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Triggers retrieved successfully", triggers)
+	}
+}
+
+// CreateTriggerHandler godoc
+// @Summary Create or replace a trigger
+// @Description Attach a trigger function to a table. With replace an existing trigger of the same name on the table is replaced
+// @Tags triggers
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param trigger body CreateTriggerRequest true "Trigger definition"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse "Trigger created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid trigger definition"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 409 {object} response.ErrorResponse "A trigger with the same name already exists on the table"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/triggers [post]
+func CreateTriggerHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		var request CreateTriggerRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		if apiErr := CreateTrigger(r.Context(), config.DB, projectOid, &request); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to create trigger:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.Created(w, r, "Trigger created successfully", map[string]string{
+			"schema": request.Schema,
+			"table":  request.Table,
+			"name":   request.Name,
+		})
+	}
+}
+
+// GetTriggerHandler godoc
+// @Summary Get a trigger
+// @Description Get the definition of a trigger
+// @Tags triggers
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param trigger_oid path string true "Trigger ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=Trigger} "Trigger retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or trigger not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/triggers/{trigger_oid} [get]
+func GetTriggerHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, triggerOid, ok := objectVariables(w, r, "trigger_oid")
+		if !ok {
+			return
+		}
+
+		trigger, apiErr := GetProjectTrigger(r.Context(), config.DB, projectOid, triggerOid)
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Trigger retrieved successfully", trigger)
+	}
+}
+
+// UpdateTriggerHandler godoc
+// @Summary Enable or disable a trigger
+// @Description Enable or disable a trigger without dropping it
+// @Tags triggers
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param trigger_oid path string true "Trigger ID"
+// @Param changes body UpdateTriggerRequest true "Trigger state"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Trigger updated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or trigger not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/triggers/{trigger_oid} [patch]
+func UpdateTriggerHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, triggerOid, ok := objectVariables(w, r, "trigger_oid")
+		if !ok {
+			return
+		}
+
+		var request UpdateTriggerRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		if apiErr := UpdateTrigger(r.Context(), config.DB, projectOid, triggerOid, &request); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to update trigger:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Trigger updated successfully", nil)
+	}
+}
+
+// DropTriggerHandler godoc
+// @Summary Drop a trigger
+// @Description Drop a trigger from its table, the trigger function is kept
+// @Tags triggers
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param trigger_oid path string true "Trigger ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Trigger dropped successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or trigger not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/triggers/{trigger_oid} [delete]
+func DropTriggerHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, triggerOid, ok := objectVariables(w, r, "trigger_oid")
+		if !ok {
+			return
+		}
+
+		if apiErr := DropTrigger(r.Context(), config.DB, projectOid, triggerOid); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to drop trigger:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Trigger dropped successfully", nil)
+	}
+}
+
+// ListTemplatesHandler godoc
+// @Summary List trigger templates
+// @Description List the built-in triggers that can be attached to a table in one call
+// @Tags triggers
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]TriggerTemplate} "Trigger templates retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /projects/{project_id}/triggers/templates [get]
+func ListTemplatesHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.OK(w, r, "Trigger templates retrieved successfully", Templates)
+	}
+}
+
+// AttachTemplateHandler godoc
+// @Summary Attach a trigger template to a table
+// @Description Create the function of a built-in template in the schema of the table and attach its trigger. The audit template also creates the <table>_audit table. Attaching a template again replaces its trigger
+// @Tags triggers
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param template_name path string true "Template name" Enums(updated_at, audit, soft_delete_guard)
+// @Param target body AttachTemplateRequest true "Table the template is attached to"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse{data=AttachedTemplate} "Trigger template attached successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or missing column"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project, table or template not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/triggers/templates/{template_name} [post]
+func AttachTemplateHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, templateName, ok := objectVariables(w, r, "template_name")
+		if !ok {
+			return
+		}
+
+		var request AttachTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		attached, apiErr := AttachTemplate(r.Context(), config.DB, projectOid, templateName, &request)
+		if apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to attach trigger template:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.Created(w, r, "Trigger template attached successfully", attached)
+	}
+}
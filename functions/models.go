@@ -0,0 +1,91 @@
+package functions
+
+// Function is a stored function or procedure of a project database
+type Function struct {
+	OID    string `json:"oid" db:"oid"`
+	Name   string `json:"name" db:"name"`
+	Schema string `json:"schema" db:"schema"`
+	// Kind is either function or procedure
+	Kind      string `json:"kind" db:"kind"`
+	Arguments string `json:"arguments" db:"arguments"`
+	// Returns is empty for procedures
+	Returns    string `json:"returns" db:"returns"`
+	Language   string `json:"language" db:"language"`
+	Definition string `json:"definition" db:"definition"`
+}
+
+// Trigger is a user trigger of a table, internal constraint triggers are not listed
+type Trigger struct {
+	OID            string   `json:"oid" db:"oid"`
+	Name           string   `json:"name" db:"name"`
+	Schema         string   `json:"schema" db:"schema"`
+	Table          string   `json:"table" db:"table_name"`
+	Timing         string   `json:"timing" db:"timing"`
+	Events         []string `json:"events" db:"events"`
+	Level          string   `json:"level" db:"level"`
+	FunctionName   string   `json:"function_name" db:"function_name"`
+	FunctionSchema string   `json:"function_schema" db:"function_schema"`
+	Enabled        bool     `json:"enabled" db:"enabled"`
+	Definition     string   `json:"definition" db:"definition"`
+}
+
+// CreateFunctionRequest holds a CREATE [OR REPLACE] FUNCTION or PROCEDURE statement,
+// unqualified names in it resolve in Schema
+type CreateFunctionRequest struct {
+	Schema     string `json:"schema"`
+	Definition string `json:"definition"`
+}
+
+type CreateTriggerRequest struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	// Timing is BEFORE, AFTER or INSTEAD OF
+	Timing string `json:"timing"`
+	// Events are INSERT, UPDATE, DELETE and TRUNCATE
+	Events []string `json:"events"`
+	// UpdateColumns limits an UPDATE trigger to changes of these columns
+	UpdateColumns []string `json:"update_columns,omitempty"`
+	// Level is ROW or STATEMENT, defaults to ROW
+	Level string `json:"level,omitempty"`
+	// Condition is the optional WHEN expression of the trigger
+	Condition      string   `json:"condition,omitempty"`
+	Function       string   `json:"function"`
+	FunctionSchema string   `json:"function_schema,omitempty"`
+	Arguments      []string `json:"arguments,omitempty"`
+	// Replace replaces an existing trigger with the same name on the table
+	Replace bool `json:"replace,omitempty"`
+}
+
+type UpdateTriggerRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// TriggerTemplate is a built-in trigger that can be attached to a table in one call
+type TriggerTemplate struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Timing      string   `json:"timing"`
+	Events      []string `json:"events"`
+	// DefaultColumn is the column the template works on when the request doesn't name one
+	DefaultColumn string `json:"default_column,omitempty"`
+
+	function string
+	body     string
+}
+
+type AttachTemplateRequest struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	// Column overrides the default column of the template
+	Column string `json:"column,omitempty"`
+	// TriggerName defaults to <table>_<template>
+	TriggerName string `json:"trigger_name,omitempty"`
+}
+
+// AttachedTemplate describes the objects created when a template is attached
+type AttachedTemplate struct {
+	Trigger    string `json:"trigger"`
+	Function   string `json:"function"`
+	AuditTable string `json:"audit_table,omitempty"`
+}
@@ -0,0 +1,62 @@
+package functions
+
+import (
+	"DBHS/utils"
+	"context"
+	"errors"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+func GetFunctions(ctx context.Context, db utils.Querier, schema string) ([]Function, error) {
+	var functions []Function
+	if err := pgxscan.Select(ctx, db, &functions, SELECT_FUNCTIONS, schema); err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
+
+// GetFunction returns nil when the function doesn't exist
+func GetFunction(ctx context.Context, db utils.Querier, oid string) (*Function, error) {
+	var function Function
+	if err := pgxscan.Get(ctx, db, &function, SELECT_FUNCTION, oid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &function, nil
+}
+
+func GetTriggers(ctx context.Context, db utils.Querier, schema, table string) ([]Trigger, error) {
+	var triggers []Trigger
+	if err := pgxscan.Select(ctx, db, &triggers, SELECT_TRIGGERS, schema, table); err != nil {
+		return nil, err
+	}
+	return triggers, nil
+}
+
+// GetTrigger returns nil when the trigger doesn't exist
+func GetTrigger(ctx context.Context, db utils.Querier, oid string) (*Trigger, error) {
+	var trigger Trigger
+	if err := pgxscan.Get(ctx, db, &trigger, SELECT_TRIGGER, oid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &trigger, nil
+}
+
+func TableExists(ctx context.Context, db utils.Querier, schema, table string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(ctx, TABLE_EXISTS, schema, table).Scan(&exists)
+	return exists, err
+}
+
+func ColumnExists(ctx context.Context, db utils.Querier, schema, table, column string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(ctx, COLUMN_EXISTS, schema, table, column).Scan(&exists)
+	return exists, err
+}
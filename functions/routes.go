@@ -0,0 +1,45 @@
+package functions
+
+import (
+	"DBHS/config"
+	"DBHS/middleware"
+	"net/http"
+)
+
+func DefineURLs() {
+	router := config.Router.PathPrefix("/api/projects/{project_id}/functions").Subrouter()
+	router.Use(middleware.JwtAuthMiddleware, middleware.CheckOwnership)
+
+	router.Handle("", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:  ListFunctionsHandler(config.App),
+		http.MethodPost: CreateFunctionHandler(config.App),
+	}))
+
+	router.Handle("/{function_oid}", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:    GetFunctionHandler(config.App),
+		http.MethodDelete: DropFunctionHandler(config.App),
+	}))
+
+	triggerRouter := config.Router.PathPrefix("/api/projects/{project_id}/triggers").Subrouter()
+	triggerRouter.Use(middleware.JwtAuthMiddleware, middleware.CheckOwnership)
+
+	triggerRouter.Handle("", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:  ListTriggersHandler(config.App),
+		http.MethodPost: CreateTriggerHandler(config.App),
+	}))
+
+	// the template routes are registered before /{trigger_oid} so they are matched first
+	triggerRouter.Handle("/templates", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: ListTemplatesHandler(config.App),
+	}))
+
+	triggerRouter.Handle("/templates/{template_name}", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: AttachTemplateHandler(config.App),
+	}))
+
+	triggerRouter.Handle("/{trigger_oid}", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:    GetTriggerHandler(config.App),
+		http.MethodPatch:  UpdateTriggerHandler(config.App),
+		http.MethodDelete: DropTriggerHandler(config.App),
+	}))
+}
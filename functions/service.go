@@ -0,0 +1,304 @@
+package functions
+
+import (
+	"DBHS/config"
+	"DBHS/utils"
+	api "DBHS/utils/apiError"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	duplicateObjectCode   = "42710"
+	duplicateFunctionCode = "42723"
+	dependentObjectsCode  = "2BP01"
+)
+
+// conflicts are the errors of the function and trigger statements answered with 409, with the
+// message of the database
+var conflicts = map[string]string{
+	duplicateObjectCode:   "",
+	duplicateFunctionCode: "",
+	dependentObjectsCode:  "Other objects depend on it, use cascade to drop them",
+}
+
+func ListFunctions(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]Function, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	functions, err := GetFunctions(ctx, userDb, schema)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if functions == nil {
+		functions = make([]Function, 0)
+	}
+	return functions, api.ApiError{}
+}
+
+func GetProjectFunction(ctx context.Context, db *pgxpool.Pool, projectOid string, functionOid string) (*Function, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+	return findFunction(ctx, userDb, functionOid)
+}
+
+// CreateFunction runs a CREATE [OR REPLACE] FUNCTION or PROCEDURE statement with the search path
+// of the function schema
+func CreateFunction(ctx context.Context, db *pgxpool.Pool, projectOid string, request *CreateFunctionRequest) (*FunctionDefinition, api.ApiError) {
+	function, err := ValidateFunctionDefinition(request.Definition, request.Schema)
+	if err != nil {
+		return nil, *api.NewApiError(err.Error(), 400, err)
+	}
+
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, function.Schema); err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if _, err := tx.Exec(ctx, function.SQL); err != nil {
+		return nil, utils.DatabaseError(err, "Failed to create function", conflicts)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, utils.DatabaseError(err, "Failed to create function", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Function %s created in project %s", utils.QualifiedName(function.Schema, function.Name), projectOid)
+	return function, api.ApiError{}
+}
+
+func DropFunction(ctx context.Context, db *pgxpool.Pool, projectOid string, functionOid string, cascade bool) api.ApiError {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	function, apiErr := findFunction(ctx, userDb, functionOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+
+	if _, err := userDb.Exec(ctx, GenerateDropFunctionQuery(function, cascade)); err != nil {
+		return utils.DatabaseError(err, "Failed to drop function", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Function %s dropped in project %s", utils.QualifiedName(function.Schema, function.Name), projectOid)
+	return api.ApiError{}
+}
+
+func ListTriggers(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, table string) ([]Trigger, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	triggers, err := GetTriggers(ctx, userDb, schema, table)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if triggers == nil {
+		triggers = make([]Trigger, 0)
+	}
+	return triggers, api.ApiError{}
+}
+
+func GetProjectTrigger(ctx context.Context, db *pgxpool.Pool, projectOid string, triggerOid string) (*Trigger, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+	return findTrigger(ctx, userDb, triggerOid)
+}
+
+// CreateTrigger creates or replaces a trigger, an unqualified function resolves in the schema of the table
+func CreateTrigger(ctx context.Context, db *pgxpool.Pool, projectOid string, request *CreateTriggerRequest) api.ApiError {
+	request.Schema = utils.SchemaOrDefault(request.Schema)
+	if request.FunctionSchema == "" {
+		request.FunctionSchema = request.Schema
+	}
+
+	query, err := GenerateCreateTriggerQuery(request)
+	if err != nil {
+		return *api.NewApiError(err.Error(), 400, err)
+	}
+
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	// the condition of the trigger can reference the functions and types of the table schema
+	if err := utils.SetSearchPath(ctx, tx, request.Schema); err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if _, err := tx.Exec(ctx, query); err != nil {
+		return utils.DatabaseError(err, "Failed to create trigger", conflicts)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return utils.DatabaseError(err, "Failed to create trigger", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Trigger %s created on %s in project %s", request.Name, utils.QualifiedName(request.Schema, request.Table), projectOid)
+	return api.ApiError{}
+}
+
+func UpdateTrigger(ctx context.Context, db *pgxpool.Pool, projectOid string, triggerOid string, request *UpdateTriggerRequest) api.ApiError {
+	if request.Enabled == nil {
+		return *api.NewApiError("enabled is required", 400, errors.New("enabled is required"))
+	}
+
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	trigger, apiErr := findTrigger(ctx, userDb, triggerOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+
+	if _, err := userDb.Exec(ctx, GenerateToggleTriggerQuery(trigger, *request.Enabled)); err != nil {
+		return utils.DatabaseError(err, "Failed to update trigger", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Trigger %s on %s set to enabled=%t in project %s", trigger.Name, utils.QualifiedName(trigger.Schema, trigger.Table), *request.Enabled, projectOid)
+	return api.ApiError{}
+}
+
+func DropTrigger(ctx context.Context, db *pgxpool.Pool, projectOid string, triggerOid string) api.ApiError {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	trigger, apiErr := findTrigger(ctx, userDb, triggerOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+
+	if _, err := userDb.Exec(ctx, GenerateDropTriggerQuery(trigger)); err != nil {
+		return utils.DatabaseError(err, "Failed to drop trigger", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Trigger %s dropped from %s in project %s", trigger.Name, utils.QualifiedName(trigger.Schema, trigger.Table), projectOid)
+	return api.ApiError{}
+}
+
+// AttachTemplate creates the function of a built-in template in the table schema and attaches its trigger to the table
+func AttachTemplate(ctx context.Context, db *pgxpool.Pool, projectOid string, templateName string, request *AttachTemplateRequest) (*AttachedTemplate, api.ApiError) {
+	template := FindTemplate(templateName)
+	if template == nil {
+		return nil, *api.NewApiError("Trigger template not found", 404, ErrUnknownTemplate)
+	}
+	request.Schema = utils.SchemaOrDefault(request.Schema)
+
+	queries, attached, err := GenerateAttachTemplateQueries(template, request)
+	if err != nil {
+		return nil, *api.NewApiError(err.Error(), 400, err)
+	}
+
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	exists, err := TableExists(ctx, userDb, request.Schema, request.Table)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if !exists {
+		return nil, *api.NewApiError("Table not found", 404, errors.New("table "+request.Table+" does not exist"))
+	}
+
+	// a template bound to a missing column would silently do nothing
+	if column := TemplateColumn(template, request); column != "" {
+		exists, err := ColumnExists(ctx, userDb, request.Schema, request.Table, column)
+		if err != nil {
+			return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		}
+		if !exists {
+			return nil, *api.NewApiError("Column "+column+" does not exist on the table", 400, errors.New("column "+column+" does not exist"))
+		}
+	}
+
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query); err != nil {
+			return nil, utils.DatabaseError(err, "Failed to attach trigger template", conflicts)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, utils.DatabaseError(err, "Failed to attach trigger template", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Trigger template %s attached to %s in project %s", template.Name, utils.QualifiedName(request.Schema, request.Table), projectOid)
+	return attached, api.ApiError{}
+}
+
+func findFunction(ctx context.Context, userDb *pgxpool.Pool, functionOid string) (*Function, api.ApiError) {
+	if err := validateOid(functionOid); err != nil {
+		return nil, *api.NewApiError("Function not found", 404, err)
+	}
+	function, err := GetFunction(ctx, userDb, functionOid)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if function == nil {
+		return nil, *api.NewApiError("Function not found", 404, errors.New("function "+functionOid+" does not exist"))
+	}
+	return function, api.ApiError{}
+}
+
+func findTrigger(ctx context.Context, userDb *pgxpool.Pool, triggerOid string) (*Trigger, api.ApiError) {
+	if err := validateOid(triggerOid); err != nil {
+		return nil, *api.NewApiError("Trigger not found", 404, err)
+	}
+	trigger, err := GetTrigger(ctx, userDb, triggerOid)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if trigger == nil {
+		return nil, *api.NewApiError("Trigger not found", 404, errors.New("trigger "+triggerOid+" does not exist"))
+	}
+	return trigger, api.ApiError{}
+}
@@ -0,0 +1,309 @@
+package functions
+
+import (
+	"DBHS/response"
+	"DBHS/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrNotFunction     = errors.New("the definition must be a single CREATE FUNCTION or CREATE PROCEDURE statement")
+	ErrInvalidTiming   = errors.New("timing must be BEFORE, AFTER or INSTEAD OF")
+	ErrInvalidLevel    = errors.New("level must be ROW or STATEMENT")
+	ErrInvalidEvent    = errors.New("events must be INSERT, UPDATE, DELETE or TRUNCATE")
+	ErrNoEvents        = errors.New("a trigger needs at least one event")
+	ErrInvalidTrigger  = errors.New("the trigger condition must be a single expression")
+	ErrUnknownTemplate = errors.New("unknown trigger template")
+	ErrInvalidOid      = errors.New("invalid object id")
+)
+
+const templateQuote = "$dbhs$"
+
+// Templates are the built-in triggers, their functions are created in the schema of the table
+var Templates = []TriggerTemplate{
+	{
+		Name:          "updated_at",
+		Description:   "Sets a timestamp column to the current time whenever a row is inserted or updated",
+		Timing:        "BEFORE",
+		Events:        []string{"INSERT", "UPDATE"},
+		DefaultColumn: "updated_at",
+		function:      "dbhs_set_updated_at",
+		body:          SET_UPDATED_AT_BODY,
+	},
+	{
+		Name:        "audit",
+		Description: "Copies every inserted, updated and deleted row as json into the <table>_audit table with the operation, time and database user",
+		Timing:      "AFTER",
+		Events:      []string{"INSERT", "UPDATE", "DELETE"},
+		function:    "dbhs_audit_row",
+		body:        AUDIT_ROW_BODY,
+	},
+	{
+		Name:          "soft_delete_guard",
+		Description:   "Rejects deleting rows whose soft delete column is still null, rows have to be soft deleted before they can be removed",
+		Timing:        "BEFORE",
+		Events:        []string{"DELETE"},
+		DefaultColumn: "deleted_at",
+		function:      "dbhs_soft_delete_guard",
+		body:          SOFT_DELETE_GUARD_BODY,
+	},
+}
+
+// FindTemplate returns nil when there is no template with the name
+func FindTemplate(name string) *TriggerTemplate {
+	for i := range Templates {
+		if Templates[i].Name == name {
+			return &Templates[i]
+		}
+	}
+	return nil
+}
+
+// bodyStatements are the statements the body of a user-defined function can run. the function is
+// created on the admin connection and its triggers fire on the admin connection too
+var bodyStatements = []string{"SelectStmt", "InsertStmt", "UpdateStmt", "DeleteStmt", "MergeStmt"}
+
+// FunctionDefinition is a validated CREATE FUNCTION or PROCEDURE statement
+type FunctionDefinition struct {
+	Schema string
+	Name   string
+	SQL    string
+}
+
+// ValidateFunctionDefinition parses a function definition, a schema qualified function name
+// takes precedence over the schema of the request
+func ValidateFunctionDefinition(definition, schema string) (*FunctionDefinition, error) {
+	statements, err := utils.ParseSQLStatements(definition)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) != 1 {
+		return nil, ErrNotFunction
+	}
+	createStmt := statements[0].Node.GetCreateFunctionStmt()
+	if createStmt == nil {
+		return nil, ErrNotFunction
+	}
+
+	names := make([]string, len(createStmt.Funcname))
+	for i, node := range createStmt.Funcname {
+		names[i] = node.GetString_().GetSval()
+	}
+	function := &FunctionDefinition{Schema: utils.SchemaOrDefault(schema), SQL: statements[0].SQL}
+	switch len(names) {
+	case 1:
+		function.Name = names[0]
+	case 2:
+		function.Schema, function.Name = names[0], names[1]
+	default:
+		return nil, errors.New("function names can't name another database")
+	}
+	if utils.IsReservedSchema(function.Schema) {
+		return nil, fmt.Errorf("schema %s is reserved", function.Schema)
+	}
+	if err := utils.CheckReferences(statements[0].Node); err != nil {
+		return nil, err
+	}
+	if err := utils.CheckFunctionBody(createStmt, function.SQL, checkBodyStatement); err != nil {
+		return nil, err
+	}
+	return function, nil
+}
+
+// checkBodyStatement rejects the statements of a function body other than the reads and writes of rows
+func checkBodyStatement(statement utils.ParsedStatement) error {
+	top := utils.InnerNode(statement.Node)
+	if top == nil {
+		return errors.New("empty statement")
+	}
+	kind := string(top.ProtoReflect().Descriptor().Name())
+	if !slices.Contains(bodyStatements, kind) {
+		return fmt.Errorf("%s statements are not allowed in functions", strings.TrimSuffix(kind, "Stmt"))
+	}
+	return utils.CheckReferences(statement.Node)
+}
+
+// GenerateCreateTriggerQuery validates a trigger request and builds its statement
+// CREATE [OR REPLACE] TRIGGER "name" timing events ON "schema"."table" FOR EACH level [WHEN (condition)] EXECUTE FUNCTION "schema"."function"(arguments)
+func GenerateCreateTriggerQuery(request *CreateTriggerRequest) (string, error) {
+	if request.Name == "" || request.Table == "" || request.Function == "" {
+		return "", errors.New("trigger name, table and function are required")
+	}
+	if utils.IsReservedSchema(utils.SchemaOrDefault(request.Schema)) {
+		return "", fmt.Errorf("schema %s is reserved", request.Schema)
+	}
+
+	timing := strings.ToUpper(strings.TrimSpace(request.Timing))
+	if timing != "BEFORE" && timing != "AFTER" && timing != "INSTEAD OF" {
+		return "", ErrInvalidTiming
+	}
+	level := strings.ToUpper(strings.TrimSpace(request.Level))
+	if level == "" {
+		level = "ROW"
+	}
+	if level != "ROW" && level != "STATEMENT" {
+		return "", ErrInvalidLevel
+	}
+
+	if len(request.Events) == 0 {
+		return "", ErrNoEvents
+	}
+	events := make([]string, 0, len(request.Events))
+	for _, event := range request.Events {
+		event = strings.ToUpper(strings.TrimSpace(event))
+		if !slices.Contains([]string{"INSERT", "UPDATE", "DELETE", "TRUNCATE"}, event) {
+			return "", ErrInvalidEvent
+		}
+		if slices.Contains(events, event) {
+			return "", fmt.Errorf("duplicate event %s", event)
+		}
+		if event == "TRUNCATE" && level == "ROW" {
+			return "", errors.New("TRUNCATE triggers must be STATEMENT level")
+		}
+		if event == "UPDATE" && len(request.UpdateColumns) > 0 {
+			columns := make([]string, len(request.UpdateColumns))
+			for i, column := range request.UpdateColumns {
+				columns[i] = utils.QuoteIdentifier(column)
+			}
+			event += " OF " + strings.Join(columns, ", ")
+		}
+		events = append(events, event)
+	}
+	if timing == "INSTEAD OF" && level != "ROW" {
+		return "", errors.New("INSTEAD OF triggers must be ROW level")
+	}
+
+	replace := ""
+	if request.Replace {
+		replace = "OR REPLACE "
+	}
+	condition := ""
+	if strings.TrimSpace(request.Condition) != "" {
+		condition = " WHEN (" + request.Condition + ")"
+	}
+	arguments := make([]string, len(request.Arguments))
+	for i, argument := range request.Arguments {
+		arguments[i] = utils.QuoteLiteral(argument)
+	}
+
+	query := fmt.Sprintf(CREATE_TRIGGER, replace, utils.QuoteIdentifier(request.Name), timing, strings.Join(events, " OR "),
+		utils.QualifiedName(request.Schema, request.Table), level, condition,
+		utils.QualifiedName(request.FunctionSchema, request.Function), strings.Join(arguments, ", "))
+
+	// the condition is the only raw sql of the request, the statement is parsed so it can't smuggle another statement
+	statements, err := utils.ParseSQLStatements(query)
+	if err != nil {
+		return "", err
+	}
+	if len(statements) != 1 || statements[0].Node.GetCreateTrigStmt() == nil {
+		return "", ErrInvalidTrigger
+	}
+	return query, nil
+}
+
+// GenerateAttachTemplateQueries returns the statements that attach a template to a table,
+// every statement replaces the objects of an earlier attachment so attaching twice is harmless
+func GenerateAttachTemplateQueries(template *TriggerTemplate, request *AttachTemplateRequest) ([]string, *AttachedTemplate, error) {
+	if request.Table == "" {
+		return nil, nil, errors.New("table is required")
+	}
+	if template.DefaultColumn == "" && request.Column != "" {
+		return nil, nil, fmt.Errorf("the %s template doesn't take a column", template.Name)
+	}
+
+	schema := utils.SchemaOrDefault(request.Schema)
+	attached := &AttachedTemplate{
+		Trigger:  request.TriggerName,
+		Function: utils.QualifiedName(schema, template.function),
+	}
+	if attached.Trigger == "" {
+		attached.Trigger = request.Table + "_" + template.Name
+	}
+
+	queries := make([]string, 0, 3)
+	argument := TemplateColumn(template, request)
+	if template.DefaultColumn == "" {
+		argument = request.Table + "_audit"
+		attached.AuditTable = utils.QualifiedName(schema, argument)
+		queries = append(queries, fmt.Sprintf(CREATE_AUDIT_TABLE, attached.AuditTable))
+	}
+
+	queries = append(queries, fmt.Sprintf(CREATE_TEMPLATE_FUNCTION, attached.Function, templateQuote+template.body+"\n"+templateQuote))
+
+	trigger, err := GenerateCreateTriggerQuery(&CreateTriggerRequest{
+		Name:           attached.Trigger,
+		Schema:         schema,
+		Table:          request.Table,
+		Timing:         template.Timing,
+		Events:         template.Events,
+		Level:          "ROW",
+		Function:       template.function,
+		FunctionSchema: schema,
+		Arguments:      []string{argument},
+		Replace:        true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(queries, trigger), attached, nil
+}
+
+// TemplateColumn returns the column a template works on, empty for templates without one
+func TemplateColumn(template *TriggerTemplate, request *AttachTemplateRequest) string {
+	if template.DefaultColumn == "" {
+		return ""
+	}
+	if request.Column != "" {
+		return request.Column
+	}
+	return template.DefaultColumn
+}
+
+// ALTER TABLE "schema"."table" ENABLE|DISABLE TRIGGER "name"
+func GenerateToggleTriggerQuery(trigger *Trigger, enabled bool) string {
+	action := "DISABLE"
+	if enabled {
+		action = "ENABLE"
+	}
+	return fmt.Sprintf(TOGGLE_TRIGGER, utils.QualifiedName(trigger.Schema, trigger.Table), action, utils.QuoteIdentifier(trigger.Name))
+}
+
+// DROP TRIGGER "name" ON "schema"."table"
+func GenerateDropTriggerQuery(trigger *Trigger) string {
+	return fmt.Sprintf(DROP_TRIGGER, utils.QuoteIdentifier(trigger.Name), utils.QualifiedName(trigger.Schema, trigger.Table))
+}
+
+// DROP FUNCTION|PROCEDURE "schema"."name"(arguments) RESTRICT|CASCADE
+func GenerateDropFunctionQuery(function *Function, cascade bool) string {
+	behavior := "RESTRICT"
+	if cascade {
+		behavior = "CASCADE"
+	}
+	return fmt.Sprintf(DROP_FUNCTION, strings.ToUpper(function.Kind), utils.QualifiedName(function.Schema, function.Name), function.Arguments, behavior)
+}
+
+// validateOid checks that an object id of the url is a postgres oid before it is cast in a query
+func validateOid(oid string) error {
+	if _, err := strconv.ParseUint(oid, 10, 32); err != nil {
+		return ErrInvalidOid
+	}
+	return nil
+}
+
+// objectVariables reads the project and the named object of the url, it writes the error response when one is missing
+func objectVariables(w http.ResponseWriter, r *http.Request, variable string) (string, string, bool) {
+	urlVariables := mux.Vars(r)
+	projectOid, object := urlVariables["project_id"], urlVariables[variable]
+	if projectOid == "" || object == "" {
+		response.BadRequest(w, r, "Project Id and "+strings.ReplaceAll(variable, "_", " ")+" are required", nil)
+		return "", "", false
+	}
+	return projectOid, object, true
+}
@@ -1,11 +1,73 @@
 package indexes
 
+import "DBHS/utils"
+
 const (
-	SELECT_ALL_INDEXES = `SELECT c.relname AS index_name, c.oid AS index_oid, am.amname AS index_type
+	// indexes of the system and platform schemas are never listed,
+	// an empty $1 lists the indexes of every user schema
+	SELECT_ALL_INDEXES = `SELECT c.relname AS index_name, c.oid AS index_oid, am.amname AS index_type, n.nspname AS schema_name, t.relname AS table_name
     FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace JOIN pg_am am ON am.oid = c.relam
-    WHERE c.relkind = 'i' AND n.nspname = 'public'`
+    JOIN pg_index i ON i.indexrelid = c.oid JOIN pg_class t ON t.oid = i.indrelid
+    WHERE c.relkind = 'i' AND ` + utils.UserSchemasFilter + ` AND ($1 = '' OR n.nspname = $1)
+    ORDER BY n.nspname, t.relname, c.relname`
 
-	SELECT_SPECIFIC_INDEX = `SELECT c.relname AS index_name, am.amname AS index_type
+	SELECT_SPECIFIC_INDEX = `SELECT c.relname AS index_name, am.amname AS index_type, n.nspname AS schema_name, t.relname AS table_name
     FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace JOIN pg_am am ON am.oid = c.relam
-    WHERE c.relkind = 'i' AND n.nspname = 'public' AND c.oid = $1`
+    JOIN pg_index i ON i.indexrelid = c.oid JOIN pg_class t ON t.oid = i.indrelid
+    WHERE c.relkind = 'i' AND ` + utils.UserSchemasFilter + ` AND c.oid = $1`
+
+	SELECT_INDEX_METHODS = `SELECT amname FROM pg_am WHERE amtype = 'i' ORDER BY amname`
+
+	SELECT_OPERATOR_CLASSES = `SELECT DISTINCT opc.opcname FROM pg_opclass opc JOIN pg_am am ON am.oid = opc.opcmethod
+    WHERE am.amname = $1 ORDER BY opc.opcname`
+
+	// a failed concurrent build leaves an invalid index behind
+	SELECT_INDEX_IS_INVALID = `SELECT EXISTS (SELECT 1 FROM pg_index WHERE indexrelid = to_regclass($1) AND NOT indisvalid)`
+
+	// the key columns and expressions are read one by one so expression keys keep their definition, the
+	// operator class, collation and ordering of every key are read apart since the definition leaves them out
+	SELECT_INDEX_STATS = `SELECT s.indexrelname AS index_name, s.indexrelid::text AS index_oid, am.amname AS index_type,
+        n.nspname AS schema_name, s.relname AS table_name,
+        ARRAY(SELECT pg_get_indexdef(i.indexrelid, k, true) FROM generate_series(1, i.indnkeyatts) AS k ORDER BY k) AS columns,
+        ARRAY(SELECT concat_ws(' ', i.indclass[k - 1], i.indcollation[k - 1], i.indoption[k - 1])
+            FROM generate_series(1, i.indnkeyatts) AS k ORDER BY k) AS key_options,
+        ARRAY(SELECT pg_get_indexdef(i.indexrelid, k, true) FROM generate_series(i.indnkeyatts + 1, i.indnatts) AS k ORDER BY k) AS include,
+        pg_get_expr(i.indpred, i.indrelid, true) AS predicate, pg_get_indexdef(i.indexrelid) AS definition,
+        i.indisunique AS is_unique, i.indisprimary AS is_primary, i.indisvalid AS is_valid,
+        s.idx_scan AS scans, s.idx_tup_read AS tuples_read, s.idx_tup_fetch AS tuples_fetched,
+        pg_relation_size(s.indexrelid) AS size_bytes
+    FROM pg_stat_user_indexes s JOIN pg_index i ON i.indexrelid = s.indexrelid
+    JOIN pg_class c ON c.oid = s.indexrelid JOIN pg_namespace n ON n.oid = c.relnamespace JOIN pg_am am ON am.oid = c.relam
+    WHERE ` + utils.UserSchemasFilter + ` AND ($1 = '' OR n.nspname = $1)
+    ORDER BY n.nspname, s.relname, s.indexrelname`
+
+	SELECT_TABLE_EXISTS = `SELECT EXISTS (SELECT 1 FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE c.relkind IN ('r', 'p', 'm') AND ` + utils.UserSchemasFilter + ` AND n.nspname = $1 AND c.relname = $2)`
+
+	// the builds of the other databases of the cluster are left out
+	SELECT_INDEX_BUILD_PROGRESS = `SELECT p.pid, n.nspname AS schema_name, t.relname AS table_name,
+        COALESCE(ic.relname, '') AS index_name, p.index_relid::text AS index_oid, p.command, p.phase,
+        p.lockers_total, p.lockers_done, p.blocks_total, p.blocks_done, p.tuples_total, p.tuples_done,
+        p.partitions_total, p.partitions_done
+    FROM pg_stat_progress_create_index p JOIN pg_class t ON t.oid = p.relid JOIN pg_namespace n ON n.oid = t.relnamespace
+    LEFT JOIN pg_class ic ON ic.oid = p.index_relid
+    WHERE p.datname = current_database()
+    ORDER BY p.pid`
+
+	// the key width is the sum of the average widths of the key columns, the indexes with expression keys
+	// or columns without statistics can't be estimated and are left out
+	SELECT_INDEX_PAGES = `SELECT c.relname AS index_name, c.oid::text AS index_oid, n.nspname AS schema_name, t.relname AS table_name,
+        c.relpages::bigint AS pages, GREATEST(c.reltuples, 0)::float8 AS tuples,
+        (SELECT COALESCE(sum(s.avg_width), 0) FROM pg_attribute a JOIN pg_stats s ON s.schemaname = n.nspname
+            AND s.tablename = t.relname AND s.attname = a.attname
+            WHERE a.attrelid = i.indrelid AND a.attnum = ANY (i.indkey))::float8 AS key_width,
+        current_setting('block_size')::bigint AS block_size,
+        COALESCE((SELECT split_part(o, '=', 2)::bigint FROM unnest(c.reloptions) AS o WHERE o LIKE 'fillfactor=%'), 90) AS fill_factor
+    FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid JOIN pg_class t ON t.oid = i.indrelid
+    JOIN pg_namespace n ON n.oid = c.relnamespace JOIN pg_am am ON am.oid = c.relam
+    WHERE am.amname = 'btree' AND i.indisvalid AND i.indexprs IS NULL AND c.relpages > 0
+        AND ` + utils.UserSchemasFilter + ` AND ($1 = '' OR n.nspname = $1)
+        AND NOT EXISTS (SELECT 1 FROM pg_attribute a WHERE a.attrelid = i.indrelid AND a.attnum = ANY (i.indkey)
+            AND NOT EXISTS (SELECT 1 FROM pg_stats s WHERE s.schemaname = n.nspname AND s.tablename = t.relname AND s.attname = a.attname))
+    ORDER BY n.nspname, t.relname, c.relname`
 )
@@ -5,13 +5,15 @@ import (
 	"DBHS/response"
 	"DBHS/utils"
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
+	"io"
 	"net/http"
 )
 
 // CreateIndex godoc
 // @Summary Create a new index
-// @Description Create a new index in the specified project
+// @Description Create a new index in the specified project. the index can be unique, partial (where), cover extra columns (include), have expression keys with a sort order and an operator class and be built concurrently
 // @Tags indexes
 // @Accept json
 // @Produce json
@@ -39,8 +41,8 @@ func CreateIndex(app *config.Application) http.HandlerFunc {
 			return
 		}
 
-		if indexData.IndexName == "" || indexData.IndexType == "" || len(indexData.Columns) == 0 || indexData.TableName == "" {
-			response.BadRequest(w, r, "Index name, type, columns and table name are required", nil)
+		if indexData.IndexName == "" || indexData.IndexType == "" || len(indexData.Columns)+len(indexData.Keys) == 0 || indexData.TableName == "" {
+			response.BadRequest(w, r, "Index name, type, columns or keys and table name are required", nil)
 			return
 		}
 
@@ -62,6 +64,7 @@ func CreateIndex(app *config.Application) http.HandlerFunc {
 // @Tags indexes
 // @Produce json
 // @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the indexes of this schema"
 // @Security BearerAuth
 // @Success 200 {object} response.SuccessResponse "Indexes retrieved successfully"
 // @Failure 400 {object} response.ErrorResponse "Project ID is required"
@@ -76,7 +79,7 @@ func ProjectIndexes(app *config.Application) http.HandlerFunc {
 			return
 		}
 
-		indexes, err := GetIndexes(r.Context(), config.DB, projectOid)
+		indexes, err := GetIndexes(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
 
 		if err.Error() != nil {
 			config.App.ErrorLog.Println("Failed to get indexes:", err)
@@ -201,3 +204,278 @@ func UpdateIndexName(app *config.Application) http.HandlerFunc {
 		response.OK(w, r, "Index name updated successfully", nil)
 	}
 }
+
+// IndexStatistics godoc
+// @Summary Get the statistics of the indexes of a project
+// @Description Scan counts, tuples read and fetched, size on disk, validity, key columns and definition of every index, the counters run since the statistics were last reset
+// @Tags indexes
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the indexes of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]IndexStats} "Index statistics retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to get index statistics"
+// @Router /projects/{project_id}/indexes/stats [get]
+func IndexStatistics(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		stats, err := GetIndexStatistics(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to get index statistics:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Index statistics retrieved successfully", stats)
+	}
+}
+
+// UnusedIndexesHandler godoc
+// @Summary Get the unused indexes of a project
+// @Description List the indexes that weren't scanned since the statistics were last reset, unique and primary key indexes are left out since they enforce constraints
+// @Tags indexes
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the indexes of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]IndexStats} "Unused indexes retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to get unused indexes"
+// @Router /projects/{project_id}/indexes/unused [get]
+func UnusedIndexesHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		unused, err := GetUnusedIndexes(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to get unused indexes:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Unused indexes retrieved successfully", unused)
+	}
+}
+
+// RedundantIndexesHandler godoc
+// @Summary Get the duplicate and overlapping indexes of a project
+// @Description List the indexes covered by another index of the same table: duplicates have the same keys, overlapping btree indexes have keys that are a prefix of the other index
+// @Tags indexes
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the indexes of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]RedundantIndex} "Redundant indexes retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to get redundant indexes"
+// @Router /projects/{project_id}/indexes/duplicates [get]
+func RedundantIndexesHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		redundant, err := GetRedundantIndexes(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to get redundant indexes:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Redundant indexes retrieved successfully", redundant)
+	}
+}
+
+// ReindexIndexHandler godoc
+// @Summary Rebuild an index
+// @Description Rebuild a bloated or invalid index. a concurrent rebuild doesn't block the writes to the table, the invalid indexes it leaves behind when it fails are dropped. the progress is reported by the index builds endpoint
+// @Tags indexes
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param index_oid path string true "Index ID"
+// @Param reindex body ReindexRequest false "Rebuild options, the schema and table are ignored"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Index rebuilt successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid input or the index can't be rebuilt"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Index not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to rebuild index"
+// @Router /projects/{project_id}/indexes/{index_oid}/reindex [post]
+func ReindexIndexHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		indexOid, projectOid := urlVariables["index_oid"], urlVariables["project_id"]
+		if indexOid == "" || projectOid == "" {
+			response.BadRequest(w, r, "Index Id and Project Id are required", nil)
+			return
+		}
+
+		// the body is optional, a plain rebuild is run without one
+		var request ReindexRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			response.BadRequest(w, r, "Invalid request body", nil)
+			return
+		}
+
+		err := ReindexSpecificIndex(r.Context(), config.DB, projectOid, indexOid, request.Concurrently)
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to rebuild index:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Index rebuilt successfully", nil)
+	}
+}
+
+// ReindexTableHandler godoc
+// @Summary Rebuild the indexes of a table
+// @Description Rebuild every index of a table. a concurrent rebuild doesn't block the writes to the table, the invalid indexes it leaves behind when it fails are dropped
+// @Tags indexes
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param reindex body ReindexRequest true "Table and rebuild options"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Table indexes rebuilt successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid input or the indexes can't be rebuilt"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or table not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to rebuild table indexes"
+// @Router /projects/{project_id}/indexes/reindex [post]
+func ReindexTableHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		var request ReindexRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", nil)
+			return
+		}
+		if request.Table == "" {
+			response.BadRequest(w, r, "Table name is required", nil)
+			return
+		}
+
+		err := ReindexTableIndexes(r.Context(), config.DB, projectOid, request)
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to rebuild table indexes:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Table indexes rebuilt successfully", nil)
+	}
+}
+
+// InvalidIndexesHandler godoc
+// @Summary Get the invalid indexes of a project
+// @Description List the indexes left invalid by a failed concurrent build or rebuild, the indexes being built are left out
+// @Tags indexes
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the indexes of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]IndexStats} "Invalid indexes retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to get invalid indexes"
+// @Router /projects/{project_id}/indexes/invalid [get]
+func InvalidIndexesHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		invalid, err := GetInvalidIndexes(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to get invalid indexes:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Invalid indexes retrieved successfully", invalid)
+	}
+}
+
+// DropInvalidIndexesHandler godoc
+// @Summary Drop the invalid indexes of a project
+// @Description Drop concurrently the indexes left invalid by a failed concurrent build or rebuild and return them, the indexes being built are kept
+// @Tags indexes
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only drop the indexes of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]IndexStats} "Invalid indexes dropped successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to drop invalid indexes"
+// @Router /projects/{project_id}/indexes/invalid [delete]
+func DropInvalidIndexesHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		dropped, err := DropInvalidIndexes(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to drop invalid indexes:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Invalid indexes dropped successfully", dropped)
+	}
+}
+
+// IndexBloatHandler godoc
+// @Summary Estimate the bloat of the indexes of a project
+// @Description Estimate the size of every btree index packed at its fill factor from the table statistics and the space a rebuild would free, the largest bloat comes first. indexes with expression keys or columns that weren't analyzed are left out
+// @Tags indexes
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only estimate the indexes of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]IndexBloat} "Index bloat estimated successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to estimate index bloat"
+// @Router /projects/{project_id}/indexes/bloat [get]
+func IndexBloatHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		bloat, err := GetIndexBloat(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to estimate index bloat:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Index bloat estimated successfully", bloat)
+	}
+}
+
+// IndexBuildsHandler godoc
+// @Summary Get the progress of the running index builds
+// @Description List the CREATE INDEX and REINDEX commands running in the project database with their phase and the progress of the phase
+// @Tags indexes
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]IndexBuildProgress} "Index builds retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Failed to get index builds"
+// @Router /projects/{project_id}/indexes/progress [get]
+func IndexBuildsHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		builds, err := GetIndexBuilds(r.Context(), config.DB, projectOid)
+		if err.Error() != nil {
+			config.App.ErrorLog.Println("Failed to get index builds:", err)
+			utils.ResponseHandler(w, r, err)
+			return
+		}
+
+		response.OK(w, r, "Index builds retrieved successfully", builds)
+	}
+}
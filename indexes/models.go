@@ -1,21 +1,133 @@
 package indexes
 
 type IndexData struct {
-	IndexName string   `json:"name"`
-	IndexType string   `json:"type"`
-	Columns   []string `json:"columns"`
-	TableName string   `json:"table_name"`
+	IndexName string `json:"name"`
+	IndexType string `json:"type"`
+	// Columns are plain key columns, Keys are keys with an expression, a sort order or an operator
+	// class. only one of them is given
+	Columns   []string   `json:"columns"`
+	Keys      []IndexKey `json:"keys,omitempty"`
+	TableName string     `json:"table_name"`
+	// Schema of the table, the public schema when it is empty
+	Schema string `json:"schema,omitempty"`
+	Unique bool   `json:"unique,omitempty"`
+	// Include lists the non-key columns stored in the index
+	Include []string `json:"include,omitempty"`
+	// Where is the predicate of a partial index
+	Where string `json:"where,omitempty"`
+	// Concurrently builds the index without blocking the writes to the table
+	Concurrently bool `json:"concurrently,omitempty"`
+}
+
+// IndexKey is a key of an index, either a column or an expression
+type IndexKey struct {
+	Column     string `json:"column,omitempty"`
+	Expression string `json:"expression,omitempty"`
+	OpClass    string `json:"opclass,omitempty"`
+	Descending bool   `json:"descending,omitempty"`
+	// NullsFirst orders the nulls, postgres puts them last in ascending and first in descending order by default
+	NullsFirst *bool `json:"nullsFirst,omitempty"`
 }
 
 type RetrievedIndex struct {
-	IndexName string `json:"index_name"`
-	IndexOid  string `json:"index_oid"`
-	IndexType string `json:"index_type"`
+	IndexName  string `json:"index_name"`
+	IndexOid   string `json:"index_oid"`
+	IndexType  string `json:"index_type"`
+	SchemaName string `json:"schema_name"`
+	TableName  string `json:"table_name"`
 }
 
 type SpecificIndex struct {
-	IndexName string `json:"index_name"`
-	IndexType string `json:"index_type"`
+	IndexName  string `json:"index_name"`
+	IndexType  string `json:"index_type"`
+	SchemaName string `json:"schema_name"`
+	TableName  string `json:"table_name"`
+}
+
+// IndexStats is an index with its usage since the statistics were last reset and its size on disk.
+// Columns are the key columns or expressions, KeyOptions the operator class, collation and ordering
+// of every key and Include the non-key columns
+type IndexStats struct {
+	IndexName     string   `json:"index_name" db:"index_name"`
+	IndexOid      string   `json:"index_oid" db:"index_oid"`
+	IndexType     string   `json:"index_type" db:"index_type"`
+	SchemaName    string   `json:"schema_name" db:"schema_name"`
+	TableName     string   `json:"table_name" db:"table_name"`
+	Columns       []string `json:"columns" db:"columns"`
+	KeyOptions    []string `json:"-" db:"key_options"`
+	Include       []string `json:"include" db:"include"`
+	Predicate     *string  `json:"predicate" db:"predicate"`
+	Definition    string   `json:"definition" db:"definition"`
+	IsUnique      bool     `json:"is_unique" db:"is_unique"`
+	IsPrimary     bool     `json:"is_primary" db:"is_primary"`
+	IsValid       bool     `json:"is_valid" db:"is_valid"`
+	Scans         int64    `json:"scans" db:"scans"`
+	TuplesRead    int64    `json:"tuples_read" db:"tuples_read"`
+	TuplesFetched int64    `json:"tuples_fetched" db:"tuples_fetched"`
+	SizeBytes     int64    `json:"size_bytes" db:"size_bytes"`
+}
+
+// RedundantIndex is an index made unnecessary by another index of the same table. a duplicate has the
+// same keys, an overlapping index has keys that are a prefix of the keys of the other index
+type RedundantIndex struct {
+	Index     IndexStats `json:"index"`
+	CoveredBy IndexStats `json:"covered_by"`
+	Kind      string     `json:"kind"`
+}
+
+// ReindexRequest rebuilds an index, or every index of Table. a concurrent rebuild doesn't block the
+// writes to the table, the schema of the table is the public schema when it is empty
+type ReindexRequest struct {
+	Schema       string `json:"schema,omitempty"`
+	Table        string `json:"table,omitempty"`
+	Concurrently bool   `json:"concurrently"`
+}
+
+// IndexBuildProgress is a running CREATE INDEX or REINDEX read from pg_stat_progress_create_index,
+// Percent is the progress of the current phase
+type IndexBuildProgress struct {
+	Pid             int     `json:"pid" db:"pid"`
+	SchemaName      string  `json:"schema_name" db:"schema_name"`
+	TableName       string  `json:"table_name" db:"table_name"`
+	IndexName       string  `json:"index_name" db:"index_name"`
+	IndexOid        string  `json:"index_oid" db:"index_oid"`
+	Command         string  `json:"command" db:"command"`
+	Phase           string  `json:"phase" db:"phase"`
+	LockersTotal    int64   `json:"lockers_total" db:"lockers_total"`
+	LockersDone     int64   `json:"lockers_done" db:"lockers_done"`
+	BlocksTotal     int64   `json:"blocks_total" db:"blocks_total"`
+	BlocksDone      int64   `json:"blocks_done" db:"blocks_done"`
+	TuplesTotal     int64   `json:"tuples_total" db:"tuples_total"`
+	TuplesDone      int64   `json:"tuples_done" db:"tuples_done"`
+	PartitionsTotal int64   `json:"partitions_total" db:"partitions_total"`
+	PartitionsDone  int64   `json:"partitions_done" db:"partitions_done"`
+	Percent         float64 `json:"percent" db:"-"`
+}
+
+// IndexPages is the size of a btree index with the statistics used to estimate its size without bloat,
+// KeyWidth is the average width of its key columns
+type IndexPages struct {
+	IndexName  string  `db:"index_name"`
+	IndexOid   string  `db:"index_oid"`
+	SchemaName string  `db:"schema_name"`
+	TableName  string  `db:"table_name"`
+	Pages      int64   `db:"pages"`
+	Tuples     float64 `db:"tuples"`
+	KeyWidth   float64 `db:"key_width"`
+	BlockSize  int64   `db:"block_size"`
+	FillFactor int64   `db:"fill_factor"`
+}
+
+// IndexBloat is the estimated space a rebuild of a btree index would free
+type IndexBloat struct {
+	IndexName     string  `json:"index_name"`
+	IndexOid      string  `json:"index_oid"`
+	SchemaName    string  `json:"schema_name"`
+	TableName     string  `json:"table_name"`
+	SizeBytes     int64   `json:"size_bytes"`
+	ExpectedBytes int64   `json:"expected_bytes"`
+	BloatBytes    int64   `json:"bloat_bytes"`
+	BloatRatio    float64 `json:"bloat_ratio"`
 }
 
 type UpdateName struct {
@@ -1,16 +1,18 @@
 package indexes
 
 import (
+	"DBHS/utils"
 	"context"
 	"errors"
 	"strings"
 
+	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func GetProjectIndexes(ctx context.Context, conn *pgxpool.Pool) ([]RetrievedIndex, error) {
-	rows, err := conn.Query(ctx, SELECT_ALL_INDEXES)
+func GetProjectIndexes(ctx context.Context, conn *pgxpool.Pool, schema string) ([]RetrievedIndex, error) {
+	rows, err := conn.Query(ctx, SELECT_ALL_INDEXES, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -19,8 +21,8 @@ func GetProjectIndexes(ctx context.Context, conn *pgxpool.Pool) ([]RetrievedInde
 	var indexes []RetrievedIndex
 	for rows.Next() {
 		var index RetrievedIndex
-		// Scan three columns: (index_name, index_oid, index_type)
-		if err := rows.Scan(&index.IndexName, &index.IndexOid, &index.IndexType); err != nil {
+		// Scan five columns: (index_name, index_oid, index_type, schema_name, table_name)
+		if err := rows.Scan(&index.IndexName, &index.IndexOid, &index.IndexType, &index.SchemaName, &index.TableName); err != nil {
 			return nil, err
 		}
 		indexes = append(indexes, index)
@@ -37,14 +39,14 @@ func GetSpecificIndexFromDatabase(ctx context.Context, conn *pgxpool.Pool, index
 		return DefaultSpecificIndex
 	}
 	var index SpecificIndex
-	if err := row.Scan(&index.IndexName, &index.IndexType); err != nil {
+	if err := row.Scan(&index.IndexName, &index.IndexType, &index.SchemaName, &index.TableName); err != nil {
 		return DefaultSpecificIndex
 	}
 	return index
 }
 
-func DeleteIndexFromDatabase(ctx context.Context, conn *pgxpool.Pool, indexName string) error {
-	DELETE_INDEX := GenerateDeleteIndexQuery(indexName)
+func DeleteIndexFromDatabase(ctx context.Context, conn *pgxpool.Pool, schema string, indexName string) error {
+	DELETE_INDEX := GenerateDeleteIndexQuery(utils.QualifiedName(schema, indexName))
 	_, err := conn.Exec(ctx, DELETE_INDEX)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -55,8 +57,8 @@ func DeleteIndexFromDatabase(ctx context.Context, conn *pgxpool.Pool, indexName
 	return nil
 }
 
-func UpdateIndexNameInDatabase(ctx context.Context, conn *pgxpool.Pool, oldName string, newName string) error {
-	UPDATE_INDEX := GenerateRenameIndexQuery(oldName, newName)
+func UpdateIndexNameInDatabase(ctx context.Context, conn *pgxpool.Pool, schema string, oldName string, newName string) error {
+	UPDATE_INDEX := GenerateRenameIndexQuery(utils.QualifiedName(schema, oldName), newName)
 	_, err := conn.Exec(ctx, UPDATE_INDEX)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -69,3 +71,78 @@ func UpdateIndexNameInDatabase(ctx context.Context, conn *pgxpool.Pool, oldName
 	}
 	return nil
 }
+
+// GetIndexMethods returns the index access methods of the project database
+func GetIndexMethods(ctx context.Context, conn *pgxpool.Pool) ([]string, error) {
+	var methods []string
+	if err := pgxscan.Select(ctx, conn, &methods, SELECT_INDEX_METHODS); err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
+
+// GetOperatorClasses returns the operator classes of an index access method
+func GetOperatorClasses(ctx context.Context, conn *pgxpool.Pool, method string) ([]string, error) {
+	var opclasses []string
+	if err := pgxscan.Select(ctx, conn, &opclasses, SELECT_OPERATOR_CLASSES, method); err != nil {
+		return nil, err
+	}
+	return opclasses, nil
+}
+
+// DropInvalidIndex drops an index left invalid by a failed concurrent build
+func DropInvalidIndex(ctx context.Context, conn *pgxpool.Pool, schema string, indexName string) error {
+	name := utils.QualifiedName(schema, indexName)
+	var invalid bool
+	if err := conn.QueryRow(ctx, SELECT_INDEX_IS_INVALID, name).Scan(&invalid); err != nil {
+		return err
+	}
+	if !invalid {
+		return nil
+	}
+	_, err := conn.Exec(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+name)
+	return err
+}
+
+// GetIndexStats returns the usage and size of the indexes of one schema, or of every user schema when schema is empty
+func GetIndexStats(ctx context.Context, conn *pgxpool.Pool, schema string) ([]IndexStats, error) {
+	stats := []IndexStats{}
+	if err := pgxscan.Select(ctx, conn, &stats, SELECT_INDEX_STATS, schema); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// TableExistsInDatabase reports whether a table of a user schema exists
+func TableExistsInDatabase(ctx context.Context, conn *pgxpool.Pool, schema string, table string) (bool, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, SELECT_TABLE_EXISTS, schema, table).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// Reindex rebuilds an index, or every index of a table. a concurrent rebuild can't run inside a
+// transaction so the statement is sent on its own
+func Reindex(ctx context.Context, conn *pgxpool.Pool, kind string, qualifiedName string, concurrently bool) error {
+	_, err := conn.Exec(ctx, GenerateReindexQuery(kind, qualifiedName, concurrently))
+	return err
+}
+
+// GetIndexBuildProgress returns the index builds and rebuilds running in the project database
+func GetIndexBuildProgress(ctx context.Context, conn *pgxpool.Pool) ([]IndexBuildProgress, error) {
+	progress := []IndexBuildProgress{}
+	if err := pgxscan.Select(ctx, conn, &progress, SELECT_INDEX_BUILD_PROGRESS); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// GetIndexPages returns the size and the key statistics of the btree indexes of one schema, or of every user schema when schema is empty
+func GetIndexPages(ctx context.Context, conn *pgxpool.Pool, schema string) ([]IndexPages, error) {
+	pages := []IndexPages{}
+	if err := pgxscan.Select(ctx, conn, &pages, SELECT_INDEX_PAGES, schema); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
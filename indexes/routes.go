@@ -24,5 +24,31 @@ func DefineURLs() {
 	router.Use(middleware.JwtAuthMiddleware)
 
 	router.Handle("", middleware.Route(empty))
+	// the statistics and maintenance routes are registered before /{index_oid} so they are matched first
+	router.Handle("/stats", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: IndexStatistics(config.App),
+	}))
+	router.Handle("/unused", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: UnusedIndexesHandler(config.App),
+	}))
+	router.Handle("/duplicates", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: RedundantIndexesHandler(config.App),
+	}))
+	router.Handle("/invalid", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:    InvalidIndexesHandler(config.App),
+		http.MethodDelete: DropInvalidIndexesHandler(config.App),
+	}))
+	router.Handle("/bloat", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: IndexBloatHandler(config.App),
+	}))
+	router.Handle("/progress", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: IndexBuildsHandler(config.App),
+	}))
+	router.Handle("/reindex", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: ReindexTableHandler(config.App),
+	}))
 	router.Handle("/{index_oid}", middleware.Route(single))
+	router.Handle("/{index_oid}/reindex", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: ReindexIndexHandler(config.App),
+	}))
 }
@@ -4,8 +4,10 @@ import (
 	"DBHS/utils"
 	"context"
 	"errors"
+	"slices"
 	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"DBHS/config"
@@ -29,13 +31,67 @@ func CreateIndexInDatabase(ctx context.Context, db *pgxpool.Pool, projectOid str
 	}
 	defer conn.Close()
 
-	// ------------------------ Create the index in the database ------------------------
+	if indexData.Schema != "" {
+		exists, err := utils.SchemaExists(ctx, conn, indexData.Schema)
+		if err != nil {
+			return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		}
+		if !exists {
+			return *api.NewApiError("Schema does not exist", 400, errors.New("schema "+indexData.Schema+" does not exist"))
+		}
+	}
+	indexData.Schema = utils.SchemaOrDefault(indexData.Schema)
+
+	// ------------------------ Validate the index against the table ------------------------
+
+	table, err := utils.GetTableSchema(ctx, indexData.Schema, indexData.TableName, conn)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			return *api.NewApiError("Table does not exist", 400, errors.New(err.Error()))
+		}
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = column.ColumnName
+	}
+	methods, err := GetIndexMethods(ctx, conn)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	opclasses, err := GetOperatorClasses(ctx, conn, strings.ToLower(strings.TrimSpace(indexData.IndexType)))
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if err := ValidateIndexData(&indexData, columns, methods, opclasses); err != nil {
+		return *api.NewApiError(err.Error(), 400, errors.New(err.Error()))
+	}
 
 	query := GenerateIndexQuery(indexData)
+	if err := CheckIndexStatement(query, &indexData); err != nil {
+		return *api.NewApiError(err.Error(), 400, errors.New(err.Error()))
+	}
+
+	// ------------------------ Create the index in the database ------------------------
+
+	// a concurrent build can't run inside a transaction, the statement is sent on its own
 	if _, err = conn.Exec(ctx, query); err != nil {
-		if strings.Contains(err.Error(), "already exists") {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "42P07" {
 			return *api.NewApiError("the index name must be unique", 400, errors.New(err.Error()))
 		}
+		if indexData.Concurrently {
+			if dropErr := DropInvalidIndex(context.Background(), conn, indexData.Schema, utils.ReplaceWhiteSpacesWithUnderscore(indexData.IndexName)); dropErr != nil {
+				config.App.ErrorLog.Println("Failed to drop invalid index:", dropErr)
+			}
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return *api.NewApiError("the table has duplicate values for the unique index", 400, errors.New(err.Error()))
+		}
+		// undefined functions, mistyped expressions and unsupported options are the client's to fix
+		if errors.As(err, &pgErr) && slices.Contains([]string{"0A", "22", "42"}, pgErr.Code[:2]) {
+			return *api.NewApiError(pgErr.Message, 400, errors.New(err.Error()))
+		}
 		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
 	}
 
@@ -43,7 +99,8 @@ func CreateIndexInDatabase(ctx context.Context, db *pgxpool.Pool, projectOid str
 	return *api.NewApiError("Index created successfully", 200, nil)
 }
 
-func GetIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string) ([]RetrievedIndex, api.ApiError) {
+// GetIndexes lists the indexes of one schema, or of every user schema when schema is empty
+func GetIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]RetrievedIndex, api.ApiError) {
 	// Get user ID from context
 	UserID, ok := ctx.Value("user-id").(int64)
 	if !ok || UserID == 0 {
@@ -62,7 +119,7 @@ func GetIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string) ([]Ret
 
 	// ------------------------ Get the indexes from the database ------------------------
 
-	indexes, err := GetProjectIndexes(ctx, conn)
+	indexes, err := GetProjectIndexes(ctx, conn, schema)
 	if err != nil {
 		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
 	}
@@ -125,7 +182,7 @@ func DeleteSpecificIndex(ctx context.Context, db *pgxpool.Pool, projectOid, inde
 		return *api.NewApiError("Index not found", 404, errors.New("index with the given ID not found"))
 	}
 
-	err = DeleteIndexFromDatabase(ctx, conn, IndexData.IndexName)
+	err = DeleteIndexFromDatabase(ctx, conn, IndexData.SchemaName, IndexData.IndexName)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
@@ -170,7 +227,7 @@ func UpdateSpecificIndex(ctx context.Context, db *pgxpool.Pool, projectOid, inde
 		return *api.NewApiError("Index name is the same as the current name", 400, errors.New("index name is the same as the current name"))
 	}
 
-	err = UpdateIndexNameInDatabase(ctx, conn, indexData.IndexName, newName)
+	err = UpdateIndexNameInDatabase(ctx, conn, indexData.SchemaName, indexData.IndexName, newName)
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
 			return *api.NewApiError("Index with the same name already exists", 400, errors.New(err.Error()))
@@ -183,3 +240,281 @@ func UpdateSpecificIndex(ctx context.Context, db *pgxpool.Pool, projectOid, inde
 
 	return *api.NewApiError("Index updated successfully", 200, nil)
 }
+
+// GetIndexStatistics lists the usage, size and health of the indexes of one schema, or of every user schema when schema is empty
+func GetIndexStatistics(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]IndexStats, api.ApiError) {
+	// Get user ID from context
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return nil, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return nil, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	// ------------------------ Get the index statistics from the database ------------------------
+
+	stats, err := GetIndexStats(ctx, conn, schema)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	return stats, *api.NewApiError("Index statistics retrieved successfully", 200, nil)
+}
+
+// GetUnusedIndexes lists the indexes that weren't scanned since the statistics were last reset
+func GetUnusedIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]IndexStats, api.ApiError) {
+	stats, apiErr := GetIndexStatistics(ctx, db, projectOid, schema)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	return UnusedIndexes(stats), *api.NewApiError("Unused indexes retrieved successfully", 200, nil)
+}
+
+// GetRedundantIndexes lists the duplicate and overlapping indexes
+func GetRedundantIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]RedundantIndex, api.ApiError) {
+	stats, apiErr := GetIndexStatistics(ctx, db, projectOid, schema)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	return RedundantIndexes(stats), *api.NewApiError("Redundant indexes retrieved successfully", 200, nil)
+}
+
+// reindexError maps the error of a failed REINDEX, a unique index can't be rebuilt over duplicate
+// values and system or exclusion constraint indexes can't be rebuilt concurrently
+func reindexError(err error) api.ApiError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && slices.Contains([]string{"0A", "22", "23", "42"}, pgErr.Code[:2]) {
+		return *api.NewApiError(pgErr.Message, 400, errors.New(err.Error()))
+	}
+	return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+}
+
+// dropReindexLeftovers drops the invalid indexes a failed concurrent rebuild left on a table
+func dropReindexLeftovers(ctx context.Context, conn *pgxpool.Pool, schema string, table string) {
+	stats, err := GetIndexStats(ctx, conn, schema)
+	if err != nil {
+		config.App.ErrorLog.Println("Failed to list the indexes left by a failed reindex:", err)
+		return
+	}
+	builds, err := GetIndexBuildProgress(ctx, conn)
+	if err != nil {
+		config.App.ErrorLog.Println("Failed to list the index builds:", err)
+		return
+	}
+	tableStats := slices.DeleteFunc(stats, func(index IndexStats) bool { return index.TableName != table })
+	for _, index := range ReindexLeftovers(InvalidIndexes(tableStats, builds)) {
+		if err := DropInvalidIndex(ctx, conn, index.SchemaName, index.IndexName); err != nil {
+			config.App.ErrorLog.Println("Failed to drop invalid index:", err)
+		}
+	}
+}
+
+// ReindexSpecificIndex rebuilds an index, a failed concurrent rebuild leaves no invalid index behind
+func ReindexSpecificIndex(ctx context.Context, db *pgxpool.Pool, projectOid, indexOid string, concurrently bool) api.ApiError {
+	// Get user ID from context
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	// ------------------------ Rebuild the index ------------------------
+
+	index := GetSpecificIndexFromDatabase(ctx, conn, indexOid)
+	if index == (SpecificIndex{}) {
+		return *api.NewApiError("Index not found", 404, errors.New("index with the given ID not found"))
+	}
+
+	if err := Reindex(ctx, conn, ReindexIndex, utils.QualifiedName(index.SchemaName, index.IndexName), concurrently); err != nil {
+		if concurrently {
+			dropReindexLeftovers(context.Background(), conn, index.SchemaName, index.TableName)
+		}
+		return reindexError(err)
+	}
+
+	config.App.InfoLog.Println("Index rebuilt successfully for project:", projectOid)
+	return *api.NewApiError("Index rebuilt successfully", 200, nil)
+}
+
+// ReindexTableIndexes rebuilds every index of a table
+func ReindexTableIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string, request ReindexRequest) api.ApiError {
+	// Get user ID from context
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	// ------------------------ Rebuild the indexes of the table ------------------------
+
+	schema := utils.SchemaOrDefault(request.Schema)
+	exists, err := TableExistsInDatabase(ctx, conn, schema, request.Table)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if !exists {
+		return *api.NewApiError("Table not found", 404, errors.New("table "+schema+"."+request.Table+" does not exist"))
+	}
+
+	if err := Reindex(ctx, conn, ReindexTable, utils.QualifiedName(schema, request.Table), request.Concurrently); err != nil {
+		if request.Concurrently {
+			dropReindexLeftovers(context.Background(), conn, schema, request.Table)
+		}
+		return reindexError(err)
+	}
+
+	config.App.InfoLog.Println("Table indexes rebuilt successfully for project:", projectOid)
+	return *api.NewApiError("Table indexes rebuilt successfully", 200, nil)
+}
+
+// GetInvalidIndexes lists the invalid indexes that aren't being built
+func GetInvalidIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]IndexStats, api.ApiError) {
+	// Get user ID from context
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return nil, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return nil, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	invalid, apiErr := invalidIndexes(ctx, conn, schema)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	return invalid, *api.NewApiError("Invalid indexes retrieved successfully", 200, nil)
+}
+
+// DropInvalidIndexes drops the invalid indexes that aren't being built and returns them
+func DropInvalidIndexes(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]IndexStats, api.ApiError) {
+	// Get user ID from context
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return nil, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return nil, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	invalid, apiErr := invalidIndexes(ctx, conn, schema)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+
+	// ------------------------ Drop the invalid indexes ------------------------
+
+	dropped := []IndexStats{}
+	for _, index := range invalid {
+		if err := DropInvalidIndex(ctx, conn, index.SchemaName, index.IndexName); err != nil {
+			return dropped, *api.NewApiError("Internal server error", 500, errors.New("failed to drop index "+index.IndexName+": "+err.Error()))
+		}
+		dropped = append(dropped, index)
+	}
+
+	config.App.InfoLog.Println("Invalid indexes dropped successfully for project:", projectOid)
+	return dropped, *api.NewApiError("Invalid indexes dropped successfully", 200, nil)
+}
+
+func invalidIndexes(ctx context.Context, conn *pgxpool.Pool, schema string) ([]IndexStats, api.ApiError) {
+	stats, err := GetIndexStats(ctx, conn, schema)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	builds, err := GetIndexBuildProgress(ctx, conn)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	return InvalidIndexes(stats, builds), api.ApiError{}
+}
+
+// GetIndexBloat estimates the bloat of the btree indexes of one schema, or of every user schema when schema is empty
+func GetIndexBloat(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]IndexBloat, api.ApiError) {
+	// Get user ID from context
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return nil, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return nil, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	pages, err := GetIndexPages(ctx, conn, schema)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	return EstimateIndexBloat(pages), *api.NewApiError("Index bloat estimated successfully", 200, nil)
+}
+
+// GetIndexBuilds lists the index builds and rebuilds running in the project database
+func GetIndexBuilds(ctx context.Context, db *pgxpool.Pool, projectOid string) ([]IndexBuildProgress, api.ApiError) {
+	// Get user ID from context
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return nil, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return nil, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	builds, err := GetIndexBuildProgress(ctx, conn)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	for i := range builds {
+		SetBuildPercent(&builds[i])
+	}
+	return builds, *api.NewApiError("Index builds retrieved successfully", 200, nil)
+}
@@ -4,19 +4,295 @@ import (
 	"DBHS/config"
 	"DBHS/projects"
 	"DBHS/utils"
+	"cmp"
 	"context"
 	"errors"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// generates a SQL query to create an index on a table
-// CREATE INDEX index_name ON table_name USING index_type (column1, column2, column3);
+// IndexError is returned when an index definition doesn't match its table or the database
+type IndexError struct {
+	Reason string
+}
+
+func (e *IndexError) Error() string {
+	return "invalid index: " + e.Reason
+}
+
+// indexKeys returns the keys of an index, plain columns are keys without options
+func indexKeys(index *IndexData) []IndexKey {
+	if len(index.Keys) > 0 {
+		return index.Keys
+	}
+	keys := make([]IndexKey, len(index.Columns))
+	for i, column := range index.Columns {
+		keys[i] = IndexKey{Column: column}
+	}
+	return keys
+}
+
+// ValidateIndexData checks an index definition against the columns of its table, the index methods
+// of the database and the operator classes of the index method
+func ValidateIndexData(index *IndexData, columns, methods, opclasses []string) error {
+	index.IndexType = strings.ToLower(strings.TrimSpace(index.IndexType))
+	if !slices.Contains(methods, index.IndexType) {
+		return &IndexError{Reason: fmt.Sprintf("unknown index type %q", index.IndexType)}
+	}
+	if len(index.Columns) > 0 && len(index.Keys) > 0 {
+		return &IndexError{Reason: "give either columns or keys"}
+	}
+	keys := indexKeys(index)
+	if len(keys) == 0 {
+		return &IndexError{Reason: "the index needs at least one key"}
+	}
+	for _, key := range keys {
+		if (key.Column == "") == (strings.TrimSpace(key.Expression) == "") {
+			return &IndexError{Reason: "a key is either a column or an expression"}
+		}
+		if key.Column != "" && !slices.Contains(columns, key.Column) {
+			return &IndexError{Reason: fmt.Sprintf("%s is not a column of the table", key.Column)}
+		}
+		if key.OpClass != "" && !slices.Contains(opclasses, key.OpClass) {
+			return &IndexError{Reason: fmt.Sprintf("unknown operator class %q for %s indexes", key.OpClass, index.IndexType)}
+		}
+	}
+	for _, column := range index.Include {
+		if !slices.Contains(columns, column) {
+			return &IndexError{Reason: fmt.Sprintf("included column %s is not a column of the table", column)}
+		}
+	}
+	return nil
+}
+
+// generates a SQL query to create an index on a table, the index is always created in the schema of its table
+// CREATE [UNIQUE] INDEX [CONCURRENTLY] "name" ON "schema"."table" USING method
+// (key [opclass] [DESC] [NULLS FIRST|LAST], ...) [INCLUDE (column, ...)] [WHERE predicate]
 func GenerateIndexQuery(Index IndexData) string {
-	columns := strings.Join(Index.Columns, ", ")
-	Index.IndexName = utils.ReplaceWhiteSpacesWithUnderscore(Index.IndexName)
-	return "CREATE INDEX " + Index.IndexName + " ON " + Index.TableName + " USING " + Index.IndexType + " (" + columns + ")"
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if Index.Unique {
+		sb.WriteString("UNIQUE ")
+	}
+	sb.WriteString("INDEX ")
+	if Index.Concurrently {
+		sb.WriteString("CONCURRENTLY ")
+	}
+
+	keys := indexKeys(&Index)
+	formatted := make([]string, len(keys))
+	for i, key := range keys {
+		formatted[i] = formatIndexKey(key)
+	}
+	name := utils.ReplaceWhiteSpacesWithUnderscore(Index.IndexName)
+	sb.WriteString(fmt.Sprintf("%s ON %s USING %s (%s)", utils.QuoteIdentifier(name),
+		utils.QualifiedName(Index.Schema, Index.TableName), utils.QuoteIdentifier(Index.IndexType), strings.Join(formatted, ", ")))
+
+	if len(Index.Include) > 0 {
+		included := make([]string, len(Index.Include))
+		for i, column := range Index.Include {
+			included[i] = utils.QuoteIdentifier(column)
+		}
+		sb.WriteString(fmt.Sprintf(" INCLUDE (%s)", strings.Join(included, ", ")))
+	}
+	if where := strings.TrimSpace(Index.Where); where != "" {
+		sb.WriteString(" WHERE " + where)
+	}
+	return sb.String()
+}
+
+// formatIndexKey returns a key of an index definition, expressions are parenthesized
+func formatIndexKey(key IndexKey) string {
+	formatted := utils.QuoteIdentifier(key.Column)
+	if key.Column == "" {
+		formatted = "(" + strings.TrimSpace(key.Expression) + ")"
+	}
+	if key.OpClass != "" {
+		formatted += " " + utils.QuoteIdentifier(key.OpClass)
+	}
+	if key.Descending {
+		formatted += " DESC"
+	}
+	if key.NullsFirst != nil {
+		if *key.NullsFirst {
+			formatted += " NULLS FIRST"
+		} else {
+			formatted += " NULLS LAST"
+		}
+	}
+	return formatted
+}
+
+// CheckIndexStatement parses a generated CREATE INDEX statement and checks that the expressions
+// and the predicate of the index didn't change its shape, so they can't smuggle other SQL in
+func CheckIndexStatement(query string, index *IndexData) error {
+	statements, err := utils.ParseSQLStatements(query)
+	if err != nil {
+		return err
+	}
+	invalid := &IndexError{Reason: "the key expressions and the predicate must be single SQL expressions"}
+	if len(statements) != 1 {
+		return invalid
+	}
+	stmt := statements[0].Node.GetIndexStmt()
+	if stmt == nil {
+		return invalid
+	}
+	hasWhere := strings.TrimSpace(index.Where) != ""
+	if len(stmt.IndexParams) != len(indexKeys(index)) || len(stmt.IndexIncludingParams) != len(index.Include) ||
+		(stmt.WhereClause != nil) != hasWhere || stmt.Unique != index.Unique || stmt.Concurrent != index.Concurrently {
+		return invalid
+	}
+	return nil
+}
+
+const (
+	DuplicateIndex   = "duplicate"
+	OverlappingIndex = "overlapping"
+)
+
+// UnusedIndexes returns the indexes that were never scanned. unique and primary key indexes
+// enforce constraints so they are never reported
+func UnusedIndexes(stats []IndexStats) []IndexStats {
+	unused := []IndexStats{}
+	for _, index := range stats {
+		if index.Scans == 0 && !index.IsUnique && !index.IsPrimary {
+			unused = append(unused, index)
+		}
+	}
+	return unused
+}
+
+// RedundantIndexes returns the indexes covered by another index of the same table with the same
+// method and predicate, keys only match with the same operator class, collation and ordering. of two
+// duplicates the one enforcing a constraint, or else the first by name, is kept. an index whose keys
+// are a prefix of a btree index is overlapping unless it is unique
+func RedundantIndexes(stats []IndexStats) []RedundantIndex {
+	redundant := []RedundantIndex{}
+	for i, index := range stats {
+		for j, other := range stats {
+			if i == j || !sameIndexTarget(index, other) {
+				continue
+			}
+			if slices.Equal(index.Columns, other.Columns) && slices.Equal(index.KeyOptions, other.KeyOptions) &&
+				slices.Equal(index.Include, other.Include) {
+				if keepsIndex(other, index, j < i) {
+					redundant = append(redundant, RedundantIndex{Index: index, CoveredBy: other, Kind: DuplicateIndex})
+					break
+				}
+				continue
+			}
+			if index.IndexType == "btree" && !index.IsUnique && len(index.Columns) < len(other.Columns) &&
+				slices.Equal(index.Columns, other.Columns[:len(index.Columns)]) &&
+				slices.Equal(index.KeyOptions, other.KeyOptions[:min(len(index.KeyOptions), len(other.KeyOptions))]) {
+				redundant = append(redundant, RedundantIndex{Index: index, CoveredBy: other, Kind: OverlappingIndex})
+				break
+			}
+		}
+	}
+	return redundant
+}
+
+// InvalidIndexes returns the invalid indexes that aren't being built, an index is invalid while it is
+// built concurrently and stays invalid when the build fails
+func InvalidIndexes(stats []IndexStats, builds []IndexBuildProgress) []IndexStats {
+	invalid := []IndexStats{}
+	for _, index := range stats {
+		building := slices.ContainsFunc(builds, func(build IndexBuildProgress) bool {
+			return build.IndexOid == index.IndexOid
+		})
+		if !index.IsValid && !building {
+			invalid = append(invalid, index)
+		}
+	}
+	return invalid
+}
+
+// ReindexLeftovers returns the invalid indexes a failed concurrent rebuild leaves behind, the new
+// indexes are suffixed with _ccnew and the old ones with _ccold
+func ReindexLeftovers(invalid []IndexStats) []IndexStats {
+	leftovers := []IndexStats{}
+	for _, index := range invalid {
+		if strings.Contains(index.IndexName, "_ccnew") || strings.Contains(index.IndexName, "_ccold") {
+			leftovers = append(leftovers, index)
+		}
+	}
+	return leftovers
+}
+
+// SetBuildPercent sets the progress of the current phase of an index build, the table scanning
+// phases count blocks and the other phases count tuples
+func SetBuildPercent(build *IndexBuildProgress) {
+	done, total := build.TuplesDone, build.TuplesTotal
+	if strings.Contains(build.Phase, "scanning") || total == 0 {
+		done, total = build.BlocksDone, build.BlocksTotal
+	}
+	if total == 0 {
+		build.Percent = 0
+		return
+	}
+	build.Percent = math.Round(float64(done)/float64(total)*10000) / 100
+}
+
+const (
+	// a btree page starts with a page header and ends with the btree special space
+	btreePageOverhead = 24 + 16
+	// an index tuple has a header and a line pointer on its page
+	indexTupleHeader = 8
+	linePointerSize  = 4
+)
+
+// EstimateIndexBloat estimates the size of btree indexes packed at their fill factor and the space
+// a rebuild would free, the largest bloat comes first
+func EstimateIndexBloat(pages []IndexPages) []IndexBloat {
+	bloat := make([]IndexBloat, 0, len(pages))
+	for _, index := range pages {
+		tupleSize := math.Ceil((indexTupleHeader+index.KeyWidth)/8)*8 + linePointerSize
+		pageSpace := float64(index.BlockSize-btreePageOverhead) * float64(index.FillFactor) / 100
+		leafPages := int64(math.Ceil(index.Tuples * tupleSize / pageSpace))
+		// the meta page comes on top of the leaf pages
+		expected := max(leafPages, 1) + 1
+		bloated := max(index.Pages-expected, 0)
+
+		bloat = append(bloat, IndexBloat{
+			IndexName:     index.IndexName,
+			IndexOid:      index.IndexOid,
+			SchemaName:    index.SchemaName,
+			TableName:     index.TableName,
+			SizeBytes:     index.Pages * index.BlockSize,
+			ExpectedBytes: min(expected, index.Pages) * index.BlockSize,
+			BloatBytes:    bloated * index.BlockSize,
+			BloatRatio:    math.Round(float64(bloated)/float64(index.Pages)*10000) / 10000,
+		})
+	}
+	slices.SortStableFunc(bloat, func(a, b IndexBloat) int {
+		return cmp.Compare(b.BloatBytes, a.BloatBytes)
+	})
+	return bloat
+}
+
+// sameIndexTarget reports whether two indexes index the same rows of the same table the same way
+func sameIndexTarget(a, b IndexStats) bool {
+	samePredicate := (a.Predicate == nil && b.Predicate == nil) ||
+		(a.Predicate != nil && b.Predicate != nil && *a.Predicate == *b.Predicate)
+	return a.SchemaName == b.SchemaName && a.TableName == b.TableName && a.IndexType == b.IndexType && samePredicate
+}
+
+// keepsIndex reports whether kept is the index kept of two duplicates, first is true when kept
+// comes first in the list
+func keepsIndex(kept, dropped IndexStats, first bool) bool {
+	if kept.IsPrimary != dropped.IsPrimary {
+		return kept.IsPrimary
+	}
+	if kept.IsUnique != dropped.IsUnique {
+		return kept.IsUnique
+	}
+	return first
 }
 
 // generates a SQL query to delete an index
@@ -28,7 +304,21 @@ func GenerateDeleteIndexQuery(indexName string) string {
 // generates a SQL query to rename an index
 // ALTER INDEX old_index_name RENAME TO new_index_name;
 func GenerateRenameIndexQuery(oldName string, newName string) string {
-	return "ALTER INDEX IF EXISTS " + oldName + " RENAME TO " + newName
+	return "ALTER INDEX IF EXISTS " + oldName + " RENAME TO " + utils.QuoteIdentifier(newName)
+}
+
+const (
+	ReindexIndex = "INDEX"
+	ReindexTable = "TABLE"
+)
+
+// generates a SQL query to rebuild an index or the indexes of a table
+func GenerateReindexQuery(kind string, qualifiedName string, concurrently bool) string {
+	query := "REINDEX " + kind + " "
+	if concurrently {
+		query += "CONCURRENTLY "
+	}
+	return query + qualifiedName
 }
 
 func ProjectPoolConnection(ctx context.Context, db *pgxpool.Pool, UserID int64, projectOid string) (*pgxpool.Pool, error) {
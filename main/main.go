@@ -98,6 +98,18 @@ func main() {
 	c.AddFunc("0 0 * * *", func() {
 		workers.GatherAnalytics(config.App)
 	})
+	// materialized views with a refresh schedule are checked every minute
+	c.AddFunc("* * * * *", func() {
+		workers.RefreshScheduledViews(config.App)
+	})
+	// tables with a partition policy get their partitions created and dropped every hour
+	c.AddFunc("0 * * * *", func() {
+		workers.MaintainPartitionPolicies(config.App)
+	})
+	// the expired rows of the tables with a ttl are purged every five minutes
+	c.AddFunc("*/5 * * * *", func() {
+		workers.PurgeExpiredRows(config.App)
+	})
 	c.Start()
 
 	err := server.ListenAndServe()
@@ -5,10 +5,16 @@ import (
 	sqleditor "DBHS/SqlEditor"
 	"DBHS/accounts"
 	"DBHS/analytics"
+	"DBHS/functions"
 	"DBHS/indexes"
+	"DBHS/migrations"
+	"DBHS/namespaces"
+	"DBHS/openapi"
 	"DBHS/projects"
 	"DBHS/schemas"
 	"DBHS/tables"
+	"DBHS/types"
+	"DBHS/views"
 )
 
 func defineURLs() {
@@ -20,4 +26,10 @@ func defineURLs() {
 	ai.DefineURLs()
 	analytics.DefineURLs()
 	sqleditor.DefineURLs()
+	migrations.DefineURLs()
+	namespaces.DefineURLs()
+	openapi.DefineURLs()
+	views.DefineURLs()
+	types.DefineURLs()
+	functions.DefineURLs()
 }
@@ -0,0 +1,27 @@
+package migrations
+
+const (
+	// platform bookkeeping lives in its own schema so it doesn't show up next to the user tables
+	CREATE_MIGRATIONS_TABLE = `
+		CREATE SCHEMA IF NOT EXISTS "_dbhs";
+		CREATE TABLE IF NOT EXISTS "_dbhs"."migrations" (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			checksum TEXT NOT NULL,
+			statements INTEGER NOT NULL,
+			applied_by BIGINT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`
+
+	MIGRATIONS_TABLE_EXISTS = `SELECT to_regclass('"_dbhs"."migrations"') IS NOT NULL`
+
+	// serializes concurrent uploads to the same project database until the transaction ends
+	LOCK_MIGRATIONS = `SELECT pg_advisory_xact_lock(hashtext('_dbhs.migrations'))`
+
+	SELECT_MIGRATIONS = `SELECT id, name, checksum, statements, applied_by, applied_at FROM "_dbhs"."migrations" ORDER BY id`
+
+	INSERT_MIGRATION = `
+		INSERT INTO "_dbhs"."migrations" (name, checksum, statements, applied_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, name, checksum, statements, applied_by, applied_at`
+)
@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"DBHS/config"
+	"DBHS/response"
+	"DBHS/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ApplyMigrationFiles godoc
+// @Summary Apply SQL migration files
+// @Description Upload one or more .sql files and apply them to the project database in a single transaction. Files are applied in file name order, already applied files with the same checksum are skipped and files that changed after they were applied are refused
+// @Tags migrations
+// @Accept multipart/form-data
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param files formData file true "SQL migration files"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse{data=ApplyResult} "Migrations applied successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid file, SQL syntax error or failing statement"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 409 {object} response.ErrorResponse "An applied migration was modified"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/migrations [post]
+func ApplyMigrationFiles(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		projectOid := urlVariables["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, MAX_UPLOAD_SIZE)
+		if err := r.ParseMultipartForm(MAX_UPLOAD_SIZE); err != nil {
+			response.BadRequest(w, r, "Invalid multipart form", err)
+			return
+		}
+
+		files, err := ReadMigrationFiles(r.MultipartForm.File[FILES_FIELD])
+		if err != nil {
+			response.BadRequest(w, r, err.Error(), err)
+			return
+		}
+
+		result, apiErr := ApplyMigrations(r.Context(), config.DB, projectOid, files)
+		if apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to apply migrations:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.Created(w, r, "Migrations applied successfully", result)
+	}
+}
+
+// GetMigrations godoc
+// @Summary List applied migrations
+// @Description List the migrations applied to the project database with their checksums
+// @Tags migrations
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]Migration} "Migrations retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/migrations [get]
+func GetMigrations(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		projectOid := urlVariables["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		migrations, apiErr := ListMigrations(r.Context(), config.DB, projectOid)
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Migrations retrieved successfully", migrations)
+	}
+}
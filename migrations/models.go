@@ -0,0 +1,26 @@
+package migrations
+
+import "time"
+
+// Migration is a row of the migrations table kept inside each project database
+type Migration struct {
+	ID         int64     `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	Checksum   string    `json:"checksum" db:"checksum"`
+	Statements int       `json:"statements" db:"statements"`
+	AppliedBy  int64     `json:"applied_by" db:"applied_by"`
+	AppliedAt  time.Time `json:"applied_at" db:"applied_at"`
+}
+
+// MigrationFile is an uploaded .sql file after it has been parsed into statements
+type MigrationFile struct {
+	Name       string
+	Checksum   string
+	Statements []string
+}
+
+type ApplyResult struct {
+	Applied []Migration `json:"applied"`
+	// Skipped lists the files that were already applied with the same checksum
+	Skipped []string `json:"skipped"`
+}
@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"DBHS/utils"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// allowedStatements are the parse tree nodes of the statements a migration can run: the definition
+// of the objects of the project schemas and the changes of their rows. migrations run on the admin
+// connection of the project, so anything reaching the server, its files or its roles is left out
+var allowedStatements = []string{
+	// tables, views and sequences
+	"CreateStmt", "AlterTableStmt", "DropStmt", "RenameStmt", "AlterObjectSchemaStmt", "TruncateStmt",
+	"CreateTableAsStmt", "ViewStmt", "RefreshMatViewStmt", "CreateSeqStmt", "AlterSeqStmt",
+	"IndexStmt", "ReindexStmt", "CreateStatsStmt", "CommentStmt", "LockStmt",
+	// schemas and types
+	"CreateSchemaStmt", "CreateEnumStmt", "AlterEnumStmt", "CompositeTypeStmt", "CreateDomainStmt",
+	"AlterDomainStmt", "CreateRangeStmt",
+	// functions, triggers and row level security
+	"CreateFunctionStmt", "CreateTrigStmt", "CreatePolicyStmt", "AlterPolicyStmt",
+	// rows
+	"SelectStmt", "InsertStmt", "UpdateStmt", "DeleteStmt", "MergeStmt", "CopyStmt",
+	// settings of the migration transaction
+	"VariableSetStmt", "TransactionStmt",
+}
+
+// checkStatement rejects the statements a migration can't run and reports whether the statement is
+// a transaction wrapper that should be skipped
+func checkStatement(statement utils.ParsedStatement) (bool, error) {
+	top := utils.InnerNode(statement.Node)
+	if top == nil {
+		return false, errors.New("empty statement")
+	}
+	kind := string(top.ProtoReflect().Descriptor().Name())
+	if !slices.Contains(allowedStatements, kind) {
+		return false, fmt.Errorf("%s statements are not allowed in migrations", strings.TrimSuffix(kind, "Stmt"))
+	}
+
+	switch stmt := top.(type) {
+	case *pg_query.TransactionStmt:
+		switch stmt.Kind {
+		case pg_query.TransactionStmtKind_TRANS_STMT_BEGIN,
+			pg_query.TransactionStmtKind_TRANS_STMT_START,
+			pg_query.TransactionStmtKind_TRANS_STMT_COMMIT:
+			return true, nil
+		}
+		return false, errors.New("transaction control statements other than BEGIN and COMMIT are not allowed")
+	case *pg_query.IndexStmt:
+		if stmt.Concurrent {
+			return false, errors.New("CREATE INDEX CONCURRENTLY cannot run inside a migration transaction")
+		}
+	case *pg_query.ReindexStmt:
+		for _, param := range stmt.Params {
+			if def := param.GetDefElem(); def != nil && def.Defname == "concurrently" {
+				return false, errors.New("REINDEX CONCURRENTLY cannot run inside a migration transaction")
+			}
+		}
+	case *pg_query.CopyStmt:
+		if stmt.IsProgram || stmt.Filename != "" {
+			return false, errors.New("COPY can't read or write server files or programs in migrations")
+		}
+	case *pg_query.CreateFunctionStmt:
+		if err := utils.CheckFunctionBody(stmt, statement.SQL, checkBodyStatement); err != nil {
+			return false, err
+		}
+	}
+
+	return false, utils.CheckReferences(statement.Node)
+}
+
+// checkBodyStatement checks a statement of the body of a function like the ones of the migration
+func checkBodyStatement(statement utils.ParsedStatement) error {
+	skip, err := checkStatement(statement)
+	if err != nil {
+		return err
+	}
+	if skip {
+		return errors.New("transaction control statements are not allowed")
+	}
+	return nil
+}
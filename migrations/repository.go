@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"DBHS/utils"
+	"context"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+func EnsureMigrationsTable(ctx context.Context, db utils.Querier) error {
+	_, err := db.Exec(ctx, CREATE_MIGRATIONS_TABLE)
+	return err
+}
+
+func MigrationsTableExists(ctx context.Context, db utils.Querier) (bool, error) {
+	var exists bool
+	err := db.QueryRow(ctx, MIGRATIONS_TABLE_EXISTS).Scan(&exists)
+	return exists, err
+}
+
+func GetAppliedMigrations(ctx context.Context, db utils.Querier) ([]Migration, error) {
+	var migrations []Migration
+	if err := pgxscan.Select(ctx, db, &migrations, SELECT_MIGRATIONS); err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+func InsertMigration(ctx context.Context, db utils.Querier, file *MigrationFile, userID int64) (Migration, error) {
+	var migration Migration
+	err := pgxscan.Get(ctx, db, &migration, INSERT_MIGRATION, file.Name, file.Checksum, len(file.Statements), userID)
+	return migration, err
+}
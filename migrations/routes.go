@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"DBHS/config"
+	"DBHS/middleware"
+	"net/http"
+)
+
+func DefineURLs() {
+	router := config.Router.PathPrefix("/api/projects/{project_id}/migrations").Subrouter()
+	router.Use(middleware.JwtAuthMiddleware, middleware.CheckOwnership)
+
+	router.Handle("", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: ApplyMigrationFiles(config.App),
+		http.MethodGet:  GetMigrations(config.App),
+	}))
+}
@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"DBHS/config"
+	"DBHS/indexes"
+	api "DBHS/utils/apiError"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func ApplyMigrations(ctx context.Context, db *pgxpool.Pool, projectOid string, files []*MigrationFile) (ApplyResult, api.ApiError) {
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return ApplyResult{}, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := indexes.ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return ApplyResult{}, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return ApplyResult{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	if err := EnsureMigrationsTable(ctx, conn); err != nil {
+		return ApplyResult{}, *api.NewApiError("Internal server error", 500, errors.New("failed to create migrations table: "+err.Error()))
+	}
+
+	// ------------------------ Apply the migrations in one transaction ------------------------
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return ApplyResult{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, LOCK_MIGRATIONS); err != nil {
+		return ApplyResult{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	applied, err := GetAppliedMigrations(ctx, tx)
+	if err != nil {
+		return ApplyResult{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	checksums := make(map[string]string, len(applied))
+	for _, migration := range applied {
+		checksums[migration.Name] = migration.Checksum
+	}
+
+	// refuse the whole upload before running anything if an applied file was edited
+	result := ApplyResult{Applied: make([]Migration, 0), Skipped: make([]string, 0)}
+	pending := make([]*MigrationFile, 0, len(files))
+	for _, file := range files {
+		checksum, exists := checksums[file.Name]
+		if !exists {
+			pending = append(pending, file)
+			continue
+		}
+		if checksum != file.Checksum {
+			return ApplyResult{}, *api.NewApiError("Migration was modified after it was applied", http.StatusConflict,
+				fmt.Errorf("checksum of %s does not match the applied migration", file.Name))
+		}
+		result.Skipped = append(result.Skipped, file.Name)
+	}
+
+	for _, file := range pending {
+		for i, statement := range file.Statements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return ApplyResult{}, *api.NewApiError("Migration failed, no changes were applied", 400,
+					fmt.Errorf("%s statement %d: %w", file.Name, i+1, err))
+			}
+		}
+
+		migration, err := InsertMigration(ctx, tx, file, UserID)
+		if err != nil {
+			return ApplyResult{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		}
+		result.Applied = append(result.Applied, migration)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return ApplyResult{}, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	config.App.InfoLog.Printf("%d migrations applied for project: %s", len(result.Applied), projectOid)
+	return result, api.ApiError{}
+}
+
+func ListMigrations(ctx context.Context, db *pgxpool.Pool, projectOid string) ([]Migration, api.ApiError) {
+	UserID, ok := ctx.Value("user-id").(int64)
+	if !ok || UserID == 0 {
+		return nil, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	// ------------------------ Get the project pool connection ------------------------
+	conn, err := indexes.ProjectPoolConnection(ctx, db, UserID, projectOid)
+	if err != nil {
+		if err.Error() == "Project not found" || err.Error() == "connection pool not found" {
+			return nil, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer conn.Close()
+
+	exists, err := MigrationsTableExists(ctx, conn)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if !exists {
+		return make([]Migration, 0), api.ApiError{}
+	}
+
+	migrations, err := GetAppliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if migrations == nil {
+		migrations = make([]Migration, 0)
+	}
+	return migrations, api.ApiError{}
+}
@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"DBHS/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	MAX_UPLOAD_SIZE = 10 << 20 // 10 MB for all the files of one request
+	FILES_FIELD     = "files"
+)
+
+var ErrNoFiles = errors.New("at least one .sql file is required")
+
+// ReadMigrationFiles reads, checksums and parses the uploaded files ordered by file name
+func ReadMigrationFiles(headers []*multipart.FileHeader) ([]*MigrationFile, error) {
+	if len(headers) == 0 {
+		return nil, ErrNoFiles
+	}
+
+	files := make([]*MigrationFile, 0, len(headers))
+	seen := make(map[string]bool)
+	for _, header := range headers {
+		name := filepath.Base(header.Filename)
+		if !strings.EqualFold(filepath.Ext(name), ".sql") {
+			return nil, fmt.Errorf("%s is not a .sql file", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s was uploaded more than once", name)
+		}
+		seen[name] = true
+
+		content, err := readFile(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		file, err := ParseMigrationFile(name, content)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	slices.SortFunc(files, func(a, b *MigrationFile) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return files, nil
+}
+
+func readFile(header *multipart.FileHeader) (string, error) {
+	f, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// ParseMigrationFile splits a migration into statements with the PostgreSQL parser.
+// BEGIN/COMMIT wrappers are dropped because every upload already runs in one transaction
+func ParseMigrationFile(name, content string) (*MigrationFile, error) {
+	sum := sha256.Sum256([]byte(content))
+	file := &MigrationFile{
+		Name:     name,
+		Checksum: hex.EncodeToString(sum[:]),
+	}
+
+	statements, err := utils.ParseSQLStatements(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for _, statement := range statements {
+		skip, err := checkStatement(statement)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", name, statement.Line, err)
+		}
+		if !skip {
+			file.Statements = append(file.Statements, statement.SQL)
+		}
+	}
+
+	if len(file.Statements) == 0 {
+		return nil, fmt.Errorf("%s does not contain any statement", name)
+	}
+	return file, nil
+}
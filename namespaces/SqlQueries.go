@@ -0,0 +1,19 @@
+package namespaces
+
+import "DBHS/utils"
+
+const (
+	SELECT_SCHEMAS = `
+		SELECT n.nspname AS name,
+			pg_get_userbyid(n.nspowner) AS owner,
+			(SELECT COUNT(*) FROM pg_class c WHERE c.relnamespace = n.oid AND c.relkind IN ('r', 'p')) AS tables
+		FROM pg_namespace n
+		WHERE ` + utils.UserSchemasFilter + `
+		ORDER BY n.nspname;`
+
+	CREATE_SCHEMA = `CREATE SCHEMA %s;`
+	DROP_SCHEMA   = `DROP SCHEMA %s %s;`
+
+	// the table records of a dropped schema are removed from the service database
+	DELETE_SCHEMA_TABLES = `DELETE FROM "Ptable" WHERE project_id = $1 AND schema_name = $2;`
+)
@@ -0,0 +1,131 @@
+package namespaces
+
+import (
+	"DBHS/config"
+	"DBHS/response"
+	"DBHS/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetSchemasHandler godoc
+// @Summary List database schemas
+// @Description List the user schemas of the project database with their owner and table count, system and platform schemas are left out
+// @Tags schemas
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]Schema} "Schemas retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/schemas [get]
+func GetSchemasHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		projectOid := urlVariables["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		schemas, apiErr := ListProjectSchemas(r.Context(), config.DB, projectOid)
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Schemas retrieved successfully", schemas)
+	}
+}
+
+// CreateSchemaHandler godoc
+// @Summary Create a database schema
+// @Description Create a new schema in the project database
+// @Tags schemas
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema body CreateSchemaRequest true "Schema name"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse "Schema created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid or reserved schema name"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 409 {object} response.ErrorResponse "Schema already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/schemas [post]
+func CreateSchemaHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		projectOid := urlVariables["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		var request CreateSchemaRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		if apiErr := CreateProjectSchema(r.Context(), config.DB, projectOid, request.Name); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to create schema:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.Created(w, r, "Schema created successfully", map[string]string{
+			"name": request.Name,
+		})
+	}
+}
+
+// DropSchemaHandler godoc
+// @Summary Drop a database schema
+// @Description Drop a schema of the project database. The public schema can't be dropped and a schema that still holds objects is only dropped with cascade
+// @Tags schemas
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema_name path string true "Schema name"
+// @Param cascade query bool false "Drop the objects of the schema as well"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Schema dropped successfully"
+// @Failure 400 {object} response.ErrorResponse "Reserved schema"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or schema not found"
+// @Failure 409 {object} response.ErrorResponse "Schema is not empty"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/schemas/{schema_name} [delete]
+func DropSchemaHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		projectOid := urlVariables["project_id"]
+		schemaName := urlVariables["schema_name"]
+		if projectOid == "" || schemaName == "" {
+			response.BadRequest(w, r, "Project Id and schema name are required", nil)
+			return
+		}
+
+		cascade := false
+		if value := r.URL.Query().Get("cascade"); value != "" {
+			var err error
+			if cascade, err = strconv.ParseBool(value); err != nil {
+				response.BadRequest(w, r, "cascade must be a boolean", err)
+				return
+			}
+		}
+
+		if apiErr := DropProjectSchema(r.Context(), config.DB, projectOid, schemaName, cascade); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to drop schema:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Schema dropped successfully", nil)
+	}
+}
@@ -0,0 +1,12 @@
+package namespaces
+
+// Schema is a postgres schema (namespace) of a project database
+type Schema struct {
+	Name   string `json:"name" db:"name"`
+	Owner  string `json:"owner" db:"owner"`
+	Tables int64  `json:"tables" db:"tables"`
+}
+
+type CreateSchemaRequest struct {
+	Name string `json:"name"`
+}
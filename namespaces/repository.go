@@ -0,0 +1,36 @@
+package namespaces
+
+import (
+	"DBHS/utils"
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+func GetSchemas(ctx context.Context, db utils.Querier) ([]Schema, error) {
+	var schemas []Schema
+	if err := pgxscan.Select(ctx, db, &schemas, SELECT_SCHEMAS); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+func CreateSchema(ctx context.Context, db utils.Querier, name string) error {
+	_, err := db.Exec(ctx, fmt.Sprintf(CREATE_SCHEMA, utils.QuoteIdentifier(name)))
+	return err
+}
+
+func DropSchema(ctx context.Context, db utils.Querier, name string, cascade bool) error {
+	behavior := "RESTRICT"
+	if cascade {
+		behavior = "CASCADE"
+	}
+	_, err := db.Exec(ctx, fmt.Sprintf(DROP_SCHEMA, utils.QuoteIdentifier(name), behavior))
+	return err
+}
+
+func DeleteSchemaTables(ctx context.Context, db utils.Querier, projectID int64, name string) error {
+	_, err := db.Exec(ctx, DELETE_SCHEMA_TABLES, projectID, name)
+	return err
+}
@@ -0,0 +1,21 @@
+package namespaces
+
+import (
+	"DBHS/config"
+	"DBHS/middleware"
+	"net/http"
+)
+
+func DefineURLs() {
+	router := config.Router.PathPrefix("/api/projects/{project_id}/schemas").Subrouter()
+	router.Use(middleware.JwtAuthMiddleware, middleware.CheckOwnership)
+
+	router.Handle("", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:  GetSchemasHandler(config.App),
+		http.MethodPost: CreateSchemaHandler(config.App),
+	}))
+
+	router.Handle("/{schema_name}", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodDelete: DropSchemaHandler(config.App),
+	}))
+}
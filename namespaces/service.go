@@ -0,0 +1,94 @@
+package namespaces
+
+import (
+	"DBHS/config"
+	"DBHS/utils"
+	api "DBHS/utils/apiError"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	duplicateSchemaCode  = "42P06"
+	dependentObjectsCode = "2BP01"
+)
+
+func ListProjectSchemas(ctx context.Context, db *pgxpool.Pool, projectOid string) ([]Schema, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	schemas, err := GetSchemas(ctx, userDb)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if schemas == nil {
+		schemas = make([]Schema, 0)
+	}
+	return schemas, api.ApiError{}
+}
+
+func CreateProjectSchema(ctx context.Context, db *pgxpool.Pool, projectOid string, name string) api.ApiError {
+	if err := ValidateSchemaName(name); err != nil {
+		return *api.NewApiError(err.Error(), 400, err)
+	}
+
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	if err := CreateSchema(ctx, userDb, name); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == duplicateSchemaCode {
+			return *api.NewApiError("Schema already exists", http.StatusConflict, errors.New(err.Error()))
+		}
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	config.App.InfoLog.Printf("Schema %s created in project %s", name, projectOid)
+	return api.ApiError{}
+}
+
+// DropProjectSchema drops a user schema, without cascade the schema has to be empty
+func DropProjectSchema(ctx context.Context, db *pgxpool.Pool, projectOid string, name string, cascade bool) api.ApiError {
+	if name == utils.DefaultSchema || utils.IsReservedSchema(name) {
+		return *api.NewApiError("Schema can not be dropped", 400, errors.New("schema "+name+" is reserved"))
+	}
+
+	projectID, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	exists, err := utils.SchemaExists(ctx, userDb, name)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if !exists {
+		return *api.NewApiError("Schema not found", 404, errors.New("schema "+name+" does not exist"))
+	}
+
+	if err := DropSchema(ctx, userDb, name, cascade); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == dependentObjectsCode {
+			return *api.NewApiError("Schema is not empty, use cascade to drop its objects", http.StatusConflict, errors.New(err.Error()))
+		}
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if err := DeleteSchemaTables(ctx, db, projectID, name); err != nil {
+		config.App.ErrorLog.Println("Failed to delete table records of dropped schema:", err, "server db and user db are not in sync")
+	}
+
+	config.App.InfoLog.Printf("Schema %s dropped in project %s", name, projectOid)
+	return api.ApiError{}
+}
@@ -0,0 +1,24 @@
+package namespaces
+
+import (
+	"DBHS/utils"
+	"errors"
+	"regexp"
+)
+
+// schema names are kept to plain lower case identifiers so they never need quoting in user queries
+var schemaNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// ValidateSchemaName checks that a name can be used for a new user schema
+func ValidateSchemaName(name string) error {
+	if name == "" {
+		return errors.New("schema name is required")
+	}
+	if !schemaNamePattern.MatchString(name) {
+		return errors.New("schema name must start with a lower case letter or underscore and contain only lower case letters, digits and underscores")
+	}
+	if utils.IsReservedSchema(name) {
+		return errors.New("schema name is reserved")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package openapi
+
+import (
+	"DBHS/config"
+	"DBHS/response"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/MarceloPetrucio/go-scalar-api-reference"
+	"github.com/gorilla/mux"
+)
+
+// GetProjectOpenAPI godoc
+// @Summary Get the OpenAPI document of a project
+// @Description Generate an OpenAPI 3.1 document of the project data API with one path per table and schemas derived from the table columns
+// @Tags openapi
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Security BearerAuth
+// @Success 200 {object} Document "OpenAPI document"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /api/projects/{project_id}/openapi.json [get]
+func GetProjectOpenAPI(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+
+		doc, err := GetProjectSpec(r.Context(), projectOid, serverURL(r), config.DB)
+		if err != nil {
+			if errors.Is(err, response.ErrUnauthorized) {
+				response.UnAuthorized(w, r, "Unauthorized", nil)
+				return
+			}
+			if errors.Is(err, ErrProjectNotFound) {
+				response.NotFound(w, r, "Project not found", nil)
+				return
+			}
+			app.ErrorLog.Println("Could not generate openapi document:", err)
+			response.InternalServerError(w, r, "Could not generate openapi document", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(doc)
+	}
+}
+
+// GetProjectReference godoc
+// @Summary Get the API reference page of a project
+// @Description Serve a Scalar API reference page rendering the generated OpenAPI document of the project
+// @Tags openapi
+// @Produce html
+// @Param project_id path string true "Project ID"
+// @Security BearerAuth
+// @Success 200 {string} string "HTML page"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /api/projects/{project_id}/openapi [get]
+func GetProjectReference(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+
+		doc, err := GetProjectSpec(r.Context(), projectOid, serverURL(r), config.DB)
+		if err != nil {
+			if errors.Is(err, response.ErrUnauthorized) {
+				response.UnAuthorized(w, r, "Unauthorized", nil)
+				return
+			}
+			if errors.Is(err, ErrProjectNotFound) {
+				response.NotFound(w, r, "Project not found", nil)
+				return
+			}
+			app.ErrorLog.Println("Could not generate openapi document:", err)
+			response.InternalServerError(w, r, "Could not generate openapi document", err)
+			return
+		}
+
+		// the page is fetched with the user token, so the document is embedded instead of linked
+		spec, err := json.Marshal(doc)
+		if err != nil {
+			response.InternalServerError(w, r, "Could not generate openapi document", err)
+			return
+		}
+		htmlContent, err := scalar.ApiReferenceHTML(&scalar.Options{
+			SpecContent: string(spec),
+			CustomOptions: scalar.CustomOptions{
+				PageTitle: doc.Info.Title,
+			},
+			DarkMode: true,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(htmlContent))
+	}
+}
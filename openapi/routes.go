@@ -0,0 +1,20 @@
+package openapi
+
+import (
+	"DBHS/config"
+	"DBHS/middleware"
+	"net/http"
+)
+
+func DefineURLs() {
+	router := config.Router.PathPrefix("/api/projects/{project_id}/openapi").Subrouter()
+	router.Use(middleware.JwtAuthMiddleware, middleware.CheckOwnership)
+
+	router.Handle(".json", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: GetProjectOpenAPI(config.App),
+	}))
+
+	router.Handle("", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: GetProjectReference(config.App),
+	}))
+}
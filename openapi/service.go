@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"DBHS/response"
+	"DBHS/schemas"
+	"DBHS/tables"
+	"DBHS/utils"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	componentUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+	ErrProjectNotFound = errors.New("Project not found")
+)
+
+// GetProjectSpec generates the OpenAPI document of the tables of a project
+func GetProjectSpec(ctx context.Context, projectOID, serverURL string, servDb *pgxpool.Pool) (*Document, error) {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return nil, response.ErrUnauthorized
+	}
+
+	projectId, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+	defer userDb.Close()
+
+	projectTables, err := tables.GetAllTablesRepository(ctx, projectId, userDb, servDb)
+	if err != nil {
+		return nil, err
+	}
+
+	enums := make(map[string]map[string][]string)
+	specTables := make([]ProjectTable, 0, len(projectTables))
+	for _, table := range projectTables {
+		if table.Schema == nil {
+			continue
+		}
+		if _, loaded := enums[table.SchemaName]; !loaded {
+			enums[table.SchemaName], err = utils.GetEnumTypes(ctx, userDb, table.SchemaName)
+			if err != nil {
+				return nil, err
+			}
+		}
+		specTables = append(specTables, ProjectTable{OID: table.OID, Schema: table.Schema})
+	}
+
+	return BuildSpec(projectOID, serverURL, specTables, enums), nil
+}
+
+// BuildSpec builds an OpenAPI document with one path per table, the row schema of every
+// table is derived from its columns. enums holds the enum types of every schema
+func BuildSpec(projectOID, serverURL string, projectTables []ProjectTable, enums map[string]map[string][]string) *Document {
+	slices.SortFunc(projectTables, func(a, b ProjectTable) int {
+		return strings.Compare(tableName(a.Schema), tableName(b.Schema))
+	})
+
+	doc := &Document{
+		OpenAPI: OpenAPIVersion,
+		Info: Info{
+			Title:       "Project " + projectOID + " API",
+			Description: "Generated from the current schema of the project tables",
+			Version:     "1.0",
+		},
+		Tags:  make([]Tag, 0, len(projectTables)),
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas: map[string]interface{}{
+				"Column": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{"type": "string"},
+						"type": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"BearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{"BearerAuth": {}}},
+	}
+	if serverURL != "" {
+		doc.Servers = []Server{{URL: serverURL}}
+	}
+
+	for _, table := range projectTables {
+		name := tableName(table.Schema)
+		rowSchema := componentUnsafe.ReplaceAllString(name, "_")
+		insertSchema := rowSchema + "_insert"
+
+		row, insert := tableSchemas(table.Schema, enums[utils.SchemaOrDefault(table.Schema.SchemaName)])
+		doc.Components.Schemas[rowSchema] = row
+		doc.Components.Schemas[insertSchema] = insert
+		doc.Tags = append(doc.Tags, Tag{Name: name})
+
+		doc.Paths[fmt.Sprintf("/api/projects/%s/tables/%s", projectOID, table.OID)] = &PathItem{
+			Get: &Operation{
+				OperationID: "list_" + rowSchema,
+				Summary:     "Read rows of " + name,
+				Tags:        []string{name},
+				Parameters: []Parameter{
+					{Name: "page", In: "query", Required: true, Schema: map[string]interface{}{"type": "integer", "minimum": 0}},
+					{Name: "limit", In: "query", Required: true, Schema: map[string]interface{}{"type": "integer", "minimum": 0}},
+					{
+						Name: "filter", In: "query", Description: "column:op:value, op is one of eq, neq, lt, lte, gt, gte, like",
+						Schema: map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					},
+				},
+				Responses: map[string]*Response{
+					"200": jsonResponse("Table Read Succesfully", map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"columns": map[string]interface{}{"type": "array", "items": ref("Column")},
+							"rows":    map[string]interface{}{"type": "array", "items": ref(rowSchema)},
+						},
+					}),
+					"401": {Description: "Unauthorized"},
+				},
+			},
+			Post: &Operation{
+				OperationID: "insert_" + rowSchema,
+				Summary:     "Insert a row into " + name,
+				Tags:        []string{name},
+				RequestBody: &RequestBody{
+					Required: true,
+					Content:  map[string]*MediaType{"application/json": {Schema: ref(insertSchema)}},
+				},
+				Responses: map[string]*Response{
+					"201": jsonResponse("row created succefully", nil),
+					"400": {Description: "bad request body"},
+					"401": {Description: "Unauthorized"},
+				},
+			},
+		}
+	}
+
+	return doc
+}
+
+// tableSchemas returns the schema of a row and of the body used to insert one.
+// columns that are nullable or have a default can be left out of an insert
+func tableSchemas(table *utils.Table, enums map[string][]string) (map[string]interface{}, map[string]interface{}) {
+	properties := make(map[string]interface{})
+	required := make([]string, 0, len(table.Columns))
+	insertRequired := make([]string, 0, len(table.Columns))
+
+	columns := slices.Clone(table.Columns)
+	slices.SortFunc(columns, func(a, b utils.TableColumn) int { return a.OrdinalPosition - b.OrdinalPosition })
+	for _, col := range columns {
+		properties[col.ColumnName] = schemas.ColumnJSONSchema(col, enums)
+		required = append(required, col.ColumnName)
+		if !col.IsNullable && col.ColumnDefault == nil {
+			insertRequired = append(insertRequired, col.ColumnName)
+		}
+	}
+
+	row := map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	insert := map[string]interface{}{"type": "object", "properties": properties, "required": insertRequired, "additionalProperties": false}
+	return row, insert
+}
+
+// tableName qualifies the names of tables outside the default schema
+func tableName(table *utils.Table) string {
+	if schema := utils.SchemaOrDefault(table.SchemaName); schema != utils.DefaultSchema {
+		return schema + "." + table.TableName
+	}
+	return table.TableName
+}
+
+// jsonResponse wraps data in the response envelope used by every endpoint
+func jsonResponse(description string, data map[string]interface{}) *Response {
+	properties := map[string]interface{}{
+		"status":  map[string]interface{}{"type": "integer"},
+		"message": map[string]interface{}{"type": "string"},
+	}
+	if data != nil {
+		properties["data"] = data
+	}
+	return &Response{
+		Description: description,
+		Content: map[string]*MediaType{
+			"application/json": {Schema: map[string]interface{}{"type": "object", "properties": properties}},
+		},
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
@@ -0,0 +1,15 @@
+package openapi
+
+import "net/http"
+
+// serverURL is the base url the request reached the api with, honouring a proxy in front of it
+func serverURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + r.Host
+}
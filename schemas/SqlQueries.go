@@ -2,7 +2,7 @@ package schemas
 
 const (
 	GetDatabaseByName   = `SELECT host, port, user_id, password, db_name, ssl_mode, created_at FROM database_config WHERE db_name = $1`
-	GetTableNameByOID   = `SELECT name FROM "Ptable" WHERE oid = $1`
+	GetTableNameByOID   = `SELECT name, schema_name FROM "Ptable" WHERE oid = $1`
 	GetTableSchemaQuery = `SELECT
 					t.table_name,
 					c.column_name,
@@ -16,7 +16,7 @@ const (
 						WHERE tc.constraint_type = 'PRIMARY KEY'
 						AND kcu.table_name = t.table_name
 						AND kcu.column_name = c.column_name
-						AND kcu.table_schema = 'public'
+						AND kcu.table_schema = $2
 					) AS is_primary_key,
 					EXISTS (
 						SELECT 1 
@@ -26,7 +26,7 @@ const (
 						WHERE tc.constraint_type = 'UNIQUE'
 						AND kcu.table_name = t.table_name
 						AND kcu.column_name = c.column_name
-						AND kcu.table_schema = 'public'
+						AND kcu.table_schema = $2
 					) AS is_unique,
 					fk.foreign_table_name,
 					fk.foreign_column_name
@@ -52,11 +52,11 @@ const (
 						tc.constraint_type = 'FOREIGN KEY'
 						AND tc.table_name = t.table_name
 						AND kcu.column_name = c.column_name
-						AND tc.table_schema = 'public'
+						AND tc.table_schema = $2
 					LIMIT 1
 				) fk ON true
 				WHERE 
-					t.table_schema = 'public'
+					t.table_schema = $2
 					AND t.table_type = 'BASE TABLE'
 					AND t.table_name = $1
 				ORDER BY 
@@ -75,7 +75,7 @@ const (
 						WHERE tc.constraint_type = 'PRIMARY KEY'
 						AND kcu.table_name = t.table_name
 						AND kcu.column_name = c.column_name
-						AND kcu.table_schema = 'public'
+						AND kcu.table_schema = $1
 					) AS is_primary_key,
 					EXISTS (
 						SELECT 1 
@@ -85,7 +85,7 @@ const (
 						WHERE tc.constraint_type = 'UNIQUE'
 						AND kcu.table_name = t.table_name
 						AND kcu.column_name = c.column_name
-						AND kcu.table_schema = 'public'
+						AND kcu.table_schema = $1
 					) AS is_unique,
 					fk.foreign_table_name,
 					fk.foreign_column_name
@@ -111,11 +111,11 @@ const (
 						tc.constraint_type = 'FOREIGN KEY'
 						AND tc.table_name = t.table_name
 						AND kcu.column_name = c.column_name
-						AND tc.table_schema = 'public'
+						AND tc.table_schema = $1
 					LIMIT 1
 				) fk ON true
 				WHERE 
-					t.table_schema = 'public'
+					t.table_schema = $1
 					AND t.table_type = 'BASE TABLE'
 				ORDER BY 
 					t.table_name, 
@@ -0,0 +1,309 @@
+package schemas
+
+import (
+	"DBHS/utils"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	CodegenTypeScript = "typescript"
+	CodegenGo         = "go"
+	CodegenJSONSchema = "jsonschema"
+
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+)
+
+var (
+	CodegenTargets = []string{CodegenTypeScript, CodegenGo, CodegenJSONSchema}
+
+	tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+	// characters that can't appear in the name of a generated file
+	unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+	// common initialisms are kept upper case in Go names, as golint expects
+	goInitialisms = map[string]bool{
+		"id": true, "url": true, "uri": true, "uuid": true, "api": true, "ip": true,
+		"http": true, "json": true, "sql": true, "html": true, "db": true,
+	}
+)
+
+// columnType is the language independent description of a postgres column type
+type columnType struct {
+	ts       string
+	goType   string
+	goImport string
+	schema   map[string]interface{}
+	nilable  bool // the go type already has a nil value
+	enum     []string
+}
+
+// resolveColumnType maps a postgres type to language types. udt is the underlying type name
+// from information_schema, array types are prefixed with an underscore
+func resolveColumnType(udt string, enums map[string][]string) columnType {
+	if labels, isEnum := enums[udt]; isEnum {
+		quoted := make([]string, len(labels))
+		for i, label := range labels {
+			quoted[i] = fmt.Sprintf("%q", label)
+		}
+		ts := "string"
+		if len(quoted) > 0 {
+			ts = strings.Join(quoted, " | ")
+		}
+		return columnType{ts: ts, goType: "string", schema: map[string]interface{}{"type": "string", "enum": labels}, enum: labels}
+	}
+
+	if element, isArray := strings.CutPrefix(udt, "_"); isArray {
+		item := resolveColumnType(element, enums)
+		ts := item.ts + "[]"
+		if item.enum != nil {
+			ts = "(" + item.ts + ")[]"
+		}
+		return columnType{
+			ts:       ts,
+			goType:   "[]" + item.goType,
+			goImport: item.goImport,
+			schema:   map[string]interface{}{"type": "array", "items": item.schema},
+			nilable:  true,
+		}
+	}
+
+	switch udt {
+	case "int2":
+		return columnType{ts: "number", goType: "int16", schema: map[string]interface{}{"type": "integer"}}
+	case "int4":
+		return columnType{ts: "number", goType: "int32", schema: map[string]interface{}{"type": "integer"}}
+	case "int8":
+		return columnType{ts: "number", goType: "int64", schema: map[string]interface{}{"type": "integer"}}
+	case "float4":
+		return columnType{ts: "number", goType: "float32", schema: map[string]interface{}{"type": "number"}}
+	case "float8", "numeric", "money":
+		return columnType{ts: "number", goType: "float64", schema: map[string]interface{}{"type": "number"}}
+	case "bool":
+		return columnType{ts: "boolean", goType: "bool", schema: map[string]interface{}{"type": "boolean"}}
+	case "uuid":
+		return columnType{ts: "string", goType: "string", schema: map[string]interface{}{"type": "string", "format": "uuid"}}
+	case "date":
+		return columnType{ts: "string", goType: "time.Time", goImport: "time", schema: map[string]interface{}{"type": "string", "format": "date"}}
+	case "timestamp", "timestamptz":
+		return columnType{ts: "string", goType: "time.Time", goImport: "time", schema: map[string]interface{}{"type": "string", "format": "date-time"}}
+	case "time", "timetz":
+		return columnType{ts: "string", goType: "string", schema: map[string]interface{}{"type": "string", "format": "time"}}
+	case "json", "jsonb":
+		return columnType{ts: "unknown", goType: "json.RawMessage", goImport: "encoding/json", schema: map[string]interface{}{}, nilable: true}
+	case "bytea":
+		return columnType{ts: "string", goType: "[]byte", schema: map[string]interface{}{"type": "string", "contentEncoding": "base64"}, nilable: true}
+	case "text", "varchar", "bpchar", "char", "name", "citext", "interval", "inet", "cidr", "macaddr", "xml", "tsvector":
+		return columnType{ts: "string", goType: "string", schema: map[string]interface{}{"type": "string"}}
+	default:
+		return columnType{ts: "unknown", goType: "any", schema: map[string]interface{}{}, nilable: true}
+	}
+}
+
+// GenerateTypeScript renders one exported interface per table
+func GenerateTypeScript(tables []*utils.Table, enums map[string][]string) string {
+	var sb strings.Builder
+	sb.WriteString("// Code generated from the database schema. DO NOT EDIT.\n")
+	interfaces := make(map[string]int)
+	for _, table := range tables {
+		sb.WriteString(fmt.Sprintf("\nexport interface %s {\n", uniqueName(pascalCase(table.TableName, false), interfaces)))
+		for _, col := range sortedColumns(table) {
+			colType := resolveColumnType(col.UdtName, enums)
+			name := col.ColumnName
+			if !tsIdentifier.MatchString(name) {
+				name = fmt.Sprintf("%q", name)
+			}
+			tsType := colType.ts
+			if col.IsNullable {
+				tsType += " | null"
+			}
+			sb.WriteString(fmt.Sprintf("  %s: %s;\n", name, tsType))
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+// GenerateGoStructs renders one struct per table with db and json tags, formatted with gofmt
+func GenerateGoStructs(tables []*utils.Table, enums map[string][]string) (string, error) {
+	imports := make(map[string]bool)
+	var body strings.Builder
+	structs := make(map[string]int)
+	for _, table := range tables {
+		body.WriteString(fmt.Sprintf("\ntype %s struct {\n", uniqueName(pascalCase(table.TableName, true), structs)))
+		used := make(map[string]int)
+		for _, col := range sortedColumns(table) {
+			colType := resolveColumnType(col.UdtName, enums)
+			if colType.goImport != "" {
+				imports[colType.goImport] = true
+			}
+			goType := colType.goType
+			if col.IsNullable && !colType.nilable {
+				goType = "*" + goType
+			}
+			field := uniqueName(pascalCase(col.ColumnName, true), used)
+			body.WriteString(fmt.Sprintf("\t%s %s `db:%q json:%q`\n", field, goType, col.ColumnName, col.ColumnName))
+		}
+		body.WriteString("}\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated from the database schema. DO NOT EDIT.\n\npackage models\n")
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		slices.Sort(paths)
+		sb.WriteString("\nimport (\n")
+		for _, path := range paths {
+			sb.WriteString(fmt.Sprintf("\t%q\n", path))
+		}
+		sb.WriteString(")\n")
+	}
+	sb.WriteString(body.String())
+
+	source, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated go code: %w", err)
+	}
+	return string(source), nil
+}
+
+// ColumnJSONSchema returns the JSON Schema of a column value, nullable columns also accept null
+func ColumnJSONSchema(col utils.TableColumn, enums map[string][]string) map[string]interface{} {
+	schema := resolveColumnType(col.UdtName, enums).schema
+	if col.IsNullable {
+		schema = map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+	return schema
+}
+
+// GenerateJSONSchema renders the JSON Schema document of a table row
+func GenerateJSONSchema(table *utils.Table, enums map[string][]string) (string, error) {
+	properties := make(map[string]interface{})
+	required := make([]string, 0, len(table.Columns))
+	for _, col := range sortedColumns(table) {
+		properties[col.ColumnName] = ColumnJSONSchema(col, enums)
+		required = append(required, col.ColumnName)
+	}
+
+	document := map[string]interface{}{
+		"$schema":              jsonSchemaDraft,
+		"title":                table.TableName,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode json schema of %s: %w", table.TableName, err)
+	}
+	return string(content) + "\n", nil
+}
+
+// GenerateCode renders the requested targets and returns the generated files ordered by path
+func GenerateCode(tables []*utils.Table, enums map[string][]string, targets []string) ([]GeneratedFile, error) {
+	files := make([]GeneratedFile, 0)
+	for _, target := range targets {
+		switch target {
+		case CodegenTypeScript:
+			files = append(files, GeneratedFile{Path: "typescript/models.ts", Content: GenerateTypeScript(tables, enums)})
+		case CodegenGo:
+			content, err := GenerateGoStructs(tables, enums)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, GeneratedFile{Path: "go/models.go", Content: content})
+		case CodegenJSONSchema:
+			names := make(map[string]int)
+			for _, table := range tables {
+				content, err := GenerateJSONSchema(table, enums)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, GeneratedFile{Path: "jsonschema/" + uniqueName(schemaFileName(table.TableName), names) + ".schema.json", Content: content})
+			}
+		default:
+			return nil, fmt.Errorf("unknown codegen target %s", target)
+		}
+	}
+	return files, nil
+}
+
+// ZipFiles packs the generated files into a zip archive
+func ZipFiles(files []GeneratedFile) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		writer, err := archive.Create(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write([]byte(file.Content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// uniqueName numbers a name already in used, user_data and userData are both UserData in Go
+func uniqueName(name string, used map[string]int) string {
+	for {
+		if used[name]++; used[name] == 1 {
+			return name
+		}
+		// the numbered name can be taken by another table too
+		name = fmt.Sprintf("%s%d", name, used[name])
+	}
+}
+
+// schemaFileName makes a file name out of a table name, which can hold any character
+func schemaFileName(tableName string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(tableName, "_"), "_")
+	if name == "" {
+		name = "table"
+	}
+	return name
+}
+
+func sortedColumns(table *utils.Table) []utils.TableColumn {
+	columns := slices.Clone(table.Columns)
+	slices.SortFunc(columns, func(a, b utils.TableColumn) int { return a.OrdinalPosition - b.OrdinalPosition })
+	return columns
+}
+
+// pascalCase converts snake_case and other separators to PascalCase, prefixing names that start with a digit.
+// with goStyle set, common initialisms are upper cased
+func pascalCase(name string, goStyle bool) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var sb strings.Builder
+	for _, word := range words {
+		if goStyle && goInitialisms[strings.ToLower(word)] {
+			sb.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	result := sb.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "T" + result
+	}
+	return result
+}
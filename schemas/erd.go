@@ -0,0 +1,242 @@
+package schemas
+
+import (
+	"DBHS/utils"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	ERDFormatJSON    = "json"
+	ERDFormatMermaid = "mermaid"
+	ERDFormatDOT     = "dot"
+
+	// layout dimensions used for the node positions of the JSON graph
+	erdNodeWidth    = 240
+	erdHeaderHeight = 40
+	erdRowHeight    = 24
+	erdGapX         = 120
+	erdGapY         = 60
+)
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// BuildERDGraph turns the table metadata into a graph of tables linked by their foreign keys.
+// tables are placed in columns: referenced tables on the left, tables referencing them to the right
+func BuildERDGraph(tables map[string]*utils.Table) *ERDGraph {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	graph := &ERDGraph{Nodes: make([]ERDNode, 0, len(names)), Edges: make([]ERDEdge, 0)}
+	deps := make(map[string][]string)
+
+	for _, name := range names {
+		table := tables[name]
+		primaryKeys := make(map[string]bool)
+		uniques := make(map[string][]string)
+		foreignKeys := make(map[string]*ERDEdge)
+		fkOrder := make([]string, 0)
+
+		for _, constraint := range utils.MergeConstraints(name, table.Constraints) {
+			columns := constraint.KeyColumns()
+			switch constraint.ConstraintType {
+			case utils.PrimaryKeyConstraint:
+				for _, column := range columns {
+					primaryKeys[column] = true
+				}
+			case utils.UniqueConstraint:
+				uniques[constraint.ConstraintName] = columns
+			case utils.ForeignKeyConstraint:
+				if constraint.ForeignTableName == nil {
+					continue
+				}
+				foreignKeys[constraint.ConstraintName] = &ERDEdge{
+					ID:            constraint.ConstraintName,
+					Source:        name,
+					Target:        *constraint.ForeignTableName,
+					SourceColumns: columns,
+					TargetColumns: constraint.ReferencedColumns(),
+				}
+				fkOrder = append(fkOrder, constraint.ConstraintName)
+			}
+		}
+
+		uniqueColumns := make(map[string]bool)
+		for _, columns := range uniques {
+			if len(columns) == 1 {
+				uniqueColumns[columns[0]] = true
+			}
+		}
+
+		columns := slices.Clone(table.Columns)
+		slices.SortFunc(columns, func(a, b utils.TableColumn) int { return a.OrdinalPosition - b.OrdinalPosition })
+		node := ERDNode{ID: name, Columns: make([]ERDColumn, 0, len(columns))}
+		nullable := make(map[string]bool)
+		for _, col := range columns {
+			nullable[col.ColumnName] = col.IsNullable
+			node.Columns = append(node.Columns, ERDColumn{
+				Name:       col.ColumnName,
+				Type:       col.DataType,
+				Nullable:   col.IsNullable,
+				PrimaryKey: primaryKeys[col.ColumnName],
+				Unique:     uniqueColumns[col.ColumnName],
+			})
+		}
+
+		for _, constraintName := range fkOrder {
+			edge := foreignKeys[constraintName]
+			for _, column := range edge.SourceColumns {
+				if nullable[column] {
+					edge.Optional = true
+				}
+			}
+			edge.Cardinality = ERDManyToOne
+			if sameColumns(edge.SourceColumns, primaryKeys) || (len(edge.SourceColumns) == 1 && uniqueColumns[edge.SourceColumns[0]]) {
+				edge.Cardinality = ERDOneToOne
+			}
+			for i := range node.Columns {
+				if slices.Contains(edge.SourceColumns, node.Columns[i].Name) {
+					node.Columns[i].ForeignKey = true
+				}
+			}
+			if edge.Target != name {
+				deps[name] = append(deps[name], edge.Target)
+			}
+			graph.Edges = append(graph.Edges, *edge)
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	layoutERDGraph(graph, names, deps)
+	return graph
+}
+
+func sameColumns(columns []string, keys map[string]bool) bool {
+	if len(columns) == 0 || len(columns) != len(keys) {
+		return false
+	}
+	for _, column := range columns {
+		if !keys[column] {
+			return false
+		}
+	}
+	return true
+}
+
+// layoutERDGraph assigns every table to the layer after the deepest table it references,
+// then stacks the tables of each layer vertically
+func layoutERDGraph(graph *ERDGraph, names []string, deps map[string][]string) {
+	layers := make(map[string]int)
+	for _, name := range utils.OrderByDependencies(names, deps) {
+		for _, dep := range deps[name] {
+			if layer, placed := layers[dep]; placed && layer+1 > layers[name] {
+				layers[name] = layer + 1
+			}
+		}
+		if _, placed := layers[name]; !placed {
+			layers[name] = 0
+		}
+	}
+
+	nextY := make(map[int]int)
+	for i := range graph.Nodes {
+		node := &graph.Nodes[i]
+		layer := layers[node.ID]
+		node.Width = erdNodeWidth
+		node.Height = erdHeaderHeight + erdRowHeight*len(node.Columns)
+		node.X = layer * (erdNodeWidth + erdGapX)
+		node.Y = nextY[layer]
+		nextY[layer] += node.Height + erdGapY
+	}
+}
+
+// RenderMermaid renders the graph as a Mermaid erDiagram
+func RenderMermaid(graph *ERDGraph) string {
+	var sb strings.Builder
+	sb.WriteString("erDiagram\n")
+	for _, node := range graph.Nodes {
+		sb.WriteString(fmt.Sprintf("    %s {\n", mermaidName(node.ID)))
+		for _, col := range node.Columns {
+			sb.WriteString(fmt.Sprintf("        %s %s", mermaidName(col.Type), mermaidName(col.Name)))
+			keys := make([]string, 0, 3)
+			if col.PrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if col.ForeignKey {
+				keys = append(keys, "FK")
+			}
+			if col.Unique {
+				keys = append(keys, "UK")
+			}
+			if len(keys) > 0 {
+				sb.WriteString(" " + strings.Join(keys, ", "))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("    }\n")
+	}
+	for _, edge := range graph.Edges {
+		source := "}o"
+		if edge.Cardinality == ERDOneToOne {
+			source = "|o"
+		}
+		target := "||"
+		if edge.Optional {
+			target = "o|"
+		}
+		sb.WriteString(fmt.Sprintf("    %s %s--%s %s : %q\n",
+			mermaidName(edge.Source), source, target, mermaidName(edge.Target), strings.Join(edge.SourceColumns, ", ")))
+	}
+	return sb.String()
+}
+
+func mermaidName(name string) string {
+	return mermaidUnsafe.ReplaceAllString(name, "_")
+}
+
+// RenderDOT renders the graph as a Graphviz digraph with one record-like HTML table per node
+func RenderDOT(graph *ERDGraph) string {
+	var sb strings.Builder
+	sb.WriteString("digraph erd {\n")
+	sb.WriteString("    graph [rankdir=LR];\n")
+	sb.WriteString("    node [shape=plaintext];\n")
+	for _, node := range graph.Nodes {
+		sb.WriteString(fmt.Sprintf("    %s [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", dotID(node.ID)))
+		sb.WriteString(fmt.Sprintf("<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>", html.EscapeString(node.ID)))
+		for _, col := range node.Columns {
+			label := col.Name + ": " + col.Type
+			if col.PrimaryKey {
+				label += " (PK)"
+			}
+			if col.ForeignKey {
+				label += " (FK)"
+			}
+			sb.WriteString(fmt.Sprintf("<tr><td port=%q align=\"left\">%s</td></tr>", html.EscapeString(col.Name), html.EscapeString(label)))
+		}
+		sb.WriteString("</table>>];\n")
+	}
+	for _, edge := range graph.Edges {
+		from, to := dotID(edge.Source), dotID(edge.Target)
+		if len(edge.SourceColumns) == 1 && len(edge.TargetColumns) == 1 {
+			from += ":" + dotID(edge.SourceColumns[0])
+			to += ":" + dotID(edge.TargetColumns[0])
+		}
+		style := ""
+		if edge.Optional {
+			style = ", style=dashed"
+		}
+		sb.WriteString(fmt.Sprintf("    %s -> %s [label=%s%s];\n", from, to, dotID(edge.ID), style))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func dotID(id string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(id, `\`, `\\`), `"`, `\"`) + `"`
+}
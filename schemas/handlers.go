@@ -4,7 +4,9 @@ import (
 	"DBHS/config"
 	"DBHS/projects"
 	"DBHS/response"
+	"DBHS/utils"
 	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
 	"net/http"
@@ -23,6 +25,7 @@ import (
 // @Failure 400 {object} response.ErrorResponse "Project ID is required"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Param schema query string false "Schema to read, defaults to public"
 // @Router /api/projects/{project-id}/schema/tables [get]
 func GetDatabaseSchema(app *config.Application) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -55,8 +58,9 @@ func GetDatabaseSchema(app *config.Application) http.HandlerFunc {
 			response.InternalServerError(w, r, "Internal Server Error", nil)
 			return
 		}
+		defer databaseConn.Close()
 
-		schema, err := getDatabaseSchema(r.Context(), databaseConn)
+		schema, err := getDatabaseSchema(r.Context(), databaseConn, schemaParameter(r))
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			config.App.ErrorLog.Println(err)
 			response.InternalServerError(w, r, "Internal Server Error", nil)
@@ -111,16 +115,17 @@ func GetDatabaseTableSchema(app *config.Application) http.HandlerFunc {
 			response.InternalServerError(w, r, "Internal Server Error", nil)
 			return
 		}
+		defer databaseConn.Close()
 
 		tableOID := urlVariables["table-id"]
-		tableName, err := getDatabaseTableName(r.Context(), config.DB, tableOID)
+		tableName, schemaName, err := getDatabaseTableName(r.Context(), config.DB, tableOID)
 		if err != nil {
 			print(err.Error())
 			response.BadRequest(w, r, "Invalid Table Id", err)
 			return
 		}
 
-		schema, err := GetTableSchema(r.Context(), databaseConn, tableName)
+		schema, err := GetTableSchema(r.Context(), databaseConn, schemaName, tableName)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			config.App.ErrorLog.Println(err)
 			response.InternalServerError(w, r, "Internal Server Error", nil)
@@ -130,3 +135,261 @@ func GetDatabaseTableSchema(app *config.Application) http.HandlerFunc {
 		response.OK(w, r, "Schema Fetched successfully", schema)
 	}
 }
+
+// GetDatabaseDDL godoc
+// @Summary Export database DDL
+// @Description Export a re-runnable SQL script that recreates the project schema: types, sequences, functions, tables, constraints, indexes and views in dependency order
+// @Tags schemas
+// @Produce json
+// @Produce plain
+// @Param project-id path string true "Project ID"
+// @Param tables query string false "Comma separated list of tables to limit the export to"
+// @Param download query bool false "Return the script as a downloadable .sql file"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "DDL exported successfully"
+// @Failure 400 {object} response.ErrorResponse "Project ID is required"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Table not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Param schema query string false "Schema to read, defaults to public"
+// @Router /api/projects/{project-id}/schema/ddl [get]
+func GetDatabaseDDL(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := r.Context().Value("user-id").(int64)
+		urlVariables := mux.Vars(r)
+
+		projectOid := urlVariables["project-id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		project, err := projects.GetUserSpecificProject(r.Context(), config.DB, userId, projectOid)
+		if err != nil {
+			if errors.Is(err, projects.ErrorProjectNotFound) {
+				response.BadRequest(w, r, "Project is not found", err)
+				return
+			}
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		projectName := strings.ToLower(project.Name)
+		projectName += "_" + strconv.FormatInt(userId, 10)
+
+		databaseConn, err := config.ConfigManager.GetDbConnection(r.Context(), projectName)
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+		defer databaseConn.Close()
+
+		tables := parseTablesParameter(r)
+		schema, err := utils.GetSchemaExport(r.Context(), databaseConn, schemaParameter(r))
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		ddl, err := utils.RenderSchemaDDL(schema, tables)
+		if err != nil {
+			response.NotFound(w, r, err.Error(), err)
+			return
+		}
+
+		download, _ := strconv.ParseBool(r.URL.Query().Get("download"))
+		if download {
+			w.Header().Set("Content-Type", "application/sql; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", projectName+"_schema.sql"))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(ddl))
+			return
+		}
+
+		response.OK(w, r, "DDL exported successfully", DDLResponse{DDL: ddl, Tables: tables})
+	}
+}
+
+// GetDatabaseERD godoc
+// @Summary Get database ERD
+// @Description Get an entity relationship diagram of the project tables as a Mermaid erDiagram, a Graphviz DOT graph or a JSON graph with node positions
+// @Tags schemas
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Param format query string false "Diagram format" Enums(json, mermaid, dot) default(json)
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=ERDResponse} "ERD generated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid format"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Param schema query string false "Schema to read, defaults to public"
+// @Router /api/projects/{project-id}/schema/erd [get]
+func GetDatabaseERD(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := r.Context().Value("user-id").(int64)
+		urlVariables := mux.Vars(r)
+
+		projectOid := urlVariables["project-id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		if format == "" {
+			format = ERDFormatJSON
+		}
+		if format != ERDFormatJSON && format != ERDFormatMermaid && format != ERDFormatDOT {
+			response.BadRequest(w, r, "format must be one of json, mermaid or dot", nil)
+			return
+		}
+
+		project, err := projects.GetUserSpecificProject(r.Context(), config.DB, userId, projectOid)
+		if err != nil {
+			if errors.Is(err, projects.ErrorProjectNotFound) {
+				response.BadRequest(w, r, "Project is not found", err)
+				return
+			}
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		projectName := strings.ToLower(project.Name)
+		projectName += "_" + strconv.FormatInt(userId, 10)
+
+		databaseConn, err := config.ConfigManager.GetDbConnection(r.Context(), projectName)
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+		defer databaseConn.Close()
+
+		tables, err := utils.GetSchemaTables(r.Context(), databaseConn, schemaParameter(r))
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		graph := BuildERDGraph(tables)
+		result := ERDResponse{Format: format}
+		switch format {
+		case ERDFormatMermaid:
+			result.Diagram = RenderMermaid(graph)
+		case ERDFormatDOT:
+			result.Diagram = RenderDOT(graph)
+		default:
+			result.Graph = graph
+		}
+
+		response.OK(w, r, "ERD generated successfully", result)
+	}
+}
+
+// GenerateDatabaseCode godoc
+// @Summary Generate code from the database schema
+// @Description Generate TypeScript interfaces, Go structs and JSON Schema documents from the project tables
+// @Tags schemas
+// @Produce json
+// @Produce application/zip
+// @Param project-id path string true "Project ID"
+// @Param targets query string false "Comma separated targets: typescript, go, jsonschema. Defaults to all"
+// @Param tables query string false "Comma separated list of tables to generate code for"
+// @Param download query bool false "Return the generated files as a zip archive"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]GeneratedFile} "Code generated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid target"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Table not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Param schema query string false "Schema to read, defaults to public"
+// @Router /api/projects/{project-id}/schema/codegen [get]
+func GenerateDatabaseCode(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := r.Context().Value("user-id").(int64)
+		urlVariables := mux.Vars(r)
+
+		projectOid := urlVariables["project-id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		targets, err := parseCodegenTargets(r)
+		if err != nil {
+			response.BadRequest(w, r, err.Error(), err)
+			return
+		}
+
+		project, err := projects.GetUserSpecificProject(r.Context(), config.DB, userId, projectOid)
+		if err != nil {
+			if errors.Is(err, projects.ErrorProjectNotFound) {
+				response.BadRequest(w, r, "Project is not found", err)
+				return
+			}
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		projectName := strings.ToLower(project.Name)
+		projectName += "_" + strconv.FormatInt(userId, 10)
+
+		databaseConn, err := config.ConfigManager.GetDbConnection(r.Context(), projectName)
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+		defer databaseConn.Close()
+
+		allTables, err := utils.GetSchemaTables(r.Context(), databaseConn, schemaParameter(r))
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		tables, err := selectTables(allTables, parseTablesParameter(r))
+		if err != nil {
+			response.NotFound(w, r, err.Error(), err)
+			return
+		}
+
+		enums, err := utils.GetEnumTypes(r.Context(), databaseConn, schemaParameter(r))
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		files, err := GenerateCode(tables, enums, targets)
+		if err != nil {
+			config.App.ErrorLog.Println(err)
+			response.InternalServerError(w, r, "Internal Server Error", nil)
+			return
+		}
+
+		download, _ := strconv.ParseBool(r.URL.Query().Get("download"))
+		if download {
+			archive, err := ZipFiles(files)
+			if err != nil {
+				config.App.ErrorLog.Println(err)
+				response.InternalServerError(w, r, "Internal Server Error", nil)
+				return
+			}
+			w.Header().Set("Content-Type", "application/zip")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", projectName+"_codegen.zip"))
+			w.WriteHeader(http.StatusOK)
+			w.Write(archive)
+			return
+		}
+
+		response.OK(w, r, "Code generated successfully", files)
+	}
+}
@@ -22,3 +22,57 @@ type TableSchema struct {
 type SchemaResponse struct {
 	Schema []TableSchema `json:"schema"`
 }
+
+type DDLResponse struct {
+	DDL    string   `json:"ddl"`
+	Tables []string `json:"tables,omitempty"`
+}
+
+const (
+	ERDManyToOne = "many-to-one"
+	ERDOneToOne  = "one-to-one"
+)
+
+type ERDGraph struct {
+	Nodes []ERDNode `json:"nodes"`
+	Edges []ERDEdge `json:"edges"`
+}
+
+type ERDNode struct {
+	ID      string      `json:"id"`
+	Columns []ERDColumn `json:"columns"`
+	X       int         `json:"x"`
+	Y       int         `json:"y"`
+	Width   int         `json:"width"`
+	Height  int         `json:"height"`
+}
+
+type ERDColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	PrimaryKey bool   `json:"primary_key"`
+	ForeignKey bool   `json:"foreign_key"`
+	Unique     bool   `json:"unique"`
+}
+
+type ERDEdge struct {
+	ID            string   `json:"id"`
+	Source        string   `json:"source"`
+	Target        string   `json:"target"`
+	SourceColumns []string `json:"source_columns"`
+	TargetColumns []string `json:"target_columns"`
+	Cardinality   string   `json:"cardinality"`
+	Optional      bool     `json:"optional"`
+}
+
+type ERDResponse struct {
+	Format  string    `json:"format"`
+	Diagram string    `json:"diagram,omitempty"`
+	Graph   *ERDGraph `json:"graph,omitempty"`
+}
+
+type GeneratedFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
@@ -9,17 +9,20 @@ import (
 	"github.com/georgysavva/scany/v2/pgxscan"
 )
 
-func getDatabaseTableName(ctx context.Context, DB utils.Querier, tableOID string) (string, error) {
-	var tableName string
-	err := pgxscan.Get(ctx, DB, &tableName, GetTableNameByOID, tableOID)
+func getDatabaseTableName(ctx context.Context, DB utils.Querier, tableOID string) (string, string, error) {
+	var table struct {
+		Name       string `db:"name"`
+		SchemaName string `db:"schema_name"`
+	}
+	err := pgxscan.Get(ctx, DB, &table, GetTableNameByOID, tableOID)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	return tableName, nil
+	return table.Name, utils.SchemaOrDefault(table.SchemaName), nil
 }
 
-func getDatabaseSchema(ctx context.Context, DB utils.Querier) (*SchemaResponse, error) {
-	rows, err := DB.Query(ctx, GetAllTablesSchema)
+func getDatabaseSchema(ctx context.Context, DB utils.Querier, schema string) (*SchemaResponse, error) {
+	rows, err := DB.Query(ctx, GetAllTablesSchema, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -93,8 +96,8 @@ func getDatabaseSchema(ctx context.Context, DB utils.Querier) (*SchemaResponse,
 	return response, nil
 }
 
-func GetTableSchema(ctx context.Context, DB utils.Querier, tableName string) (*SchemaResponse, error) {
-	rows, err := DB.Query(ctx, GetTableSchemaQuery, tableName)
+func GetTableSchema(ctx context.Context, DB utils.Querier, schema, tableName string) (*SchemaResponse, error) {
+	rows, err := DB.Query(ctx, GetTableSchemaQuery, tableName, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query table schema: %w", err)
 	}
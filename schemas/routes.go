@@ -17,4 +17,19 @@ func DefineURLs() {
 	router.Handle("/{table-id}", middleware.Route(map[string]http.HandlerFunc{
 		http.MethodGet: GetDatabaseTableSchema(config.App),
 	}))
+
+	schemaRouter := config.Router.PathPrefix("/api/projects/{project-id}/schema").Subrouter()
+	schemaRouter.Use(middleware.JwtAuthMiddleware)
+
+	schemaRouter.Handle("/ddl", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: GetDatabaseDDL(config.App),
+	}))
+
+	schemaRouter.Handle("/erd", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: GetDatabaseERD(config.App),
+	}))
+
+	schemaRouter.Handle("/codegen", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: GenerateDatabaseCode(config.App),
+	}))
 }
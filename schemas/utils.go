@@ -0,0 +1,67 @@
+package schemas
+
+import (
+	"DBHS/utils"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// schemaParameter reads ?schema= and falls back to the default schema
+func schemaParameter(r *http.Request) string {
+	return utils.SchemaOrDefault(strings.TrimSpace(r.URL.Query().Get("schema")))
+}
+
+// parseTablesParameter accepts both ?tables=a,b and ?tables=a&tables=b
+func parseTablesParameter(r *http.Request) []string {
+	tables := make([]string, 0)
+	for _, value := range r.URL.Query()["tables"] {
+		for _, table := range strings.Split(value, ",") {
+			if table = strings.TrimSpace(table); table != "" {
+				tables = append(tables, table)
+			}
+		}
+	}
+	return tables
+}
+
+// parseCodegenTargets reads ?targets=typescript,go and defaults to every target
+func parseCodegenTargets(r *http.Request) ([]string, error) {
+	targets := make([]string, 0)
+	for _, value := range r.URL.Query()["targets"] {
+		for _, target := range strings.Split(value, ",") {
+			target = strings.ToLower(strings.TrimSpace(target))
+			if target == "" || slices.Contains(targets, target) {
+				continue
+			}
+			if !slices.Contains(CodegenTargets, target) {
+				return nil, fmt.Errorf("unknown target %s, expected one of %s", target, strings.Join(CodegenTargets, ", "))
+			}
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return CodegenTargets, nil
+	}
+	return targets, nil
+}
+
+// selectTables returns the requested tables ordered by name, or every table when none are requested
+func selectTables(tables map[string]*utils.Table, names []string) ([]*utils.Table, error) {
+	if len(names) == 0 {
+		for name := range tables {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	selected := make([]*utils.Table, 0, len(names))
+	for _, name := range slices.Compact(names) {
+		table, exists := tables[name]
+		if !exists {
+			return nil, fmt.Errorf("table %s does not exist", name)
+		}
+		selected = append(selected, table)
+	}
+	return selected, nil
+}
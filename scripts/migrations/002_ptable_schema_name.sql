@@ -0,0 +1,2 @@
+-- tables can live in any schema of a project database, existing records belong to public
+ALTER TABLE "Ptable" ADD COLUMN IF NOT EXISTS schema_name TEXT NOT NULL DEFAULT 'public';
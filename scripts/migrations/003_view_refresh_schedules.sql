@@ -0,0 +1,13 @@
+-- scheduled refreshes of the materialized views of every project
+CREATE TABLE IF NOT EXISTS "view_refresh_schedules" (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES "projects"(id) ON DELETE CASCADE,
+    schema_name TEXT NOT NULL,
+    view_name TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    concurrently BOOLEAN NOT NULL DEFAULT false,
+    last_refreshed_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (project_id, schema_name, view_name)
+);
@@ -0,0 +1,14 @@
+-- time range partitions kept ahead of time and dropped after a retention by the partition worker
+CREATE TABLE IF NOT EXISTS "partition_policies" (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES "projects"(id) ON DELETE CASCADE,
+    schema_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    partition_interval TEXT NOT NULL CHECK (partition_interval IN ('day', 'week', 'month', 'year')),
+    premake INTEGER NOT NULL CHECK (premake > 0),
+    retention INTEGER CHECK (retention >= 0),
+    last_run_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (project_id, schema_name, table_name)
+);
@@ -0,0 +1,18 @@
+-- rows of a table with a ttl expire once their ttl column is older than the ttl interval,
+-- the ttl worker deletes them in batches
+ALTER TABLE "Ptable" ADD COLUMN IF NOT EXISTS ttl_column TEXT;
+ALTER TABLE "Ptable" ADD COLUMN IF NOT EXISTS ttl_interval INTERVAL CHECK (ttl_interval > INTERVAL '0');
+ALTER TABLE "Ptable" ADD COLUMN IF NOT EXISTS ttl_batch_size INTEGER NOT NULL DEFAULT 1000 CHECK (ttl_batch_size > 0);
+
+-- one row per worker run on a table, the rows purged by every run
+CREATE TABLE IF NOT EXISTS "table_ttl_runs" (
+    id BIGSERIAL PRIMARY KEY,
+    table_id BIGINT NOT NULL REFERENCES "Ptable"(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rows_purged BIGINT NOT NULL,
+    batches INTEGER NOT NULL,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS table_ttl_runs_table_idx ON "table_ttl_runs" (table_id, started_at DESC);
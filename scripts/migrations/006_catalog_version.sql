@@ -0,0 +1,3 @@
+-- the catalog version of the project database the tables of a project were last synced at,
+-- an event trigger in the project database bumps the version on every table DDL
+ALTER TABLE "projects" ADD COLUMN IF NOT EXISTS catalog_version BIGINT;
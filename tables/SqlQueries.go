@@ -1,11 +1,23 @@
 package tables
 
 const (
-	InsertNewTableRecordStmt = `INSERT INTO "Ptable" (oid, name, description, project_id) VALUES ($1, $2, $3, $4) RETURNING id;`
+	InsertNewTableRecordStmt = `INSERT INTO "Ptable" (oid, name, description, project_id, schema_name) VALUES ($1, $2, $3, $4, $5) RETURNING id;`
 	CheckOwnershipStmt       = `SELECT COUNT(*) FROM "projects" WHERE oid = $1 AND owner_id = $2;`
 	GetTableNameStmt         = `SELECT name FROM "Ptable" WHERE oid = $1;`
+	GetTableRecordStmt       = `SELECT id, oid, name, schema_name, project_id FROM "Ptable" WHERE oid = $1;`
+	GetProjectTablesStmt     = `SELECT id, oid, name, schema_name FROM "Ptable" WHERE project_id = $1;`
 	DropTableStmt            = `DROP TABLE IF EXISTS %s;`
 	DeleteTableStmt          = `DELETE FROM "Ptable" WHERE %s = $1;`
+	UpdateTableNameStmt      = `UPDATE "Ptable" SET name = $1 WHERE oid = $2;`
+
+	// planner statistics are used for the estimates so a dry run never scans the table
+	EstimateTableRowsStmt = `SELECT GREATEST(c.reltuples, 0)::bigint
+							FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+							WHERE n.nspname = $1 AND c.relname = $2;`
+	EstimateColumnNullFracStmt = `SELECT COALESCE(MAX(null_frac), 0)
+								FROM pg_stats
+								WHERE schemaname = $1 AND tablename = $2 AND attname = $3;`
+	ValidationLockTimeoutStmt = `SET LOCAL lock_timeout = '5s';`
 
 	ReadTableStmt = `SELECT 
 						c.column_name AS column_name,
@@ -47,9 +59,158 @@ const (
 						AND c.column_name = fk.column_name
 					WHERE 
 						c.table_name = $1
-						AND c.table_schema = 'public'
+						AND c.table_schema = current_schema()
 					ORDER BY 
 						c.column_name;`
+	DeleteProjectTableRecordStmt = `DELETE FROM "Ptable" WHERE project_id = $1 AND schema_name = $2 AND name = $3;`
+
+	AttachPartitionStmt = `ALTER TABLE %s ATTACH PARTITION %s %s;`
+	DetachPartitionStmt = `ALTER TABLE %s DETACH PARTITION %s%s;`
+
+	IsPartitionOfStmt = `SELECT EXISTS (
+							SELECT 1 FROM pg_inherits i
+							JOIN pg_class c ON c.oid = i.inhrelid
+							JOIN pg_namespace n ON n.oid = c.relnamespace
+							JOIN pg_class parent ON parent.oid = i.inhparent
+							JOIN pg_namespace pn ON pn.oid = parent.relnamespace
+							WHERE c.relispartition AND n.nspname = $1 AND c.relname = $2 AND pn.nspname = $3 AND parent.relname = $4
+						);`
+
+	// the policies only manage tables partitioned by range on a single date or timestamp column
+	PartitionKeyTypeStmt = `SELECT CASE pt.partstrat WHEN 'r' THEN 'RANGE' WHEN 'l' THEN 'LIST' ELSE 'HASH' END,
+								pt.partnatts, COALESCE(format_type(a.atttypid, NULL), '')
+							FROM pg_partitioned_table pt
+							JOIN pg_class c ON c.oid = pt.partrelid
+							JOIN pg_namespace n ON n.oid = c.relnamespace
+							LEFT JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = pt.partattrs[0]
+							WHERE n.nspname = $1 AND c.relname = $2;`
+
+	// partition policies live in the service database so the worker doesn't have to visit every project
+	GetPartitionPolicyStmt = `SELECT id, partition_interval, premake, retention, last_run_at, last_error
+							FROM "partition_policies" WHERE project_id = $1 AND schema_name = $2 AND table_name = $3;`
+	GetPartitionPoliciesStmt = `SELECT pp.id, pp.partition_interval, pp.premake, pp.retention, pp.last_run_at, pp.last_error,
+								pp.schema_name, pp.table_name, p.oid AS project_oid, p.owner_id
+							FROM "partition_policies" pp JOIN "projects" p ON p.id = pp.project_id;`
+	UpsertPartitionPolicyStmt = `INSERT INTO "partition_policies" (project_id, schema_name, table_name, partition_interval, premake, retention)
+							VALUES ($1, $2, $3, $4, $5, $6)
+							ON CONFLICT (project_id, schema_name, table_name)
+							DO UPDATE SET partition_interval = EXCLUDED.partition_interval, premake = EXCLUDED.premake, retention = EXCLUDED.retention
+							RETURNING id;`
+	DeletePartitionPolicyStmt = `DELETE FROM "partition_policies" WHERE project_id = $1 AND schema_name = $2 AND table_name = $3;`
+	RecordPartitionRunStmt    = `UPDATE "partition_policies" SET last_run_at = now(), last_error = $2 WHERE id = $1;`
+	RenamePartitionPolicyStmt = `UPDATE "partition_policies" SET table_name = $4 WHERE project_id = $1 AND schema_name = $2 AND table_name = $3;`
+
+	// the ttl of a table is stored on its Ptable record, the interval is read as text so it
+	// can be passed back to the user database
+	GetTableTTLStmt = `SELECT ttl_column, ttl_interval::text AS ttl_interval, ttl_batch_size
+							FROM "Ptable" WHERE oid = $1 AND ttl_column IS NOT NULL;`
+	GetTableTTLsStmt = `SELECT t.id AS table_id, t.schema_name, t.name AS table_name,
+								t.ttl_column, t.ttl_interval::text AS ttl_interval, t.ttl_batch_size,
+								p.oid AS project_oid, p.owner_id
+							FROM "Ptable" t JOIN "projects" p ON p.id = t.project_id
+							WHERE t.ttl_column IS NOT NULL;`
+	SetTableTTLStmt = `UPDATE "Ptable" SET ttl_column = $2, ttl_interval = $3::interval, ttl_batch_size = $4 WHERE oid = $1
+							RETURNING ttl_interval::text;`
+	DeleteTableTTLStmt = `UPDATE "Ptable" SET ttl_column = NULL, ttl_interval = NULL WHERE oid = $1;`
+	GetTTLRunsStmt     = `SELECT r.started_at, r.finished_at, r.rows_purged, r.batches, r.error
+							FROM "table_ttl_runs" r JOIN "Ptable" t ON t.id = r.table_id
+							WHERE t.oid = $1 ORDER BY r.started_at DESC LIMIT $2;`
+	InsertTTLRunStmt = `INSERT INTO "table_ttl_runs" (table_id, started_at, rows_purged, batches, error) VALUES ($1, $2, $3, $4, $5);`
+	DeleteOldTTLRunsStmt = `DELETE FROM "table_ttl_runs" WHERE started_at < now() - INTERVAL '30 days';`
+
+	// rows are addressed by partition and ctid so a batch works on partitioned tables too
+	PurgeExpiredRowsStmt = `DELETE FROM %[1]s WHERE (tableoid, ctid) IN (
+								SELECT tableoid, ctid FROM %[1]s WHERE %[2]s < now() - $1::interval LIMIT $2
+							);`
+
+	// the catalog queries of the table operations take the qualified name of the table
+	TableExistsStmt   = `SELECT to_regclass($1) IS NOT NULL;`
+	TruncateTableStmt = `TRUNCATE TABLE %s%s%s;`
+	// tables referencing the table directly or through other references are emptied by a cascading truncate
+	ReferencingTablesStmt = `WITH RECURSIVE refs(relid) AS (
+								SELECT $1::text::regclass::oid
+								UNION
+								SELECT con.conrelid FROM pg_constraint con JOIN refs ON con.confrelid = refs.relid
+								WHERE con.contype = 'f'
+							)
+							SELECT n.nspname || '.' || c.relname
+							FROM refs JOIN pg_class c ON c.oid = refs.relid JOIN pg_namespace n ON n.oid = c.relnamespace
+							WHERE refs.relid <> $1::text::regclass AND NOT c.relispartition
+							ORDER BY 1;`
+
+	RenameTableStmt      = `ALTER TABLE %s RENAME TO %s;`
+	RenameIndexStmt      = `ALTER INDEX %s RENAME TO %s;`
+	RenameSequenceStmt   = `ALTER SEQUENCE %s RENAME TO %s;`
+	RenameConstraintStmt = `ALTER TABLE %s RENAME CONSTRAINT %s TO %s;`
+	// renaming an index renames the constraint it backs, only constraints without an index are listed
+	TableObjectsStmt = `SELECT 'index' AS kind, i.relname AS name
+							FROM pg_index x JOIN pg_class i ON i.oid = x.indexrelid
+							WHERE x.indrelid = $1::text::regclass
+						UNION ALL
+						SELECT 'sequence', s.relname
+							FROM pg_depend d JOIN pg_class s ON s.oid = d.objid
+							WHERE d.classid = 'pg_class'::regclass AND d.refclassid = 'pg_class'::regclass
+								AND d.refobjid = $1::text::regclass AND s.relkind = 'S' AND d.deptype IN ('a', 'i')
+						UNION ALL
+						SELECT 'constraint', con.conname
+							FROM pg_constraint con
+							WHERE con.conrelid = $1::text::regclass AND con.contype IN ('c', 'f');`
+
+	DuplicateTableStmt = `CREATE TABLE %s (LIKE %s INCLUDING ALL);`
+	// generated columns are computed by the target table so they are never copied
+	CopyColumnsStmt = `SELECT a.attname FROM pg_attribute a
+						WHERE a.attrelid = $1::text::regclass AND a.attnum > 0 AND NOT a.attisdropped AND a.attgenerated = ''
+						ORDER BY a.attnum;`
+	ColumnSequencesStmt = `SELECT a.attname AS column_name, pg_get_serial_sequence($1, a.attname) AS sequence_name,
+								a.attidentity <> '' AS is_identity
+							FROM pg_attribute a
+							WHERE a.attrelid = $1::text::regclass AND a.attnum > 0 AND NOT a.attisdropped
+								AND pg_get_serial_sequence($1, a.attname) IS NOT NULL
+							ORDER BY a.attnum;`
+	CreateOwnedSequenceStmt = `CREATE SEQUENCE %s OWNED BY %s.%s;`
+	SetColumnDefaultStmt    = `ALTER TABLE %s ALTER COLUMN %s SET DEFAULT nextval(%s::regclass);`
+	AddConstraintStmt       = `ALTER TABLE %s ADD %s;`
+	InsertTableDataStmt     = `INSERT INTO %[1]s (%[2]s) OVERRIDING SYSTEM VALUE SELECT %[2]s FROM %[3]s;`
+	SequenceStateStmt       = `SELECT last_value, is_called FROM %s;`
+	SetSequenceStateStmt    = `SELECT setval(pg_get_serial_sequence($1, $2), $3, $4);`
+	CopyTableToStmt         = `COPY (SELECT %s FROM %s) TO STDOUT;`
+	CopyTableFromStmt       = `COPY %s (%s) FROM STDIN;`
+
+	// every project database counts its table DDL in _dbhs.catalog_version. the event trigger bumps the
+	// version inside the transaction of the change so a sync never reads a version before its change is visible.
+	// the trigger never fails the DDL of the project, a missing version table is reinstalled by the next sync
+	InstallCatalogTrackingStmt = `
+		CREATE SCHEMA IF NOT EXISTS "_dbhs";
+		CREATE TABLE IF NOT EXISTS "_dbhs"."catalog_version" (
+			id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+			version BIGINT NOT NULL DEFAULT 0,
+			changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		INSERT INTO "_dbhs"."catalog_version" (id) VALUES (true) ON CONFLICT DO NOTHING;
+		CREATE OR REPLACE FUNCTION "_dbhs"."bump_catalog_version"() RETURNS event_trigger
+		LANGUAGE plpgsql SECURITY DEFINER SET search_path = pg_catalog, pg_temp AS $$
+		BEGIN
+			IF to_regclass('"_dbhs"."catalog_version"') IS NULL THEN
+				RETURN;
+			END IF;
+			UPDATE "_dbhs"."catalog_version" SET version = version + 1, changed_at = now();
+		EXCEPTION WHEN OTHERS THEN
+			RAISE WARNING 'catalog version not bumped: %', SQLERRM;
+		END;
+		$$;
+		DROP EVENT TRIGGER IF EXISTS "dbhs_catalog_version";
+		CREATE EVENT TRIGGER "dbhs_catalog_version" ON ddl_command_end
+			WHEN TAG IN ('CREATE TABLE', 'CREATE TABLE AS', 'SELECT INTO', 'ALTER TABLE', 'DROP TABLE',
+				'CREATE SCHEMA', 'ALTER SCHEMA', 'DROP SCHEMA', 'DROP OWNED')
+			EXECUTE FUNCTION "_dbhs"."bump_catalog_version"();`
+	// the version only counts while the event trigger is enabled
+	CatalogVersionStmt = `SELECT v.version, EXISTS (
+								SELECT 1 FROM pg_event_trigger WHERE evtname = 'dbhs_catalog_version' AND evtenabled <> 'D'
+							) FROM "_dbhs"."catalog_version" v;`
+	GetSyncedCatalogVersionStmt  = `SELECT catalog_version FROM "projects" WHERE id = $1;`
+	LockSyncedCatalogVersionStmt = `SELECT catalog_version FROM "projects" WHERE id = $1 FOR UPDATE;`
+	SetSyncedCatalogVersionStmt  = `UPDATE "projects" SET catalog_version = $2 WHERE id = $1;`
+
 	InsertNewRowStmt = `
 		INSERT INTO "%s"(%s) VALUES(%s)
 	`
@@ -146,6 +146,14 @@ func CreateTableHandler(app *config.Application) http.HandlerFunc {
 				response.UnAuthorized(w, r, "Unauthorized", nil)
 				return
 			}
+			if errors.Is(err, response.ErrBadRequest) {
+				response.BadRequest(w, r, "Schema does not exist", nil)
+				return
+			}
+			if requestErr := schemaRequestError(err); requestErr != nil {
+				response.BadRequest(w, r, requestErr.Error(), err)
+				return
+			}
 			app.ErrorLog.Println("Table creation failed:", err)
 			response.InternalServerError(w, r, "Failed to create table", err)
 			return
@@ -166,8 +174,10 @@ func CreateTableHandler(app *config.Application) http.HandlerFunc {
 // @Param project_id path string true "Project ID"
 // @Param table_id path string true "Table ID"
 // @Param updates body UpdateTableSchema true "new table schema updates"
+// @Param dry_run query bool false "Only return the generated DDL, the destructive operations and the estimated affected rows"
+// @Param validate query bool false "With dry_run, execute the DDL inside a rolled-back transaction to check that it applies"
 // @Security BearerAuth
-// @Success 200 {object} response.SuccessResponse
+// @Success 200 {object} response.SuccessResponse{data=UpdatePreview} "Table updated, or the update preview when dry_run is set"
 // @Failure 400 {object} response.ErrorResponse400
 // @Failure 401 {object} response.ErrorResponse401
 // @Failure 404 {object} response.ErrorResponse404
@@ -192,12 +202,52 @@ func UpdateTableHandler(app *config.Application) http.HandlerFunc {
 			return
 		}
 
+		if updates.Schema == nil {
+			response.BadRequest(w, r, "Table schema is required", nil)
+			return
+		}
+
+		dryRun, err := utils.ParseBoolParameter(r, "dry_run")
+		if err != nil {
+			response.BadRequest(w, r, "dry_run must be a boolean", err)
+			return
+		}
+
+		if dryRun {
+			validate, err := utils.ParseBoolParameter(r, "validate")
+			if err != nil {
+				response.BadRequest(w, r, "validate must be a boolean", err)
+				return
+			}
+
+			preview, err := PreviewTableUpdate(r.Context(), projectOID, tableId, &updates, validate, config.DB)
+			if err != nil {
+				if errors.Is(err, response.ErrUnauthorized) {
+					response.UnAuthorized(w, r, "Unauthorized", nil)
+					return
+				}
+				if requestErr := schemaRequestError(err); requestErr != nil {
+					response.BadRequest(w, r, requestErr.Error(), err)
+					return
+				}
+				app.ErrorLog.Println("Table update preview failed:", err)
+				response.InternalServerError(w, r, "Failed to preview table update", err)
+				return
+			}
+			response.OK(w, r, "Table update preview generated successfully", preview)
+			return
+		}
+
 		// Call the service function to update the table
 		if err := UpdateTable(r.Context(), projectOID, tableId, &updates, config.DB); err != nil {
 			if errors.Is(err, response.ErrUnauthorized) {
 				response.UnAuthorized(w, r, "Unauthorized", nil)
 				return
 			}
+			if requestErr := schemaRequestError(err); requestErr != nil {
+				response.BadRequest(w, r, requestErr.Error(), err)
+				return
+			}
 			app.ErrorLog.Println("Table update failed:", err)
 			response.InternalServerError(w, r, "Failed to update table", err)
 			return
@@ -366,3 +416,547 @@ func InsertRowHandler(app *config.Application) http.HandlerFunc {
 		response.Created(w, r, "row created succefully", nil)
 	}
 }
+
+// partitionError writes the response of a failed partition request
+func partitionError(app *config.Application, w http.ResponseWriter, r *http.Request, message string, err error) {
+	switch {
+	case errors.Is(err, response.ErrUnauthorized):
+		response.UnAuthorized(w, r, "Unauthorized", nil)
+	case errors.Is(err, ErrPartitionNotFound), errors.Is(err, ErrPolicyNotFound):
+		response.NotFound(w, r, err.Error(), nil)
+	case errors.Is(err, ErrNotPartitioned), errors.Is(err, ErrInvalidPolicy), errors.Is(err, ErrUnsupportedPolicy):
+		response.BadRequest(w, r, err.Error(), nil)
+	default:
+		if requestErr := partitionRequestError(err); requestErr != nil {
+			response.BadRequest(w, r, requestErr.Error(), err)
+			return
+		}
+		app.ErrorLog.Println(message+":", err)
+		response.InternalServerError(w, r, message, err)
+	}
+}
+
+// ListPartitionsHandler godoc
+// @Summary List the partitions of a table
+// @Description Get the partition key and the partitions, with their bounds, of a partitioned table
+// @Tags tables
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=PartitionList}
+// @Failure 400 {object} response.ErrorResponse400 "Table is not partitioned"
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partitions [get]
+func ListPartitionsHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		partitions, err := ListPartitions(r.Context(), urlVariables["project_id"], urlVariables["table_id"], config.DB)
+		if err != nil {
+			partitionError(app, w, r, "Failed to list partitions", err)
+			return
+		}
+		response.OK(w, r, "Partitions retrieved successfully", partitions)
+	}
+}
+
+// CreatePartitionHandler godoc
+// @Summary Create a partition
+// @Description Create a range, list, hash or default partition of a partitioned table, the partition can be partitioned itself
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param partition body CreatePartitionRequest true "Partition name and bound"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partitions [post]
+func CreatePartitionHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request CreatePartitionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+		if request.Name == "" {
+			response.BadRequest(w, r, "Partition name is required", nil)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		if err := CreatePartition(r.Context(), urlVariables["project_id"], urlVariables["table_id"], &request, config.DB); err != nil {
+			partitionError(app, w, r, "Failed to create partition", err)
+			return
+		}
+		response.Created(w, r, "Partition created successfully", nil)
+	}
+}
+
+// AttachPartitionHandler godoc
+// @Summary Attach a table as a partition
+// @Description Attach an existing table with the columns of the partitioned table as one of its partitions
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param partition body AttachPartitionRequest true "Table to attach and its bound"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partitions/attach [post]
+func AttachPartitionHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request AttachPartitionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+		if request.Table == "" {
+			response.BadRequest(w, r, "Table to attach is required", nil)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		if err := AttachPartition(r.Context(), urlVariables["project_id"], urlVariables["table_id"], &request, config.DB); err != nil {
+			partitionError(app, w, r, "Failed to attach partition", err)
+			return
+		}
+		response.OK(w, r, "Partition attached successfully", nil)
+	}
+}
+
+// DetachPartitionHandler godoc
+// @Summary Detach a partition
+// @Description Detach a partition, it becomes a standalone table of the project
+// @Tags tables
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param partition_name path string true "Partition name"
+// @Param schema query string false "Schema of the partition, the schema of the table by default"
+// @Param concurrently query bool false "Detach without blocking the queries on the table"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "The oid of the detached table"
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partitions/{partition_name}/detach [post]
+func DetachPartitionHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		concurrently, err := utils.ParseBoolParameter(r, "concurrently")
+		if err != nil {
+			response.BadRequest(w, r, "concurrently must be a boolean", err)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		tableOID, err := DetachPartition(r.Context(), urlVariables["project_id"], urlVariables["table_id"],
+			r.URL.Query().Get("schema"), urlVariables["partition_name"], concurrently, config.DB)
+		if err != nil {
+			partitionError(app, w, r, "Failed to detach partition", err)
+			return
+		}
+		response.OK(w, r, "Partition detached successfully", map[string]string{
+			"oid": tableOID,
+		})
+	}
+}
+
+// DropPartitionHandler godoc
+// @Summary Drop a partition
+// @Description Drop a partition of a table together with its rows
+// @Tags tables
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param partition_name path string true "Partition name"
+// @Param schema query string false "Schema of the partition, the schema of the table by default"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partitions/{partition_name} [delete]
+func DropPartitionHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		err := DropPartition(r.Context(), urlVariables["project_id"], urlVariables["table_id"],
+			r.URL.Query().Get("schema"), urlVariables["partition_name"], config.DB)
+		if err != nil {
+			partitionError(app, w, r, "Failed to drop partition", err)
+			return
+		}
+		response.OK(w, r, "Partition dropped successfully", nil)
+	}
+}
+
+// GetPartitionPolicyHandler godoc
+// @Summary Get the partition policy of a table
+// @Description Get the interval, premake and retention of the time range partitions kept by the partition worker
+// @Tags tables
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=PartitionPolicy}
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404 "Table has no partition policy"
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partition-policy [get]
+func GetPartitionPolicyHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		policy, err := GetTablePartitionPolicy(r.Context(), urlVariables["project_id"], urlVariables["table_id"], config.DB)
+		if err != nil {
+			partitionError(app, w, r, "Failed to get partition policy", err)
+			return
+		}
+		response.OK(w, r, "Partition policy retrieved successfully", policy)
+	}
+}
+
+// SetPartitionPolicyHandler godoc
+// @Summary Set the partition policy of a table
+// @Description Keep premake time range partitions ahead of the current one, named <table>_p<start>, and drop the ones that ended more than retention intervals ago. The policy is applied right away and then every hour
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param policy body PartitionPolicy true "interval is day, week, month or year, a null retention keeps every partition"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=PartitionMaintenance} "The partitions created and dropped by the first run"
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partition-policy [put]
+func SetPartitionPolicyHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var policy PartitionPolicy
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		maintenance, err := SetTablePartitionPolicy(r.Context(), urlVariables["project_id"], urlVariables["table_id"], &policy, config.DB)
+		if err != nil {
+			partitionError(app, w, r, "Failed to apply partition policy", err)
+			return
+		}
+		response.OK(w, r, "Partition policy saved successfully", maintenance)
+	}
+}
+
+// DeletePartitionPolicyHandler godoc
+// @Summary Delete the partition policy of a table
+// @Description Stop creating and dropping partitions of the table, the existing partitions are kept
+// @Tags tables
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/partition-policy [delete]
+func DeletePartitionPolicyHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		if err := DeleteTablePartitionPolicy(r.Context(), urlVariables["project_id"], urlVariables["table_id"], config.DB); err != nil {
+			partitionError(app, w, r, "Failed to delete partition policy", err)
+			return
+		}
+		response.OK(w, r, "Partition policy deleted successfully", nil)
+	}
+}
+
+// ttlError writes the response of a failed ttl request
+func ttlError(app *config.Application, w http.ResponseWriter, r *http.Request, message string, err error) {
+	switch {
+	case errors.Is(err, response.ErrUnauthorized):
+		response.UnAuthorized(w, r, "Unauthorized", nil)
+	case errors.Is(err, ErrTTLNotFound):
+		response.NotFound(w, r, err.Error(), nil)
+	case errors.Is(err, ErrInvalidTTL):
+		response.BadRequest(w, r, err.Error(), nil)
+	default:
+		app.ErrorLog.Println(message+":", err)
+		response.InternalServerError(w, r, message, err)
+	}
+}
+
+// GetTableTTLHandler godoc
+// @Summary Get the ttl of a table
+// @Description Get the ttl column and interval of a table with the rows purged by the latest runs of the ttl worker
+// @Tags tables
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=TableTTL}
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404 "Table has no ttl"
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/ttl [get]
+func GetTableTTLHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		ttl, err := GetTableTTLSettings(r.Context(), urlVariables["project_id"], urlVariables["table_id"], config.DB)
+		if err != nil {
+			ttlError(app, w, r, "Failed to get table ttl", err)
+			return
+		}
+		response.OK(w, r, "Table ttl retrieved successfully", ttl)
+	}
+}
+
+// SetTableTTLHandler godoc
+// @Summary Set the ttl of a table
+// @Description Expire the rows of a table once their date or timestamp column is older than the interval, the ttl worker deletes the expired rows in batches every five minutes
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param ttl body TableTTL true "column, a postgres interval such as 30 days and an optional batch size, 1000 by default"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/ttl [put]
+func SetTableTTLHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ttl TableTTL
+		if err := json.NewDecoder(r.Body).Decode(&ttl); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		if err := SetTableTTLSettings(r.Context(), urlVariables["project_id"], urlVariables["table_id"], &ttl, config.DB); err != nil {
+			ttlError(app, w, r, "Failed to set table ttl", err)
+			return
+		}
+		response.OK(w, r, "Table ttl saved successfully", nil)
+	}
+}
+
+// DeleteTableTTLHandler godoc
+// @Summary Delete the ttl of a table
+// @Description Stop expiring the rows of a table
+// @Tags tables
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/ttl [delete]
+func DeleteTableTTLHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urlVariables := mux.Vars(r)
+		if err := DeleteTableTTLSettings(r.Context(), urlVariables["project_id"], urlVariables["table_id"], config.DB); err != nil {
+			ttlError(app, w, r, "Failed to delete table ttl", err)
+			return
+		}
+		response.OK(w, r, "Table ttl deleted successfully", nil)
+	}
+}
+
+// tableOperationError writes the response of a failed truncate, rename, duplicate or copy
+func tableOperationError(app *config.Application, w http.ResponseWriter, r *http.Request, message string, err error) {
+	var confirmationErr *TruncateConfirmationError
+	switch {
+	case errors.Is(err, response.ErrUnauthorized):
+		response.UnAuthorized(w, r, "Unauthorized", nil)
+	case errors.As(err, &confirmationErr):
+		response.CreateResponse(w, r, http.StatusConflict, confirmationErr.Error(), nil, map[string][]string{
+			"tables": confirmationErr.Tables,
+		}, nil)
+	case errors.Is(err, ErrTableExists):
+		response.CreateResponse(w, r, http.StatusConflict, err.Error(), err, nil, nil)
+	case errors.Is(err, ErrProjectNotFound):
+		response.NotFound(w, r, err.Error(), nil)
+	case errors.Is(err, ErrSameProject):
+		response.BadRequest(w, r, err.Error(), nil)
+	default:
+		if requestErr := partitionRequestError(err); requestErr != nil {
+			response.BadRequest(w, r, requestErr.Error(), err)
+			return
+		}
+		app.ErrorLog.Println(message+":", err)
+		response.InternalServerError(w, r, message, err)
+	}
+}
+
+// TruncateTableHandler godoc
+// @Summary Truncate a table
+// @Description Delete every row of a table. a cascading truncate also empties the tables referencing it, they have to be listed in confirmTables as "schema.table"
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param truncate body TruncateRequest true "Truncate options"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 409 {object} response.SuccessResponse "The tables the truncate cascades to that were not confirmed"
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/truncate [post]
+func TruncateTableHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request TruncateRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		if err := TruncateTable(r.Context(), urlVariables["project_id"], urlVariables["table_id"], &request, config.DB); err != nil {
+			tableOperationError(app, w, r, "Failed to truncate table", err)
+			return
+		}
+		response.OK(w, r, "Table truncated successfully", nil)
+	}
+}
+
+// RenameTableHandler godoc
+// @Summary Rename a table
+// @Description Rename a table, its indexes, sequences and constraints named after it are renamed with it
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param rename body RenameTableRequest true "New table name"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 409 {object} response.ErrorResponse "A table with this name already exists"
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/rename [post]
+func RenameTableHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request RenameTableRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+		if request.Name == "" {
+			response.BadRequest(w, r, "Table name is required", nil)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		if err := RenameTable(r.Context(), urlVariables["project_id"], urlVariables["table_id"], request.Name, config.DB); err != nil {
+			tableOperationError(app, w, r, "Failed to rename table", err)
+			return
+		}
+		response.OK(w, r, "Table renamed successfully", nil)
+	}
+}
+
+// DuplicateTableHandler godoc
+// @Summary Duplicate a table
+// @Description Create a copy of a table in the same project, with or without its rows
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param duplicate body DuplicateTableRequest true "Name and schema of the copy"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse{data=CopiedTable}
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404
+// @Failure 409 {object} response.ErrorResponse "A table with this name already exists"
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/duplicate [post]
+func DuplicateTableHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request DuplicateTableRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+		if request.Name == "" {
+			response.BadRequest(w, r, "Table name is required", nil)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		copied, err := DuplicateTable(r.Context(), urlVariables["project_id"], urlVariables["table_id"], &request, config.DB)
+		if err != nil {
+			tableOperationError(app, w, r, "Failed to duplicate table", err)
+			return
+		}
+		response.Created(w, r, "Table duplicated successfully", copied)
+	}
+}
+
+// CopyTableHandler godoc
+// @Summary Copy a table to another project
+// @Description Create the table, with the types and sequences it uses, in another project of the user, with or without its rows. foreign keys to tables that are not copied are skipped and returned as warnings
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param table_id path string true "Table ID"
+// @Param copy body CopyTableRequest true "Target project"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse{data=CopiedTable}
+// @Failure 400 {object} response.ErrorResponse400
+// @Failure 401 {object} response.ErrorResponse401
+// @Failure 404 {object} response.ErrorResponse404 "Table or target project not found"
+// @Failure 409 {object} response.ErrorResponse "The target project already has the table"
+// @Failure 500 {object} response.ErrorResponse500
+// @Router /api/projects/{project_id}/tables/{table_id}/copy [post]
+func CopyTableHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request CopyTableRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+		if request.ProjectID == "" {
+			response.BadRequest(w, r, "Target project is required", nil)
+			return
+		}
+
+		urlVariables := mux.Vars(r)
+		copied, err := CopyTableToProject(r.Context(), urlVariables["project_id"], urlVariables["table_id"], &request, config.DB)
+		if err != nil {
+			tableOperationError(app, w, r, "Failed to copy table", err)
+			return
+		}
+		response.Created(w, r, "Table copied successfully", copied)
+	}
+}
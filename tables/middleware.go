@@ -9,6 +9,8 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// SyncTables keeps the table records of the project in sync with its database, the catalog
+// is only scanned when the project database reports a DDL change since the last sync
 func SyncTables(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Sync table schemas between old and new
@@ -32,7 +34,9 @@ func SyncTables(next http.Handler) http.Handler {
 			return
 		}
 		
-		if err := SyncTableSchemas(r.Context(), projectId, config.DB, userDb); err != nil {
+		err = SyncChangedTableSchemas(r.Context(), projectId, config.DB, userDb)
+		userDb.Close()
+		if err != nil {
 			response.InternalServerError(w, r, err.Error(), err)
 			return
 		}
@@ -2,6 +2,7 @@ package tables
 
 import (
 	"DBHS/utils"
+	"time"
 )
 
 // Table struct is a row record of the tables table in the database
@@ -9,17 +10,172 @@ type Table struct {
 	ID          int64       `json:"id" db:"id"`
 	ProjectID   int64       `json:"project_id" db:"project_id"`
 	OID         string      `json:"oid" db:"oid"`
+	SchemaName  string      `json:"schema_name" db:"schema_name"`
 	Name        string      `json:"name" db:"name" validate:"required"`
 	Description string      `json:"description" db:"description"`
 	Schema      *utils.Table `json:"schema" validate:"required"`
 }
 
+// tableKey identifies a table of a project database across its schemas
+type tableKey struct {
+	schema string
+	name   string
+}
+
 type UpdateTableSchema struct {
 	Table
 	Renames []utils.RenameRelation `json:"renames"`
 }
 
+// UpdatePreview is the result of a dry run of a table schema update
+type UpdatePreview struct {
+	DDL           string              `json:"ddl"`
+	Statements    []string            `json:"statements"`
+	Destructive   []DestructiveChange `json:"destructive"`
+	EstimatedRows int64               `json:"estimatedRows"`
+	// Validated is true when the DDL was executed inside a rolled-back transaction
+	Validated       bool   `json:"validated"`
+	ValidationError string `json:"validationError,omitempty"`
+}
+
+type DestructiveChange struct {
+	utils.SchemaChange
+	AffectedRows int64 `json:"affectedRows"`
+}
+
+
+// PartitionList is the partition key and the partitions of a partitioned table
+type PartitionList struct {
+	Key        *utils.PartitionSpec `json:"key"`
+	Partitions []utils.Partition    `json:"partitions"`
+}
+
+// CreatePartitionRequest creates a partition of a partitioned table, the partition is created in
+// the schema of its table when Schema is empty
+type CreatePartitionRequest struct {
+	Name   string               `json:"name"`
+	Schema string               `json:"schema"`
+	Bound  utils.PartitionBound `json:"bound"`
+	// PartitionBy partitions the new partition itself
+	PartitionBy *utils.PartitionSpec `json:"partitionBy,omitempty"`
+}
+
+// AttachPartitionRequest attaches an existing table of the project as a partition
+type AttachPartitionRequest struct {
+	Table  string               `json:"table"`
+	Schema string               `json:"schema"`
+	Bound  utils.PartitionBound `json:"bound"`
+}
+
+// PartitionPolicy keeps Premake time range partitions ahead of the current one and drops the
+// partitions that ended more than Retention intervals ago, a nil Retention keeps every partition
+type PartitionPolicy struct {
+	ID        int64      `json:"-" db:"id"`
+	Interval  string     `json:"interval" db:"partition_interval"`
+	Premake   int        `json:"premake" db:"premake"`
+	Retention *int       `json:"retention" db:"retention"`
+	LastRunAt *time.Time `json:"lastRunAt" db:"last_run_at"`
+	LastError *string    `json:"lastError" db:"last_error"`
+}
+
+// ScheduledPartitionPolicy is a partition policy with the table and the project it belongs to
+type ScheduledPartitionPolicy struct {
+	PartitionPolicy
+	SchemaName string `db:"schema_name"`
+	TableName  string `db:"table_name"`
+	ProjectOid string `db:"project_oid"`
+	OwnerID    int64  `db:"owner_id"`
+}
+
+// PartitionMaintenance lists the partitions created and dropped by a run of a partition policy
+type PartitionMaintenance struct {
+	Created []string `json:"created"`
+	Dropped []string `json:"dropped"`
+}
+
+// TimePartition is a partition of a time range partitioned table managed by a partition policy
+type TimePartition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// TableTTL expires the rows of a table once their Column is older than Interval, a postgres
+// interval such as "30 days". the ttl worker deletes at most BatchSize rows per statement
+type TableTTL struct {
+	Column    string   `json:"column" db:"ttl_column"`
+	Interval  string   `json:"interval" db:"ttl_interval"`
+	BatchSize int      `json:"batchSize" db:"ttl_batch_size"`
+	Runs      []TTLRun `json:"runs" db:"-"`
+}
+
+// TTLRun is the outcome of a run of the ttl worker on a table
+type TTLRun struct {
+	StartedAt  time.Time `json:"startedAt" db:"started_at"`
+	FinishedAt time.Time `json:"finishedAt" db:"finished_at"`
+	RowsPurged int64     `json:"rowsPurged" db:"rows_purged"`
+	Batches    int       `json:"batches" db:"batches"`
+	Error      *string   `json:"error" db:"error"`
+}
+
+// ScheduledTTL is the ttl of a table with the table and the project it belongs to
+type ScheduledTTL struct {
+	TableID    int64  `db:"table_id"`
+	SchemaName string `db:"schema_name"`
+	TableName  string `db:"table_name"`
+	TableTTL
+	ProjectOid string `db:"project_oid"`
+	OwnerID    int64  `db:"owner_id"`
+}
+
+// TruncateRequest empties a table. a cascading truncate also empties the tables that reference
+// it, every one of them has to be listed in ConfirmTables as "schema.table"
+type TruncateRequest struct {
+	RestartIdentity bool     `json:"restartIdentity"`
+	Cascade         bool     `json:"cascade"`
+	ConfirmTables   []string `json:"confirmTables"`
+}
+
+// RenameTableRequest renames a table, its indexes, sequences and constraints named after it
+// are renamed with it
+type RenameTableRequest struct {
+	Name string `json:"name"`
+}
+
+// DuplicateTableRequest copies a table of a project into a new table of the same project,
+// the copy is created in the schema of the table when Schema is empty
+type DuplicateTableRequest struct {
+	Name     string `json:"name"`
+	Schema   string `json:"schema"`
+	WithData bool   `json:"withData"`
+}
+
+// CopyTableRequest copies a table into another project of the same owner
+type CopyTableRequest struct {
+	ProjectID string `json:"projectId"`
+	WithData  bool   `json:"withData"`
+}
+
+// CopiedTable is the table created by a duplicate or a copy, Warnings lists the foreign keys
+// that couldn't be recreated
+type CopiedTable struct {
+	OID      string   `json:"oid"`
+	Rows     int64    `json:"rows"`
+	Warnings []string `json:"warnings"`
+}
 
+// ColumnSequence is a serial or identity column and the sequence that feeds it
+type ColumnSequence struct {
+	ColumnName   string `db:"column_name"`
+	SequenceName string `db:"sequence_name"`
+	IsIdentity   bool   `db:"is_identity"`
+}
+
+// TableObject is an index, sequence or constraint that belongs to a table
+type TableObject struct {
+	Kind string `db:"kind"`
+	Name string `db:"name"`
+}
 
 type ShortTable struct {
 	OID  string `json:"oid" db:"oid"`
@@ -49,6 +205,8 @@ type ColumnCollection struct {
 	Columns []Column `json:"columns"`
 }
 
+// Column is a single column definition, multi-column keys and named checks are given
+// as utils.ConstraintInfo entries of the table schema
 type Column struct {
 	Name         string     `json:"name"`
 	Type         string     `json:"type"`
@@ -56,11 +214,18 @@ type Column struct {
 	IsNullable   *bool      `json:"isNullable"`
 	IsPrimaryKey *bool      `json:"isPrimaryKey"`
 	ForeignKey   ForeignKey `json:"foreignKey"`
+	// Default is the default expression of the column
+	Default *string `json:"default,omitempty"`
+	// Check is a check expression on the column
+	Check string `json:"check,omitempty"`
 }
 
 type ForeignKey struct {
 	ColumnName string `json:"columnName"`
 	TableName  string `json:"tableName"`
+	// OnDelete and OnUpdate are the referential actions, NO ACTION when empty
+	OnDelete string `json:"onDelete,omitempty"`
+	OnUpdate string `json:"onUpdate,omitempty"`
 }
 
 /*
@@ -4,33 +4,39 @@ import (
 	"DBHS/config"
 	"DBHS/utils"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func GetAllTablesRepository(ctx context.Context, projectId int64, userDb utils.Querier, servDb utils.Querier) ([]Table, error) {
 	var tables []Table
-	err := pgxscan.Select(ctx, servDb, &tables, `SELECT id, oid, name FROM "Ptable" WHERE project_id = $1`, projectId)
+	err := pgxscan.Select(ctx, servDb, &tables, GetProjectTablesStmt, projectId)
 	if err != nil {
 		return nil, err
 	}
 
-	// extract the table schema
-	tableSchema, err := utils.GetTables(ctx, userDb)
+	// extract the table schema of every user schema
+	tableSchema, err := getProjectTableSchemas(ctx, userDb)
 	if err != nil {
 		return nil, err
 	}
 
-	presentTables := make(map[string]bool)
+	presentTables := make(map[tableKey]bool)
 	// delete the table recored if they are not present in the schema
 	for i := 0; i < len(tables); i++ {
-		presentTables[tables[i].Name] = true
-		if _, ok := tableSchema[tables[i].Name]; !ok {
+		key := tableKey{utils.SchemaOrDefault(tables[i].SchemaName), tables[i].Name}
+		presentTables[key] = true
+		if _, ok := tableSchema[key]; !ok {
 			// delete the table record from the database
 			if err := DeleteTableRecord(ctx, tables[i].ID, servDb); err != nil {
 				config.App.ErrorLog.Printf("Failed to delete table record %s: %v", tables[i].OID, err)
@@ -42,32 +48,54 @@ func GetAllTablesRepository(ctx context.Context, projectId int64, userDb utils.Q
 	}
 
 	// insert new table entries if they are present in the schema but not in the database
-	for name, _ := range tableSchema {
-		if presentTables[name] {
+	for key := range tableSchema {
+		if presentTables[key] {
 			continue // skip if the table is already present
 		}
 		// create a new table record
 		newTable := &Table{
-			Name:      name,
-			ProjectID: projectId,
-			OID:       utils.GenerateOID(),
+			Name:       key.name,
+			SchemaName: key.schema,
+			ProjectID:  projectId,
+			OID:        utils.GenerateOID(),
 		}
 		if err := InsertNewTable(ctx, newTable, &newTable.ID, servDb); err != nil {
-			config.App.ErrorLog.Printf("Failed to insert new table %s: %v", name, err)
+			config.App.ErrorLog.Printf("Failed to insert new table %s: %v", key.name, err)
 		}
 		tables = append(tables, *newTable)
 	}
 
 	// convert the table schema to the table model
 	for i := range tables {
-		tables[i].Schema = tableSchema[tables[i].Name]
+		tables[i].SchemaName = utils.SchemaOrDefault(tables[i].SchemaName)
+		tables[i].Schema = tableSchema[tableKey{tables[i].SchemaName, tables[i].Name}]
 	}
 
 	return tables, err
 }
 
+// getProjectTableSchemas returns the tables of every user schema of the project database
+func getProjectTableSchemas(ctx context.Context, userDb utils.Querier) (map[tableKey]*utils.Table, error) {
+	schemas, err := utils.ListSchemas(ctx, userDb)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[tableKey]*utils.Table)
+	for _, schema := range schemas {
+		schemaTables, err := utils.GetSchemaTables(ctx, userDb, schema)
+		if err != nil {
+			return nil, err
+		}
+		for name, table := range schemaTables {
+			tables[tableKey{schema, name}] = table
+		}
+	}
+	return tables, nil
+}
+
 func InsertNewTable(ctx context.Context, table *Table, TableId *int64, db utils.Querier) error {
-	err := db.QueryRow(ctx, InsertNewTableRecordStmt, table.OID, table.Name, table.Description, table.ProjectID).Scan(TableId)
+	err := db.QueryRow(ctx, InsertNewTableRecordStmt, table.OID, table.Name, table.Description, table.ProjectID, utils.SchemaOrDefault(table.SchemaName)).Scan(TableId)
 	if err != nil {
 		return fmt.Errorf("failed to insert new table: %w", err)
 	}
@@ -113,6 +141,17 @@ func GetTableName(ctx context.Context, tableOID string, db utils.Querier) (strin
 	return tableName, nil
 }
 
+// GetTableRecord returns the Ptable record of a table, including the schema it lives in
+func GetTableRecord(ctx context.Context, tableOID string, db utils.Querier) (*Table, error) {
+	var table Table
+	err := pgxscan.Get(ctx, db, &table, GetTableRecordStmt, tableOID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table record: %w", err)
+	}
+	table.SchemaName = utils.SchemaOrDefault(table.SchemaName)
+	return &table, nil
+}
+
 func DeleteTableFromHostingServer(ctx context.Context, tableName string, db utils.Querier) error {
 	_, err := db.Exec(ctx, fmt.Sprintf(DropTableStmt, tableName))
 	if err != nil {
@@ -297,4 +336,327 @@ func InserRow(ctx context.Context, tableNmae string, data map[string]interface{}
 
 	_, err := db.Exec(ctx, query, values...)
 	return err
-}
\ No newline at end of file
+}
+func EstimateTableRows(ctx context.Context, schema, tableName string, db utils.Querier) (int64, error) {
+	var rows int64
+	err := db.QueryRow(ctx, EstimateTableRowsStmt, schema, tableName).Scan(&rows)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate table rows: %w", err)
+	}
+	return rows, nil
+}
+
+// EstimateColumnValues estimates how many rows hold a non null value in the column
+func EstimateColumnValues(ctx context.Context, schema, tableName, columnName string, tableRows int64, db utils.Querier) (int64, error) {
+	var nullFrac float64
+	err := db.QueryRow(ctx, EstimateColumnNullFracStmt, schema, tableName, columnName).Scan(&nullFrac)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate column values: %w", err)
+	}
+	return int64(float64(tableRows) * (1 - nullFrac)), nil
+}
+
+// DeleteProjectTableRecord removes the Ptable record of a table of a project by its name
+func DeleteProjectTableRecord(ctx context.Context, projectId int64, schema, tableName string, db utils.Querier) error {
+	_, err := db.Exec(ctx, DeleteProjectTableRecordStmt, projectId, schema, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to delete table record: %w", err)
+	}
+	return nil
+}
+
+// IsPartitionOf reports whether a table is a partition of a partitioned table
+func IsPartitionOf(ctx context.Context, schema, name, parentSchema, parent string, db utils.Querier) (bool, error) {
+	var isPartition bool
+	err := db.QueryRow(ctx, IsPartitionOfStmt, schema, name, parentSchema, parent).Scan(&isPartition)
+	if err != nil {
+		return false, fmt.Errorf("failed to check partition: %w", err)
+	}
+	return isPartition, nil
+}
+
+// GetPartitionKeyType returns the strategy, the number of key columns and the type of the first key column
+// of a partitioned table
+func GetPartitionKeyType(ctx context.Context, schema, tableName string, db utils.Querier) (string, int, string, error) {
+	var strategy, keyType string
+	var columns int
+	err := db.QueryRow(ctx, PartitionKeyTypeStmt, schema, tableName).Scan(&strategy, &columns, &keyType)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", 0, "", ErrNotPartitioned
+		}
+		return "", 0, "", fmt.Errorf("failed to get partition key: %w", err)
+	}
+	return strategy, columns, keyType, nil
+}
+
+// GetPartitionPolicy returns nil when the table has no partition policy
+func GetPartitionPolicy(ctx context.Context, projectId int64, schema, tableName string, db utils.Querier) (*PartitionPolicy, error) {
+	var policy PartitionPolicy
+	err := pgxscan.Get(ctx, db, &policy, GetPartitionPolicyStmt, projectId, schema, tableName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get partition policy: %w", err)
+	}
+	return &policy, nil
+}
+
+func GetPartitionPolicies(ctx context.Context, db utils.Querier) ([]ScheduledPartitionPolicy, error) {
+	var policies []ScheduledPartitionPolicy
+	if err := pgxscan.Select(ctx, db, &policies, GetPartitionPoliciesStmt); err != nil {
+		return nil, fmt.Errorf("failed to get partition policies: %w", err)
+	}
+	return policies, nil
+}
+
+func UpsertPartitionPolicy(ctx context.Context, projectId int64, schema, tableName string, policy *PartitionPolicy, db utils.Querier) error {
+	err := db.QueryRow(ctx, UpsertPartitionPolicyStmt, projectId, schema, tableName, policy.Interval, policy.Premake, policy.Retention).Scan(&policy.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save partition policy: %w", err)
+	}
+	return nil
+}
+
+func DeletePartitionPolicy(ctx context.Context, projectId int64, schema, tableName string, db utils.Querier) error {
+	_, err := db.Exec(ctx, DeletePartitionPolicyStmt, projectId, schema, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to delete partition policy: %w", err)
+	}
+	return nil
+}
+
+// RecordPartitionRun stores the outcome of a run of a partition policy, a nil error clears the last error
+func RecordPartitionRun(ctx context.Context, policyId int64, runErr error, db utils.Querier) error {
+	var lastError *string
+	if runErr != nil {
+		message := runErr.Error()
+		lastError = &message
+	}
+	_, err := db.Exec(ctx, RecordPartitionRunStmt, policyId, lastError)
+	return err
+}
+
+// RenamePartitionPolicy moves the partition policy of a table to its new name
+func RenamePartitionPolicy(ctx context.Context, projectId int64, schema, oldName, newName string, db utils.Querier) error {
+	_, err := db.Exec(ctx, RenamePartitionPolicyStmt, projectId, schema, oldName, newName)
+	if err != nil {
+		return fmt.Errorf("failed to rename partition policy: %w", err)
+	}
+	return nil
+}
+
+// GetTableTTL returns nil when the table has no ttl
+func GetTableTTL(ctx context.Context, tableOID string, db utils.Querier) (*TableTTL, error) {
+	var ttl TableTTL
+	err := pgxscan.Get(ctx, db, &ttl, GetTableTTLStmt, tableOID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get table ttl: %w", err)
+	}
+	return &ttl, nil
+}
+
+func GetTableTTLs(ctx context.Context, db utils.Querier) ([]ScheduledTTL, error) {
+	var ttls []ScheduledTTL
+	if err := pgxscan.Select(ctx, db, &ttls, GetTableTTLsStmt); err != nil {
+		return nil, fmt.Errorf("failed to get table ttls: %w", err)
+	}
+	return ttls, nil
+}
+
+// SetTableTTL stores the ttl of a table, the interval is normalized by postgres
+func SetTableTTL(ctx context.Context, tableOID string, ttl *TableTTL, db utils.Querier) error {
+	err := db.QueryRow(ctx, SetTableTTLStmt, tableOID, ttl.Column, ttl.Interval, ttl.BatchSize).Scan(&ttl.Interval)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		// invalid or non positive intervals
+		if errors.As(err, &pgErr) && (pgErr.Code[:2] == "22" || pgErr.Code == "23514") {
+			return ErrInvalidTTL
+		}
+		return fmt.Errorf("failed to set table ttl: %w", err)
+	}
+	return nil
+}
+
+func DeleteTableTTL(ctx context.Context, tableOID string, db utils.Querier) error {
+	_, err := db.Exec(ctx, DeleteTableTTLStmt, tableOID)
+	if err != nil {
+		return fmt.Errorf("failed to delete table ttl: %w", err)
+	}
+	return nil
+}
+
+// GetTTLRuns returns the latest runs of the ttl worker on a table
+func GetTTLRuns(ctx context.Context, tableOID string, limit int, db utils.Querier) ([]TTLRun, error) {
+	runs := make([]TTLRun, 0)
+	if err := pgxscan.Select(ctx, db, &runs, GetTTLRunsStmt, tableOID, limit); err != nil {
+		return nil, fmt.Errorf("failed to get ttl runs: %w", err)
+	}
+	return runs, nil
+}
+
+func InsertTTLRun(ctx context.Context, tableId int64, run *TTLRun, db utils.Querier) error {
+	_, err := db.Exec(ctx, InsertTTLRunStmt, tableId, run.StartedAt, run.RowsPurged, run.Batches, run.Error)
+	if err != nil {
+		return fmt.Errorf("failed to record ttl run: %w", err)
+	}
+	return nil
+}
+
+// DeleteOldTTLRuns keeps the runs of the last 30 days
+func DeleteOldTTLRuns(ctx context.Context, db utils.Querier) error {
+	_, err := db.Exec(ctx, DeleteOldTTLRunsStmt)
+	if err != nil {
+		return fmt.Errorf("failed to delete old ttl runs: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredRows deletes one batch of expired rows and returns how many rows it deleted
+func PurgeExpiredRows(ctx context.Context, schema, tableName string, ttl *TableTTL, db utils.Querier) (int64, error) {
+	query := fmt.Sprintf(PurgeExpiredRowsStmt, utils.QualifiedName(schema, tableName), utils.QuoteIdentifier(ttl.Column))
+	tag, err := db.Exec(ctx, query, ttl.Interval, ttl.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired rows: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// TableExists reports whether a table exists in a database, the name is a qualified name
+func TableExists(ctx context.Context, qualifiedName string, db utils.Querier) (bool, error) {
+	var exists bool
+	if err := db.QueryRow(ctx, TableExistsStmt, qualifiedName).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check table: %w", err)
+	}
+	return exists, nil
+}
+
+// GetReferencingTables returns the tables, as "schema.table", that a cascading truncate of a table empties
+func GetReferencingTables(ctx context.Context, qualifiedName string, db utils.Querier) ([]string, error) {
+	var tables []string
+	if err := pgxscan.Select(ctx, db, &tables, ReferencingTablesStmt, qualifiedName); err != nil {
+		return nil, fmt.Errorf("failed to query referencing tables: %w", err)
+	}
+	return tables, nil
+}
+
+// GetTableObjects returns the indexes, owned sequences and constraints without an index of a table
+func GetTableObjects(ctx context.Context, qualifiedName string, db utils.Querier) ([]TableObject, error) {
+	var objects []TableObject
+	if err := pgxscan.Select(ctx, db, &objects, TableObjectsStmt, qualifiedName); err != nil {
+		return nil, fmt.Errorf("failed to query table objects: %w", err)
+	}
+	return objects, nil
+}
+
+// GetCopyColumns returns the columns of a table that are copied with its rows
+func GetCopyColumns(ctx context.Context, qualifiedName string, db utils.Querier) ([]string, error) {
+	var columns []string
+	if err := pgxscan.Select(ctx, db, &columns, CopyColumnsStmt, qualifiedName); err != nil {
+		return nil, fmt.Errorf("failed to query table columns: %w", err)
+	}
+	return columns, nil
+}
+
+// GetColumnSequences returns the serial and identity columns of a table with their sequences
+func GetColumnSequences(ctx context.Context, qualifiedName string, db utils.Querier) ([]ColumnSequence, error) {
+	var sequences []ColumnSequence
+	if err := pgxscan.Select(ctx, db, &sequences, ColumnSequencesStmt, qualifiedName); err != nil {
+		return nil, fmt.Errorf("failed to query column sequences: %w", err)
+	}
+	return sequences, nil
+}
+
+// CopySequenceState sets the sequence of a column of a table to the state of another sequence,
+// the next value of the column follows the copied rows
+func CopySequenceState(ctx context.Context, sequence string, source utils.Querier, qualifiedName, column string, target utils.Querier) error {
+	var lastValue int64
+	var isCalled bool
+	if err := source.QueryRow(ctx, fmt.Sprintf(SequenceStateStmt, sequence)).Scan(&lastValue, &isCalled); err != nil {
+		return fmt.Errorf("failed to read sequence %s: %w", sequence, err)
+	}
+	if _, err := target.Exec(ctx, SetSequenceStateStmt, qualifiedName, column, lastValue, isCalled); err != nil {
+		return fmt.Errorf("failed to set the sequence of %s: %w", column, err)
+	}
+	return nil
+}
+
+// copyTableData streams the rows of a table from a project database into the same table of
+// another one with COPY and returns the number of copied rows
+func copyTableData(ctx context.Context, source *pgxpool.Pool, target pgx.Tx, tableName string, columns []string) (int64, error) {
+	conn, err := source.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	columnList := utils.QuoteColumns(columns)
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Conn().PgConn().CopyTo(ctx, writer, fmt.Sprintf(CopyTableToStmt, columnList, tableName))
+		writer.CloseWithError(err)
+		done <- err
+	}()
+
+	tag, err := target.Conn().PgConn().CopyFrom(ctx, reader, fmt.Sprintf(CopyTableFromStmt, tableName, columnList))
+	// closing the reader unblocks the source when the target stopped reading early
+	reader.Close()
+	if copyErr := <-done; err == nil {
+		err = copyErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy table data: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetCatalogVersion returns the catalog version of a project database, tracked is false when the
+// event trigger counting the DDL changes is missing or disabled
+func GetCatalogVersion(ctx context.Context, db utils.Querier) (int64, bool, error) {
+	var version int64
+	var tracked bool
+	err := db.QueryRow(ctx, CatalogVersionStmt).Scan(&version, &tracked)
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) || errors.As(err, &pgErr) && (pgErr.Code == "42P01" || pgErr.Code == "3F000") {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read catalog version: %w", err)
+	}
+	return version, tracked, nil
+}
+
+// InstallCatalogTracking creates the catalog version and its event trigger in a project database
+func InstallCatalogTracking(ctx context.Context, db utils.Querier) error {
+	if _, err := db.Exec(ctx, InstallCatalogTrackingStmt); err != nil {
+		return fmt.Errorf("failed to install catalog tracking: %w", err)
+	}
+	return nil
+}
+
+// GetSyncedCatalogVersion returns the catalog version the tables of a project were last synced at,
+// nil when they were never synced. lock holds the project until the transaction ends
+func GetSyncedCatalogVersion(ctx context.Context, projectId int64, lock bool, db utils.Querier) (*int64, error) {
+	query := GetSyncedCatalogVersionStmt
+	if lock {
+		query = LockSyncedCatalogVersionStmt
+	}
+	var version *int64
+	if err := db.QueryRow(ctx, query, projectId).Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to read synced catalog version: %w", err)
+	}
+	return version, nil
+}
+
+// SetSyncedCatalogVersion records the catalog version the tables of a project were synced at
+func SetSyncedCatalogVersion(ctx context.Context, projectId, version int64, db utils.Querier) error {
+	if _, err := db.Exec(ctx, SetSyncedCatalogVersionStmt, projectId, version); err != nil {
+		return fmt.Errorf("failed to record synced catalog version: %w", err)
+	}
+	return nil
+}
@@ -9,6 +9,21 @@ import (
 /*
 	POST 	/api/projects/{project_id}/tables
 	GET 	/api/projects/{project_id}/tables/{table_id}/schema
+	GET 	/api/projects/{project_id}/tables/{table_id}/partitions
+	POST 	/api/projects/{project_id}/tables/{table_id}/partitions
+	POST 	/api/projects/{project_id}/tables/{table_id}/partitions/attach
+	POST 	/api/projects/{project_id}/tables/{table_id}/partitions/{partition_name}/detach
+	DELETE 	/api/projects/{project_id}/tables/{table_id}/partitions/{partition_name}
+	GET 	/api/projects/{project_id}/tables/{table_id}/partition-policy
+	PUT 	/api/projects/{project_id}/tables/{table_id}/partition-policy
+	DELETE 	/api/projects/{project_id}/tables/{table_id}/partition-policy
+	GET 	/api/projects/{project_id}/tables/{table_id}/ttl
+	PUT 	/api/projects/{project_id}/tables/{table_id}/ttl
+	DELETE 	/api/projects/{project_id}/tables/{table_id}/ttl
+	POST 	/api/projects/{project_id}/tables/{table_id}/truncate
+	POST 	/api/projects/{project_id}/tables/{table_id}/rename
+	POST 	/api/projects/{project_id}/tables/{table_id}/duplicate
+	POST 	/api/projects/{project_id}/tables/{table_id}/copy
 	PUT 	/api/projects/{project_id}/tables/{table_id}
 	DELETE 	/api/projects/{project_id}/tables/{table_id}
 	GET 	/api/projects/{project_id}/tables/{table_id}?
@@ -38,6 +53,52 @@ func DefineURLs() {
 	router.Handle("/{table_id}/schema", middleware.Route(map[string]http.HandlerFunc{
 		http.MethodGet: GetTableSchemaHandler(config.App),
 	}))
+
+	router.Handle("/{table_id}/partitions", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:  ListPartitionsHandler(config.App),
+		http.MethodPost: CreatePartitionHandler(config.App),
+	}))
+
+	// attach is registered before /{partition_name} so it is matched first
+	router.Handle("/{table_id}/partitions/attach", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: AttachPartitionHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/partitions/{partition_name}", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodDelete: DropPartitionHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/partitions/{partition_name}/detach", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: DetachPartitionHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/partition-policy", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:    GetPartitionPolicyHandler(config.App),
+		http.MethodPut:    SetPartitionPolicyHandler(config.App),
+		http.MethodDelete: DeletePartitionPolicyHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/ttl", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:    GetTableTTLHandler(config.App),
+		http.MethodPut:    SetTableTTLHandler(config.App),
+		http.MethodDelete: DeleteTableTTLHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/truncate", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: TruncateTableHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/rename", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: RenameTableHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/duplicate", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: DuplicateTableHandler(config.App),
+	}))
+
+	router.Handle("/{table_id}/copy", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: CopyTableHandler(config.App),
+	}))
 }
 
 /*
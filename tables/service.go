@@ -5,7 +5,10 @@ import (
 	"DBHS/response"
 	"DBHS/utils"
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -39,20 +42,21 @@ func GetTableSchema(ctx context.Context, projectOID string, tableOID string, ser
 		return nil, err
 	}
 
-	tableName, err := GetTableName(ctx, tableOID, servDb)
+	record, err := GetTableRecord(ctx, tableOID, servDb)
 	if err != nil {
 		return nil, err
 	}
 
-	schema, err := utils.GetTable(ctx, tableName, userDb)
+	schema, err := utils.GetSchemaTable(ctx, record.SchemaName, record.Name, userDb)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Table{
-		Schema: schema,
-		OID:    tableOID,
-		Name:   schema.TableName,
+		Schema:     schema,
+		OID:        tableOID,
+		SchemaName: record.SchemaName,
+		Name:       schema.TableName,
 	}, nil
 }
 
@@ -66,6 +70,17 @@ func CreateTable(ctx context.Context, projectOID string, table *Table, servDb *p
 	if err != nil {
 		return "", err
 	}
+
+	table.SchemaName = utils.SchemaOrDefault(table.SchemaName)
+	exists, err := utils.SchemaExists(ctx, userDb, table.SchemaName)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", response.ErrBadRequest
+	}
+	table.Schema.SchemaName = table.SchemaName
+
 	// create the table in the user db
 	tx, err := userDb.Begin(ctx)
 	if err != nil {
@@ -73,6 +88,14 @@ func CreateTable(ctx context.Context, projectOID string, table *Table, servDb *p
 	}
 	defer tx.Rollback(ctx)
 
+	if err := utils.SetSearchPath(ctx, tx, table.SchemaName); err != nil {
+		return "", err
+	}
+
+	if err := utils.ValidateColumnTypes(ctx, tx, table.Schema.Columns); err != nil {
+		return "", err
+	}
+
 	if err := CreateTableIntoHostingServer(ctx, table, tx); err != nil {
 		return "", err
 	}
@@ -94,31 +117,21 @@ func CreateTable(ctx context.Context, projectOID string, table *Table, servDb *p
 }
 
 func UpdateTable(ctx context.Context, projectOID string, tableOID string, newSchema *UpdateTableSchema, servDb *pgxpool.Pool) error {
-	userId, ok := ctx.Value("user-id").(int64)
-	if !ok || userId == 0 {
-		return response.ErrUnauthorized
-	}
-
-	_, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
-	if err != nil {
-		return err
-	}
-
-	oldSchema, err := utils.GetTableSchema(ctx, tableOID, servDb)
+	userDb, oldSchema, DDLUpdate, err := prepareTableUpdate(ctx, projectOID, tableOID, newSchema, servDb)
 	if err != nil {
 		return err
 	}
+	defer userDb.Close()
 
-	DDLUpdate, err := utils.CompareTableSchemas(oldSchema, newSchema.Schema, newSchema.Renames)
+	tx, err := userDb.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	tx, err := userDb.Begin(ctx)
-	if err != nil {
+	if err := utils.SetSearchPath(ctx, tx, oldSchema.SchemaName); err != nil {
 		return err
 	}
-	defer tx.Rollback(ctx)
 
 	if _, err := tx.Exec(ctx, DDLUpdate); err != nil {
 		return err
@@ -126,8 +139,7 @@ func UpdateTable(ctx context.Context, projectOID string, tableOID string, newSch
 
 	// Update the table name in the service database
 	if oldSchema.TableName != newSchema.Schema.TableName {
-		if _, err := servDb.Exec(ctx, "UPDATE \"Ptable\" SET name = $1 WHERE oid = $2",
-			newSchema.Schema.TableName, tableOID); err != nil {
+		if _, err := servDb.Exec(ctx, UpdateTableNameStmt, newSchema.Schema.TableName, tableOID); err != nil {
 			return err
 		}
 	}
@@ -139,6 +151,126 @@ func UpdateTable(ctx context.Context, projectOID string, tableOID string, newSch
 	return nil
 }
 
+// PreviewTableUpdate computes the DDL of a schema update without applying it.
+// when validate is set the DDL is executed inside a transaction that is always rolled back
+func PreviewTableUpdate(ctx context.Context, projectOID string, tableOID string, newSchema *UpdateTableSchema, validate bool, servDb *pgxpool.Pool) (*UpdatePreview, error) {
+	userDb, oldSchema, DDLUpdate, err := prepareTableUpdate(ctx, projectOID, tableOID, newSchema, servDb)
+	if err != nil {
+		return nil, err
+	}
+	defer userDb.Close()
+
+	tableRows, err := EstimateTableRows(ctx, oldSchema.SchemaName, oldSchema.TableName, userDb)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := SplitDDLStatements(DDLUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &UpdatePreview{
+		DDL:           DDLUpdate,
+		Statements:    statements,
+		Destructive:   make([]DestructiveChange, 0),
+		EstimatedRows: tableRows,
+	}
+
+	for _, change := range utils.DestructiveChanges(oldSchema, newSchema.Schema, newSchema.Renames) {
+		affectedRows := tableRows
+		if change.Column != "" {
+			affectedRows, err = EstimateColumnValues(ctx, oldSchema.SchemaName, oldSchema.TableName, change.Column, tableRows, userDb)
+			if err != nil {
+				return nil, err
+			}
+		}
+		preview.Destructive = append(preview.Destructive, DestructiveChange{
+			SchemaChange: change,
+			AffectedRows: affectedRows,
+		})
+	}
+
+	if !validate {
+		return preview, nil
+	}
+
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, oldSchema.SchemaName); err != nil {
+		return nil, err
+	}
+
+	// don't wait behind long running transactions while validating
+	if _, err := tx.Exec(ctx, ValidationLockTimeoutStmt); err != nil {
+		return nil, err
+	}
+
+	preview.Validated = true
+	if _, err := tx.Exec(ctx, DDLUpdate); err != nil {
+		preview.ValidationError = err.Error()
+	}
+
+	return preview, nil
+}
+
+// prepareTableUpdate reads the current table schema from the user database and generates
+// the DDL that turns it into the requested schema, the caller closes the returned pool
+func prepareTableUpdate(ctx context.Context, projectOID string, tableOID string, newSchema *UpdateTableSchema, servDb *pgxpool.Pool) (*pgxpool.Pool, *utils.Table, string, error) {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return nil, nil, "", response.ErrUnauthorized
+	}
+
+	_, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	record, err := GetTableRecord(ctx, tableOID, servDb)
+	if err != nil {
+		userDb.Close()
+		return nil, nil, "", err
+	}
+
+	oldSchema, err := utils.GetSchemaTable(ctx, record.SchemaName, record.Name, userDb)
+	if err != nil {
+		userDb.Close()
+		return nil, nil, "", err
+	}
+
+	if err := validateColumnTypes(ctx, userDb, record.SchemaName, newSchema.Schema.Columns); err != nil {
+		userDb.Close()
+		return nil, nil, "", err
+	}
+
+	DDLUpdate, err := utils.CompareTableSchemas(oldSchema, newSchema.Schema, newSchema.Renames)
+	if err != nil {
+		userDb.Close()
+		return nil, nil, "", err
+	}
+
+	return userDb, oldSchema, DDLUpdate, nil
+}
+
+// validateColumnTypes resolves the column types with the search path of the table schema
+func validateColumnTypes(ctx context.Context, userDb *pgxpool.Pool, schema string, columns []utils.TableColumn) error {
+	tx, err := userDb.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, schema); err != nil {
+		return err
+	}
+	return utils.ValidateColumnTypes(ctx, tx, columns)
+}
+
 func DeleteTable(ctx context.Context, projectOID, tableOID string, servDb *pgxpool.Pool) error {
 	userId, ok := ctx.Value("user-id").(int64)
 	if !ok || userId == 0 {
@@ -150,10 +282,11 @@ func DeleteTable(ctx context.Context, projectOID, tableOID string, servDb *pgxpo
 		return err
 	}
 
-	tableName, err := GetTableName(ctx, tableOID, servDb)
+	record, err := GetTableRecord(ctx, tableOID, servDb)
 	if err != nil {
 		return err
 	}
+	tableName := record.Name
 
 	usertx, err := userDb.Begin(ctx)
 	if err != nil {
@@ -161,7 +294,7 @@ func DeleteTable(ctx context.Context, projectOID, tableOID string, servDb *pgxpo
 	}
 	defer usertx.Rollback(ctx)
 
-	if err := DeleteTableFromHostingServer(ctx, tableName, usertx); err != nil {
+	if err := DeleteTableFromHostingServer(ctx, utils.QualifiedName(record.SchemaName, tableName), usertx); err != nil {
 		return err
 	}
 
@@ -222,12 +355,23 @@ func ReadTable(ctx context.Context, projectOID, tableOID string, parameters map[
 		return nil, err
 	}
 
-	tableName, err := GetTableName(ctx, tableOID, servDb)
+	record, err := GetTableRecord(ctx, tableOID, servDb)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := ReadTableData(ctx, tableName, parameters, userDb)
+	// the table name is resolved through the search path of the table schema
+	tx, err := userDb.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, record.SchemaName); err != nil {
+		return nil, err
+	}
+
+	data, err := ReadTableData(ctx, record.Name, parameters, tx)
 	if err != nil {
 		return nil, err
 	}
@@ -250,12 +394,22 @@ func InserNewRow(ctx context.Context, projectOID, tableOID string, data map[stri
 		return err
 	}
 
-	tableName, err := GetTableName(ctx, tableOID, servDb)
+	record, err := GetTableRecord(ctx, tableOID, servDb)
 	if err != nil {
 		return err
 	}
 
-	tableColumns, err := ReadTableColumns(ctx, tableName, userDb)
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, record.SchemaName); err != nil {
+		return err
+	}
+
+	tableColumns, err := ReadTableColumns(ctx, record.Name, tx)
 	if err != nil {
 		return err
 	}
@@ -270,5 +424,768 @@ func InserNewRow(ctx context.Context, projectOID, tableOID string, data map[stri
 		return response.ErrBadRequest
 	}
 
-	return InserRow(ctx, tableName, data, userDb)
+	if err := InserRow(ctx, record.Name, data, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// partitionedTable returns the user database, the record and the partition key of a partitioned table.
+// the caller closes the database
+func partitionedTable(ctx context.Context, projectOID, tableOID string, servDb *pgxpool.Pool) (int64, *pgxpool.Pool, *Table, *utils.PartitionSpec, error) {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return 0, nil, nil, nil, response.ErrUnauthorized
+	}
+
+	projectId, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+
+	record, err := GetTableRecord(ctx, tableOID, servDb)
+	if err != nil {
+		userDb.Close()
+		return 0, nil, nil, nil, err
+	}
+
+	keys, err := utils.GetPartitionKeys(ctx, userDb, record.SchemaName, record.Name)
+	if err != nil {
+		userDb.Close()
+		return 0, nil, nil, nil, err
+	}
+	if keys[record.Name] == nil {
+		userDb.Close()
+		return 0, nil, nil, nil, ErrNotPartitioned
+	}
+	return projectId, userDb, record, keys[record.Name], nil
+}
+
+func ListPartitions(ctx context.Context, projectOID, tableOID string, servDb *pgxpool.Pool) (*PartitionList, error) {
+	_, userDb, record, key, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return nil, err
+	}
+	defer userDb.Close()
+
+	partitions, err := utils.GetPartitions(ctx, userDb, record.SchemaName, record.Name)
+	if err != nil {
+		return nil, err
+	}
+	if partitions == nil {
+		partitions = make([]utils.Partition, 0)
+	}
+	return &PartitionList{Key: key, Partitions: partitions}, nil
+}
+
+func CreatePartition(ctx context.Context, projectOID, tableOID string, request *CreatePartitionRequest, servDb *pgxpool.Pool) error {
+	_, userDb, record, _, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return err
+	}
+	defer userDb.Close()
+
+	bound, err := utils.FormatPartitionBound(&request.Bound)
+	if err != nil {
+		return err
+	}
+	if request.PartitionBy != nil {
+		// the partition has the columns of its table
+		table, err := utils.GetTableSchema(ctx, record.SchemaName, record.Name, userDb)
+		if err != nil {
+			return err
+		}
+		if err := utils.ValidatePartitionSpec(request.PartitionBy, table.Columns); err != nil {
+			return err
+		}
+	}
+
+	schema := request.Schema
+	if schema == "" {
+		schema = record.SchemaName
+	}
+	query := utils.CreatePartitionStatement(utils.QualifiedName(schema, request.Name), utils.QualifiedName(record.SchemaName, record.Name), bound, request.PartitionBy)
+	if _, err := userDb.Exec(ctx, query); err != nil {
+		return err
+	}
+
+	config.App.InfoLog.Printf("Partition %s of table %s created in project %s by user %s", request.Name, record.Name, projectOID, ctx.Value("user-name").(string))
+	return nil
+}
+
+// AttachPartition attaches an existing table as a partition, the table stops being listed on its own
+func AttachPartition(ctx context.Context, projectOID, tableOID string, request *AttachPartitionRequest, servDb *pgxpool.Pool) error {
+	projectId, userDb, record, _, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return err
+	}
+	defer userDb.Close()
+
+	bound, err := utils.FormatPartitionBound(&request.Bound)
+	if err != nil {
+		return err
+	}
+
+	schema := request.Schema
+	if schema == "" {
+		schema = record.SchemaName
+	}
+	query := fmt.Sprintf(AttachPartitionStmt, utils.QualifiedName(record.SchemaName, record.Name), utils.QualifiedName(schema, request.Table), bound)
+	if _, err := userDb.Exec(ctx, query); err != nil {
+		return err
+	}
+
+	if err := DeleteProjectTableRecord(ctx, projectId, schema, request.Table, servDb); err != nil {
+		return err
+	}
+
+	config.App.InfoLog.Printf("Table %s attached to table %s in project %s by user %s", request.Table, record.Name, projectOID, ctx.Value("user-name").(string))
+	return nil
+}
+
+// DetachPartition turns a partition into a standalone table and returns the oid of its new table record.
+// a concurrent detach doesn't block the queries on the partitioned table but can't run in a transaction
+func DetachPartition(ctx context.Context, projectOID, tableOID, schema, partition string, concurrently bool, servDb *pgxpool.Pool) (string, error) {
+	projectId, userDb, record, _, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return "", err
+	}
+	defer userDb.Close()
+
+	if schema == "" {
+		schema = record.SchemaName
+	}
+	isPartition, err := IsPartitionOf(ctx, schema, partition, record.SchemaName, record.Name, userDb)
+	if err != nil {
+		return "", err
+	}
+	if !isPartition {
+		return "", ErrPartitionNotFound
+	}
+
+	mode := ""
+	if concurrently {
+		mode = " CONCURRENTLY"
+	}
+	query := fmt.Sprintf(DetachPartitionStmt, utils.QualifiedName(record.SchemaName, record.Name), utils.QualifiedName(schema, partition), mode)
+	if _, err := userDb.Exec(ctx, query); err != nil {
+		return "", err
+	}
+
+	detached := &Table{
+		Name:       partition,
+		SchemaName: schema,
+		ProjectID:  projectId,
+		OID:        utils.GenerateOID(),
+	}
+	if err := InsertNewTable(ctx, detached, &detached.ID, servDb); err != nil {
+		return "", err
+	}
+
+	config.App.InfoLog.Printf("Partition %s detached from table %s in project %s by user %s", partition, record.Name, projectOID, ctx.Value("user-name").(string))
+	return detached.OID, nil
+}
+
+// DropPartition drops a partition and the rows it holds
+func DropPartition(ctx context.Context, projectOID, tableOID, schema, partition string, servDb *pgxpool.Pool) error {
+	_, userDb, record, _, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return err
+	}
+	defer userDb.Close()
+
+	if schema == "" {
+		schema = record.SchemaName
+	}
+	isPartition, err := IsPartitionOf(ctx, schema, partition, record.SchemaName, record.Name, userDb)
+	if err != nil {
+		return err
+	}
+	if !isPartition {
+		return ErrPartitionNotFound
+	}
+
+	if err := DeleteTableFromHostingServer(ctx, utils.QualifiedName(schema, partition), userDb); err != nil {
+		return err
+	}
+
+	config.App.InfoLog.Printf("Partition %s of table %s dropped in project %s by user %s", partition, record.Name, projectOID, ctx.Value("user-name").(string))
+	return nil
+}
+
+func GetTablePartitionPolicy(ctx context.Context, projectOID, tableOID string, servDb *pgxpool.Pool) (*PartitionPolicy, error) {
+	projectId, userDb, record, _, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return nil, err
+	}
+	defer userDb.Close()
+
+	policy, err := GetPartitionPolicy(ctx, projectId, record.SchemaName, record.Name, servDb)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, ErrPolicyNotFound
+	}
+	return policy, nil
+}
+
+// SetTablePartitionPolicy saves the partition policy of a table and applies it right away
+func SetTablePartitionPolicy(ctx context.Context, projectOID, tableOID string, policy *PartitionPolicy, servDb *pgxpool.Pool) (*PartitionMaintenance, error) {
+	if err := ValidatePartitionPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	projectId, userDb, record, _, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return nil, err
+	}
+	defer userDb.Close()
+
+	strategy, columns, keyType, err := GetPartitionKeyType(ctx, record.SchemaName, record.Name, userDb)
+	if err != nil {
+		return nil, err
+	}
+	if strategy != utils.RangePartition || columns != 1 || !isTimeType(keyType) {
+		return nil, ErrUnsupportedPolicy
+	}
+
+	if err := UpsertPartitionPolicy(ctx, projectId, record.SchemaName, record.Name, policy, servDb); err != nil {
+		return nil, err
+	}
+
+	maintenance, runErr := MaintainPartitions(ctx, record.SchemaName, record.Name, policy, userDb)
+	if err := RecordPartitionRun(ctx, policy.ID, runErr, servDb); err != nil {
+		config.App.ErrorLog.Println("Failed to record the partition maintenance of table", record.Name, ":", err)
+	}
+	return maintenance, runErr
+}
+
+func DeleteTablePartitionPolicy(ctx context.Context, projectOID, tableOID string, servDb *pgxpool.Pool) error {
+	projectId, userDb, record, _, err := partitionedTable(ctx, projectOID, tableOID, servDb)
+	if err != nil {
+		return err
+	}
+	defer userDb.Close()
+	return DeletePartitionPolicy(ctx, projectId, record.SchemaName, record.Name, servDb)
+}
+
+// MaintainPartitions creates the upcoming partitions of a policy and drops the expired ones.
+// the partitions are created before any is dropped so a failed drop never leaves a gap ahead
+func MaintainPartitions(ctx context.Context, schema, tableName string, policy *PartitionPolicy, userDb utils.Querier) (*PartitionMaintenance, error) {
+	maintenance := &PartitionMaintenance{Created: make([]string, 0), Dropped: make([]string, 0)}
+
+	strategy, columns, keyType, err := GetPartitionKeyType(ctx, schema, tableName, userDb)
+	if err != nil {
+		return maintenance, err
+	}
+	if strategy != utils.RangePartition || columns != 1 || !isTimeType(keyType) {
+		return maintenance, ErrUnsupportedPolicy
+	}
+
+	partitions, err := utils.GetPartitions(ctx, userDb, schema, tableName)
+	if err != nil {
+		return maintenance, err
+	}
+	existing := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		// managed partitions live in the schema of their table
+		if partition.SchemaName == schema {
+			existing = append(existing, partition.Name)
+		}
+	}
+
+	create, drop := PlanTimePartitions(tableName, policy, existing, time.Now())
+	for _, partition := range create {
+		bound, err := timePartitionBound(partition, keyType)
+		if err != nil {
+			return maintenance, err
+		}
+		query := utils.CreatePartitionStatement(utils.QualifiedName(schema, partition.Name), utils.QualifiedName(schema, tableName), bound, nil)
+		if _, err := userDb.Exec(ctx, query); err != nil {
+			return maintenance, fmt.Errorf("failed to create partition %s: %w", partition.Name, err)
+		}
+		maintenance.Created = append(maintenance.Created, partition.Name)
+	}
+
+	for _, name := range drop {
+		if err := DeleteTableFromHostingServer(ctx, utils.QualifiedName(schema, name), userDb); err != nil {
+			return maintenance, err
+		}
+		maintenance.Dropped = append(maintenance.Dropped, name)
+	}
+	return maintenance, nil
+}
+
+// RunPartitionPolicy applies a stored partition policy with the database of its project
+func RunPartitionPolicy(ctx context.Context, policy *ScheduledPartitionPolicy, servDb *pgxpool.Pool) (*PartitionMaintenance, error) {
+	_, userDb, err := utils.ExtractDb(ctx, policy.ProjectOid, policy.OwnerID, servDb)
+	if err != nil {
+		return &PartitionMaintenance{}, err
+	}
+	defer userDb.Close()
+	return MaintainPartitions(ctx, policy.SchemaName, policy.TableName, &policy.PartitionPolicy, userDb)
+}
+
+// GetTableTTLSettings returns the ttl of a table with the latest runs of the ttl worker
+func GetTableTTLSettings(ctx context.Context, projectOID, tableOID string, servDb *pgxpool.Pool) (*TableTTL, error) {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return nil, response.ErrUnauthorized
+	}
+
+	ttl, err := GetTableTTL(ctx, tableOID, servDb)
+	if err != nil {
+		return nil, err
+	}
+	if ttl == nil {
+		return nil, ErrTTLNotFound
+	}
+
+	ttl.Runs, err = GetTTLRuns(ctx, tableOID, 20, servDb)
+	if err != nil {
+		return nil, err
+	}
+	return ttl, nil
+}
+
+func SetTableTTLSettings(ctx context.Context, projectOID, tableOID string, ttl *TableTTL, servDb *pgxpool.Pool) error {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return response.ErrUnauthorized
+	}
+
+	_, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		return err
+	}
+	defer userDb.Close()
+
+	record, err := GetTableRecord(ctx, tableOID, servDb)
+	if err != nil {
+		return err
+	}
+
+	table, err := utils.GetTableSchema(ctx, record.SchemaName, record.Name, userDb)
+	if err != nil {
+		return err
+	}
+	if err := ValidateTableTTL(ttl, table.Columns); err != nil {
+		return err
+	}
+
+	if err := SetTableTTL(ctx, tableOID, ttl, servDb); err != nil {
+		return err
+	}
+
+	config.App.InfoLog.Printf("TTL of table %s set to %s on column %s in project %s by user %s", record.Name, ttl.Interval, ttl.Column, projectOID, ctx.Value("user-name").(string))
+	return nil
+}
+
+func DeleteTableTTLSettings(ctx context.Context, projectOID, tableOID string, servDb *pgxpool.Pool) error {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return response.ErrUnauthorized
+	}
+	return DeleteTableTTL(ctx, tableOID, servDb)
+}
+
+// PurgeTableTTL deletes the expired rows of a table in batches of the ttl batch size. every batch is
+// its own statement so the locks are held briefly, a run stops after maxTTLBatches batches
+func PurgeTableTTL(ctx context.Context, ttl *ScheduledTTL, servDb *pgxpool.Pool) (*TTLRun, error) {
+	run := &TTLRun{StartedAt: time.Now()}
+
+	_, userDb, err := utils.ExtractDb(ctx, ttl.ProjectOid, ttl.OwnerID, servDb)
+	if err != nil {
+		return run, err
+	}
+	defer userDb.Close()
+
+	for run.Batches < maxTTLBatches {
+		purged, err := PurgeExpiredRows(ctx, ttl.SchemaName, ttl.TableName, &ttl.TableTTL, userDb)
+		if err != nil {
+			return run, err
+		}
+		run.Batches++
+		run.RowsPurged += purged
+		if purged < int64(ttl.BatchSize) {
+			break
+		}
+	}
+	return run, nil
+}
+
+// TruncateTable empties a table. a cascading truncate empties the tables referencing it as well,
+// it is refused until every one of them is confirmed
+func TruncateTable(ctx context.Context, projectOID, tableOID string, request *TruncateRequest, servDb *pgxpool.Pool) error {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return response.ErrUnauthorized
+	}
+
+	_, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		return err
+	}
+	defer userDb.Close()
+
+	record, err := GetTableRecord(ctx, tableOID, servDb)
+	if err != nil {
+		return err
+	}
+	tableName := utils.QualifiedName(record.SchemaName, record.Name)
+
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	identity, cascade := "", ""
+	if request.RestartIdentity {
+		identity = " RESTART IDENTITY"
+	}
+	if request.Cascade {
+		referencing, err := GetReferencingTables(ctx, tableName, tx)
+		if err != nil {
+			return err
+		}
+		if missing := UnconfirmedTables(referencing, request.ConfirmTables); len(missing) > 0 {
+			return &TruncateConfirmationError{Tables: missing}
+		}
+		cascade = " CASCADE"
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(TruncateTableStmt, tableName, identity, cascade)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	config.App.InfoLog.Printf("Table %s truncated in project %s by user %s", record.Name, projectOID, ctx.Value("user-name").(string))
+	return nil
+}
+
+// RenameTable renames a table together with the indexes, sequences and constraints named after it
+// and keeps its Ptable record and partition policy in sync
+func RenameTable(ctx context.Context, projectOID, tableOID, name string, servDb *pgxpool.Pool) error {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return response.ErrUnauthorized
+	}
+
+	projectId, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		return err
+	}
+	defer userDb.Close()
+
+	record, err := GetTableRecord(ctx, tableOID, servDb)
+	if err != nil {
+		return err
+	}
+	if record.Name == name {
+		return nil
+	}
+	tableName := utils.QualifiedName(record.SchemaName, record.Name)
+	renamed := utils.QualifiedName(record.SchemaName, name)
+
+	usertx, err := userDb.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer usertx.Rollback(ctx)
+
+	exists, err := TableExists(ctx, renamed, usertx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrTableExists
+	}
+
+	objects, err := GetTableObjects(ctx, tableName, usertx)
+	if err != nil {
+		return err
+	}
+	if _, err := usertx.Exec(ctx, fmt.Sprintf(RenameTableStmt, tableName, utils.QuoteIdentifier(name))); err != nil {
+		return err
+	}
+	// owned sequences are created in the schema of their table
+	for _, object := range objects {
+		newName, ok := RenamedObject(object.Name, record.Name, name)
+		if !ok {
+			continue
+		}
+		var query string
+		switch object.Kind {
+		case "index":
+			query = fmt.Sprintf(RenameIndexStmt, utils.QualifiedName(record.SchemaName, object.Name), utils.QuoteIdentifier(newName))
+		case "sequence":
+			query = fmt.Sprintf(RenameSequenceStmt, utils.QualifiedName(record.SchemaName, object.Name), utils.QuoteIdentifier(newName))
+		default:
+			query = fmt.Sprintf(RenameConstraintStmt, renamed, utils.QuoteIdentifier(object.Name), utils.QuoteIdentifier(newName))
+		}
+		if _, err := usertx.Exec(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	servtx, err := servDb.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer servtx.Rollback(ctx)
+
+	if _, err := servtx.Exec(ctx, UpdateTableNameStmt, name, tableOID); err != nil {
+		return err
+	}
+	if err := RenamePartitionPolicy(ctx, projectId, record.SchemaName, record.Name, name, servtx); err != nil {
+		return err
+	}
+
+	if err := servtx.Commit(ctx); err != nil {
+		return err
+	}
+	if err := usertx.Commit(ctx); err != nil {
+		config.App.ErrorLog.Println("Failed to commit transaction:", err, "server db and user db are not in sync")
+		return err
+	}
+
+	config.App.InfoLog.Printf("Table %s renamed to %s in project %s by user %s", record.Name, name, projectOID, ctx.Value("user-name").(string))
+	return nil
+}
+
+// DuplicateTable creates a copy of a table in the same project. the copy has the columns, defaults,
+// checks, indexes and comments of the table, its serial columns get sequences of their own and its
+// foreign keys are recreated, a self reference pointing at the copy
+func DuplicateTable(ctx context.Context, projectOID, tableOID string, request *DuplicateTableRequest, servDb *pgxpool.Pool) (*CopiedTable, error) {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return nil, response.ErrUnauthorized
+	}
+
+	projectId, userDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		return nil, err
+	}
+	defer userDb.Close()
+
+	record, err := GetTableRecord(ctx, tableOID, servDb)
+	if err != nil {
+		return nil, err
+	}
+	schema := request.Schema
+	if schema == "" {
+		schema = record.SchemaName
+	}
+	source := utils.QualifiedName(record.SchemaName, record.Name)
+	target := utils.QualifiedName(schema, request.Name)
+
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	exists, err := TableExists(ctx, target, tx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrTableExists
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(DuplicateTableStmt, target, source)); err != nil {
+		return nil, err
+	}
+
+	// the copied defaults of serial columns still call the sequences of the table,
+	// identity columns get a new sequence from LIKE
+	sequences, err := GetColumnSequences(ctx, source, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sequence := range sequences {
+		if sequence.IsIdentity {
+			continue
+		}
+		column := utils.QuoteIdentifier(sequence.ColumnName)
+		sequenceName := utils.QualifiedName(schema, fmt.Sprintf("%s_%s_seq", request.Name, sequence.ColumnName))
+		if _, err := tx.Exec(ctx, fmt.Sprintf(CreateOwnedSequenceStmt, sequenceName, target, column)); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(SetColumnDefaultStmt, target, column, utils.QuoteLiteral(sequenceName))); err != nil {
+			return nil, err
+		}
+	}
+
+	constraints, err := utils.GetTableConstraints(ctx, record.SchemaName, record.Name, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, constraint := range utils.MergeConstraints(record.Name, constraints) {
+		if constraint.ConstraintType != utils.ForeignKeyConstraint {
+			continue
+		}
+		if constraint.ForeignSchemaName == nil && *constraint.ForeignTableName == record.Name {
+			constraint.ForeignTableName = &request.Name
+			constraint.ForeignSchemaName = &schema
+		} else if constraint.ForeignSchemaName == nil {
+			constraint.ForeignSchemaName = &record.SchemaName
+		}
+		constraint.ConstraintName, _ = RenamedObject(constraint.ConstraintName, record.Name, request.Name)
+		if _, err := tx.Exec(ctx, fmt.Sprintf(AddConstraintStmt, target, utils.FormatConstraint(&constraint))); err != nil {
+			return nil, err
+		}
+	}
+
+	copied := &CopiedTable{Warnings: []string{}}
+	if request.WithData {
+		columns, err := GetCopyColumns(ctx, source, tx)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) > 0 {
+			tag, err := tx.Exec(ctx, fmt.Sprintf(InsertTableDataStmt, target, utils.QuoteColumns(columns), source))
+			if err != nil {
+				return nil, err
+			}
+			copied.Rows = tag.RowsAffected()
+		}
+		for _, sequence := range sequences {
+			if err := CopySequenceState(ctx, sequence.SequenceName, tx, target, sequence.ColumnName, tx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	duplicate := &Table{
+		Name:       request.Name,
+		SchemaName: schema,
+		ProjectID:  projectId,
+		OID:        utils.GenerateOID(),
+	}
+	if err := InsertNewTable(ctx, duplicate, &duplicate.ID, servDb); err != nil {
+		return nil, err
+	}
+	copied.OID = duplicate.OID
+
+	config.App.InfoLog.Printf("Table %s duplicated as %s in project %s by user %s", record.Name, request.Name, projectOID, ctx.Value("user-name").(string))
+	return copied, nil
+}
+
+// CopyTableToProject creates a table, in the same schema and with the same name, in another project
+// of the owner. the table is recreated from the schema export so its types and sequences come along,
+// foreign keys to tables that are not copied are skipped and reported as warnings
+func CopyTableToProject(ctx context.Context, projectOID, tableOID string, request *CopyTableRequest, servDb *pgxpool.Pool) (*CopiedTable, error) {
+	userId, ok := ctx.Value("user-id").(int64)
+	if !ok || userId == 0 {
+		return nil, response.ErrUnauthorized
+	}
+	if request.ProjectID == projectOID {
+		return nil, ErrSameProject
+	}
+
+	owner, err := CheckOwnershipQuery(ctx, request.ProjectID, int(userId), servDb)
+	if err != nil {
+		return nil, err
+	}
+	if !owner {
+		return nil, ErrProjectNotFound
+	}
+
+	_, sourceDb, err := utils.ExtractDb(ctx, projectOID, userId, servDb)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceDb.Close()
+	targetId, targetDb, err := utils.ExtractDb(ctx, request.ProjectID, userId, servDb)
+	if err != nil {
+		return nil, err
+	}
+	defer targetDb.Close()
+
+	record, err := GetTableRecord(ctx, tableOID, servDb)
+	if err != nil {
+		return nil, err
+	}
+	tableName := utils.QualifiedName(record.SchemaName, record.Name)
+
+	exists, err := TableExists(ctx, tableName, targetDb)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrTableExists
+	}
+
+	ddl, err := utils.ExportSchemaDDL(ctx, sourceDb, record.SchemaName, []string{record.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := targetDb.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	// the script sets the search path of the session, it is reset before the connection goes back to the pool
+	defer conn.Exec(context.Background(), "RESET ALL")
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, ddl); err != nil {
+		return nil, err
+	}
+
+	copied := &CopiedTable{Warnings: CopyWarnings(ddl)}
+	if request.WithData {
+		columns, err := GetCopyColumns(ctx, tableName, sourceDb)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) > 0 {
+			if copied.Rows, err = copyTableData(ctx, sourceDb, tx, tableName, columns); err != nil {
+				return nil, err
+			}
+		}
+		sequences, err := GetColumnSequences(ctx, tableName, sourceDb)
+		if err != nil {
+			return nil, err
+		}
+		for _, sequence := range sequences {
+			if err := CopySequenceState(ctx, sequence.SequenceName, sourceDb, tableName, sequence.ColumnName, tx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	table := &Table{
+		Name:       record.Name,
+		SchemaName: record.SchemaName,
+		ProjectID:  targetId,
+		OID:        utils.GenerateOID(),
+	}
+	if err := InsertNewTable(ctx, table, &table.ID, servDb); err != nil {
+		return nil, err
+	}
+	copied.OID = table.OID
+
+	config.App.InfoLog.Printf("Table %s of project %s copied to project %s by user %s", record.Name, projectOID, request.ProjectID, ctx.Value("user-name").(string))
+	return copied, nil
 }
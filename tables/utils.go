@@ -6,16 +6,47 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
-func CreateColumnDefinition(column *Column) string {
+var (
+	ErrNotPartitioned    = errors.New("table is not partitioned")
+	ErrPartitionNotFound = errors.New("partition not found")
+	ErrPolicyNotFound    = errors.New("table has no partition policy")
+	ErrUnsupportedPolicy = errors.New("partition policies need a table partitioned by range on a single date or timestamp column")
+	ErrInvalidPolicy     = errors.New("interval must be day, week, month or year, premake between 1 and 366 and retention not negative")
+	ErrTTLNotFound       = errors.New("table has no ttl")
+	ErrInvalidTTL        = errors.New("ttl needs a date or timestamp column of the table, a positive interval and a batch size between 1 and 100000")
+	ErrTableExists       = errors.New("a table with this name already exists")
+	ErrSameProject       = errors.New("the table already belongs to this project, duplicate it instead")
+	ErrProjectNotFound   = errors.New("target project not found")
+)
+
+// TruncateConfirmationError is returned by a cascading truncate that would empty tables the
+// request didn't confirm
+type TruncateConfirmationError struct {
+	Tables []string
+}
+
+func (e *TruncateConfirmationError) Error() string {
+	return "the truncate cascades to tables that were not confirmed: " + strings.Join(e.Tables, ", ")
+}
+
+// CreateColumnDefinition returns the definition of a column in CREATE and ALTER TABLE, the
+// referential actions of its foreign key are checked as they are written into the DDL
+func CreateColumnDefinition(column *Column) (string, error) {
 	res := fmt.Sprintf("%s %s", column.Name, column.Type)
 	if column.IsPrimaryKey != nil && *column.IsPrimaryKey {
 		res += " PRIMARY KEY"
@@ -26,16 +57,37 @@ func CreateColumnDefinition(column *Column) string {
 	if column.IsNullable != nil && !*column.IsNullable {
 		res += " NOT NULL"
 	}
+	if column.Default != nil {
+		res += " DEFAULT " + *column.Default
+	}
+	if column.Check != "" {
+		res += fmt.Sprintf(" CHECK (%s)", column.Check)
+	}
 	if column.ForeignKey.TableName != "" {
 		res += fmt.Sprintf(" REFERENCES %s(%s)", column.ForeignKey.TableName, column.ForeignKey.ColumnName)
+		for _, action := range []string{column.ForeignKey.OnDelete, column.ForeignKey.OnUpdate} {
+			if !utils.IsReferentialAction(action) {
+				return "", &utils.ConstraintError{Constraint: column.Name + "_fkey", Reason: "has an invalid referential action " + action}
+			}
+		}
+		if column.ForeignKey.OnDelete != "" {
+			res += " ON DELETE " + strings.ToUpper(column.ForeignKey.OnDelete)
+		}
+		if column.ForeignKey.OnUpdate != "" {
+			res += " ON UPDATE " + strings.ToUpper(column.ForeignKey.OnUpdate)
+		}
 	}
-	return res
+	return res, nil
 }
 
 func ParseTableIntoSQLCreate(table *ClientTable) (string, error) {
 	columns := make([]string, len(table.Columns))
 	for i, column := range table.Columns {
-		columns[i] = CreateColumnDefinition(&column)
+		definition, err := CreateColumnDefinition(&column)
+		if err != nil {
+			return "", err
+		}
+		columns[i] = definition
 	}
 	createTableSQL := fmt.Sprintf("CREATE TABLE %s (%s);", table.TableName, strings.Join(columns, ", "))
 	return createTableSQL, nil
@@ -70,7 +122,10 @@ func ExecuteUpdate(tableName string, table map[string]DbColumn, updates *TableUp
 	// inserts
 	insertStmt := "ALTER TABLE %s ADD COLUMN %s"
 	for _, insert := range updates.Inserts.Columns {
-		column := CreateColumnDefinition(&insert)
+		column, err := CreateColumnDefinition(&insert)
+		if err != nil {
+			return err
+		}
 		query := fmt.Sprintf(insertStmt, tableName, column)
 		if _, err := db.Exec(context.Background(), query); err != nil {
 			return fmt.Errorf("failed to insert column: %w", err)
@@ -134,6 +189,32 @@ func ExecuteUpdate(tableName string, table map[string]DbColumn, updates *TableUp
 	return nil
 }
 
+// SplitDDLStatements returns the statements of a generated DDL script without its comments, the
+// script is split by the PostgreSQL parser so a statement spanning several lines stays whole
+func SplitDDLStatements(ddl string) ([]string, error) {
+	parsed, err := utils.ParseSQLStatements(ddl)
+	if err != nil {
+		return nil, err
+	}
+	statements := make([]string, len(parsed))
+	for i, statement := range parsed {
+		// the comments before a statement are part of its text
+		scanned, err := pg_query.Scan(statement.SQL)
+		if err != nil {
+			return nil, err
+		}
+		start := 0
+		for _, token := range scanned.Tokens {
+			if token.Token != pg_query.Token_SQL_COMMENT && token.Token != pg_query.Token_C_COMMENT {
+				start = int(token.Start)
+				break
+			}
+		}
+		statements[i] = statement.SQL[start:]
+	}
+	return statements, nil
+}
+
 func CreateUniqueConstraintName(tableName string, columnName string) string {
 	return fmt.Sprintf("%s_%s_key", tableName, columnName)
 }
@@ -141,24 +222,28 @@ func CreateUniqueConstraintName(tableName string, columnName string) string {
 
 func SyncTableSchemas(ctx context.Context, projectId int64, servDb utils.Querier, userDb utils.Querier) error {
 	var tables []Table
-	err := pgxscan.Select(ctx, servDb, &tables, `SELECT id, oid, name FROM "Ptable" WHERE project_id = $1`, projectId)
+	err := pgxscan.Select(ctx, servDb, &tables, GetProjectTablesStmt, projectId)
 	if err != nil {
 		return err
 	}
     
-	// extract the table schema
-	tableSchema, err := utils.GetTables(ctx, userDb)
+	// extract the table schema of every user schema
+	tableSchema, err := getProjectTableSchemas(ctx, userDb)
 	if err != nil {
 		return err
 	}
 
-	presentTables := make(map[string]bool)
+	presentTables := make(map[tableKey]bool)
 	// delete the table recored if they are not present in the schema
 	for i := 0; i < len(tables); i++ {
-		presentTables[tables[i].Name] = true
-		if _, ok := tableSchema[tables[i].Name]; !ok {
+		key := tableKey{utils.SchemaOrDefault(tables[i].SchemaName), tables[i].Name}
+		presentTables[key] = true
+		if _, ok := tableSchema[key]; !ok {
 			// delete the table record from the database
-			if err := DeleteTableRecord(ctx, tables[i].ID, servDb); err != nil {
+			err := inSavepoint(ctx, servDb, func(db utils.Querier) error {
+				return DeleteTableRecord(ctx, tables[i].ID, db)
+			})
+			if err != nil {
 				config.App.ErrorLog.Printf("Failed to delete table record %s: %v", tables[i].OID, err)
 			}
 			// remove the table from the list
@@ -168,18 +253,22 @@ func SyncTableSchemas(ctx context.Context, projectId int64, servDb utils.Querier
 	}
 
 	// insert new table entries if they are present in the schema but not in the database
-	for name, _ := range tableSchema {
-		if presentTables[name] {
+	for key := range tableSchema {
+		if presentTables[key] {
             continue // skip if the table is already present
         }
         // create a new table record
         newTable := &Table{
-            Name:      name,
-            ProjectID: projectId,
-            OID:       utils.GenerateOID(),
+            Name:       key.name,
+            SchemaName: key.schema,
+            ProjectID:  projectId,
+            OID:        utils.GenerateOID(),
         }
-        if err := InsertNewTable(ctx, newTable, &newTable.ID, servDb); err != nil {
-            config.App.ErrorLog.Printf("Failed to insert new table %s: %v", name, err)
+        err := inSavepoint(ctx, servDb, func(db utils.Querier) error {
+            return InsertNewTable(ctx, newTable, &newTable.ID, db)
+        })
+        if err != nil {
+            config.App.ErrorLog.Printf("Failed to insert new table %s: %v", key.name, err)
         }
         tables = append(tables, *newTable)
 	}
@@ -187,6 +276,303 @@ func SyncTableSchemas(ctx context.Context, projectId int64, servDb utils.Querier
 	return nil
 }
 
+// inSavepoint runs a change of the table records inside a savepoint when the records are synced in a
+// transaction, a failed change is then rolled back without aborting the transaction
+func inSavepoint(ctx context.Context, db utils.Querier, change func(db utils.Querier) error) error {
+	tx, ok := db.(pgx.Tx)
+	if !ok {
+		return change(db)
+	}
+	return pgx.BeginFunc(ctx, tx, func(savepoint pgx.Tx) error {
+		return change(savepoint)
+	})
+}
+
+// SyncChangedTableSchemas syncs the table records of a project only when its catalog changed since
+// the last sync. the catalog tracking is installed by the first sync and reinstalled when it was
+// dropped or disabled, the tables are fully synced then since changes may have been missed. when
+// it can't be installed the tables are fully synced and the synced version is left unset
+func SyncChangedTableSchemas(ctx context.Context, projectId int64, servDb *pgxpool.Pool, userDb utils.Querier) error {
+	version, tracked, err := GetCatalogVersion(ctx, userDb)
+	if err != nil {
+		return err
+	}
+	synced, err := GetSyncedCatalogVersion(ctx, projectId, false, servDb)
+	if err != nil {
+		return err
+	}
+	if tracked && synced != nil && *synced == version {
+		return nil
+	}
+
+	// concurrent requests wait for the first one to sync the project
+	tx, err := servDb.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if synced, err = GetSyncedCatalogVersion(ctx, projectId, true, tx); err != nil {
+		return err
+	}
+	if version, tracked, err = GetCatalogVersion(ctx, userDb); err != nil {
+		return err
+	}
+	if !tracked {
+		if err := InstallCatalogTracking(ctx, userDb); err != nil {
+			// the event trigger needs a superuser, without it the tables are fully synced by every request
+			config.App.ErrorLog.Printf("Warning: catalog tracking unavailable for project %d, syncing every table: %v", projectId, err)
+			if err := SyncTableSchemas(ctx, projectId, tx, userDb); err != nil {
+				return err
+			}
+			return tx.Commit(ctx)
+		}
+		if version, _, err = GetCatalogVersion(ctx, userDb); err != nil {
+			return err
+		}
+	} else if synced != nil && *synced == version {
+		return tx.Commit(ctx)
+	}
+
+	if err := SyncTableSchemas(ctx, projectId, tx, userDb); err != nil {
+		return err
+	}
+	if err := SetSyncedCatalogVersion(ctx, projectId, version, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// schemaRequestError returns the error of a table definition that the client has to fix,
+// nil when the error isn't caused by the definition
+func schemaRequestError(err error) error {
+	var typeErr *utils.UnknownTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr
+	}
+	var constraintErr *utils.ConstraintError
+	if errors.As(err, &constraintErr) {
+		return constraintErr
+	}
+	var partitionErr *utils.PartitionError
+	if errors.As(err, &partitionErr) {
+		return partitionErr
+	}
+	return nil
+}
+
+// partitionRequestError returns the error of a partition request that the client has to fix,
+// the DDL of overlapping bounds, missing tables and mismatched columns is rejected by postgres
+func partitionRequestError(err error) error {
+	if requestErr := schemaRequestError(err); requestErr != nil {
+		return requestErr
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && slices.Contains([]string{"22", "23", "42"}, pgErr.Code[:2]) {
+		return pgErr
+	}
+	return nil
+}
+
+const (
+	defaultTTLBatchSize = 1000
+	maxTTLBatchSize     = 100000
+	// maxTTLBatches bounds a run of the ttl worker on a table, the rest is purged by the next run
+	maxTTLBatches = 100
+)
+
+// partitionLayouts are the name suffixes of the partitions managed by a partition policy
+var partitionLayouts = map[string]string{
+	"day":   "20060102",
+	"week":  "20060102",
+	"month": "200601",
+	"year":  "2006",
+}
+
+// ValidatePartitionPolicy checks the interval, premake and retention of a partition policy
+func ValidatePartitionPolicy(policy *PartitionPolicy) error {
+	policy.Interval = strings.ToLower(strings.TrimSpace(policy.Interval))
+	if _, ok := partitionLayouts[policy.Interval]; !ok {
+		return ErrInvalidPolicy
+	}
+	if policy.Premake < 1 || policy.Premake > 366 {
+		return ErrInvalidPolicy
+	}
+	if policy.Retention != nil && *policy.Retention < 0 {
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+// truncateToInterval returns the start of the interval holding t, weeks start on monday
+func truncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	year, month, day := t.Date()
+	switch interval {
+	case "week":
+		start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func addInterval(t time.Time, interval string, n int) time.Time {
+	switch interval {
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	case "year":
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// maxIdentifierLength is the length in bytes postgres truncates identifiers to
+const maxIdentifierLength = 63
+
+// timePartitionPrefix is the name of the partitions of a table without their time suffix. the name of
+// a long table is cut so the longest suffix still fits, the hash of the full name keeps the prefixes
+// of tables sharing the cut part apart
+func timePartitionPrefix(tableName string) string {
+	const suffixLength = len("_p") + len("20060102")
+	if len(tableName)+suffixLength <= maxIdentifierLength {
+		return tableName + "_p"
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(tableName))
+	hashSuffix := fmt.Sprintf("_%08x", hash.Sum32())
+
+	cut := maxIdentifierLength - suffixLength - len(hashSuffix)
+	for cut > 0 && !utf8.RuneStart(tableName[cut]) {
+		cut--
+	}
+	return tableName[:cut] + hashSuffix + "_p"
+}
+
+// timePartitionName names the partition of a table starting at start, e.g. events_p202501
+func timePartitionName(tableName, interval string, start time.Time) string {
+	return timePartitionPrefix(tableName) + start.Format(partitionLayouts[interval])
+}
+
+// PlanTimePartitions returns the partitions a policy has to create, the current interval and the
+// Premake following ones, and the partitions it manages that ended more than Retention intervals ago.
+// partitions that don't follow the naming of the policy are never dropped
+func PlanTimePartitions(tableName string, policy *PartitionPolicy, existing []string, now time.Time) ([]TimePartition, []string) {
+	current := truncateToInterval(now, policy.Interval)
+	present := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		present[name] = true
+	}
+
+	create := make([]TimePartition, 0)
+	for i := 0; i <= policy.Premake; i++ {
+		start := addInterval(current, policy.Interval, i)
+		name := timePartitionName(tableName, policy.Interval, start)
+		if !present[name] {
+			create = append(create, TimePartition{Name: name, From: start, To: addInterval(start, policy.Interval, 1)})
+		}
+	}
+
+	drop := make([]string, 0)
+	if policy.Retention == nil {
+		return create, drop
+	}
+	cutoff := addInterval(current, policy.Interval, -*policy.Retention)
+	for _, name := range existing {
+		suffix, ok := strings.CutPrefix(name, timePartitionPrefix(tableName))
+		if !ok {
+			continue
+		}
+		start, err := time.Parse(partitionLayouts[policy.Interval], suffix)
+		if err != nil || !truncateToInterval(start, policy.Interval).Equal(start) {
+			continue
+		}
+		if !addInterval(start, policy.Interval, 1).After(cutoff) {
+			drop = append(drop, name)
+		}
+	}
+	return create, drop
+}
+
+// isTimeType reports whether a type name, as information_schema and format_type print it, is a date or timestamp
+func isTimeType(dataType string) bool {
+	return slices.Contains([]string{"date", "timestamp without time zone", "timestamp with time zone"}, dataType)
+}
+
+// ValidateTableTTL checks a ttl against the columns of its table, an unset batch size gets the default
+func ValidateTableTTL(ttl *TableTTL, columns []utils.TableColumn) error {
+	if ttl.BatchSize == 0 {
+		ttl.BatchSize = defaultTTLBatchSize
+	}
+	if ttl.BatchSize < 0 || ttl.BatchSize > maxTTLBatchSize || strings.TrimSpace(ttl.Interval) == "" {
+		return ErrInvalidTTL
+	}
+	for _, column := range columns {
+		if column.ColumnName == ttl.Column {
+			if !isTimeType(column.DataType) {
+				return ErrInvalidTTL
+			}
+			return nil
+		}
+	}
+	return ErrInvalidTTL
+}
+
+// timePartitionBound is the range bound of a managed partition as a literal of the key type
+func timePartitionBound(partition TimePartition, keyType string) (string, error) {
+	layout := "2006-01-02 15:04:05"
+	switch keyType {
+	case "date":
+		layout = "2006-01-02"
+	case "timestamp with time zone":
+		layout = "2006-01-02 15:04:05+00"
+	}
+	return utils.FormatPartitionBound(&utils.PartitionBound{
+		From: []string{partition.From.Format(layout)},
+		To:   []string{partition.To.Format(layout)},
+	})
+}
+
+// UnconfirmedTables returns the tables emptied by a cascading truncate that are missing from the
+// confirmed tables, names are compared as "schema.table"
+func UnconfirmedTables(referencing, confirmed []string) []string {
+	var missing []string
+	for _, table := range referencing {
+		if !slices.Contains(confirmed, table) {
+			missing = append(missing, table)
+		}
+	}
+	return missing
+}
+
+// RenamedObject returns the name of an index, sequence or constraint of a renamed table. objects
+// named after the table, like users_pkey or users_id_seq, follow it, other names are kept
+func RenamedObject(name, oldTable, newTable string) (string, bool) {
+	suffix, found := strings.CutPrefix(name, oldTable+"_")
+	if !found || suffix == "" {
+		return name, false
+	}
+	return newTable + "_" + suffix, true
+}
+
+// CopyWarnings returns the objects the schema export of a copied table skipped
+func CopyWarnings(ddl string) []string {
+	warnings := []string{}
+	for _, line := range strings.Split(ddl, "\n") {
+		if warning, found := strings.CutPrefix(line, "-- skipped "); found {
+			warnings = append(warnings, "skipped "+warning)
+		}
+	}
+	return warnings
+}
+
 func CheckForNonNegativeNumber(s string) (error) {
 	num, err := strconv.Atoi(s);
 	if err != nil {
@@ -0,0 +1,109 @@
+package analytics_test
+
+import (
+	"DBHS/analytics"
+	"DBHS/indexes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAdvisableStatement(t *testing.T) {
+	assert.True(t, analytics.IsAdvisableStatement("SELECT * FROM orders WHERE customer_id = $1"))
+	assert.True(t, analytics.IsAdvisableStatement("UPDATE orders SET status = $1 WHERE id = $2"))
+	assert.True(t, analytics.IsAdvisableStatement("DELETE FROM orders WHERE created_at < $1"))
+	assert.False(t, analytics.IsAdvisableStatement("INSERT INTO orders (id) VALUES ($1)"))
+	assert.False(t, analytics.IsAdvisableStatement("SELECT * INTO archive FROM orders WHERE id = $1"))
+	assert.False(t, analytics.IsAdvisableStatement("SELECT 1; SELECT 2"))
+	assert.False(t, analytics.IsAdvisableStatement("SELECT FROM WHERE"))
+}
+
+func TestIsStatementExplainError(t *testing.T) {
+	for code, expected := range map[string]bool{
+		"42P01": true,  // undefined table
+		"42601": true,  // syntax error
+		"22P02": true,  // invalid text representation
+		"0A000": true,  // feature not supported
+		"57014": true,  // statement timeout
+		"54001": true,  // statement too complex
+		"08006": false, // connection failure
+		"53300": false, // too many connections
+		"XX000": false, // internal error
+	} {
+		err := fmt.Errorf("explain: %w", &pgconn.PgError{Code: code})
+		assert.Equal(t, expected, analytics.IsStatementExplainError(err), code)
+	}
+	assert.False(t, analytics.IsStatementExplainError(context.Canceled))
+	assert.False(t, analytics.IsStatementExplainError(errors.New("conn closed")))
+}
+
+func TestFilterColumns(t *testing.T) {
+	equality, ranges := analytics.FilterColumns("((orders.customer_id = $1) AND (orders.created_at >= $2) AND (orders.created_at < $3))")
+	assert.Equal(t, []string{"customer_id"}, equality)
+	assert.Equal(t, []string{"created_at"}, ranges)
+
+	equality, ranges = analytics.FilterColumns("(((orders.status)::text = 'paid'::text) AND (orders.total BETWEEN $1 AND $2))")
+	assert.Equal(t, []string{"status"}, equality)
+	assert.Equal(t, []string{"total"}, ranges)
+
+	equality, _ = analytics.FilterColumns("(orders.id = ANY ($1))")
+	assert.Equal(t, []string{"id"}, equality)
+
+	// a disjunction or a comparison between two columns can't use a single index
+	equality, ranges = analytics.FilterColumns("((orders.a = $1) OR (orders.b = $2))")
+	assert.Empty(t, equality)
+	assert.Empty(t, ranges)
+	equality, _ = analytics.FilterColumns("(orders.a = orders.b)")
+	assert.Empty(t, equality)
+}
+
+func TestIndexAdvice(t *testing.T) {
+	plan := analytics.PlanNode{
+		NodeType:  "Limit",
+		TotalCost: 2000,
+		Plans: []analytics.PlanNode{{
+			NodeType:     "Seq Scan",
+			RelationName: "orders",
+			Schema:       "public",
+			Filter:       "(orders.customer_id = $1)",
+			PlanRows:     50,
+			TotalCost:    1000,
+		}},
+	}
+	scans := analytics.SequentialScans(plan)
+	require.Len(t, scans, 1)
+
+	statement := analytics.WorkloadStatement{Query: "SELECT * FROM orders WHERE customer_id = $1 LIMIT 10", Calls: 100, TotalTimeMs: 1000}
+	_, ok := analytics.ScanCandidate(statement, plan, scans[0], 500)
+	assert.False(t, ok, "small tables are scanned")
+
+	candidate, ok := analytics.ScanCandidate(statement, plan, scans[0], 100000)
+	require.True(t, ok)
+	assert.Equal(t, []string{"customer_id"}, candidate.Columns)
+	assert.InDelta(t, 0.0005, candidate.Selectivity, 1e-9)
+	assert.InDelta(t, 1000*0.5*(1-0.0005), candidate.TimeSavedMs, 1e-6)
+
+	other := candidate
+	other.Query = "DELETE FROM orders WHERE customer_id = $1"
+	other.TimeSavedMs = 100
+	ranged := analytics.IndexCandidate{Schema: "public", Table: "events", Columns: []string{"created_at"}, Query: "SELECT", TimeSavedMs: 2000}
+
+	advice := analytics.BuildIndexAdvice([]analytics.IndexCandidate{candidate, other, ranged}, nil)
+	require.Len(t, advice, 2)
+	assert.Equal(t, "events", advice[0].Table)
+	assert.Equal(t, "orders_customer_id_idx", advice[1].Index.IndexName)
+	assert.Equal(t, int64(200), advice[1].Calls)
+	assert.Len(t, advice[1].Queries, 2)
+	assert.Equal(t, `CREATE INDEX CONCURRENTLY "orders_customer_id_idx" ON "public"."orders" USING "btree" ("customer_id")`, advice[1].Statement)
+
+	existing := []indexes.IndexStats{{SchemaName: "public", TableName: "orders", IndexType: "btree", IsValid: true, Columns: []string{"customer_id", "created_at"}}}
+	advice = analytics.BuildIndexAdvice([]analytics.IndexCandidate{candidate, ranged}, existing)
+	require.Len(t, advice, 1)
+	assert.Equal(t, "events", advice[0].Table)
+}
@@ -0,0 +1,147 @@
+package functions_test
+
+import (
+	"DBHS/functions"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFunctionDefinition(t *testing.T) {
+	definition := `CREATE OR REPLACE FUNCTION add(a int, b int) RETURNS int LANGUAGE sql AS $$ SELECT a + b $$;`
+	function, err := functions.ValidateFunctionDefinition(definition, "")
+	require.NoError(t, err)
+	assert.Equal(t, "public", function.Schema)
+	assert.Equal(t, "add", function.Name)
+	assert.Equal(t, `CREATE OR REPLACE FUNCTION add(a int, b int) RETURNS int LANGUAGE sql AS $$ SELECT a + b $$`, function.SQL)
+
+	function, err = functions.ValidateFunctionDefinition(`CREATE PROCEDURE billing.close_month() LANGUAGE plpgsql AS $$ BEGIN END $$`, "public")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", function.Schema)
+	assert.Equal(t, "close_month", function.Name)
+
+	_, err = functions.ValidateFunctionDefinition(`CREATE FUNCTION f() RETURNS int LANGUAGE sql AS $$ SELECT 1 $$; DROP TABLE users`, "")
+	assert.ErrorIs(t, err, functions.ErrNotFunction)
+
+	_, err = functions.ValidateFunctionDefinition(`DROP TABLE users`, "")
+	assert.ErrorIs(t, err, functions.ErrNotFunction)
+
+	_, err = functions.ValidateFunctionDefinition(`CREATE FUNCTION _dbhs.f() RETURNS int LANGUAGE sql AS $$ SELECT 1 $$`, "")
+	assert.Error(t, err)
+
+	_, err = functions.ValidateFunctionDefinition(`CREATE FUNCTION (`, "")
+	assert.Error(t, err)
+
+	function, err = functions.ValidateFunctionDefinition(`CREATE FUNCTION touch() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+	NEW.updated_at := now();
+	INSERT INTO audit (row_data) VALUES (to_jsonb(NEW));
+	RETURN NEW;
+END $$`, "")
+	require.NoError(t, err)
+	assert.Equal(t, "touch", function.Name)
+}
+
+func TestValidateFunctionDefinitionRejectsUnsafeFunctions(t *testing.T) {
+	rejected := map[string]string{
+		"security definer":   `CREATE FUNCTION f() RETURNS int LANGUAGE sql SECURITY DEFINER AS $$ SELECT 1 $$`,
+		"dynamic sql":        `CREATE FUNCTION f() RETURNS void LANGUAGE plpgsql AS $$ BEGIN EXECUTE 'DROP TABLE users'; END $$`,
+		"read server file":   `CREATE FUNCTION f() RETURNS text LANGUAGE plpgsql AS $$ BEGIN RETURN pg_read_file('/etc/passwd'); END $$`,
+		"import large file":  `CREATE FUNCTION f() RETURNS oid LANGUAGE sql AS $$ SELECT lo_import('/etc/passwd') $$`,
+		"c function":         `CREATE FUNCTION f() RETURNS void LANGUAGE c AS '/tmp/evil.so', 'evil'`,
+		"untrusted language": `CREATE FUNCTION f() RETURNS void LANGUAGE plperlu AS $$ system('id') $$`,
+		"internal schema":    `CREATE FUNCTION f() RETURNS void LANGUAGE plpgsql AS $$ BEGIN DELETE FROM _dbhs.catalog_version; END $$`,
+		"internal sql body":  `CREATE FUNCTION f() RETURNS bigint BEGIN ATOMIC SELECT version FROM _dbhs.catalog_version; END`,
+		"ddl in body":        `CREATE FUNCTION f() RETURNS void LANGUAGE plpgsql AS $$ BEGIN ALTER SYSTEM SET fsync = off; END $$`,
+		"copy in body":       `CREATE FUNCTION f() RETURNS void LANGUAGE sql AS $$ COPY users TO PROGRAM 'id' $$`,
+		"set role":           `CREATE FUNCTION f() RETURNS int LANGUAGE sql SET role = postgres AS $$ SELECT 1 $$`,
+	}
+	for name, definition := range rejected {
+		_, err := functions.ValidateFunctionDefinition(definition, "")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestGenerateCreateTriggerQuery(t *testing.T) {
+	request := &functions.CreateTriggerRequest{
+		Name:          "orders_changed",
+		Schema:        "sales",
+		Table:         "orders",
+		Timing:        "after",
+		Events:        []string{"insert", "update"},
+		UpdateColumns: []string{"status"},
+		Condition:     "NEW.status IS NOT NULL",
+		Function:      "notify_change",
+		Arguments:     []string{"it's"},
+		Replace:       true,
+	}
+	query, err := functions.GenerateCreateTriggerQuery(request)
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE OR REPLACE TRIGGER "orders_changed" AFTER INSERT OR UPDATE OF "status" ON "sales"."orders" FOR EACH ROW WHEN (NEW.status IS NOT NULL) EXECUTE FUNCTION "public"."notify_change"('it''s')`, query)
+
+	request = &functions.CreateTriggerRequest{Name: "t", Table: "orders", Timing: "BEFORE", Events: []string{"TRUNCATE"}, Function: "f"}
+	_, err = functions.GenerateCreateTriggerQuery(request)
+	assert.Error(t, err)
+	request.Level = "STATEMENT"
+	query, err = functions.GenerateCreateTriggerQuery(request)
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE TRIGGER "t" BEFORE TRUNCATE ON "public"."orders" FOR EACH STATEMENT EXECUTE FUNCTION "public"."f"()`, query)
+
+	invalid := []*functions.CreateTriggerRequest{
+		{Name: "t", Table: "orders", Timing: "DURING", Events: []string{"INSERT"}, Function: "f"},
+		{Name: "t", Table: "orders", Timing: "AFTER", Events: []string{"SELECT"}, Function: "f"},
+		{Name: "t", Table: "orders", Timing: "AFTER", Events: []string{"INSERT", "INSERT"}, Function: "f"},
+		{Name: "t", Table: "orders", Timing: "AFTER", Function: "f"},
+		{Name: "t", Table: "orders", Timing: "INSTEAD OF", Level: "STATEMENT", Events: []string{"INSERT"}, Function: "f"},
+		{Name: "t", Table: "orders", Timing: "AFTER", Events: []string{"INSERT"}, Function: "f", Condition: "true) EXECUTE FUNCTION f(); DROP TABLE orders; --"},
+	}
+	for _, request := range invalid {
+		_, err := functions.GenerateCreateTriggerQuery(request)
+		assert.Error(t, err, request)
+	}
+}
+
+func TestGenerateAttachTemplateQueries(t *testing.T) {
+	template := functions.FindTemplate("updated_at")
+	require.NotNil(t, template)
+	queries, attached, err := functions.GenerateAttachTemplateQueries(template, &functions.AttachTemplateRequest{Table: "orders", Column: "modified_at"})
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "orders_updated_at", attached.Trigger)
+	assert.Equal(t, `"public"."dbhs_set_updated_at"`, attached.Function)
+	assert.Contains(t, queries[0], `CREATE OR REPLACE FUNCTION "public"."dbhs_set_updated_at"() RETURNS trigger LANGUAGE plpgsql AS $dbhs$`)
+	assert.Equal(t, `CREATE OR REPLACE TRIGGER "orders_updated_at" BEFORE INSERT OR UPDATE ON "public"."orders" FOR EACH ROW EXECUTE FUNCTION "public"."dbhs_set_updated_at"('modified_at')`, queries[1])
+
+	template = functions.FindTemplate("audit")
+	require.NotNil(t, template)
+	queries, attached, err = functions.GenerateAttachTemplateQueries(template, &functions.AttachTemplateRequest{Schema: "sales", Table: "orders", TriggerName: "orders_history"})
+	require.NoError(t, err)
+	require.Len(t, queries, 3)
+	assert.Equal(t, `"sales"."orders_audit"`, attached.AuditTable)
+	assert.Contains(t, queries[0], `CREATE TABLE IF NOT EXISTS "sales"."orders_audit"`)
+	assert.Equal(t, `CREATE OR REPLACE TRIGGER "orders_history" AFTER INSERT OR UPDATE OR DELETE ON "sales"."orders" FOR EACH ROW EXECUTE FUNCTION "sales"."dbhs_audit_row"('orders_audit')`, queries[2])
+
+	_, _, err = functions.GenerateAttachTemplateQueries(template, &functions.AttachTemplateRequest{Table: "orders", Column: "id"})
+	assert.Error(t, err)
+
+	template = functions.FindTemplate("soft_delete_guard")
+	require.NotNil(t, template)
+	queries, _, err = functions.GenerateAttachTemplateQueries(template, &functions.AttachTemplateRequest{Table: "orders"})
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE OR REPLACE TRIGGER "orders_soft_delete_guard" BEFORE DELETE ON "public"."orders" FOR EACH ROW EXECUTE FUNCTION "public"."dbhs_soft_delete_guard"('deleted_at')`, queries[1])
+
+	assert.Nil(t, functions.FindTemplate("missing"))
+}
+
+func TestGenerateTriggerAndFunctionStatements(t *testing.T) {
+	trigger := &functions.Trigger{Name: "orders_audit", Schema: "sales", Table: "orders"}
+	assert.Equal(t, `ALTER TABLE "sales"."orders" DISABLE TRIGGER "orders_audit"`, functions.GenerateToggleTriggerQuery(trigger, false))
+	assert.Equal(t, `ALTER TABLE "sales"."orders" ENABLE TRIGGER "orders_audit"`, functions.GenerateToggleTriggerQuery(trigger, true))
+	assert.Equal(t, `DROP TRIGGER "orders_audit" ON "sales"."orders"`, functions.GenerateDropTriggerQuery(trigger))
+
+	function := &functions.Function{Name: "add", Schema: "public", Kind: "function", Arguments: "a integer, b integer"}
+	assert.Equal(t, `DROP FUNCTION "public"."add"(a integer, b integer) CASCADE`, functions.GenerateDropFunctionQuery(function, true))
+	procedure := &functions.Function{Name: "close_month", Schema: "billing", Kind: "procedure"}
+	assert.Equal(t, `DROP PROCEDURE "billing"."close_month"() RESTRICT`, functions.GenerateDropFunctionQuery(procedure, false))
+}
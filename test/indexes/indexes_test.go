@@ -0,0 +1,200 @@
+package indexes_test
+
+import (
+	"DBHS/indexes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	tableColumns = []string{"id", "email", "created_at", "deleted_at"}
+	indexMethods = []string{"brin", "btree", "gin", "gist", "hash", "spgist"}
+	opclasses    = []string{"text_pattern_ops", "varchar_pattern_ops"}
+)
+
+func TestGenerateIndexQuery(t *testing.T) {
+	index := indexes.IndexData{IndexName: "users email", IndexType: "btree", Columns: []string{"email"}, TableName: "users"}
+	assert.Equal(t, `CREATE INDEX "users_email" ON "public"."users" USING "btree" ("email")`, indexes.GenerateIndexQuery(index))
+
+	nullsFirst := true
+	index = indexes.IndexData{
+		IndexName:    "users_active_email",
+		IndexType:    "btree",
+		TableName:    "Users",
+		Schema:       "app",
+		Unique:       true,
+		Concurrently: true,
+		Keys: []indexes.IndexKey{
+			{Expression: "lower(email)", OpClass: "text_pattern_ops"},
+			{Column: "created_at", Descending: true, NullsFirst: &nullsFirst},
+		},
+		Include: []string{"id"},
+		Where:   "deleted_at IS NULL",
+	}
+	assert.Equal(t, `CREATE UNIQUE INDEX CONCURRENTLY "users_active_email" ON "app"."Users" USING "btree" `+
+		`((lower(email)) "text_pattern_ops", "created_at" DESC NULLS FIRST) INCLUDE ("id") WHERE deleted_at IS NULL`,
+		indexes.GenerateIndexQuery(index))
+}
+
+func TestValidateIndexData(t *testing.T) {
+	index := &indexes.IndexData{IndexType: " BTREE ", Columns: []string{"email"}, Include: []string{"id"}}
+	require.NoError(t, indexes.ValidateIndexData(index, tableColumns, indexMethods, opclasses))
+	assert.Equal(t, "btree", index.IndexType)
+
+	invalid := []*indexes.IndexData{
+		{IndexType: "bloom", Columns: []string{"email"}},
+		{IndexType: "btree"},
+		{IndexType: "btree", Columns: []string{"email"}, Keys: []indexes.IndexKey{{Column: "id"}}},
+		{IndexType: "btree", Columns: []string{"missing"}},
+		{IndexType: "btree", Keys: []indexes.IndexKey{{Column: "email", Expression: "lower(email)"}}},
+		{IndexType: "btree", Keys: []indexes.IndexKey{{Column: "email", OpClass: "gin_trgm_ops"}}},
+		{IndexType: "btree", Columns: []string{"email"}, Include: []string{"missing"}},
+	}
+	for _, index := range invalid {
+		var indexErr *indexes.IndexError
+		assert.ErrorAs(t, indexes.ValidateIndexData(index, tableColumns, indexMethods, opclasses), &indexErr, index)
+	}
+}
+
+func TestCheckIndexStatement(t *testing.T) {
+	index := &indexes.IndexData{
+		IndexName: "users_lower_email",
+		IndexType: "btree",
+		TableName: "users",
+		Keys:      []indexes.IndexKey{{Expression: "lower(email)"}},
+		Where:     "deleted_at IS NULL",
+	}
+	require.NoError(t, indexes.CheckIndexStatement(indexes.GenerateIndexQuery(*index), index))
+
+	injected := []*indexes.IndexData{
+		{IndexName: "i", IndexType: "btree", TableName: "users", Keys: []indexes.IndexKey{{Expression: "id); DROP TABLE users; --"}}},
+		{IndexName: "i", IndexType: "btree", TableName: "users", Keys: []indexes.IndexKey{{Expression: "id), (email"}}},
+		{IndexName: "i", IndexType: "btree", TableName: "users", Keys: []indexes.IndexKey{{Expression: "id) WHERE (true"}}},
+		{IndexName: "i", IndexType: "btree", TableName: "users", Columns: []string{"id"}, Where: "true; DROP TABLE users"},
+	}
+	for _, index := range injected {
+		assert.Error(t, indexes.CheckIndexStatement(indexes.GenerateIndexQuery(*index), index), index.Keys, index.Where)
+	}
+}
+
+func TestUnusedIndexes(t *testing.T) {
+	stats := []indexes.IndexStats{
+		{IndexName: "users_pkey", IsUnique: true, IsPrimary: true},
+		{IndexName: "users_email_key", IsUnique: true},
+		{IndexName: "users_created_at_idx"},
+		{IndexName: "users_name_idx", Scans: 12},
+	}
+	unused := indexes.UnusedIndexes(stats)
+	require.Len(t, unused, 1)
+	assert.Equal(t, "users_created_at_idx", unused[0].IndexName)
+}
+
+func TestRedundantIndexes(t *testing.T) {
+	partial := "deleted_at IS NULL"
+	index := func(name string, columns []string) indexes.IndexStats {
+		return indexes.IndexStats{IndexName: name, IndexType: "btree", SchemaName: "public", TableName: "users", Columns: columns}
+	}
+	pkey := index("users_pkey", []string{"id"})
+	pkey.IsPrimary, pkey.IsUnique = true, true
+	duplicate := index("users_id_idx", []string{"id"})
+	email := index("users_email_idx", []string{"email"})
+	emailName := index("users_email_name_idx", []string{"email", "name"})
+	partialEmail := index("users_active_email_idx", []string{"email"})
+	partialEmail.Predicate = &partial
+	other := index("orders_email_idx", []string{"email"})
+	other.TableName = "orders"
+
+	redundant := indexes.RedundantIndexes([]indexes.IndexStats{duplicate, pkey, email, emailName, partialEmail, other})
+	require.Len(t, redundant, 2)
+	assert.Equal(t, "users_id_idx", redundant[0].Index.IndexName)
+	assert.Equal(t, "users_pkey", redundant[0].CoveredBy.IndexName)
+	assert.Equal(t, indexes.DuplicateIndex, redundant[0].Kind)
+	assert.Equal(t, "users_email_idx", redundant[1].Index.IndexName)
+	assert.Equal(t, "users_email_name_idx", redundant[1].CoveredBy.IndexName)
+	assert.Equal(t, indexes.OverlappingIndex, redundant[1].Kind)
+
+	// of two plain duplicates only the second one is reported
+	second := index("users_email_idx2", []string{"email"})
+	redundant = indexes.RedundantIndexes([]indexes.IndexStats{email, second})
+	require.Len(t, redundant, 1)
+	assert.Equal(t, "users_email_idx2", redundant[0].Index.IndexName)
+
+	// keys with another operator class, collation or ordering answer other queries
+	email.KeyOptions = []string{"3126 100 0"}
+	patternEmail := index("users_email_pattern_idx", []string{"email"})
+	patternEmail.KeyOptions = []string{"10044 100 0"}
+	collatedEmail := index("users_email_c_idx", []string{"email"})
+	collatedEmail.KeyOptions = []string{"3126 950 0"}
+	descEmailName := index("users_email_name_desc_idx", []string{"email", "name"})
+	descEmailName.KeyOptions = []string{"3126 100 3", "3126 100 0"}
+	redundant = indexes.RedundantIndexes([]indexes.IndexStats{email, patternEmail, collatedEmail, descEmailName})
+	assert.Empty(t, redundant)
+
+	second.KeyOptions = []string{"3126 100 0"}
+	emailName.KeyOptions = []string{"3126 100 0", "3126 100 0"}
+	redundant = indexes.RedundantIndexes([]indexes.IndexStats{email, second, emailName})
+	require.Len(t, redundant, 2)
+	assert.Equal(t, indexes.OverlappingIndex, redundant[0].Kind)
+	assert.Equal(t, "users_email_idx2", redundant[1].Index.IndexName)
+}
+
+func TestGenerateReindexQuery(t *testing.T) {
+	assert.Equal(t, `REINDEX INDEX "public"."users_email"`, indexes.GenerateReindexQuery(indexes.ReindexIndex, `"public"."users_email"`, false))
+	assert.Equal(t, `REINDEX TABLE CONCURRENTLY "app"."Users"`, indexes.GenerateReindexQuery(indexes.ReindexTable, `"app"."Users"`, true))
+}
+
+func TestInvalidIndexes(t *testing.T) {
+	stats := []indexes.IndexStats{
+		{IndexName: "users_email_idx", IndexOid: "1", IsValid: true},
+		{IndexName: "users_name_idx", IndexOid: "2"},
+		{IndexName: "users_email_idx_ccnew", IndexOid: "3"},
+		{IndexName: "users_created_at_idx", IndexOid: "4"},
+	}
+	builds := []indexes.IndexBuildProgress{{IndexOid: "4", Command: "CREATE INDEX CONCURRENTLY"}}
+
+	invalid := indexes.InvalidIndexes(stats, builds)
+	require.Len(t, invalid, 2)
+	assert.Equal(t, "users_name_idx", invalid[0].IndexName)
+	assert.Equal(t, "users_email_idx_ccnew", invalid[1].IndexName)
+
+	leftovers := indexes.ReindexLeftovers(invalid)
+	require.Len(t, leftovers, 1)
+	assert.Equal(t, "users_email_idx_ccnew", leftovers[0].IndexName)
+}
+
+func TestSetBuildPercent(t *testing.T) {
+	build := indexes.IndexBuildProgress{Phase: "building index: scanning table", BlocksTotal: 400, BlocksDone: 100}
+	indexes.SetBuildPercent(&build)
+	assert.Equal(t, 25.0, build.Percent)
+
+	build = indexes.IndexBuildProgress{Phase: "building index: loading tuples in tree", BlocksTotal: 400, BlocksDone: 400, TuplesTotal: 3, TuplesDone: 1}
+	indexes.SetBuildPercent(&build)
+	assert.Equal(t, 33.33, build.Percent)
+
+	build = indexes.IndexBuildProgress{Phase: "waiting for writers before build"}
+	indexes.SetBuildPercent(&build)
+	assert.Zero(t, build.Percent)
+}
+
+func TestEstimateIndexBloat(t *testing.T) {
+	pages := []indexes.IndexPages{
+		{IndexName: "users_pkey", Pages: 280, Tuples: 100000, KeyWidth: 4, BlockSize: 8192, FillFactor: 90},
+		{IndexName: "users_id_idx", Pages: 1000, Tuples: 100000, KeyWidth: 4, BlockSize: 8192, FillFactor: 90},
+		{IndexName: "users_tiny_idx", Pages: 1, Tuples: 0, KeyWidth: 4, BlockSize: 8192, FillFactor: 90},
+	}
+	bloat := indexes.EstimateIndexBloat(pages)
+	require.Len(t, bloat, 3)
+
+	// 20 byte tuples in 7336 usable bytes per page need 273 leaf pages and the meta page
+	assert.Equal(t, "users_id_idx", bloat[0].IndexName)
+	assert.Equal(t, int64(1000*8192), bloat[0].SizeBytes)
+	assert.Equal(t, int64(274*8192), bloat[0].ExpectedBytes)
+	assert.Equal(t, int64(726*8192), bloat[0].BloatBytes)
+	assert.Equal(t, 0.726, bloat[0].BloatRatio)
+
+	assert.Equal(t, int64(6*8192), bloat[1].BloatBytes)
+	assert.Zero(t, bloat[2].BloatBytes)
+	assert.Equal(t, int64(8192), bloat[2].ExpectedBytes)
+}
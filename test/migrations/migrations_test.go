@@ -0,0 +1,131 @@
+package migrations_test
+
+import (
+	"DBHS/migrations"
+	"DBHS/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMigrationFile(t *testing.T) {
+	content := `BEGIN;
+CREATE TABLE users (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL DEFAULT 'a;b');
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at := now();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+COMMIT;
+`
+	file, err := migrations.ParseMigrationFile("001_users.sql", content)
+	require.NoError(t, err)
+	assert.Len(t, file.Statements, 2)
+	assert.Contains(t, file.Statements[0], "'a;b'")
+	assert.Len(t, file.Checksum, 64)
+
+	other, err := migrations.ParseMigrationFile("001_users.sql", content+"\n-- edited\n")
+	require.NoError(t, err)
+	assert.NotEqual(t, file.Checksum, other.Checksum)
+}
+
+func TestParseMigrationFileRejectsInvalidStatements(t *testing.T) {
+	_, err := migrations.ParseMigrationFile("002_index.sql", "CREATE INDEX CONCURRENTLY users_name ON users (name);")
+	assert.ErrorContains(t, err, "CONCURRENTLY")
+
+	_, err = migrations.ParseMigrationFile("003_broken.sql", "CREATE TABLE a (id int);\nCREATE TABLE b (id int,);")
+	var syntaxErr *utils.SQLSyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 2, syntaxErr.Line)
+
+	_, err = migrations.ParseMigrationFile("004_empty.sql", "-- nothing here")
+	assert.Error(t, err)
+}
+
+func TestParseMigrationFileRejectsUnsafeStatements(t *testing.T) {
+	rejected := map[string]string{
+		"copy to program":           "COPY users TO PROGRAM 'curl http://example.com'",
+		"copy from program":         "COPY users FROM PROGRAM 'cat /etc/passwd'",
+		"copy from server file":     "COPY users FROM '/etc/passwd'",
+		"copy to server file":       "COPY users TO '/tmp/users.csv'",
+		"do block":                  "DO $$ BEGIN PERFORM 1; END $$",
+		"grant role":                "GRANT pg_read_server_files TO project_user",
+		"grant privileges":          "GRANT SELECT ON users TO PUBLIC",
+		"alter role set":            "ALTER ROLE project_user SET search_path = public",
+		"alter database set":        "ALTER DATABASE project SET log_statement = 'all'",
+		"create extension":          "CREATE EXTENSION file_fdw",
+		"load library":              "LOAD 'auto_explain'",
+		"create event trigger":      "CREATE EVENT TRIGGER audit ON ddl_command_end EXECUTE FUNCTION audit()",
+		"create role":               "CREATE ROLE admin SUPERUSER",
+		"alter system":              "ALTER SYSTEM SET max_connections = 10",
+		"vacuum":                    "VACUUM users",
+		"create internal table":     "CREATE TABLE _dbhs.hijack (id int)",
+		"alter internal table":      "ALTER TABLE _dbhs.catalog_version ADD COLUMN x int",
+		"update internal table":     "UPDATE _dbhs.catalog_version SET version = 0",
+		"drop internal table":       "DROP TABLE _dbhs.catalog_version",
+		"drop internal schema":      "DROP SCHEMA _dbhs CASCADE",
+		"rename internal schema":    "ALTER SCHEMA _dbhs RENAME TO moved",
+		"move into internal schema": "ALTER TABLE users SET SCHEMA _dbhs",
+		"internal function":         "SELECT _dbhs.track_catalog_changes()",
+		"internal trigger function": "CREATE TRIGGER t AFTER INSERT ON users EXECUTE FUNCTION _dbhs.track()",
+		"update catalog":            "UPDATE pg_catalog.pg_class SET relname = 'x'",
+		"unqualified catalog":       "DELETE FROM pg_authid",
+		"create in catalog":         "CREATE TABLE pg_catalog.hijack (id int)",
+		"drop catalog function":     "DROP FUNCTION pg_catalog.now()",
+		"read server file":          "INSERT INTO notes (body) SELECT pg_read_file('/etc/passwd')",
+		"set role":                  "SET ROLE postgres",
+		"set session authorization": "SET SESSION AUTHORIZATION postgres",
+		"change table owner":        "ALTER TABLE users OWNER TO postgres",
+		"schema authorization":      "CREATE SCHEMA app AUTHORIZATION postgres",
+		"untrusted language":        "CREATE FUNCTION shell() RETURNS void AS $$ system('id') $$ LANGUAGE plperlu",
+		"c function":                "CREATE FUNCTION evil() RETURNS void AS '/tmp/evil.so', 'evil' LANGUAGE c",
+		"plpgsql dynamic sql":       "CREATE FUNCTION f() RETURNS void AS $$ BEGIN EXECUTE 'COPY users TO PROGRAM ''id'''; END $$ LANGUAGE plpgsql",
+		"plpgsql unsafe function":   "CREATE FUNCTION f() RETURNS text AS $$ DECLARE t text; BEGIN t := pg_read_file('/etc/passwd'); RETURN t; END $$ LANGUAGE plpgsql",
+		"plpgsql internal schema":   "CREATE FUNCTION f() RETURNS void AS $$ BEGIN DELETE FROM _dbhs.catalog_version; END $$ LANGUAGE plpgsql",
+		"sql function copy":         "CREATE FUNCTION f() RETURNS void AS 'COPY users TO PROGRAM ''id''' LANGUAGE sql",
+		"sql function internal":     "CREATE FUNCTION f() RETURNS bigint BEGIN ATOMIC SELECT version FROM _dbhs.catalog_version; END",
+		"function set role":         "CREATE FUNCTION f() RETURNS int SET role = postgres AS 'SELECT 1' LANGUAGE sql",
+		"call procedure":            "CALL cleanup()",
+	}
+	for name, statement := range rejected {
+		_, err := migrations.ParseMigrationFile("005_unsafe.sql", statement+";")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseMigrationFileAllowsSchemaChanges(t *testing.T) {
+	content := `SET search_path = public;
+CREATE SCHEMA billing;
+CREATE TYPE billing.status AS ENUM ('open', 'paid');
+CREATE TABLE billing.invoices (
+	id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	status billing.status NOT NULL DEFAULT 'open',
+	issued_at timestamptz NOT NULL DEFAULT now(),
+	total numeric(10, 2) CHECK (total >= 0)
+);
+CREATE INDEX invoices_issued ON billing.invoices (issued_at);
+CREATE VIEW billing.monthly AS SELECT extract(month FROM issued_at) AS month, sum(total) FROM billing.invoices GROUP BY 1;
+CREATE FUNCTION billing.open_total() RETURNS numeric AS 'SELECT sum(total) FROM billing.invoices WHERE status = ''open''' LANGUAGE sql;
+CREATE FUNCTION billing.paid() RETURNS trigger AS $$
+DECLARE
+	previous billing.status;
+BEGIN
+	previous := OLD.status;
+	IF previous = 'open' AND NEW.status = 'paid' THEN
+		INSERT INTO billing.events (invoice_id, kind) VALUES (NEW.id, 'paid');
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER invoices_paid AFTER UPDATE ON billing.invoices FOR EACH ROW EXECUTE FUNCTION billing.paid();
+ALTER TABLE billing.invoices ENABLE ROW LEVEL SECURITY;
+COMMENT ON TABLE billing.invoices IS 'issued invoices';
+UPDATE users SET name = lower(name);
+DROP TABLE IF EXISTS legacy_invoices;
+`
+	file, err := migrations.ParseMigrationFile("006_billing.sql", content)
+	require.NoError(t, err)
+	assert.Len(t, file.Statements, 13)
+}
@@ -0,0 +1,46 @@
+package openapi_test
+
+import (
+	"DBHS/openapi"
+	"DBHS/utils"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSpec(t *testing.T) {
+	def := "nextval('users_id_seq'::regclass)"
+	tables := []openapi.ProjectTable{
+		{
+			OID: "tbl1",
+			Schema: &utils.Table{
+				TableName: "users",
+				Columns: []utils.TableColumn{
+					{ColumnName: "id", UdtName: "int4", ColumnDefault: &def, OrdinalPosition: 1},
+					{ColumnName: "email", UdtName: "text", OrdinalPosition: 2},
+					{ColumnName: "bio", UdtName: "text", IsNullable: true, OrdinalPosition: 3},
+				},
+			},
+		},
+	}
+
+	doc := openapi.BuildSpec("proj1", "https://api.example.com", tables, nil)
+
+	assert.Equal(t, openapi.OpenAPIVersion, doc.OpenAPI)
+	assert.Equal(t, "https://api.example.com", doc.Servers[0].URL)
+	path, exists := doc.Paths["/api/projects/proj1/tables/tbl1"]
+	require.True(t, exists)
+	require.NotNil(t, path.Get)
+	require.NotNil(t, path.Post)
+	assert.Equal(t, "#/components/schemas/users_insert", path.Post.RequestBody.Content["application/json"].Schema["$ref"])
+
+	insert := doc.Components.Schemas["users_insert"].(map[string]interface{})
+	assert.Equal(t, []string{"email"}, insert["required"])
+	row := doc.Components.Schemas["users"].(map[string]interface{})
+	assert.Equal(t, []string{"id", "email", "bio"}, row["required"])
+
+	_, err := json.Marshal(doc)
+	assert.NoError(t, err)
+}
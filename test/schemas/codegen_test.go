@@ -0,0 +1,108 @@
+package schemas_test
+
+import (
+	"DBHS/schemas"
+	"DBHS/utils"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func codegenTables() []*utils.Table {
+	return []*utils.Table{
+		{
+			TableName: "user_accounts",
+			Columns: []utils.TableColumn{
+				{ColumnName: "id", UdtName: "int8", OrdinalPosition: 1},
+				{ColumnName: "nick-name", UdtName: "varchar", IsNullable: true, OrdinalPosition: 2},
+				{ColumnName: "tags", UdtName: "_text", OrdinalPosition: 3},
+				{ColumnName: "status", UdtName: "account_status", OrdinalPosition: 4},
+				{ColumnName: "created_at", UdtName: "timestamptz", IsNullable: true, OrdinalPosition: 5},
+				{ColumnName: "settings", UdtName: "jsonb", IsNullable: true, OrdinalPosition: 6},
+			},
+		},
+	}
+}
+
+var codegenEnums = map[string][]string{"account_status": {"active", "banned"}}
+
+func TestGenerateTypeScript(t *testing.T) {
+	ts := schemas.GenerateTypeScript(codegenTables(), codegenEnums)
+
+	assert.Contains(t, ts, "export interface UserAccounts {")
+	assert.Contains(t, ts, "  id: number;")
+	assert.Contains(t, ts, `  "nick-name": string | null;`)
+	assert.Contains(t, ts, "  tags: string[];")
+	assert.Contains(t, ts, `  status: "active" | "banned";`)
+	assert.Contains(t, ts, "  settings: unknown | null;")
+}
+
+func TestGenerateGoStructs(t *testing.T) {
+	source, err := schemas.GenerateGoStructs(codegenTables(), codegenEnums)
+	require.NoError(t, err)
+
+	assert.Contains(t, source, "\t\"encoding/json\"\n\t\"time\"\n")
+	assert.Contains(t, source, "type UserAccounts struct {")
+	assert.Regexp(t, "ID +int64 +`db:\"id\" json:\"id\"`", source)
+	assert.Regexp(t, "NickName +\\*string", source)
+	assert.Regexp(t, "Tags +\\[\\]string", source)
+	assert.Regexp(t, "CreatedAt +\\*time.Time", source)
+	assert.Regexp(t, "Settings +json.RawMessage", source)
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	content, err := schemas.GenerateJSONSchema(codegenTables()[0], codegenEnums)
+	require.NoError(t, err)
+
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(content), &document))
+	properties := document["properties"].(map[string]interface{})
+
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["id"])
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, properties["tags"])
+	assert.Equal(t, []interface{}{"active", "banned"}, properties["status"].(map[string]interface{})["enum"])
+	assert.Len(t, properties["created_at"].(map[string]interface{})["anyOf"], 2)
+	assert.Len(t, document["required"], 6)
+}
+
+func TestGenerateCodeUnknownTarget(t *testing.T) {
+	_, err := schemas.GenerateCode(codegenTables(), codegenEnums, []string{"rust"})
+	assert.Error(t, err)
+
+	files, err := schemas.GenerateCode(codegenTables(), codegenEnums, schemas.CodegenTargets)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	archive, err := schemas.ZipFiles(files)
+	require.NoError(t, err)
+	assert.NotEmpty(t, archive)
+}
+
+func TestGenerateCodeCollidingNames(t *testing.T) {
+	tables := []*utils.Table{
+		{TableName: "user_data", Columns: []utils.TableColumn{{ColumnName: "id", UdtName: "int8", OrdinalPosition: 1}}},
+		{TableName: "userData", Columns: []utils.TableColumn{{ColumnName: "id", UdtName: "int8", OrdinalPosition: 1}}},
+		{TableName: "../../etc/passwd", Columns: []utils.TableColumn{{ColumnName: "id", UdtName: "int8", OrdinalPosition: 1}}},
+		{TableName: "etc_passwd", Columns: []utils.TableColumn{{ColumnName: "id", UdtName: "int8", OrdinalPosition: 1}}},
+	}
+
+	source, err := schemas.GenerateGoStructs(tables, nil)
+	require.NoError(t, err)
+	assert.Contains(t, source, "type UserData struct {")
+	assert.Contains(t, source, "type UserData2 struct {")
+
+	files, err := schemas.GenerateCode(tables, nil, []string{schemas.CodegenJSONSchema})
+	require.NoError(t, err)
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	assert.Equal(t, []string{
+		"jsonschema/user_data.schema.json",
+		"jsonschema/userData.schema.json",
+		"jsonschema/etc_passwd.schema.json",
+		"jsonschema/etc_passwd2.schema.json",
+	}, paths)
+}
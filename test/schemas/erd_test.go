@@ -0,0 +1,80 @@
+package schemas_test
+
+import (
+	"DBHS/schemas"
+	"DBHS/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func constraint(table, name, kind, column string, foreignTable, foreignColumn *string) utils.ConstraintInfo {
+	return utils.ConstraintInfo{
+		TableName: table, ConstraintName: name, ConstraintType: kind, ColumnName: strPtr(column),
+		ForeignTableName: foreignTable, ForeignColumnName: foreignColumn,
+	}
+}
+
+func sampleTables() map[string]*utils.Table {
+	return map[string]*utils.Table{
+		"users": {
+			TableName: "users",
+			Columns: []utils.TableColumn{
+				{ColumnName: "id", DataType: "integer", OrdinalPosition: 1},
+				{ColumnName: "email", DataType: "character varying", OrdinalPosition: 2},
+			},
+			Constraints: []utils.ConstraintInfo{
+				constraint("users", "users_pkey", "PRIMARY KEY", "id", strPtr("users"), strPtr("id")),
+				constraint("users", "users_email_key", "UNIQUE", "email", strPtr("users"), strPtr("email")),
+			},
+		},
+		"orders": {
+			TableName: "orders",
+			Columns: []utils.TableColumn{
+				{ColumnName: "id", DataType: "integer", OrdinalPosition: 1},
+				{ColumnName: "user_id", DataType: "integer", IsNullable: true, OrdinalPosition: 2},
+			},
+			Constraints: []utils.ConstraintInfo{
+				constraint("orders", "orders_pkey", "PRIMARY KEY", "id", strPtr("orders"), strPtr("id")),
+				constraint("orders", "orders_user_id_fkey", "FOREIGN KEY", "user_id", strPtr("users"), strPtr("id")),
+			},
+		},
+	}
+}
+
+func TestBuildERDGraph(t *testing.T) {
+	graph := schemas.BuildERDGraph(sampleTables())
+
+	require.Len(t, graph.Nodes, 2)
+	require.Len(t, graph.Edges, 1)
+	edge := graph.Edges[0]
+	assert.Equal(t, "orders", edge.Source)
+	assert.Equal(t, "users", edge.Target)
+	assert.Equal(t, []string{"user_id"}, edge.SourceColumns)
+	assert.Equal(t, schemas.ERDManyToOne, edge.Cardinality)
+	assert.True(t, edge.Optional)
+
+	positions := map[string]schemas.ERDNode{}
+	for _, node := range graph.Nodes {
+		positions[node.ID] = node
+	}
+	assert.Less(t, positions["users"].X, positions["orders"].X, "referenced tables are placed first")
+	assert.True(t, positions["orders"].Columns[1].ForeignKey)
+	assert.True(t, positions["users"].Columns[1].Unique)
+}
+
+func TestRenderERDFormats(t *testing.T) {
+	graph := schemas.BuildERDGraph(sampleTables())
+
+	mermaid := schemas.RenderMermaid(graph)
+	assert.Contains(t, mermaid, "erDiagram\n")
+	assert.Contains(t, mermaid, "character_varying email UK")
+	assert.Contains(t, mermaid, `orders }o--o| users : "user_id"`)
+
+	dot := schemas.RenderDOT(graph)
+	assert.Contains(t, dot, "digraph erd {")
+	assert.Contains(t, dot, `"orders":"user_id" -> "users":"id" [label="orders_user_id_fkey", style=dashed];`)
+}
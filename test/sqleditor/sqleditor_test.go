@@ -0,0 +1,313 @@
+package sqleditor_test
+
+import (
+	sqleditor "DBHS/SqlEditor"
+	"DBHS/config"
+	"DBHS/utils"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateQueryAllowsPostgresSyntax(t *testing.T) {
+	queries := []string{
+		"SELECT id::text, created_at::date FROM users WHERE name ILIKE 'a%'",
+		"INSERT INTO users (name) VALUES ($$it's; quoted$$) RETURNING id",
+		"UPDATE users SET name = 'b' WHERE id = 1 RETURNING *",
+		"DELETE FROM users WHERE id = ANY ('{1,2}'::int[])",
+		"WITH recent AS (SELECT * FROM orders WHERE created_at > now() - interval '1 day') SELECT count(*) FROM recent",
+		"SELECT relname FROM pg_class WHERE relkind = 'r'",
+		"WITH moved AS (DELETE FROM orders WHERE id = 1 RETURNING *) INSERT INTO archive SELECT * FROM moved",
+	}
+	for _, query := range queries {
+		statements, err := sqleditor.ValidateQuery(query)
+		assert.NoError(t, err, query)
+		assert.Len(t, statements, 1, query)
+	}
+}
+
+func TestValidateQueryRejectsStatements(t *testing.T) {
+	rejected := map[string]string{
+		"DROP TABLE users":              "DROP statements are not allowed",
+		"CREATE TABLE t (id int)":       "CREATE statements are not allowed",
+		"TRUNCATE users":                "TRUNCATE statements are not allowed",
+		"COPY users TO '/tmp/users'":    "COPY statements are not allowed",
+		"SELECT * INTO copy FROM users": "SELECT INTO creates a table and is not allowed",
+		"WITH x AS (DELETE FROM users RETURNING *) SELECT * FROM x":  "data-modifying statements can't be nested in a SELECT",
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity":     "function pg_terminate_backend is not allowed",
+		"SELECT pg_catalog.pg_read_file('/etc/passwd')":              "function pg_read_file is not allowed",
+		"SELECT query_to_xml('DROP TABLE users', true, true, '')":    "function query_to_xml is not allowed",
+		"SELECT * FROM pg_ls_waldir()":                               "function pg_ls_waldir is not allowed",
+		"SELECT pg_stat_statements_reset()":                          "function pg_stat_statements_reset is not allowed",
+		"SELECT pg_create_logical_replication_slot('s', 'pgoutput')": "function pg_create_logical_replication_slot is not allowed",
+		"SELECT dblink_connect('host=10.0.0.1 user=postgres')":       "function dblink_connect is not allowed",
+		"UPDATE pg_class SET relname = 'x'":                          "cannot modify protected schema pg_catalog",
+		"DELETE FROM information_schema.tables":                      "cannot modify protected schema information_schema",
+		"SELECT * FROM _dbhs.catalog_version":                        "schema _dbhs can't be accessed",
+	}
+	for query, reason := range rejected {
+		_, err := sqleditor.ValidateQuery(query)
+		var policyErr *sqleditor.PolicyError
+		require.ErrorAs(t, err, &policyErr, query)
+		assert.Equal(t, reason, policyErr.Reason, query)
+	}
+
+	_, err := sqleditor.ValidateQuery("SELECT 1;\nDROP TABLE users")
+	var policyErr *sqleditor.PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, 2, policyErr.Line)
+}
+
+func TestValidateQuerySyntaxError(t *testing.T) {
+	_, err := sqleditor.ValidateQuery("SELECT FROM WHERE")
+	var syntaxErr *utils.SQLSyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+}
+
+func TestBuildExplainQuery(t *testing.T) {
+	assert.Equal(t, "EXPLAIN (FORMAT JSON) SELECT 1", sqleditor.BuildExplainQuery("SELECT 1", false))
+	assert.Equal(t, "EXPLAIN (FORMAT JSON, ANALYZE, BUFFERS) DELETE FROM users", sqleditor.BuildExplainQuery("DELETE FROM users", true))
+}
+
+func TestParseExplainOutput(t *testing.T) {
+	output := `[{
+		"Plan": {
+			"Node Type": "Sort", "Startup Cost": 900, "Total Cost": 950, "Plan Rows": 20, "Plan Width": 8,
+			"Actual Startup Time": 80.5, "Actual Total Time": 95.1, "Actual Rows": 40000, "Actual Loops": 1,
+			"Sort Key": ["o.created_at"], "Sort Method": "external merge", "Sort Space Used": 2048, "Sort Space Type": "Disk",
+			"Plans": [{
+				"Node Type": "Hash Join", "Parent Relationship": "Outer", "Join Type": "Inner",
+				"Startup Cost": 10, "Total Cost": 800, "Plan Rows": 20, "Plan Width": 8,
+				"Actual Startup Time": 1, "Actual Total Time": 70, "Actual Rows": 40000, "Actual Loops": 1,
+				"Plans": [
+					{"Node Type": "Seq Scan", "Relation Name": "orders", "Schema": "public", "Alias": "o",
+						"Startup Cost": 0, "Total Cost": 500, "Plan Rows": 40000, "Plan Width": 8,
+						"Actual Startup Time": 0.1, "Actual Total Time": 30, "Actual Rows": 40000, "Actual Loops": 1,
+						"Filter": "(o.total > 10)", "Rows Removed by Filter": 10000, "Shared Read Blocks": 120},
+					{"Node Type": "Hash", "Startup Cost": 5, "Total Cost": 5, "Plan Rows": 50, "Plan Width": 4,
+						"Actual Startup Time": 0.5, "Actual Total Time": 0.5, "Actual Rows": 50, "Actual Loops": 1, "Hash Batches": 4,
+						"Plans": [{"Node Type": "Seq Scan", "Relation Name": "customers", "Schema": "public",
+							"Startup Cost": 0, "Total Cost": 5, "Plan Rows": 50, "Plan Width": 4,
+							"Actual Startup Time": 0.01, "Actual Total Time": 0.2, "Actual Rows": 50, "Actual Loops": 1}]}
+				]
+			}]
+		},
+		"Planning Time": 0.25,
+		"Execution Time": 96.3
+	}]`
+
+	explained, err := sqleditor.ParseExplainOutput([]byte(output), true)
+	require.NoError(t, err)
+	assert.True(t, explained.Analyzed)
+	assert.Equal(t, 0.25, explained.PlanningTime)
+	assert.Equal(t, 96.3, explained.ExecutionTime)
+	assert.Equal(t, 1, explained.Plan.NodeID)
+	assert.Equal(t, 3, explained.Plan.Plans[0].Plans[0].NodeID)
+	assert.Equal(t, 5, explained.Plan.Plans[0].Plans[1].Plans[0].NodeID)
+	assert.Equal(t, int64(120), explained.Plan.Plans[0].Plans[0].SharedReadBlocks)
+
+	kinds := make(map[int][]string)
+	for _, hotspot := range explained.Hotspots {
+		kinds[hotspot.Node] = append(kinds[hotspot.Node], hotspot.Kind)
+	}
+	assert.Equal(t, map[int][]string{
+		1: {sqleditor.HotspotEstimateMiss, sqleditor.HotspotSortSpill},
+		2: {sqleditor.HotspotEstimateMiss},
+		3: {sqleditor.HotspotSeqScan},
+		4: {sqleditor.HotspotHashSpill},
+	}, kinds)
+	assert.Equal(t, "sequential scan of orders reads 50000 rows and removes 10000 of them by filter", explained.Hotspots[3].Message)
+
+	// a plan that wasn't analyzed only has its estimates
+	explained, err = sqleditor.ParseExplainOutput([]byte(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "orders", "Plan Rows": 5000}}]`), false)
+	require.NoError(t, err)
+	require.Len(t, explained.Hotspots, 1)
+	assert.Equal(t, "sequential scan of orders reads 5000 rows", explained.Hotspots[0].Message)
+
+	_, err = sqleditor.ParseExplainOutput([]byte(`[]`), false)
+	assert.Error(t, err)
+}
+
+func TestResultValue(t *testing.T) {
+	cases := []struct {
+		oid      uint32
+		raw      []byte
+		expected string
+	}{
+		{pgtype.Int4OID, []byte("42"), `42`},
+		{pgtype.NumericOID, []byte("12345678901234567890.50"), `12345678901234567890.50`},
+		{pgtype.NumericOID, []byte("NaN"), `"NaN"`},
+		{pgtype.Float8OID, []byte("-Infinity"), `"-Infinity"`},
+		{pgtype.BoolOID, []byte("t"), `true`},
+		{pgtype.JSONBOID, []byte(`{"a": [1, 2]}`), `{"a":[1,2]}`},
+		{pgtype.TimestamptzOID, []byte("2024-05-01 10:00:00+00"), `"2024-05-01 10:00:00+00"`},
+		{pgtype.Int4ArrayOID, []byte("{1,2}"), `"{1,2}"`},
+		{pgtype.TextOID, nil, `null`},
+	}
+	for _, c := range cases {
+		encoded, err := json.Marshal(sqleditor.ResultValue(c.oid, c.raw))
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, string(encoded), string(c.raw))
+	}
+}
+
+func TestResultColumns(t *testing.T) {
+	pgTypes := config.PgTypes
+	defer func() { config.PgTypes = pgTypes }()
+	config.PgTypes = map[uint32]string{pgtype.Int4OID: "int4"}
+
+	columns := sqleditor.ResultColumns([]pgconn.FieldDescription{
+		{Name: "id", DataTypeOID: pgtype.Int4OID},
+		{Name: "label", DataTypeOID: 987654},
+	})
+	assert.Equal(t, []sqleditor.ResultColumn{
+		{Name: "id", Type: "int4", TypeOID: pgtype.Int4OID},
+		{Name: "label", Type: "987654", TypeOID: 987654},
+	}, columns)
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := sqleditor.NewNDJSONWriter(recorder)
+	assert.False(t, stream.Started())
+
+	assert.NoError(t, stream.Columns([]sqleditor.ResultColumn{{Name: "id", Type: "int4", TypeOID: pgtype.Int4OID}}))
+	assert.NoError(t, stream.Row([]any{json.RawMessage("1")}))
+	assert.NoError(t, stream.Row([]any{nil}))
+	assert.NoError(t, stream.Summary(sqleditor.StatementSummary{CommandTag: "SELECT 2", RowsAffected: 2}))
+
+	assert.True(t, stream.Started())
+	assert.Equal(t, sqleditor.NDJSONContentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `{"columns":[{"name":"id","type":"int4","type_oid":23}]}
+{"row":[1]}
+{"row":[null]}
+{"summary":{"command_tag":"SELECT 2","rows_affected":2,"execution_time":0,"truncated":false}}
+`, recorder.Body.String())
+}
+
+func TestScriptPosition(t *testing.T) {
+	script := "INSERT INTO notes (body) VALUES ('né');\n  -- then\n  SELECT id,\n    missing FROM notes"
+	statements, err := utils.ParseSQLStatements(script)
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+	// the comment before a statement is part of it
+	assert.Equal(t, 2, statements[1].Line)
+	assert.Equal(t, 3, statements[1].Column)
+
+	// PostgreSQL counts the position of an error in characters from the start of the statement
+	line, column := statements[1].ScriptPosition(script, strings.Index(statements[1].SQL, "missing")+1)
+	assert.Equal(t, 4, line)
+	assert.Equal(t, 5, column)
+	line, column = statements[0].ScriptPosition(script, 1)
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 1, column)
+}
+
+func TestBindNamedParams(t *testing.T) {
+	params := []sqleditor.QueryParam{
+		{Name: "since", Value: json.RawMessage(`"2024-01-01"`), Type: "date"},
+		{Name: "status", Value: json.RawMessage(`"active"`)},
+	}
+	query, ordered, err := sqleditor.BindNamedParams(`SELECT tags[1:2], created::date, ':since' -- :status
+FROM users WHERE status = :status AND created >= :since AND status <> :status`, params)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT tags[1:2], created::date, ':since' -- :status
+FROM users WHERE status = $1 AND created >= $2 AND status <> $1`, query)
+	assert.Equal(t, []sqleditor.QueryParam{params[1], params[0]}, ordered)
+
+	// positional parameters are left as they are
+	positional := []sqleditor.QueryParam{{Value: json.RawMessage(`1`)}}
+	query, ordered, err = sqleditor.BindNamedParams("SELECT * FROM users WHERE id = $1", positional)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1", query)
+	assert.Equal(t, positional, ordered)
+
+	cases := map[string][]sqleditor.QueryParam{
+		"parameters must be all named or all positional": {{Name: "id"}, {}},
+		"parameter :id is given twice":                   {{Name: "id"}, {Name: "id"}},
+		"placeholder :name has no parameter":             {{Name: "id"}},
+		"parameter :unused is not used in the query":     {{Name: "id"}, {Name: "name"}, {Name: "unused"}},
+	}
+	for reason, params := range cases {
+		_, _, err := sqleditor.BindNamedParams("SELECT * FROM users WHERE id = :id AND name = :name", params)
+		var paramErr *sqleditor.ParamError
+		require.ErrorAs(t, err, &paramErr, reason)
+		assert.Equal(t, reason, paramErr.Reason)
+	}
+}
+
+func TestCheckParams(t *testing.T) {
+	statements, err := utils.ParseSQLStatements("UPDATE users SET name = $2 WHERE id = $1; SELECT * FROM users WHERE id = $1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, sqleditor.Placeholders(statements[0]))
+	assert.Equal(t, 1, sqleditor.Placeholders(statements[1]))
+
+	two := []sqleditor.QueryParam{{Value: json.RawMessage(`1`)}, {Value: json.RawMessage(`"bob"`)}}
+	assert.NoError(t, sqleditor.CheckParams(statements, two))
+	err = sqleditor.CheckParams(statements, two[:1])
+	assert.EqualError(t, err, "the query has 2 placeholders but 1 parameters were given")
+}
+
+func TestParamText(t *testing.T) {
+	cases := map[string]any{
+		`"it's"`:             "it's",
+		`12.50`:              "12.50",
+		`true`:               "true",
+		`{"a": [1, 2]}`:      `{"a":[1,2]}`,
+		`null`:               nil,
+		``:                   nil,
+		`"{1,2}"`:            "{1,2}",
+		`"2024-05-01T10:00"`: "2024-05-01T10:00",
+	}
+	for value, expected := range cases {
+		text, err := sqleditor.ParamText(json.RawMessage(value))
+		require.NoError(t, err, value)
+		if expected == nil {
+			assert.Nil(t, text, value)
+			continue
+		}
+		assert.Equal(t, expected, string(text), value)
+	}
+	_, err := sqleditor.ParamText(json.RawMessage(`{"a":`))
+	assert.Error(t, err)
+}
+
+func TestNewQueryExecution(t *testing.T) {
+	timeout, maxRows := config.SQL_EDITOR_STATEMENT_TIMEOUT_SECOND, config.SQL_EDITOR_MAX_ROWS
+	defer func() {
+		config.SQL_EDITOR_STATEMENT_TIMEOUT_SECOND, config.SQL_EDITOR_MAX_ROWS = timeout, maxRows
+	}()
+	config.SQL_EDITOR_STATEMENT_TIMEOUT_SECOND, config.SQL_EDITOR_MAX_ROWS = 30, 1000
+
+	execution := sqleditor.NewQueryExecution(7, "project", sqleditor.RequestBody{QueryID: "q1"})
+	assert.Equal(t, sqleditor.QueryExecution{QueryID: "q1", OwnerID: 7, ProjectOid: "project", Timeout: 30 * time.Second, MaxRows: 1000}, execution)
+
+	// a request can lower the limits but not raise them
+	execution = sqleditor.NewQueryExecution(7, "project", sqleditor.RequestBody{TimeoutMs: 1500, MaxRows: 50})
+	assert.Equal(t, 1500*time.Millisecond, execution.Timeout)
+	assert.Equal(t, 50, execution.MaxRows)
+	execution = sqleditor.NewQueryExecution(7, "project", sqleditor.RequestBody{TimeoutMs: 120000, MaxRows: 5000})
+	assert.Equal(t, 30*time.Second, execution.Timeout)
+	assert.Equal(t, 1000, execution.MaxRows)
+}
+
+func TestValidateQueryID(t *testing.T) {
+	assert.NoError(t, sqleditor.ValidateQueryID("3f6c1a52-8d4e-4c1b-9a7e-2b1f0c9d8e7a"))
+	for _, queryID := range []string{
+		"q1",
+		"3f6c1a528d4e4c1b9a7e2b1f0c9d8e7a",
+		"{3f6c1a52-8d4e-4c1b-9a7e-2b1f0c9d8e7a}",
+		"urn:uuid:3f6c1a52-8d4e-4c1b-9a7e-2b1f0c9d8e7a",
+		"3f6c1a52-8d4e-4c1b-9a7e-2b1f0c9d8e7z",
+		strings.Repeat("a", 100),
+	} {
+		assert.ErrorIs(t, sqleditor.ValidateQueryID(queryID), sqleditor.ErrInvalidQueryID, queryID)
+	}
+}
@@ -37,7 +37,11 @@ func mockCreateTable(ctx context.Context, projectOID string, table *tables.Clien
 	defer tx.Rollback(ctx)
 
 	// Create the table in the user db
-	if err := tables.CreateTableIntoHostingServer(ctx, table, tx); err != nil {
+	createTableSQL, err := tables.ParseTableIntoSQLCreate(table)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, createTableSQL); err != nil {
 		return "", err
 	}
 
@@ -359,18 +363,16 @@ func (suite *ServiceTestSuite) TestCreateTable() {
 
 func (suite *ServiceTestSuite) TestUpdateTable() {
 	// First create a table to update
-	table := &tables.ClientTable{
-		TableName: "update_test_table",
-		Columns: []tables.Column{
-			{
-				Name:         "id",
-				Type:         "serial",
-				IsPrimaryKey: func() *bool { b := true; return &b }(),
+	table := &tables.Table{
+		Name: "update_test_table",
+		Schema: &utils.Table{
+			TableName: "update_test_table",
+			Columns: []utils.TableColumn{
+				{ColumnName: "id", DataType: "serial"},
+				{ColumnName: "description", DataType: "text", IsNullable: true},
 			},
-			{
-				Name:       "description",
-				Type:       "text",
-				IsNullable: func() *bool { b := true; return &b }(),
+			Constraints: []utils.ConstraintInfo{
+				{ConstraintType: utils.PrimaryKeyConstraint, Columns: []string{"id"}},
 			},
 		},
 	}
@@ -381,26 +383,18 @@ func (suite *ServiceTestSuite) TestUpdateTable() {
 	// Give the system time to fully create the table
 	time.Sleep(100 * time.Millisecond)
 
-	// Create update definition
-	updates := &tables.TableUpdate{
-		Inserts: tables.ColumnCollection{
-			Columns: []tables.Column{
-				{
-					Name:       "created_at",
-					Type:       "timestamp",
-					IsNullable: func() *bool { b := false; return &b }(),
-				},
-			},
-		},
-		Updates: []tables.UpdateColumn{
-			{
-				Name: "description",
-				Update: tables.Column{
-					Name: "title",
-					Type: "varchar(200)",
-				},
-			},
-		},
+	// Create update definition from the current schema: rename description to title and add created_at
+	current, err := utils.GetSchemaTable(suite.ctx, "public", "update_test_table", suite.userDB)
+	require.NoError(suite.T(), err)
+	for i := range current.Columns {
+		if current.Columns[i].ColumnName == "description" {
+			current.Columns[i].ColumnName = "title"
+		}
+	}
+	current.Columns = append(current.Columns, utils.TableColumn{ColumnName: "created_at", DataType: "timestamp without time zone"})
+	updates := &tables.UpdateTableSchema{
+		Table:   tables.Table{Name: "update_test_table", Schema: current},
+		Renames: []utils.RenameRelation{{OldName: "description", NewName: "title"}},
 	}
 
 	// Update the table
@@ -13,7 +13,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
@@ -57,43 +59,49 @@ func (m *MockRow) Scan(dest ...interface{}) error {
 func TestCheckForValidTable(t *testing.T) {
 	tests := []struct {
 		name     string
-		table    *tables.ClientTable
+		table    *tables.Table
 		expected bool
 	}{
 		{
 			name: "Valid table",
-			table: &tables.ClientTable{
-				TableName: "test_table",
-				Columns: []tables.Column{
-					{Name: "id", Type: "int"},
+			table: &tables.Table{
+				Name: "test_table",
+				Schema: &utils.Table{
+					Columns: []utils.TableColumn{
+						{ColumnName: "id", DataType: "int"},
+					},
 				},
 			},
 			expected: true,
 		},
 		{
 			name: "Empty table name",
-			table: &tables.ClientTable{
-				TableName: "",
-				Columns: []tables.Column{
-					{Name: "id", Type: "int"},
+			table: &tables.Table{
+				Name: "",
+				Schema: &utils.Table{
+					Columns: []utils.TableColumn{
+						{ColumnName: "id", DataType: "int"},
+					},
 				},
 			},
 			expected: false,
 		},
 		{
 			name: "No columns",
-			table: &tables.ClientTable{
-				TableName: "test_table",
-				Columns:   []tables.Column{},
+			table: &tables.Table{
+				Name:   "test_table",
+				Schema: &utils.Table{Columns: []utils.TableColumn{}},
 			},
 			expected: false,
 		},
 		{
 			name: "Invalid column",
-			table: &tables.ClientTable{
-				TableName: "test_table",
-				Columns: []tables.Column{
-					{Name: "", Type: "int"},
+			table: &tables.Table{
+				Name: "test_table",
+				Schema: &utils.Table{
+					Columns: []utils.TableColumn{
+						{ColumnName: "", DataType: "int"},
+					},
 				},
 			},
 			expected: false,
@@ -111,6 +119,7 @@ func TestCheckForValidTable(t *testing.T) {
 func TestCreateColumnDefinition(t *testing.T) {
 	trueValue := true
 	falseValue := false
+	defaultValue := "1"
 
 	tests := []struct {
 		name     string
@@ -156,16 +165,179 @@ func TestCreateColumnDefinition(t *testing.T) {
 			},
 			expected: "project_id int REFERENCES projects(id)",
 		},
+		{
+			name: "Foreign key with actions, default and check",
+			column: &tables.Column{
+				Name:    "project_id",
+				Type:    "int",
+				Default: &defaultValue,
+				Check:   "project_id > 0",
+				ForeignKey: tables.ForeignKey{
+					TableName:  "projects",
+					ColumnName: "id",
+					OnDelete:   "cascade",
+					OnUpdate:   "set null",
+				},
+			},
+			expected: "project_id int DEFAULT 1 CHECK (project_id > 0) REFERENCES projects(id) ON DELETE CASCADE ON UPDATE SET NULL",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := tables.CreateColumnDefinition(tt.column)
+			result, err := tables.CreateColumnDefinition(tt.column)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
+func TestCreateColumnDefinitionRejectsUnknownActions(t *testing.T) {
+	for _, action := range []string{"DROP TABLE users", "cascade; DROP TABLE users", "NOTHING"} {
+		column := &tables.Column{
+			Name: "project_id",
+			Type: "int",
+			ForeignKey: tables.ForeignKey{
+				TableName:  "projects",
+				ColumnName: "id",
+				OnDelete:   action,
+			},
+		}
+		_, err := tables.CreateColumnDefinition(column)
+		var constraintErr *utils.ConstraintError
+		assert.ErrorAs(t, err, &constraintErr, action)
+
+		column.ForeignKey.OnDelete, column.ForeignKey.OnUpdate = "", action
+		_, err = tables.CreateColumnDefinition(column)
+		assert.ErrorAs(t, err, &constraintErr, action)
+	}
+}
+
+func TestPlanTimePartitions(t *testing.T) {
+	retention := 2
+	policy := &tables.PartitionPolicy{Interval: "month", Premake: 2, Retention: &retention}
+	existing := []string{"events_p202410", "events_p202411", "events_p202412", "events_p202501", "events_p202502", "events_archive", "events_p2024"}
+	now := time.Date(2025, time.February, 14, 10, 30, 0, 0, time.UTC)
+
+	create, drop := tables.PlanTimePartitions("events", policy, existing, now)
+	require.Len(t, create, 2)
+	assert.Equal(t, "events_p202503", create[0].Name)
+	assert.Equal(t, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), create[0].From)
+	assert.Equal(t, time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC), create[0].To)
+	assert.Equal(t, "events_p202504", create[1].Name)
+	assert.Equal(t, []string{"events_p202410", "events_p202411"}, drop)
+
+	policy = &tables.PartitionPolicy{Interval: "week", Premake: 1}
+	create, drop = tables.PlanTimePartitions("events", policy, nil, now)
+	require.Len(t, create, 2)
+	assert.Equal(t, "events_p20250210", create[0].Name)
+	assert.Equal(t, "events_p20250217", create[1].Name)
+	assert.Empty(t, drop)
+}
+
+func TestPlanTimePartitionsLongTableName(t *testing.T) {
+	policy := &tables.PartitionPolicy{Interval: "day", Premake: 0}
+	now := time.Date(2025, time.February, 14, 10, 30, 0, 0, time.UTC)
+
+	long := strings.Repeat("measurement_", 5) + "readings"
+	other := strings.Repeat("measurement_", 5) + "results"
+	create, _ := tables.PlanTimePartitions(long, policy, nil, now)
+	require.Len(t, create, 1)
+	name := create[0].Name
+	assert.LessOrEqual(t, len(name), 63)
+	assert.True(t, strings.HasSuffix(name, "_p20250214"), name)
+
+	// tables sharing the cut part of their name get distinct partitions
+	otherCreate, _ := tables.PlanTimePartitions(other, policy, nil, now)
+	require.Len(t, otherCreate, 1)
+	assert.NotEqual(t, name, otherCreate[0].Name)
+
+	// a multibyte name is cut on a character boundary
+	create, _ = tables.PlanTimePartitions(strings.Repeat("é", 40), policy, nil, now)
+	require.Len(t, create, 1)
+	assert.LessOrEqual(t, len(create[0].Name), 63)
+	assert.True(t, utf8.ValidString(create[0].Name))
+
+	// the partitions of a long table are recognized by the retention
+	retention := 1
+	policy = &tables.PartitionPolicy{Interval: "day", Premake: 0, Retention: &retention}
+	old := strings.Replace(name, "20250214", "20250210", 1)
+	_, drop := tables.PlanTimePartitions(long, policy, []string{old, name}, now)
+	assert.Equal(t, []string{old}, drop)
+}
+
+func TestValidatePartitionPolicy(t *testing.T) {
+	retention := -1
+	invalid := []tables.PartitionPolicy{
+		{Interval: "hour", Premake: 1},
+		{Interval: "day", Premake: 0},
+		{Interval: "day", Premake: 1, Retention: &retention},
+	}
+	for _, policy := range invalid {
+		assert.ErrorIs(t, tables.ValidatePartitionPolicy(&policy), tables.ErrInvalidPolicy)
+	}
+	policy := tables.PartitionPolicy{Interval: " Month ", Premake: 3}
+	require.NoError(t, tables.ValidatePartitionPolicy(&policy))
+	assert.Equal(t, "month", policy.Interval)
+}
+
+func TestValidateTableTTL(t *testing.T) {
+	columns := []utils.TableColumn{
+		{ColumnName: "token", DataType: "text"},
+		{ColumnName: "expires_at", DataType: "timestamp with time zone"},
+	}
+	ttl := &tables.TableTTL{Column: "expires_at", Interval: "1 day"}
+	require.NoError(t, tables.ValidateTableTTL(ttl, columns))
+	assert.Equal(t, 1000, ttl.BatchSize)
+
+	invalid := []*tables.TableTTL{
+		{Column: "token", Interval: "1 day"},
+		{Column: "missing", Interval: "1 day"},
+		{Column: "expires_at"},
+		{Column: "expires_at", Interval: "1 day", BatchSize: -1},
+		{Column: "expires_at", Interval: "1 day", BatchSize: 1000000},
+	}
+	for _, ttl := range invalid {
+		assert.ErrorIs(t, tables.ValidateTableTTL(ttl, columns), tables.ErrInvalidTTL, ttl)
+	}
+}
+
+func TestRenamedObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		renamed bool
+	}{
+		{"users_pkey", "accounts_pkey", true},
+		{"users_id_seq", "accounts_id_seq", true},
+		{"users_email_key", "accounts_email_key", true},
+		{"users_", "users_", false},
+		{"usersx_pkey", "usersx_pkey", false},
+		{"idx_users_email", "idx_users_email", false},
+	}
+	for _, tt := range tests {
+		got, renamed := tables.RenamedObject(tt.name, "users", "accounts")
+		assert.Equal(t, tt.want, got, tt.name)
+		assert.Equal(t, tt.renamed, renamed, tt.name)
+	}
+}
+
+func TestUnconfirmedTables(t *testing.T) {
+	referencing := []string{"public.orders", "public.order_items"}
+	assert.Equal(t, referencing, tables.UnconfirmedTables(referencing, nil))
+	assert.Equal(t, []string{"public.order_items"}, tables.UnconfirmedTables(referencing, []string{"public.orders"}))
+	assert.Empty(t, tables.UnconfirmedTables(referencing, []string{"public.order_items", "public.orders"}))
+	assert.Empty(t, tables.UnconfirmedTables(nil, nil))
+}
+
+func TestCopyWarnings(t *testing.T) {
+	ddl := "CREATE TABLE \"orders\" ();\n" +
+		"-- skipped orders_user_id_fkey: references users which is not part of the export\n" +
+		"CREATE INDEX orders_idx ON \"orders\" (id);\n"
+	assert.Equal(t, []string{"skipped orders_user_id_fkey: references users which is not part of the export"}, tables.CopyWarnings(ddl))
+	assert.Empty(t, tables.CopyWarnings("CREATE TABLE \"orders\" ();\n"))
+}
+
 // Integration Test Suite
 type TablesIntegrationTestSuite struct {
 	suite.Suite
@@ -564,7 +736,7 @@ func (suite *TablesIntegrationTestSuite) TestGetAllTables() {
 	w := httptest.NewRecorder()
 
 	// Call the handler
-	handler := tables.GetAllTablesHanlder(suite.app)
+	handler := tables.GetAllTablesHandler(suite.app)
 	handler(w, req)
 
 	// Print the response body for debugging
@@ -816,3 +988,26 @@ func TestTablesIntegrationSuite(t *testing.T) {
 	}
 	suite.Run(t, new(TablesIntegrationTestSuite))
 }
+
+func TestSplitDDLStatements(t *testing.T) {
+	ddl := `-- Comparing table schema for orders
+-- Generated automatically
+
+ALTER TABLE "orders" ADD COLUMN "note" TEXT DEFAULT 'first line
+second line; still the default';
+ALTER TABLE "orders" ADD CONSTRAINT "total_positive" CHECK (
+	total >= 0
+);
+COMMENT ON COLUMN "orders"."note" IS 'a;b';
+`
+	statements, err := tables.SplitDDLStatements(ddl)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE \"orders\" ADD COLUMN \"note\" TEXT DEFAULT 'first line\nsecond line; still the default'",
+		"ALTER TABLE \"orders\" ADD CONSTRAINT \"total_positive\" CHECK (\n\ttotal >= 0\n)",
+		`COMMENT ON COLUMN "orders"."note" IS 'a;b'`,
+	}, statements)
+
+	_, err = tables.SplitDDLStatements("ALTER TABLE (")
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package types_test
+
+import (
+	"DBHS/types"
+	"DBHS/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCreateType(t *testing.T) {
+	assert.NoError(t, types.ValidateCreateType(&types.CreateTypeRequest{Name: "mood", Kind: types.KindEnum, Values: []string{"sad", "happy"}}))
+	assert.ErrorIs(t, types.ValidateCreateType(&types.CreateTypeRequest{Name: "mood", Kind: types.KindEnum}), types.ErrNoValues)
+	assert.Error(t, types.ValidateCreateType(&types.CreateTypeRequest{Name: "mood", Kind: types.KindEnum, Values: []string{"a", "a"}}))
+	assert.ErrorIs(t, types.ValidateCreateType(&types.CreateTypeRequest{Name: "point", Kind: types.KindComposite}), types.ErrNoAttributes)
+	assert.ErrorIs(t, types.ValidateCreateType(&types.CreateTypeRequest{Name: "x", Kind: "domain"}), types.ErrInvalidKind)
+	assert.Error(t, types.ValidateCreateType(&types.CreateTypeRequest{Name: "x", Schema: "pg_catalog", Kind: types.KindEnum, Values: []string{"a"}}))
+}
+
+func TestGenerateCreateTypeQuery(t *testing.T) {
+	enum := &types.CreateTypeRequest{Name: "mood", Kind: types.KindEnum, Values: []string{"sad", "it's ok"}}
+	assert.Equal(t, `CREATE TYPE "public"."mood" AS ENUM ('sad', 'it''s ok')`, types.GenerateCreateTypeQuery(enum))
+
+	composite := &types.CreateTypeRequest{Name: "address", Schema: "crm", Kind: types.KindComposite,
+		Attributes: []types.Attribute{{Name: "street", Type: "text"}, {Name: "zip", Type: "varchar(10)"}}}
+	assert.Equal(t, `CREATE TYPE "crm"."address" AS ("street" text, "zip" varchar(10))`, types.GenerateCreateTypeQuery(composite))
+}
+
+func TestGenerateAlterTypeQueries(t *testing.T) {
+	enum := &types.CustomType{Name: "mood", Schema: "public", Kind: types.KindEnum}
+	queries, err := types.GenerateAlterTypeQueries(enum, &types.AlterTypeRequest{
+		RenameValues: []utils.RenameRelation{{OldName: "sad", NewName: "unhappy"}},
+		AddValues:    []types.EnumValue{{Value: "ok", After: "unhappy"}, {Value: "ecstatic"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`ALTER TYPE "public"."mood" RENAME VALUE 'sad' TO 'unhappy'`,
+		`ALTER TYPE "public"."mood" ADD VALUE IF NOT EXISTS 'ok' AFTER 'unhappy'`,
+		`ALTER TYPE "public"."mood" ADD VALUE IF NOT EXISTS 'ecstatic'`,
+	}, queries)
+
+	_, err = types.GenerateAlterTypeQueries(enum, &types.AlterTypeRequest{DropAttributes: []string{"a"}})
+	assert.ErrorIs(t, err, types.ErrEnumChanges)
+	_, err = types.GenerateAlterTypeQueries(enum, &types.AlterTypeRequest{})
+	assert.ErrorIs(t, err, types.ErrNoChanges)
+
+	composite := &types.CustomType{Name: "address", Schema: "crm", Kind: types.KindComposite}
+	queries, err = types.GenerateAlterTypeQueries(composite, &types.AlterTypeRequest{
+		DropAttributes:   []string{"zip"},
+		RenameAttributes: []utils.RenameRelation{{OldName: "street", NewName: "line1"}},
+		AddAttributes:    []types.Attribute{{Name: "country", Type: "text"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`ALTER TYPE "crm"."address" DROP ATTRIBUTE "zip"`,
+		`ALTER TYPE "crm"."address" RENAME ATTRIBUTE "street" TO "line1"`,
+		`ALTER TYPE "crm"."address" ADD ATTRIBUTE "country" text`,
+	}, queries)
+
+	_, err = types.GenerateAlterTypeQueries(composite, &types.AlterTypeRequest{AddValues: []types.EnumValue{{Value: "a"}}})
+	assert.ErrorIs(t, err, types.ErrCompositeChanges)
+}
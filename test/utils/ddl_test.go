@@ -0,0 +1,122 @@
+package utils_test
+
+import (
+	"DBHS/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func sampleExport() *utils.SchemaExport {
+	return &utils.SchemaExport{
+		Enums: []utils.ExportEnum{
+			{Name: "order_status", Labels: []string{"pending", "it's shipped"}},
+			{Name: "unused", Labels: []string{"a"}},
+			{Name: "currency", Labels: []string{"usd", "eur"}},
+		},
+		Composites: []utils.ExportComposite{
+			{Name: "address", Attributes: []string{"street text", "city text"}},
+			{Name: "money", Attributes: []string{"amount numeric(12,2)", "currency currency"}, DependsOn: []string{"currency"}},
+			{Name: "line_total", Attributes: []string{"net money", "gross money"}, DependsOn: []string{"money"}},
+		},
+		Sequences: []utils.ExportSequence{
+			{Name: "users_id_seq", DataType: "integer", StartValue: 1, MinValue: 1, MaxValue: 2147483647, IncrementBy: 1, CacheSize: 1,
+				OwnedByTable: strPtr("users"), OwnedByColumn: strPtr("id")},
+		},
+		Columns: []utils.ExportColumn{
+			{TableName: "order_items", ColumnName: "order_id", DataType: "integer", NotNull: true},
+			{TableName: "order_items", ColumnName: "line", DataType: "integer", NotNull: true},
+			{TableName: "order_items", ColumnName: "total", DataType: "line_total", CompositeType: strPtr("line_total")},
+			{TableName: "orders", ColumnName: "id", DataType: "integer", NotNull: true, Identity: "d"},
+			{TableName: "orders", ColumnName: "user_id", DataType: "integer"},
+			{TableName: "orders", ColumnName: "status", DataType: "order_status", EnumType: strPtr("order_status")},
+			{TableName: "users", ColumnName: "id", DataType: "integer", NotNull: true, ColumnDefault: strPtr("nextval('users_id_seq'::regclass)")},
+		},
+		Constraints: []utils.ExportConstraint{
+			{TableName: "order_items", ConstraintName: "order_items_pkey", ConstraintType: "p", Definition: "PRIMARY KEY (order_id, line)"},
+			{TableName: "order_items", ConstraintName: "order_items_order_id_fkey", ConstraintType: "f",
+				Definition: "FOREIGN KEY (order_id) REFERENCES orders(id)", ReferencedTable: strPtr("orders")},
+			{TableName: "orders", ConstraintName: "orders_pkey", ConstraintType: "p", Definition: "PRIMARY KEY (id)"},
+			{TableName: "orders", ConstraintName: "orders_user_id_fkey", ConstraintType: "f",
+				Definition: "FOREIGN KEY (user_id) REFERENCES users(id)", ReferencedTable: strPtr("users")},
+		},
+		Indexes: []utils.ExportIndex{
+			{TableName: "orders", IndexName: "orders_status_idx", Definition: "CREATE INDEX orders_status_idx ON public.orders USING btree (status)"},
+		},
+		Views: []utils.ExportView{
+			{Name: "a_recent", Definition: " SELECT * FROM open_orders;"},
+			{Name: "open_orders", Definition: " SELECT * FROM orders WHERE status = 'pending';"},
+		},
+		ViewDeps: []utils.ExportViewDependency{{ViewName: "a_recent", DependsOn: "open_orders"}},
+		Functions: []utils.ExportFunction{
+			{Name: "touch", Definition: "CREATE OR REPLACE FUNCTION public.touch()\n RETURNS trigger\n LANGUAGE plpgsql\nAS $function$ BEGIN RETURN NEW; END $function$\n"},
+		},
+	}
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	deps := map[string][]string{
+		"order_items": {"orders"},
+		"orders":      {"users"},
+		"a":           {"b"},
+		"b":           {"a"},
+	}
+	ordered := utils.OrderByDependencies([]string{"a", "b", "order_items", "orders", "users"}, deps)
+	assert.Equal(t, []string{"b", "a", "users", "orders", "order_items"}, ordered)
+}
+
+func TestRenderSchemaDDL(t *testing.T) {
+	ddl, err := utils.RenderSchemaDDL(sampleExport(), nil)
+	require.NoError(t, err)
+
+	order := []string{
+		`CREATE TYPE "order_status" AS ENUM ('pending', 'it''s shipped')`,
+		`CREATE TYPE "money" AS (amount numeric(12,2), currency currency)`,
+		`CREATE TYPE "line_total" AS (net money, gross money)`,
+		`CREATE SEQUENCE IF NOT EXISTS "users_id_seq"`,
+		"CREATE OR REPLACE FUNCTION public.touch()",
+		`CREATE TABLE IF NOT EXISTS "users"`,
+		`CREATE TABLE IF NOT EXISTS "orders"`,
+		`CREATE TABLE IF NOT EXISTS "order_items"`,
+		`ALTER SEQUENCE "users_id_seq" OWNED BY "users"."id"`,
+		`ADD CONSTRAINT "order_items_pkey" PRIMARY KEY (order_id, line)`,
+		`ADD CONSTRAINT "orders_user_id_fkey"`,
+		"CREATE INDEX IF NOT EXISTS orders_status_idx",
+		`CREATE OR REPLACE VIEW "open_orders"`,
+		`CREATE OR REPLACE VIEW "a_recent"`,
+	}
+	last := -1
+	for _, fragment := range order {
+		i := strings.Index(ddl, fragment)
+		require.GreaterOrEqual(t, i, 0, "missing %q", fragment)
+		assert.Greater(t, i, last, "%q is out of order", fragment)
+		last = i
+	}
+	assert.Contains(t, ddl, `"id" integer GENERATED BY DEFAULT AS IDENTITY NOT NULL`)
+	assert.Contains(t, ddl, "conname = 'orders_pkey' AND conrelid = '\"orders\"'::regclass")
+}
+
+func TestRenderSchemaDDLSelectedTables(t *testing.T) {
+	ddl, err := utils.RenderSchemaDDL(sampleExport(), []string{"orders"})
+	require.NoError(t, err)
+
+	assert.Contains(t, ddl, `CREATE TYPE "order_status"`)
+	assert.NotContains(t, ddl, `CREATE TYPE "unused"`)
+	assert.NotContains(t, ddl, `CREATE TABLE IF NOT EXISTS "users"`)
+	assert.NotContains(t, ddl, "users_id_seq")
+	assert.NotContains(t, ddl, "CREATE OR REPLACE VIEW")
+	assert.Contains(t, ddl, "-- skipped orders_user_id_fkey: references users")
+
+	ddl, err = utils.RenderSchemaDDL(sampleExport(), []string{"order_items", "orders"})
+	require.NoError(t, err)
+	assert.Contains(t, ddl, `CREATE TYPE "currency"`)
+	assert.Contains(t, ddl, `CREATE TYPE "line_total"`)
+	assert.NotContains(t, ddl, `CREATE TYPE "address"`)
+
+	_, err = utils.RenderSchemaDDL(sampleExport(), []string{"missing"})
+	assert.Error(t, err)
+}
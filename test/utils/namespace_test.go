@@ -0,0 +1,23 @@
+package utils_test
+
+import (
+	"DBHS/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReservedSchema(t *testing.T) {
+	assert.True(t, utils.IsReservedSchema("_dbhs"))
+	assert.True(t, utils.IsReservedSchema("information_schema"))
+	assert.True(t, utils.IsReservedSchema("pg_catalog"))
+	assert.True(t, utils.IsReservedSchema("pg_toast"))
+	assert.False(t, utils.IsReservedSchema("public"))
+	assert.False(t, utils.IsReservedSchema("sales"))
+}
+
+func TestQualifiedName(t *testing.T) {
+	assert.Equal(t, `"public"."users"`, utils.QualifiedName("", "users"))
+	assert.Equal(t, `"sales"."orders"`, utils.QualifiedName("sales", "orders"))
+	assert.Equal(t, `"Sales"."order ""items"""`, utils.QualifiedName("Sales", `order "items"`))
+}
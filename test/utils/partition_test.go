@@ -0,0 +1,130 @@
+package utils_test
+
+import (
+	"DBHS/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatPartitionBound(t *testing.T) {
+	valid := map[string]utils.PartitionBound{
+		`FOR VALUES FROM ('2025-01-01') TO ('2025-02-01')`:       {From: []string{"2025-01-01"}, To: []string{"2025-02-01"}},
+		`FOR VALUES FROM (MINVALUE, 'a') TO ('it''s', MAXVALUE)`: {From: []string{"minvalue", "a"}, To: []string{"it's", "MAXVALUE"}},
+		`FOR VALUES IN ('eu', 'us', NULL)`:                       {In: []string{"eu", "us", "null"}},
+		`FOR VALUES WITH (MODULUS 4, REMAINDER 3)`:               {Modulus: 4, Remainder: 3},
+		`DEFAULT`: {IsDefault: true},
+	}
+	for expected, bound := range valid {
+		clause, err := utils.FormatPartitionBound(&bound)
+		require.NoError(t, err)
+		assert.Equal(t, expected, clause)
+	}
+
+	invalid := []utils.PartitionBound{
+		{},
+		{From: []string{"1"}},
+		{From: []string{"1", "2"}, To: []string{"3"}},
+		{Modulus: 4, Remainder: 4},
+		{In: []string{"eu"}, IsDefault: true},
+	}
+	for _, bound := range invalid {
+		_, err := utils.FormatPartitionBound(&bound)
+		var partitionErr *utils.PartitionError
+		assert.ErrorAs(t, err, &partitionErr, bound)
+	}
+	_, err := utils.FormatPartitionBound(nil)
+	assert.Error(t, err)
+}
+
+func TestGenerateCreateTableDDLPartitioned(t *testing.T) {
+	table := &utils.Table{
+		TableName: "events",
+		Columns: []utils.TableColumn{
+			{ColumnName: "id", DataType: "bigint"},
+			{ColumnName: "created_at", DataType: "timestamp with time zone"},
+		},
+		Constraints: []utils.ConstraintInfo{
+			{ConstraintType: utils.PrimaryKeyConstraint, Columns: []string{"id", "created_at"}},
+		},
+		Partition: &utils.PartitionSpec{Strategy: "range", Columns: []string{"created_at"}, Definition: "RANGE (id); DROP TABLE users"},
+	}
+	ddl, err := utils.GenerateCreateTableDDL(table)
+	require.NoError(t, err)
+	assert.Contains(t, ddl, `CONSTRAINT "events_pkey" PRIMARY KEY ("id", "created_at")`+"\n) PARTITION BY RANGE (\"created_at\");")
+	assert.NotContains(t, ddl, "DROP TABLE")
+
+	table.Partition = &utils.PartitionSpec{Strategy: "list", Columns: []string{"id", "created_at"}}
+	_, err = utils.GenerateCreateTableDDL(table)
+	assert.Error(t, err)
+	table.Partition = &utils.PartitionSpec{Strategy: "hash", Columns: []string{"missing"}}
+	_, err = utils.GenerateCreateTableDDL(table)
+	assert.Error(t, err)
+	table.Partition = &utils.PartitionSpec{Strategy: "interval", Columns: []string{"id"}}
+	_, err = utils.GenerateCreateTableDDL(table)
+	assert.Error(t, err)
+
+	assert.Equal(t, `CREATE TABLE "public"."events_eu" PARTITION OF "public"."events" FOR VALUES IN ('eu') PARTITION BY HASH ("id")`,
+		utils.CreatePartitionStatement(`"public"."events_eu"`, `"public"."events"`, "FOR VALUES IN ('eu')", &utils.PartitionSpec{Strategy: "HASH", Columns: []string{"id"}}))
+}
+
+func TestCompareTableSchemasPartitionKey(t *testing.T) {
+	oldTable := &utils.Table{
+		TableName: "events",
+		Columns:   []utils.TableColumn{{ColumnName: "id", DataType: "bigint"}, {ColumnName: "region", DataType: "text"}},
+		Partition: &utils.PartitionSpec{Strategy: "LIST", Columns: []string{"region"}, Definition: "LIST (region)"},
+	}
+	newTable := &utils.Table{TableName: "events", Columns: oldTable.Columns}
+	_, err := utils.CompareTableSchemas(oldTable, newTable, nil)
+	require.NoError(t, err)
+
+	newTable.Partition = &utils.PartitionSpec{Strategy: "list", Columns: []string{"region"}}
+	_, err = utils.CompareTableSchemas(oldTable, newTable, nil)
+	require.NoError(t, err)
+
+	newTable.Partition = &utils.PartitionSpec{Strategy: "HASH", Columns: []string{"id"}}
+	_, err = utils.CompareTableSchemas(oldTable, newTable, nil)
+	var partitionErr *utils.PartitionError
+	assert.ErrorAs(t, err, &partitionErr)
+}
+
+func TestRenderSchemaDDLPartitions(t *testing.T) {
+	export := &utils.SchemaExport{
+		Schema: "public",
+		Columns: []utils.ExportColumn{
+			{TableName: "events", ColumnName: "id", DataType: "bigint", NotNull: true},
+			{TableName: "events", ColumnName: "created_at", DataType: "timestamp with time zone", NotNull: true},
+			{TableName: "users", ColumnName: "id", DataType: "bigint", NotNull: true},
+		},
+		PartitionKeys: []utils.ExportPartitionKey{
+			{TableName: "events", Definition: "RANGE (created_at)"},
+			{TableName: "events_2025", Definition: "LIST (id)"},
+		},
+		Partitions: []utils.ExportPartition{
+			{Name: "events_2025_a", ParentName: "events_2025", Bound: "FOR VALUES IN ('1')"},
+			{Name: "events_2025", ParentName: "events", Bound: "FOR VALUES FROM ('2025-01-01 00:00:00+00') TO ('2026-01-01 00:00:00+00')"},
+		},
+	}
+	ddl, err := utils.RenderSchemaDDL(export, nil)
+	require.NoError(t, err)
+
+	order := []string{
+		"CREATE TABLE IF NOT EXISTS \"events\" (\n    \"id\" bigint NOT NULL,\n    \"created_at\" timestamp with time zone NOT NULL\n) PARTITION BY RANGE (created_at);",
+		`CREATE TABLE IF NOT EXISTS "users"`,
+		`CREATE TABLE IF NOT EXISTS "events_2025" PARTITION OF "events" FOR VALUES FROM ('2025-01-01 00:00:00+00') TO ('2026-01-01 00:00:00+00') PARTITION BY LIST (id);`,
+		`CREATE TABLE IF NOT EXISTS "events_2025_a" PARTITION OF "events_2025" FOR VALUES IN ('1');`,
+	}
+	last := -1
+	for _, fragment := range order {
+		i := strings.Index(ddl, fragment)
+		require.GreaterOrEqual(t, i, 0, "missing %q", fragment)
+		assert.Greater(t, i, last, "%q is out of order", fragment)
+		last = i
+	}
+
+	ddl, err = utils.RenderSchemaDDL(export, []string{"users"})
+	require.NoError(t, err)
+	assert.NotContains(t, ddl, "PARTITION OF")
+}
@@ -0,0 +1,243 @@
+package utils_test
+
+import (
+	"DBHS/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestIsNarrowingTypeChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      utils.TableColumn
+		new      utils.TableColumn
+		expected bool
+	}{
+		{"int to bigint", utils.TableColumn{DataType: "integer"}, utils.TableColumn{DataType: "bigint"}, false},
+		{"bigint to int", utils.TableColumn{DataType: "bigint"}, utils.TableColumn{DataType: "integer"}, true},
+		{"numeric to int", utils.TableColumn{DataType: "numeric"}, utils.TableColumn{DataType: "integer"}, true},
+		{"anything to text", utils.TableColumn{DataType: "jsonb"}, utils.TableColumn{DataType: "text"}, false},
+		{"text to varchar", utils.TableColumn{DataType: "text"}, utils.TableColumn{DataType: "character varying", CharacterMaximumLength: intPtr(20)}, true},
+		{
+			"longer varchar",
+			utils.TableColumn{DataType: "character varying", CharacterMaximumLength: intPtr(20)},
+			utils.TableColumn{DataType: "character varying", CharacterMaximumLength: intPtr(50)},
+			false,
+		},
+		{
+			"shorter varchar",
+			utils.TableColumn{DataType: "character varying", CharacterMaximumLength: intPtr(50)},
+			utils.TableColumn{DataType: "character varying", CharacterMaximumLength: intPtr(20)},
+			true,
+		},
+		{
+			"smaller numeric scale",
+			utils.TableColumn{DataType: "numeric", NumericPrecision: intPtr(10), NumericScale: intPtr(4)},
+			utils.TableColumn{DataType: "numeric", NumericPrecision: intPtr(10), NumericScale: intPtr(2)},
+			true,
+		},
+		{"text to integer", utils.TableColumn{DataType: "text"}, utils.TableColumn{DataType: "integer"}, true},
+		// aliases of the same type
+		{"int to INTEGER", utils.TableColumn{DataType: "int"}, utils.TableColumn{DataType: "INTEGER"}, false},
+		{"int4 to integer", utils.TableColumn{DataType: "int4"}, utils.TableColumn{DataType: "integer"}, false},
+		{"varchar to CHARACTER VARYING", utils.TableColumn{DataType: "varchar"}, utils.TableColumn{DataType: "CHARACTER VARYING"}, false},
+		{"timestamptz to timestamp with time zone", utils.TableColumn{DataType: "timestamptz"}, utils.TableColumn{DataType: "timestamp with time zone"}, false},
+		{"timestamp to timestamp without time zone", utils.TableColumn{DataType: "timestamp"}, utils.TableColumn{DataType: "timestamp without time zone"}, false},
+		{"bool to boolean", utils.TableColumn{DataType: "bool"}, utils.TableColumn{DataType: "boolean"}, false},
+		{"float8 to double precision", utils.TableColumn{DataType: "float8"}, utils.TableColumn{DataType: "double precision"}, false},
+		{"decimal to numeric", utils.TableColumn{DataType: "decimal"}, utils.TableColumn{DataType: "numeric"}, false},
+		{"serial to int", utils.TableColumn{DataType: "serial"}, utils.TableColumn{DataType: "int"}, false},
+		// lossy conversions between numeric types
+		{"int to real", utils.TableColumn{DataType: "integer"}, utils.TableColumn{DataType: "real"}, true},
+		{"int4 to float4", utils.TableColumn{DataType: "int4"}, utils.TableColumn{DataType: "float4"}, true},
+		{"bigint to real", utils.TableColumn{DataType: "bigint"}, utils.TableColumn{DataType: "real"}, true},
+		{"bigint to double precision", utils.TableColumn{DataType: "bigint"}, utils.TableColumn{DataType: "double precision"}, true},
+		{"int8 to float8", utils.TableColumn{DataType: "int8"}, utils.TableColumn{DataType: "float8"}, true},
+		{"smallint to real", utils.TableColumn{DataType: "smallint"}, utils.TableColumn{DataType: "real"}, false},
+		{"int to double precision", utils.TableColumn{DataType: "integer"}, utils.TableColumn{DataType: "double precision"}, false},
+		{"timestamptz to timestamp", utils.TableColumn{DataType: "timestamptz"}, utils.TableColumn{DataType: "timestamp"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, utils.IsNarrowingTypeChange(tt.old, tt.new))
+		})
+	}
+}
+
+func TestCanonicalTypeName(t *testing.T) {
+	tests := map[string]string{
+		"int":                       "INTEGER",
+		"varchar(20)":               "CHARACTER VARYING",
+		"numeric(10, 2)":            "NUMERIC",
+		"timestamp  with time zone": "TIMESTAMP WITH TIME ZONE",
+		"character varying":         "CHARACTER VARYING",
+		"jsonb":                     "JSONB",
+	}
+	for dataType, expected := range tests {
+		assert.Equal(t, expected, utils.CanonicalTypeName(dataType), dataType)
+	}
+}
+
+func TestDestructiveChanges(t *testing.T) {
+	constraintColumn := "email"
+	oldTable := &utils.Table{
+		TableName: "users",
+		Columns: []utils.TableColumn{
+			{ColumnName: "id", DataType: "bigint"},
+			{ColumnName: "email", DataType: "text"},
+			{ColumnName: "nickname", DataType: "text"},
+			{ColumnName: "age", DataType: "integer"},
+		},
+		Constraints: []utils.ConstraintInfo{
+			{ConstraintName: "users_email_key", ConstraintType: "UNIQUE", ColumnName: &constraintColumn},
+		},
+	}
+	newTable := &utils.Table{
+		TableName: "users",
+		Columns: []utils.TableColumn{
+			{ColumnName: "id", DataType: "integer"},
+			{ColumnName: "mail", DataType: "text"},
+			{ColumnName: "age", DataType: "bigint"},
+		},
+	}
+	renames := []utils.RenameRelation{{OldName: "email", NewName: "mail"}}
+
+	changes := utils.DestructiveChanges(oldTable, newTable, renames)
+
+	operations := make(map[string]string)
+	for _, change := range changes {
+		operations[change.Column+change.Constraint] = change.Operation
+	}
+	assert.Equal(t, map[string]string{
+		"id":              utils.NarrowTypeOperation,
+		"nickname":        utils.DropColumnOperation,
+		"users_email_key": utils.DropConstraintOperation,
+	}, operations)
+}
+
+func TestGenerateCreateTableDDLUserDefinedTypes(t *testing.T) {
+	table := &utils.Table{
+		TableName: "orders",
+		Columns: []utils.TableColumn{
+			{ColumnName: "status", DataType: "USER-DEFINED", UdtName: "OrderStatus"},
+			{ColumnName: "tags", DataType: "ARRAY", UdtName: "_text"},
+		},
+	}
+	ddl, err := utils.GenerateCreateTableDDL(table)
+	require.NoError(t, err)
+	assert.Contains(t, ddl, `"status" "OrderStatus"`)
+	assert.Contains(t, ddl, `"tags" text[]`)
+}
+
+func TestMergeConstraints(t *testing.T) {
+	// composite foreign keys read per column come back as the cross product of both column lists
+	rows := []utils.ConstraintInfo{
+		{ConstraintName: "items_order_fkey", ConstraintType: "FOREIGN KEY", ColumnName: strPtr("order_id"), ForeignTableName: strPtr("orders"), ForeignColumnName: strPtr("id")},
+		{ConstraintName: "items_order_fkey", ConstraintType: "FOREIGN KEY", ColumnName: strPtr("order_id"), ForeignTableName: strPtr("orders"), ForeignColumnName: strPtr("tenant_id")},
+		{ConstraintName: "items_order_fkey", ConstraintType: "FOREIGN KEY", ColumnName: strPtr("tenant_id"), ForeignTableName: strPtr("orders"), ForeignColumnName: strPtr("id")},
+		{ConstraintName: "items_order_fkey", ConstraintType: "FOREIGN KEY", ColumnName: strPtr("tenant_id"), ForeignTableName: strPtr("orders"), ForeignColumnName: strPtr("tenant_id")},
+		{ConstraintType: "unique", Columns: []string{"order_id", "line"}},
+		{ConstraintType: "CHECK", CheckClause: strPtr("line > 0")},
+		{ConstraintName: "2200_1_not_null", ConstraintType: "NOT NULL"},
+	}
+
+	merged := utils.MergeConstraints("items", rows)
+	require.Len(t, merged, 3)
+	assert.Equal(t, []string{"order_id", "tenant_id"}, merged[0].Columns)
+	assert.Equal(t, []string{"id", "tenant_id"}, merged[0].ForeignColumns)
+	assert.Equal(t, utils.NoAction, merged[0].OnDelete)
+	assert.Equal(t, "order_id", *merged[0].ColumnName)
+	assert.Equal(t, "items_order_id_line_key", merged[1].ConstraintName)
+	assert.Equal(t, "UNIQUE", merged[1].ConstraintType)
+	assert.Equal(t, "items_check", merged[2].ConstraintName)
+}
+
+func TestGenerateCreateTableDDLCompositeConstraints(t *testing.T) {
+	table := &utils.Table{
+		TableName: "order_items",
+		Columns: []utils.TableColumn{
+			{ColumnName: "order_id", DataType: "bigint"},
+			{ColumnName: "tenant_id", DataType: "bigint"},
+			{ColumnName: "line", DataType: "integer", ColumnDefault: strPtr("1")},
+		},
+		Constraints: []utils.ConstraintInfo{
+			{ConstraintType: "PRIMARY KEY", Columns: []string{"order_id", "tenant_id", "line"}},
+			{ConstraintName: "order_items_order_fkey", ConstraintType: "FOREIGN KEY", Columns: []string{"order_id", "tenant_id"},
+				ForeignTableName: strPtr("orders"), ForeignColumns: []string{"id", "tenant_id"}, OnDelete: "cascade", OnUpdate: "restrict"},
+			{ConstraintName: "line_positive", ConstraintType: "CHECK", CheckClause: strPtr("line > 0")},
+		},
+	}
+	ddl, err := utils.GenerateCreateTableDDL(table)
+	require.NoError(t, err)
+	assert.Contains(t, ddl, `"line" INTEGER NOT NULL DEFAULT 1`)
+	assert.Contains(t, ddl, `CONSTRAINT "order_items_pkey" PRIMARY KEY ("order_id", "tenant_id", "line")`)
+	assert.Contains(t, ddl, `CONSTRAINT "order_items_order_fkey" FOREIGN KEY ("order_id", "tenant_id") REFERENCES "orders" ("id", "tenant_id") ON DELETE CASCADE ON UPDATE RESTRICT`)
+	assert.Contains(t, ddl, `CONSTRAINT "line_positive" CHECK (line > 0)`)
+
+	table.Constraints = append(table.Constraints, utils.ConstraintInfo{
+		ConstraintName: "order_items_user_fkey", ConstraintType: "FOREIGN KEY", Columns: []string{"order_id"},
+		ForeignTableName: strPtr("users"), ForeignColumns: []string{"id", "tenant_id"},
+	})
+	_, err = utils.GenerateCreateTableDDL(table)
+	var constraintErr *utils.ConstraintError
+	assert.ErrorAs(t, err, &constraintErr)
+}
+
+func TestCompareTableSchemasConstraints(t *testing.T) {
+	oldTable := &utils.Table{
+		TableName: "order_items",
+		Columns:   []utils.TableColumn{{ColumnName: "order_id", DataType: "bigint"}, {ColumnName: "line", DataType: "integer"}},
+		Constraints: []utils.ConstraintInfo{
+			{ConstraintName: "order_items_pkey", ConstraintType: "PRIMARY KEY", Columns: []string{"order_id", "line"}},
+			{ConstraintName: "order_items_order_fkey", ConstraintType: "FOREIGN KEY", Columns: []string{"order_id"},
+				ForeignTableName: strPtr("orders"), ForeignColumns: []string{"id"}, OnDelete: "NO ACTION", OnUpdate: "NO ACTION"},
+			{ConstraintName: "line_positive", ConstraintType: "CHECK", Columns: []string{"line"}, CheckClause: strPtr("(line > 0)")},
+		},
+	}
+	newTable := &utils.Table{
+		TableName: "order_items",
+		Columns:   oldTable.Columns,
+		Constraints: []utils.ConstraintInfo{
+			{ConstraintName: "order_items_pkey", ConstraintType: "PRIMARY KEY", Columns: []string{"order_id", "line"}},
+			{ConstraintName: "order_items_order_fkey", ConstraintType: "FOREIGN KEY", Columns: []string{"order_id"},
+				ForeignTableName: strPtr("orders"), ForeignColumns: []string{"id"}, OnDelete: "CASCADE"},
+			{ConstraintName: "line_positive", ConstraintType: "CHECK", CheckClause: strPtr("line > 0")},
+		},
+	}
+
+	ddl, err := utils.CompareTableSchemas(oldTable, newTable, nil)
+	require.NoError(t, err)
+	assert.Contains(t, ddl, `ALTER TABLE "order_items" DROP CONSTRAINT IF EXISTS "order_items_order_fkey";`)
+	assert.Contains(t, ddl, `ALTER TABLE "order_items" ADD CONSTRAINT "order_items_order_fkey" FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;`)
+	assert.NotContains(t, ddl, "order_items_pkey")
+	assert.NotContains(t, ddl, "line_positive")
+}
+
+func TestValidateConstraintsCheckExpression(t *testing.T) {
+	valid := []string{"line > 0", "(total >= (0)::numeric)", "status IN ('open', 'paid') AND line < 100"}
+	for _, clause := range valid {
+		constraints := []utils.ConstraintInfo{{ConstraintName: "c", ConstraintType: "CHECK", CheckClause: strPtr(clause)}}
+		assert.NoError(t, utils.ValidateConstraints(constraints), clause)
+	}
+
+	invalid := []string{
+		"true); DROP TABLE users; --",
+		"true) OR (false",
+		"true; DROP TABLE users",
+		"1 FROM pg_authid",
+		"pg_read_file('/etc/passwd') <> ''",
+		"_dbhs.track() IS NULL",
+	}
+	for _, clause := range invalid {
+		constraints := []utils.ConstraintInfo{{ConstraintName: "c", ConstraintType: "CHECK", CheckClause: strPtr(clause)}}
+		var constraintErr *utils.ConstraintError
+		assert.ErrorAs(t, utils.ValidateConstraints(constraints), &constraintErr, clause)
+	}
+}
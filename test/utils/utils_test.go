@@ -0,0 +1,54 @@
+package utils_test
+
+import (
+	"DBHS/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBoolParameter(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?cascade=true&dry_run=maybe", nil)
+
+	value, err := utils.ParseBoolParameter(r, "cascade")
+	assert.NoError(t, err)
+	assert.True(t, value)
+
+	value, err = utils.ParseBoolParameter(r, "concurrently")
+	assert.NoError(t, err)
+	assert.False(t, value)
+
+	_, err = utils.ParseBoolParameter(r, "dry_run")
+	assert.Error(t, err)
+}
+
+func TestDatabaseError(t *testing.T) {
+	conflicts := map[string]string{"42P07": "A relation with the same name already exists", "42723": ""}
+	pgError := func(code string) error {
+		return fmt.Errorf("statement failed: %w", &pgconn.PgError{Code: code, Message: "database message"})
+	}
+
+	cases := []struct {
+		err     error
+		status  int
+		message string
+	}{
+		{pgError("42P07"), http.StatusConflict, "A relation with the same name already exists"},
+		{pgError("42723"), http.StatusConflict, "database message"},
+		{pgError("42601"), http.StatusBadRequest, "database message"},
+		{pgError("22P02"), http.StatusBadRequest, "database message"},
+		{pgError("55000"), http.StatusBadRequest, "database message"},
+		{pgError("53300"), http.StatusInternalServerError, "Failed"},
+		{errors.New("connection refused"), http.StatusInternalServerError, "Failed"},
+	}
+	for _, c := range cases {
+		apiErr := utils.DatabaseError(c.err, "Failed", conflicts)
+		assert.Equal(t, c.status, apiErr.StatusCode, c.err.Error())
+		assert.Equal(t, c.message, apiErr.Message, c.err.Error())
+	}
+}
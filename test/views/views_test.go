@@ -0,0 +1,72 @@
+package views_test
+
+import (
+	"DBHS/views"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateViewDefinition(t *testing.T) {
+	definition, err := views.ValidateViewDefinition("  SELECT id, name FROM users WHERE active;  ")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id, name FROM users WHERE active", definition)
+
+	definition, err = views.ValidateViewDefinition("SELECT 1 UNION ALL SELECT 2")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1 UNION ALL SELECT 2", definition)
+
+	_, err = views.ValidateViewDefinition("SELECT 1; SELECT 2")
+	assert.ErrorIs(t, err, views.ErrNotSelect)
+
+	_, err = views.ValidateViewDefinition("DELETE FROM users")
+	assert.ErrorIs(t, err, views.ErrNotSelect)
+
+	_, err = views.ValidateViewDefinition("SELECT * INTO copy FROM users")
+	assert.ErrorIs(t, err, views.ErrSelectInto)
+
+	_, err = views.ValidateViewDefinition("SELECT FROM WHERE")
+	assert.Error(t, err)
+
+	for definition, reason := range map[string]string{
+		"SELECT pg_read_file('/etc/passwd')":                            "function pg_read_file is not allowed",
+		"SELECT * FROM dblink('host=10.0.0.1', 'SELECT 1') AS t(x int)": "function dblink is not allowed",
+		"SELECT * FROM _dbhs.migrations":                                "schema _dbhs can't be referenced",
+		"SELECT id FROM users WHERE id IN (SELECT _dbhs.track())":       "schema _dbhs can't be referenced",
+	} {
+		_, err = views.ValidateViewDefinition(definition)
+		assert.EqualError(t, err, reason, definition)
+	}
+}
+
+func TestGenerateViewQueries(t *testing.T) {
+	request := &views.CreateViewRequest{Name: "active_users", Definition: "SELECT 1"}
+	assert.Equal(t, `CREATE VIEW "public"."active_users" AS SELECT 1`, views.GenerateCreateViewQuery(request, "SELECT 1"))
+
+	withData := false
+	request = &views.CreateViewRequest{Name: "totals", Schema: "sales", Materialized: true, WithData: &withData}
+	assert.Equal(t, `CREATE MATERIALIZED VIEW "sales"."totals" AS SELECT 1 WITH NO DATA`, views.GenerateCreateViewQuery(request, "SELECT 1"))
+
+	view := &views.View{Name: "totals", Schema: "sales", Materialized: true}
+	assert.Equal(t, `DROP MATERIALIZED VIEW "sales"."totals" CASCADE`, views.GenerateDropViewQuery(view, true))
+	assert.Equal(t, `REFRESH MATERIALIZED VIEW CONCURRENTLY "sales"."totals"`, views.GenerateRefreshViewQuery("sales", "totals", true))
+	assert.Equal(t, `REFRESH MATERIALIZED VIEW "sales"."totals"`, views.GenerateRefreshViewQuery("sales", "totals", false))
+}
+
+func TestIsRefreshDue(t *testing.T) {
+	schedule, err := views.ParseSchedule("*/15 * * * *")
+	require.NoError(t, err)
+
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	assert.False(t, views.IsRefreshDue(schedule, nil, created, created.Add(10*time.Minute)))
+	assert.True(t, views.IsRefreshDue(schedule, nil, created, created.Add(15*time.Minute)))
+
+	last := created.Add(15 * time.Minute)
+	assert.False(t, views.IsRefreshDue(schedule, &last, created, created.Add(20*time.Minute)))
+	assert.True(t, views.IsRefreshDue(schedule, &last, created, created.Add(31*time.Minute)))
+
+	_, err = views.ParseSchedule("every minute")
+	assert.Error(t, err)
+}
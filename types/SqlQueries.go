@@ -0,0 +1,31 @@
+package types
+
+import "DBHS/utils"
+
+const typeColumns = `
+		SELECT t.typname AS name, n.nspname AS schema,
+			CASE t.typtype WHEN 'e' THEN 'enum' ELSE 'composite' END AS kind,
+			COALESCE((SELECT array_agg(e.enumlabel ORDER BY e.enumsortorder) FROM pg_enum e WHERE e.enumtypid = t.oid), '{}') AS values,
+			COALESCE((SELECT json_agg(json_build_object('name', a.attname, 'type', format_type(a.atttypid, a.atttypmod)) ORDER BY a.attnum)
+				FROM pg_attribute a WHERE a.attrelid = t.typrelid AND a.attnum > 0 AND NOT a.attisdropped), '[]') AS attributes
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		LEFT JOIN pg_class c ON c.oid = t.typrelid
+		WHERE (t.typtype = 'e' OR (t.typtype = 'c' AND c.relkind = 'c'))
+			AND ` + utils.UserSchemasFilter
+
+const (
+	// an empty $1 lists the types of every user schema
+	SELECT_TYPES = typeColumns + ` AND ($1 = '' OR n.nspname = $1) ORDER BY n.nspname, t.typname`
+
+	SELECT_TYPE = typeColumns + ` AND n.nspname = $1 AND t.typname = $2`
+
+	CREATE_ENUM       = `CREATE TYPE %s AS ENUM (%s)`
+	CREATE_COMPOSITE  = `CREATE TYPE %s AS (%s)`
+	DROP_TYPE         = `DROP TYPE %s %s`
+	ADD_ENUM_VALUE    = `ALTER TYPE %s ADD VALUE IF NOT EXISTS %s`
+	RENAME_ENUM_VALUE = `ALTER TYPE %s RENAME VALUE %s TO %s`
+	ADD_ATTRIBUTE     = `ALTER TYPE %s ADD ATTRIBUTE %s %s`
+	RENAME_ATTRIBUTE  = `ALTER TYPE %s RENAME ATTRIBUTE %s TO %s`
+	DROP_ATTRIBUTE    = `ALTER TYPE %s DROP ATTRIBUTE %s`
+)
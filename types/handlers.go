@@ -0,0 +1,195 @@
+package types
+
+import (
+	"DBHS/config"
+	"DBHS/response"
+	"DBHS/utils"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ListTypesHandler godoc
+// @Summary List custom types
+// @Description List the enum and composite types of the project database with their values and attributes
+// @Tags types
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the types of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]CustomType} "Types retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/types [get]
+func ListTypesHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		types, apiErr := ListTypes(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Types retrieved successfully", types)
+	}
+}
+
+// CreateTypeHandler godoc
+// @Summary Create a custom type
+// @Description Create an enum type from its values or a composite type from its attributes. Attribute types are checked against the types of the project
+// @Tags types
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param type body CreateTypeRequest true "Type definition"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse "Type created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid type definition or unknown attribute type"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 409 {object} response.ErrorResponse "A type with the same name already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/types [post]
+func CreateTypeHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		var request CreateTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		if apiErr := CreateType(r.Context(), config.DB, projectOid, &request); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to create type:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.Created(w, r, "Type created successfully", map[string]string{
+			"schema": request.Schema,
+			"name":   request.Name,
+		})
+	}
+}
+
+// GetTypeHandler godoc
+// @Summary Get a custom type
+// @Description Get the values of an enum type or the attributes of a composite type
+// @Tags types
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param type_name path string true "Type name"
+// @Param schema query string false "Schema of the type, defaults to public"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=CustomType} "Type retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or type not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/types/{type_name} [get]
+func GetTypeHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, typeName, ok := typeVariables(w, r)
+		if !ok {
+			return
+		}
+
+		customType, apiErr := GetProjectType(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"), typeName)
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Type retrieved successfully", customType)
+	}
+}
+
+// AlterTypeHandler godoc
+// @Summary Alter a custom type
+// @Description Add or rename the values of an enum type, or add, rename and drop the attributes of a composite type. Enum values can't be removed
+// @Tags types
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param type_name path string true "Type name"
+// @Param schema query string false "Schema of the type, defaults to public"
+// @Param changes body AlterTypeRequest true "Type changes"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Type altered successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid changes"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or type not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/types/{type_name} [patch]
+func AlterTypeHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, typeName, ok := typeVariables(w, r)
+		if !ok {
+			return
+		}
+
+		var request AlterTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		if apiErr := AlterType(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"), typeName, &request); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to alter type:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Type altered successfully", nil)
+	}
+}
+
+// DropTypeHandler godoc
+// @Summary Drop a custom type
+// @Description Drop an enum or composite type, with cascade the columns and objects that use it are dropped as well
+// @Tags types
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param type_name path string true "Type name"
+// @Param schema query string false "Schema of the type, defaults to public"
+// @Param cascade query bool false "Drop the objects that use the type as well"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "Type dropped successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or type not found"
+// @Failure 409 {object} response.ErrorResponse "Other objects use the type"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/types/{type_name} [delete]
+func DropTypeHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, typeName, ok := typeVariables(w, r)
+		if !ok {
+			return
+		}
+
+		cascade, err := utils.ParseBoolParameter(r, "cascade")
+		if err != nil {
+			response.BadRequest(w, r, "cascade must be a boolean", err)
+			return
+		}
+
+		if apiErr := DropType(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"), typeName, cascade); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to drop type:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Type dropped successfully", nil)
+	}
+}
@@ -0,0 +1,48 @@
+package types
+
+import "DBHS/utils"
+
+const (
+	KindEnum      = "enum"
+	KindComposite = "composite"
+)
+
+// CustomType is an enum or composite type of a project database
+type CustomType struct {
+	Name       string      `json:"name" db:"name"`
+	Schema     string      `json:"schema" db:"schema"`
+	Kind       string      `json:"kind" db:"kind"`
+	Values     []string    `json:"values,omitempty" db:"values"`
+	Attributes []Attribute `json:"attributes,omitempty" db:"attributes"`
+}
+
+// Attribute is a field of a composite type
+type Attribute struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type CreateTypeRequest struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+	// Kind is either enum or composite
+	Kind       string      `json:"kind"`
+	Values     []string    `json:"values,omitempty"`
+	Attributes []Attribute `json:"attributes,omitempty"`
+}
+
+// EnumValue is a new enum value, it is appended unless Before or After names an existing value
+type EnumValue struct {
+	Value  string `json:"value"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// AlterTypeRequest holds the changes of a type, values for enums and attributes for composite types
+type AlterTypeRequest struct {
+	AddValues        []EnumValue            `json:"add_values,omitempty"`
+	RenameValues     []utils.RenameRelation `json:"rename_values,omitempty"`
+	AddAttributes    []Attribute            `json:"add_attributes,omitempty"`
+	RenameAttributes []utils.RenameRelation `json:"rename_attributes,omitempty"`
+	DropAttributes   []string               `json:"drop_attributes,omitempty"`
+}
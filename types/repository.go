@@ -0,0 +1,30 @@
+package types
+
+import (
+	"DBHS/utils"
+	"context"
+	"errors"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+func GetTypes(ctx context.Context, db utils.Querier, schema string) ([]CustomType, error) {
+	var types []CustomType
+	if err := pgxscan.Select(ctx, db, &types, SELECT_TYPES, schema); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// GetType returns nil when the type doesn't exist
+func GetType(ctx context.Context, db utils.Querier, schema, name string) (*CustomType, error) {
+	var customType CustomType
+	if err := pgxscan.Get(ctx, db, &customType, SELECT_TYPE, schema, name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customType, nil
+}
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"DBHS/config"
+	"DBHS/middleware"
+	"net/http"
+)
+
+func DefineURLs() {
+	router := config.Router.PathPrefix("/api/projects/{project_id}/types").Subrouter()
+	router.Use(middleware.JwtAuthMiddleware, middleware.CheckOwnership)
+
+	router.Handle("", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:  ListTypesHandler(config.App),
+		http.MethodPost: CreateTypeHandler(config.App),
+	}))
+
+	router.Handle("/{type_name}", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:    GetTypeHandler(config.App),
+		http.MethodPatch:  AlterTypeHandler(config.App),
+		http.MethodDelete: DropTypeHandler(config.App),
+	}))
+}
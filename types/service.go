@@ -0,0 +1,180 @@
+package types
+
+import (
+	"DBHS/config"
+	"DBHS/utils"
+	api "DBHS/utils/apiError"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	duplicateObjectCode  = "42710"
+	dependentObjectsCode = "2BP01"
+)
+
+// conflicts are the errors of the type statements answered with 409
+var conflicts = map[string]string{
+	duplicateObjectCode:  "A type with the same name already exists",
+	dependentObjectsCode: "Other objects use the type, use cascade to drop them",
+}
+
+func ListTypes(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]CustomType, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	types, err := GetTypes(ctx, userDb, schema)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if types == nil {
+		types = make([]CustomType, 0)
+	}
+	return types, api.ApiError{}
+}
+
+func GetProjectType(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string) (*CustomType, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+	return findType(ctx, userDb, schema, name)
+}
+
+func CreateType(ctx context.Context, db *pgxpool.Pool, projectOid string, request *CreateTypeRequest) api.ApiError {
+	if err := ValidateCreateType(request); err != nil {
+		return *api.NewApiError(err.Error(), 400, err)
+	}
+	request.Schema = utils.SchemaOrDefault(request.Schema)
+
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	// attribute types resolve in the schema of the new type
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, request.Schema); err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if apiErr := validateAttributes(ctx, tx, request.Attributes); apiErr.Error() != nil {
+		return apiErr
+	}
+
+	if _, err := tx.Exec(ctx, GenerateCreateTypeQuery(request)); err != nil {
+		return utils.DatabaseError(err, "Failed to create type", conflicts)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return utils.DatabaseError(err, "Failed to create type", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Type %s created in project %s", utils.QualifiedName(request.Schema, request.Name), projectOid)
+	return api.ApiError{}
+}
+
+// AlterType adds or renames the values of an enum, or adds, renames and drops the attributes of a composite type.
+// all the changes are applied in one transaction
+func AlterType(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string, request *AlterTypeRequest) api.ApiError {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	customType, apiErr := findType(ctx, userDb, schema, name)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+
+	queries, err := GenerateAlterTypeQueries(customType, request)
+	if err != nil {
+		return *api.NewApiError(err.Error(), 400, err)
+	}
+
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, customType.Schema); err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if apiErr := validateAttributes(ctx, tx, request.AddAttributes); apiErr.Error() != nil {
+		return apiErr
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query); err != nil {
+			return utils.DatabaseError(err, "Failed to alter type", conflicts)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return utils.DatabaseError(err, "Failed to alter type", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Type %s altered in project %s", utils.QualifiedName(customType.Schema, customType.Name), projectOid)
+	return api.ApiError{}
+}
+
+func DropType(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string, cascade bool) api.ApiError {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	customType, apiErr := findType(ctx, userDb, schema, name)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+
+	if _, err := userDb.Exec(ctx, GenerateDropTypeQuery(customType.Schema, customType.Name, cascade)); err != nil {
+		return utils.DatabaseError(err, "Failed to drop type", conflicts)
+	}
+
+	config.App.InfoLog.Printf("Type %s dropped in project %s", utils.QualifiedName(customType.Schema, customType.Name), projectOid)
+	return api.ApiError{}
+}
+
+func validateAttributes(ctx context.Context, tx pgx.Tx, attributes []Attribute) api.ApiError {
+	for _, attribute := range attributes {
+		exists, err := utils.TypeExists(ctx, tx, attribute.Type)
+		if err != nil {
+			return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		}
+		if !exists {
+			typeErr := &utils.UnknownTypeError{Column: attribute.Name, Type: attribute.Type}
+			return *api.NewApiError(typeErr.Error(), 400, typeErr)
+		}
+	}
+	return api.ApiError{}
+}
+
+func findType(ctx context.Context, userDb *pgxpool.Pool, schema, name string) (*CustomType, api.ApiError) {
+	customType, err := GetType(ctx, userDb, utils.SchemaOrDefault(schema), name)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if customType == nil {
+		return nil, *api.NewApiError("Type not found", 404, errors.New("type "+name+" does not exist"))
+	}
+	return customType, api.ApiError{}
+}
@@ -0,0 +1,169 @@
+package types
+
+import (
+	"DBHS/response"
+	"DBHS/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrInvalidKind      = errors.New("kind must be enum or composite")
+	ErrNoValues         = errors.New("an enum type needs at least one value")
+	ErrNoAttributes     = errors.New("a composite type needs at least one attribute")
+	ErrNoChanges        = errors.New("no changes were given")
+	ErrEnumChanges      = errors.New("attributes can only be changed on composite types")
+	ErrCompositeChanges = errors.New("values can only be changed on enum types")
+)
+
+// ValidateCreateType checks the shape of a new type, the attribute types are resolved by the database
+func ValidateCreateType(request *CreateTypeRequest) error {
+	if request.Name == "" {
+		return errors.New("type name is required")
+	}
+	if utils.IsReservedSchema(utils.SchemaOrDefault(request.Schema)) {
+		return fmt.Errorf("schema %s is reserved", request.Schema)
+	}
+	switch request.Kind {
+	case KindEnum:
+		if len(request.Values) == 0 {
+			return ErrNoValues
+		}
+		if err := checkDuplicates(request.Values, "value"); err != nil {
+			return err
+		}
+	case KindComposite:
+		if len(request.Attributes) == 0 {
+			return ErrNoAttributes
+		}
+		names := make([]string, len(request.Attributes))
+		for i, attribute := range request.Attributes {
+			if attribute.Name == "" || attribute.Type == "" {
+				return errors.New("every attribute needs a name and a type")
+			}
+			names[i] = attribute.Name
+		}
+		if err := checkDuplicates(names, "attribute"); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidKind
+	}
+	return nil
+}
+
+func checkDuplicates(names []string, object string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("%s names can't be empty", object)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate %s %s", object, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// GenerateCreateTypeQuery creates an enum or composite type
+// CREATE TYPE "schema"."name" AS ENUM ('a', 'b') | CREATE TYPE "schema"."name" AS ("a" type, ...)
+func GenerateCreateTypeQuery(request *CreateTypeRequest) string {
+	name := utils.QualifiedName(request.Schema, request.Name)
+	if request.Kind == KindEnum {
+		values := make([]string, len(request.Values))
+		for i, value := range request.Values {
+			values[i] = utils.QuoteLiteral(value)
+		}
+		return fmt.Sprintf(CREATE_ENUM, name, strings.Join(values, ", "))
+	}
+	attributes := make([]string, len(request.Attributes))
+	for i, attribute := range request.Attributes {
+		attributes[i] = utils.QuoteIdentifier(attribute.Name) + " " + attribute.Type
+	}
+	return fmt.Sprintf(CREATE_COMPOSITE, name, strings.Join(attributes, ", "))
+}
+
+// GenerateAlterTypeQueries returns the ALTER TYPE statements of the requested changes.
+// renames run before the additions so a new value can take the old name of a renamed one
+func GenerateAlterTypeQueries(customType *CustomType, request *AlterTypeRequest) ([]string, error) {
+	name := utils.QualifiedName(customType.Schema, customType.Name)
+	valueChanges := len(request.AddValues) + len(request.RenameValues)
+	attributeChanges := len(request.AddAttributes) + len(request.RenameAttributes) + len(request.DropAttributes)
+	if valueChanges+attributeChanges == 0 {
+		return nil, ErrNoChanges
+	}
+
+	queries := make([]string, 0, valueChanges+attributeChanges)
+	if customType.Kind == KindEnum {
+		if attributeChanges > 0 {
+			return nil, ErrEnumChanges
+		}
+		for _, rename := range request.RenameValues {
+			if rename.OldName == "" || rename.NewName == "" {
+				return nil, errors.New("renamed values need the old and the new value")
+			}
+			queries = append(queries, fmt.Sprintf(RENAME_ENUM_VALUE, name, utils.QuoteLiteral(rename.OldName), utils.QuoteLiteral(rename.NewName)))
+		}
+		for _, value := range request.AddValues {
+			if value.Value == "" {
+				return nil, errors.New("enum values can't be empty")
+			}
+			if value.Before != "" && value.After != "" {
+				return nil, errors.New("a value can be placed either before or after another value")
+			}
+			query := fmt.Sprintf(ADD_ENUM_VALUE, name, utils.QuoteLiteral(value.Value))
+			if value.Before != "" {
+				query += " BEFORE " + utils.QuoteLiteral(value.Before)
+			} else if value.After != "" {
+				query += " AFTER " + utils.QuoteLiteral(value.After)
+			}
+			queries = append(queries, query)
+		}
+		return queries, nil
+	}
+
+	if valueChanges > 0 {
+		return nil, ErrCompositeChanges
+	}
+	for _, attribute := range request.DropAttributes {
+		queries = append(queries, fmt.Sprintf(DROP_ATTRIBUTE, name, utils.QuoteIdentifier(attribute)))
+	}
+	for _, rename := range request.RenameAttributes {
+		if rename.OldName == "" || rename.NewName == "" {
+			return nil, errors.New("renamed attributes need the old and the new name")
+		}
+		queries = append(queries, fmt.Sprintf(RENAME_ATTRIBUTE, name, utils.QuoteIdentifier(rename.OldName), utils.QuoteIdentifier(rename.NewName)))
+	}
+	for _, attribute := range request.AddAttributes {
+		if attribute.Name == "" || attribute.Type == "" {
+			return nil, errors.New("every attribute needs a name and a type")
+		}
+		queries = append(queries, fmt.Sprintf(ADD_ATTRIBUTE, name, utils.QuoteIdentifier(attribute.Name), attribute.Type))
+	}
+	return queries, nil
+}
+
+// DROP TYPE "schema"."name" RESTRICT|CASCADE
+func GenerateDropTypeQuery(schema, name string, cascade bool) string {
+	behavior := "RESTRICT"
+	if cascade {
+		behavior = "CASCADE"
+	}
+	return fmt.Sprintf(DROP_TYPE, utils.QualifiedName(schema, name), behavior)
+}
+
+// typeVariables reads the project and type of the url, it writes the error response when one is missing
+func typeVariables(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	urlVariables := mux.Vars(r)
+	projectOid, typeName := urlVariables["project_id"], urlVariables["type_name"]
+	if projectOid == "" || typeName == "" {
+		response.BadRequest(w, r, "Project Id and type name are required", nil)
+		return "", "", false
+	}
+	return projectOid, typeName, true
+}
@@ -0,0 +1,324 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+const (
+	PrimaryKeyConstraint = "PRIMARY KEY"
+	UniqueConstraint     = "UNIQUE"
+	ForeignKeyConstraint = "FOREIGN KEY"
+	CheckConstraint      = "CHECK"
+
+	// NoAction is the referential action of a foreign key that doesn't name one
+	NoAction = "NO ACTION"
+)
+
+var referentialActions = []string{NoAction, "RESTRICT", "CASCADE", "SET NULL", "SET DEFAULT"}
+
+// KeyColumns returns the columns of the constraint, a constraint that only sets ColumnName
+// covers that single column
+func (c *ConstraintInfo) KeyColumns() []string {
+	if len(c.Columns) > 0 {
+		return c.Columns
+	}
+	if c.ColumnName != nil && *c.ColumnName != "" {
+		return []string{*c.ColumnName}
+	}
+	return nil
+}
+
+// ReferencedColumns returns the columns of the referenced table of a foreign key, empty when the
+// foreign key references the primary key
+func (c *ConstraintInfo) ReferencedColumns() []string {
+	if len(c.ForeignColumns) > 0 {
+		return c.ForeignColumns
+	}
+	if c.ForeignColumnName != nil && *c.ForeignColumnName != "" {
+		return []string{*c.ForeignColumnName}
+	}
+	return nil
+}
+
+// MergeConstraints returns one entry per constraint. Entries that share a constraint name are
+// merged into a multi-column constraint (the way information_schema returns composite keys),
+// unnamed constraints get the name postgres would give them and the referential actions are normalized.
+// NOT NULL entries are skipped, nullability belongs to the columns
+func MergeConstraints(tableName string, constraints []ConstraintInfo) []ConstraintInfo {
+	merged := make([]ConstraintInfo, 0, len(constraints))
+	byName := make(map[string]int)
+	used := make(map[string]bool)
+	for _, constraint := range constraints {
+		if constraint.ConstraintName != "" {
+			used[constraint.ConstraintName] = true
+		}
+	}
+
+	for _, constraint := range constraints {
+		constraint.ConstraintType = strings.ToUpper(strings.TrimSpace(constraint.ConstraintType))
+		if constraint.ConstraintType == "NOT NULL" {
+			continue
+		}
+
+		if i, exists := byName[constraint.ConstraintName]; exists && constraint.ConstraintName != "" {
+			// composite keys read row by row come back as the cross product of both column lists
+			for _, column := range constraint.KeyColumns() {
+				if !slices.Contains(merged[i].Columns, column) {
+					merged[i].Columns = append(merged[i].Columns, column)
+				}
+			}
+			for _, column := range constraint.ReferencedColumns() {
+				if !slices.Contains(merged[i].ForeignColumns, column) {
+					merged[i].ForeignColumns = append(merged[i].ForeignColumns, column)
+				}
+			}
+			continue
+		}
+
+		constraint.Columns = slices.Clone(constraint.KeyColumns())
+		constraint.ForeignColumns = slices.Clone(constraint.ReferencedColumns())
+		if constraint.ConstraintType == ForeignKeyConstraint {
+			constraint.OnDelete = normalizeAction(constraint.OnDelete)
+			constraint.OnUpdate = normalizeAction(constraint.OnUpdate)
+		}
+		if constraint.ConstraintName == "" {
+			constraint.ConstraintName = constraintName(tableName, &constraint, used)
+			used[constraint.ConstraintName] = true
+		}
+		byName[constraint.ConstraintName] = len(merged)
+		merged = append(merged, constraint)
+	}
+
+	for i := range merged {
+		merged[i].ColumnName, merged[i].ForeignColumnName = nil, nil
+		if len(merged[i].Columns) > 0 {
+			merged[i].ColumnName = &merged[i].Columns[0]
+		}
+		if len(merged[i].ForeignColumns) > 0 {
+			merged[i].ForeignColumnName = &merged[i].ForeignColumns[0]
+		}
+	}
+	return merged
+}
+
+// constraintName follows the postgres naming of constraints: table_pkey, table_columns_key,
+// table_columns_fkey and table_check, a number is appended when the name is taken
+func constraintName(tableName string, constraint *ConstraintInfo, used map[string]bool) string {
+	var name string
+	switch constraint.ConstraintType {
+	case PrimaryKeyConstraint:
+		name = tableName + "_pkey"
+	case UniqueConstraint:
+		name = strings.Join(append([]string{tableName}, constraint.KeyColumns()...), "_") + "_key"
+	case ForeignKeyConstraint:
+		name = strings.Join(append([]string{tableName}, constraint.KeyColumns()...), "_") + "_fkey"
+	default:
+		name = tableName + "_check"
+	}
+	candidate := name
+	for i := 1; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	return candidate
+}
+
+// IsReferentialAction reports whether action is a referential action of a foreign key, an empty
+// action is NO ACTION
+func IsReferentialAction(action string) bool {
+	return slices.Contains(referentialActions, normalizeAction(action))
+}
+
+func normalizeAction(action string) string {
+	action = strings.Join(strings.Fields(strings.ToUpper(action)), " ")
+	if action == "" {
+		return NoAction
+	}
+	return action
+}
+
+// ConstraintError is returned when a constraint of a table definition can't be turned into DDL
+type ConstraintError struct {
+	Constraint string
+	Reason     string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("constraint %s %s", e.Constraint, e.Reason)
+}
+
+// ValidateConstraints checks merged constraints before they are turned into DDL
+func ValidateConstraints(constraints []ConstraintInfo) error {
+	primaryKey := ""
+	for _, constraint := range constraints {
+		invalid := func(reason string) error {
+			return &ConstraintError{Constraint: constraint.ConstraintName, Reason: reason}
+		}
+		columns := constraint.KeyColumns()
+		switch constraint.ConstraintType {
+		case PrimaryKeyConstraint, UniqueConstraint:
+			if len(columns) == 0 {
+				return invalid("needs at least one column")
+			}
+			if constraint.ConstraintType == PrimaryKeyConstraint {
+				if primaryKey != "" {
+					return invalid("is a second primary key, the table already has " + primaryKey)
+				}
+				primaryKey = constraint.ConstraintName
+			}
+		case ForeignKeyConstraint:
+			if len(columns) == 0 {
+				return invalid("needs at least one column")
+			}
+			if constraint.ForeignTableName == nil || *constraint.ForeignTableName == "" {
+				return invalid("needs a referenced table")
+			}
+			if referenced := constraint.ReferencedColumns(); len(referenced) > 0 && len(referenced) != len(columns) {
+				return invalid(fmt.Sprintf("has %d columns but references %d columns", len(columns), len(referenced)))
+			}
+			for _, action := range []string{constraint.OnDelete, constraint.OnUpdate} {
+				if !IsReferentialAction(action) {
+					return invalid("has an invalid referential action " + action)
+				}
+			}
+		case CheckConstraint:
+			if constraint.CheckClause == nil || strings.TrimSpace(*constraint.CheckClause) == "" {
+				return invalid("needs a check expression")
+			}
+			if err := checkExpression(*constraint.CheckClause); err != nil {
+				return invalid("has an invalid check expression: " + err.Error())
+			}
+		default:
+			return invalid("has an unknown type " + constraint.ConstraintType)
+		}
+	}
+	return nil
+}
+
+// checkExpression parses a raw check expression as SELECT <expression>, it must stay a single
+// expression so it can't close the clause it is written in and smuggle another statement
+func checkExpression(expression string) error {
+	statements, err := ParseSQLStatements("SELECT " + expression)
+	if err != nil {
+		return err
+	}
+	if len(statements) != 1 {
+		return errors.New("must be a single expression")
+	}
+	stmt := statements[0].Node.GetSelectStmt()
+	if stmt == nil || len(stmt.TargetList) != 1 || stmt.FromClause != nil || stmt.WhereClause != nil ||
+		stmt.GroupClause != nil || stmt.HavingClause != nil || stmt.SortClause != nil || stmt.LimitCount != nil ||
+		stmt.WithClause != nil || stmt.IntoClause != nil || stmt.Op != pg_query.SetOperation_SETOP_NONE {
+		return errors.New("must be a single expression")
+	}
+	return CheckUnsafeReferences(statements[0].Node)
+}
+
+// QuoteColumns returns a comma separated list of quoted column names
+func QuoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = QuoteIdentifier(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// FormatConstraint returns the CONSTRAINT clause of a constraint, as used in CREATE and ALTER TABLE.
+// the check expression is written as it is, the constraint has to be checked by ValidateConstraints
+func FormatConstraint(constraint *ConstraintInfo) string {
+	if constraint == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("CONSTRAINT %s", QuoteIdentifier(constraint.ConstraintName)))
+	switch constraint.ConstraintType {
+	case PrimaryKeyConstraint:
+		sb.WriteString(fmt.Sprintf(" PRIMARY KEY (%s)", QuoteColumns(constraint.KeyColumns())))
+	case UniqueConstraint:
+		sb.WriteString(fmt.Sprintf(" UNIQUE (%s)", QuoteColumns(constraint.KeyColumns())))
+	case ForeignKeyConstraint:
+		sb.WriteString(fmt.Sprintf(" FOREIGN KEY (%s) REFERENCES %s", QuoteColumns(constraint.KeyColumns()), referencedTable(constraint)))
+		if referenced := constraint.ReferencedColumns(); len(referenced) > 0 {
+			sb.WriteString(fmt.Sprintf(" (%s)", QuoteColumns(referenced)))
+		}
+		if action := normalizeAction(constraint.OnDelete); action != NoAction {
+			sb.WriteString(" ON DELETE " + action)
+		}
+		if action := normalizeAction(constraint.OnUpdate); action != NoAction {
+			sb.WriteString(" ON UPDATE " + action)
+		}
+	case CheckConstraint:
+		sb.WriteString(fmt.Sprintf(" CHECK (%s)", *constraint.CheckClause))
+	}
+	return sb.String()
+}
+
+// referencedTable is qualified only for foreign keys to another schema, the DDL runs with the
+// search path of the table schema
+func referencedTable(constraint *ConstraintInfo) string {
+	if constraint.ForeignSchemaName != nil && *constraint.ForeignSchemaName != "" {
+		return QualifiedName(*constraint.ForeignSchemaName, *constraint.ForeignTableName)
+	}
+	return QuoteIdentifier(*constraint.ForeignTableName)
+}
+
+// sameConstraint reports whether two merged constraints of the same name have the same definition.
+// check expressions are compared without whitespace and parentheses since postgres stores them reformatted
+func sameConstraint(a, b *ConstraintInfo) bool {
+	if a.ConstraintType != b.ConstraintType {
+		return false
+	}
+	// the columns of a check constraint are derived from its expression
+	if a.ConstraintType == CheckConstraint {
+		return normalizeExpression(stringValue(a.CheckClause)) == normalizeExpression(stringValue(b.CheckClause))
+	}
+	if !slices.Equal(a.KeyColumns(), b.KeyColumns()) {
+		return false
+	}
+	if a.ConstraintType == ForeignKeyConstraint {
+		return stringValue(a.ForeignSchemaName) == stringValue(b.ForeignSchemaName) &&
+			stringValue(a.ForeignTableName) == stringValue(b.ForeignTableName) &&
+			slices.Equal(a.ReferencedColumns(), b.ReferencedColumns()) &&
+			normalizeAction(a.OnDelete) == normalizeAction(b.OnDelete) &&
+			normalizeAction(a.OnUpdate) == normalizeAction(b.OnUpdate)
+	}
+	return true
+}
+
+func normalizeExpression(expression string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.ToLower(expression))
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+var constraintRanks = map[string]int{PrimaryKeyConstraint: 0, UniqueConstraint: 1, CheckConstraint: 2, ForeignKeyConstraint: 3}
+
+// sortConstraints orders constraints by type and name, keys before foreign keys or the reverse
+func sortConstraints(constraints []*ConstraintInfo, foreignKeysFirst bool) {
+	slices.SortFunc(constraints, func(a, b *ConstraintInfo) int {
+		rankA, rankB := constraintRanks[a.ConstraintType], constraintRanks[b.ConstraintType]
+		if rankA != rankB {
+			if foreignKeysFirst {
+				return rankB - rankA
+			}
+			return rankA - rankB
+		}
+		return strings.Compare(a.ConstraintName, b.ConstraintName)
+	})
+}
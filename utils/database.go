@@ -2,9 +2,12 @@ package utils
 
 import (
 	"DBHS/config"
+	api "DBHS/utils/apiError"
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -79,4 +82,23 @@ func ExtractDb(ctx context.Context, projectOID string, UserID int64, servDb *pgx
 	}
 
 	return projectId.(int64), userDb, nil
-}
\ No newline at end of file
+}
+
+// ProjectDatabase returns the id and the database of a project of the user in the context
+// with the api errors of the services that work on project databases. the pool is opened for
+// the call, the caller closes it when it is done with it
+func ProjectDatabase(ctx context.Context, servDb *pgxpool.Pool, projectOID string) (int64, *pgxpool.Pool, api.ApiError) {
+	userID, ok := ctx.Value("user-id").(int64)
+	if !ok || userID == 0 {
+		return 0, nil, *api.NewApiError("Unauthorized", 401, errors.New("user is not authorized"))
+	}
+
+	projectID, userDb, err := ExtractDb(ctx, projectOID, userID, servDb)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil, *api.NewApiError("Project not found", 404, errors.New(err.Error()))
+		}
+		return 0, nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	return projectID, userDb, api.ApiError{}
+}
@@ -0,0 +1,708 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+// SchemaExport holds the catalog objects of a project database needed to rebuild its schema
+type SchemaExport struct {
+	Schema      string
+	Enums       []ExportEnum
+	Composites  []ExportComposite
+	Sequences   []ExportSequence
+	Columns     []ExportColumn
+	Constraints []ExportConstraint
+	Indexes     []ExportIndex
+	Views       []ExportView
+	ViewDeps    []ExportViewDependency
+	Functions   []ExportFunction
+	// PartitionKeys are the keys of the partitioned tables, Partitions the partitions of the schema
+	PartitionKeys []ExportPartitionKey
+	Partitions    []ExportPartition
+}
+
+type ExportEnum struct {
+	Name   string   `db:"name"`
+	Labels []string `db:"labels"`
+}
+
+// ExportComposite is a composite type, DependsOn lists the enum and composite types of the
+// same schema used by its attributes
+type ExportComposite struct {
+	Name       string   `db:"name"`
+	Attributes []string `db:"attributes"`
+	DependsOn  []string `db:"depends_on"`
+}
+
+type ExportSequence struct {
+	Name          string  `db:"name"`
+	DataType      string  `db:"data_type"`
+	StartValue    int64   `db:"start_value"`
+	MinValue      int64   `db:"min_value"`
+	MaxValue      int64   `db:"max_value"`
+	IncrementBy   int64   `db:"increment_by"`
+	Cycle         bool    `db:"cycle"`
+	CacheSize     int64   `db:"cache_size"`
+	OwnedByTable  *string `db:"owned_by_table"`
+	OwnedByColumn *string `db:"owned_by_column"`
+}
+
+type ExportColumn struct {
+	TableName     string  `db:"table_name"`
+	ColumnName    string  `db:"column_name"`
+	DataType      string  `db:"data_type"`
+	NotNull       bool    `db:"not_null"`
+	ColumnDefault *string `db:"column_default"`
+	Identity      string  `db:"identity"`
+	Generated     string  `db:"generated"`
+	EnumType      *string `db:"enum_type"`
+	CompositeType *string `db:"composite_type"`
+}
+
+type ExportConstraint struct {
+	TableName       string  `db:"table_name"`
+	ConstraintName  string  `db:"constraint_name"`
+	ConstraintType  string  `db:"constraint_type"`
+	Definition      string  `db:"definition"`
+	ReferencedTable *string `db:"referenced_table"`
+}
+
+type ExportIndex struct {
+	TableName  string `db:"table_name"`
+	IndexName  string `db:"index_name"`
+	Definition string `db:"definition"`
+}
+
+type ExportView struct {
+	Name         string `db:"name"`
+	Materialized bool   `db:"materialized"`
+	Definition   string `db:"definition"`
+}
+
+type ExportViewDependency struct {
+	ViewName  string `db:"view_name"`
+	DependsOn string `db:"depends_on"`
+}
+
+type ExportFunction struct {
+	Name       string `db:"name"`
+	Definition string `db:"definition"`
+}
+
+type ExportPartitionKey struct {
+	TableName  string `db:"table_name"`
+	Definition string `db:"definition"`
+}
+
+type ExportPartition struct {
+	Name       string `db:"name"`
+	ParentName string `db:"parent_name"`
+	Bound      string `db:"bound"`
+}
+
+const (
+	exportEnumsQuery = `
+		SELECT t.typname AS name, array_agg(e.enumlabel ORDER BY e.enumsortorder) AS labels
+		FROM pg_type t
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = $1
+		GROUP BY t.typname
+		ORDER BY t.typname;`
+
+	// only standalone composite types, the row types of tables are created with their tables
+	exportCompositesQuery = `
+		SELECT t.typname AS name,
+			array_agg(quote_ident(a.attname) || ' ' || format_type(a.atttypid, a.atttypmod) ORDER BY a.attnum) AS attributes,
+			array_remove(array_agg(DISTINCT dep.typname), NULL) AS depends_on
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_class c ON c.oid = t.typrelid AND c.relkind = 'c'
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		JOIN pg_type at ON at.oid = a.atttypid
+		LEFT JOIN pg_type dep ON dep.oid = CASE WHEN at.typelem <> 0 THEN at.typelem ELSE at.oid END
+			AND dep.typnamespace = t.typnamespace
+			AND dep.typtype IN ('e', 'c')
+		WHERE n.nspname = $1
+		GROUP BY t.typname
+		ORDER BY t.typname;`
+
+	// identity sequences are recreated by their columns so they are left out
+	exportSequencesQuery = `
+		SELECT
+			s.sequencename AS name,
+			s.data_type::text AS data_type,
+			s.start_value AS start_value,
+			s.min_value AS min_value,
+			s.max_value AS max_value,
+			s.increment_by AS increment_by,
+			s.cycle AS cycle,
+			s.cache_size AS cache_size,
+			owner.relname AS owned_by_table,
+			owner_column.attname AS owned_by_column
+		FROM pg_sequences s
+		JOIN pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_class c ON c.relname = s.sequencename AND c.relnamespace = n.oid
+		LEFT JOIN pg_depend d ON d.objid = c.oid
+			AND d.classid = 'pg_class'::regclass
+			AND d.refclassid = 'pg_class'::regclass
+			AND d.deptype IN ('a', 'i')
+		LEFT JOIN pg_class owner ON owner.oid = d.refobjid
+		LEFT JOIN pg_attribute owner_column ON owner_column.attrelid = d.refobjid AND owner_column.attnum = d.refobjsubid
+		WHERE s.schemaname = $1
+			AND (d.deptype IS NULL OR d.deptype = 'a')
+		ORDER BY s.sequencename;`
+
+	exportColumnsQuery = `
+		SELECT
+			c.relname AS table_name,
+			a.attname AS column_name,
+			format_type(a.atttypid, a.atttypmod) AS data_type,
+			a.attnotnull AS not_null,
+			pg_get_expr(ad.adbin, ad.adrelid) AS column_default,
+			a.attidentity::text AS identity,
+			a.attgenerated::text AS generated,
+			CASE
+				WHEN t.typtype = 'e' THEN t.typname
+				WHEN et.typtype = 'e' THEN et.typname
+			END AS enum_type,
+			CASE
+				WHEN t.typtype = 'c' THEN t.typname
+				WHEN et.typtype = 'c' THEN et.typname
+			END AS composite_type
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		JOIN pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_type et ON et.oid = t.typelem AND t.typelem <> 0
+		LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+		ORDER BY c.relname, a.attnum;`
+
+	// pg_get_constraintdef keeps every column of composite keys in their declared order,
+	// partitions get the constraints of their partitioned table when they are created
+	exportConstraintsQuery = `
+		SELECT
+			c.relname AS table_name,
+			con.conname AS constraint_name,
+			con.contype::text AS constraint_type,
+			pg_get_constraintdef(con.oid) AS definition,
+			ref.relname AS referenced_table
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_class ref ON ref.oid = con.confrelid
+		WHERE n.nspname = $1
+			AND c.relkind IN ('r', 'p')
+			AND NOT c.relispartition
+			AND con.contype IN ('p', 'u', 'f', 'c', 'x')
+		ORDER BY c.relname, con.contype, con.conname;`
+
+	// indexes backing PRIMARY KEY, UNIQUE and EXCLUDE constraints are created with the constraint.
+	// the indexes of a partitioned table are defined ON ONLY the parent, without ONLY they are
+	// created on every partition like the partitions of the export need
+	exportIndexesQuery = `
+		SELECT t.relname AS table_name, i.relname AS index_name,
+			replace(pg_get_indexdef(i.oid), ' ON ONLY ', ' ON ') AS definition
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1
+			AND t.relkind IN ('r', 'm', 'p')
+			AND NOT t.relispartition
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_constraint con
+				WHERE con.conindid = ix.indexrelid AND con.contype IN ('p', 'u', 'x')
+			)
+		ORDER BY t.relname, i.relname;`
+
+	exportViewsQuery = `
+		SELECT c.relname AS name, c.relkind = 'm' AS materialized, pg_get_viewdef(c.oid, true) AS definition
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+			AND c.relkind IN ('v', 'm')
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_depend d
+				WHERE d.objid = c.oid AND d.classid = 'pg_class'::regclass AND d.deptype = 'e'
+			)
+		ORDER BY c.relname;`
+
+	exportViewDependenciesQuery = `
+		SELECT DISTINCT v.relname AS view_name, dep.relname AS depends_on
+		FROM pg_depend d
+		JOIN pg_rewrite r ON r.oid = d.objid
+		JOIN pg_class v ON v.oid = r.ev_class
+		JOIN pg_class dep ON dep.oid = d.refobjid
+		JOIN pg_namespace n ON n.oid = v.relnamespace
+		WHERE d.classid = 'pg_rewrite'::regclass
+			AND d.refclassid = 'pg_class'::regclass
+			AND n.nspname = $1
+			AND v.relkind IN ('v', 'm')
+			AND dep.relkind IN ('v', 'm')
+			AND dep.oid <> v.oid;`
+
+	exportPartitionKeysQuery = `
+		SELECT c.relname AS table_name, pg_get_partkeydef(c.oid) AS definition
+		FROM pg_partitioned_table pt
+		JOIN pg_class c ON c.oid = pt.partrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		ORDER BY c.relname;`
+
+	// only the partitions living in the schema of their partitioned table
+	exportPartitionsQuery = `
+		SELECT c.relname AS name, parent.relname AS parent_name, pg_get_expr(c.relpartbound, c.oid) AS bound
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class parent ON parent.oid = i.inhparent
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+			AND c.relispartition
+			AND parent.relnamespace = c.relnamespace
+		ORDER BY parent.relname, c.relname;`
+
+	// functions created by extensions are restored by CREATE EXTENSION and are left out
+	exportFunctionsQuery = `
+		SELECT p.proname AS name, pg_get_functiondef(p.oid) AS definition
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1
+			AND p.prokind IN ('f', 'p')
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_depend d
+				WHERE d.objid = p.oid AND d.classid = 'pg_proc'::regclass AND d.deptype = 'e'
+			)
+		ORDER BY p.proname, p.oid;`
+)
+
+// GetSchemaExport reads every object needed to rebuild a schema of a database
+func GetSchemaExport(ctx context.Context, db Querier, schema string) (*SchemaExport, error) {
+	export := &SchemaExport{Schema: schema}
+	queries := []struct {
+		name  string
+		dest  interface{}
+		query string
+	}{
+		{"enums", &export.Enums, exportEnumsQuery},
+		{"composite types", &export.Composites, exportCompositesQuery},
+		{"sequences", &export.Sequences, exportSequencesQuery},
+		{"columns", &export.Columns, exportColumnsQuery},
+		{"constraints", &export.Constraints, exportConstraintsQuery},
+		{"indexes", &export.Indexes, exportIndexesQuery},
+		{"views", &export.Views, exportViewsQuery},
+		{"view dependencies", &export.ViewDeps, exportViewDependenciesQuery},
+		{"functions", &export.Functions, exportFunctionsQuery},
+		{"partition keys", &export.PartitionKeys, exportPartitionKeysQuery},
+		{"partitions", &export.Partitions, exportPartitionsQuery},
+	}
+	for _, q := range queries {
+		if err := pgxscan.Select(ctx, db, q.dest, q.query, schema); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", q.name, err)
+		}
+	}
+	return export, nil
+}
+
+// GetEnumTypes returns the labels of every enum type in a schema keyed by type name
+func GetEnumTypes(ctx context.Context, db Querier, schema string) (map[string][]string, error) {
+	var enums []ExportEnum
+	if err := pgxscan.Select(ctx, db, &enums, exportEnumsQuery, schema); err != nil {
+		return nil, fmt.Errorf("failed to query enum types: %w", err)
+	}
+	types := make(map[string][]string, len(enums))
+	for _, enum := range enums {
+		types[enum.Name] = enum.Labels
+	}
+	return types, nil
+}
+
+// GetCompositeTypes returns the standalone composite types of a schema
+func GetCompositeTypes(ctx context.Context, db Querier, schema string) ([]ExportComposite, error) {
+	var composites []ExportComposite
+	if err := pgxscan.Select(ctx, db, &composites, exportCompositesQuery, schema); err != nil {
+		return nil, fmt.Errorf("failed to query composite types: %w", err)
+	}
+	return composites, nil
+}
+
+// ExportSchemaDDL generates a re-runnable SQL script of a database schema.
+// when tables is not empty the script is limited to those tables and the types and sequences they use
+func ExportSchemaDDL(ctx context.Context, db Querier, schema string, tables []string) (string, error) {
+	export, err := GetSchemaExport(ctx, db, schema)
+	if err != nil {
+		return "", err
+	}
+	return RenderSchemaDDL(export, tables)
+}
+
+// QuoteIdentifier quotes a SQL identifier, escaping embedded double quotes
+func QuoteIdentifier(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+// QuoteLiteral quotes a SQL string literal
+func QuoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// RenderSchemaDDL builds the script from an export. Objects are created in dependency order:
+// types, sequences, functions, tables, constraints, foreign keys, indexes and finally views
+func RenderSchemaDDL(export *SchemaExport, tables []string) (string, error) {
+	tableColumns := make(map[string][]ExportColumn)
+	tableNames := make([]string, 0)
+	for _, col := range export.Columns {
+		if _, exists := tableColumns[col.TableName]; !exists {
+			tableNames = append(tableNames, col.TableName)
+		}
+		tableColumns[col.TableName] = append(tableColumns[col.TableName], col)
+	}
+
+	selected := make(map[string]bool)
+	filtered := len(tables) > 0
+	for _, table := range tables {
+		if _, exists := tableColumns[table]; !exists {
+			return "", fmt.Errorf("table %s does not exist", table)
+		}
+		selected[table] = true
+	}
+	if !filtered {
+		for _, table := range tableNames {
+			selected[table] = true
+		}
+	}
+
+	// foreign keys decide the order of the tables
+	tableDeps := make(map[string][]string)
+	for _, constraint := range export.Constraints {
+		if constraint.ConstraintType == "f" && constraint.ReferencedTable != nil && *constraint.ReferencedTable != constraint.TableName {
+			tableDeps[constraint.TableName] = append(tableDeps[constraint.TableName], *constraint.ReferencedTable)
+		}
+	}
+	orderedTables := make([]string, 0, len(selected))
+	for _, table := range OrderByDependencies(tableNames, tableDeps) {
+		if selected[table] {
+			orderedTables = append(orderedTables, table)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("-- Database Schema DDL Export\n")
+	sb.WriteString("-- Generated automatically, the script can be run more than once\n")
+	if filtered {
+		sb.WriteString(fmt.Sprintf("-- Limited to the tables: %s\n", strings.Join(orderedTables, ", ")))
+	}
+	schema := SchemaOrDefault(export.Schema)
+	sb.WriteString("\nSET check_function_bodies = false;\n")
+	sb.WriteString(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;\n", QuoteIdentifier(schema)))
+	searchPath := QuoteIdentifier(schema)
+	if schema != DefaultSchema {
+		searchPath += ", " + QuoteIdentifier(DefaultSchema)
+	}
+	sb.WriteString(fmt.Sprintf("SET search_path TO %s;\n", searchPath))
+
+	// enum and composite types, composite types can use other types of the schema
+	compositeNames := make([]string, len(export.Composites))
+	composites := make(map[string]ExportComposite, len(export.Composites))
+	compositeDeps := make(map[string][]string, len(export.Composites))
+	for i, composite := range export.Composites {
+		compositeNames[i] = composite.Name
+		composites[composite.Name] = composite
+		compositeDeps[composite.Name] = composite.DependsOn
+	}
+	usedTypes := make(map[string]bool)
+	var useType func(name string)
+	useType = func(name string) {
+		if usedTypes[name] {
+			return
+		}
+		usedTypes[name] = true
+		for _, dep := range compositeDeps[name] {
+			useType(dep)
+		}
+	}
+	for _, table := range orderedTables {
+		for _, col := range tableColumns[table] {
+			if col.EnumType != nil {
+				useType(*col.EnumType)
+			}
+			if col.CompositeType != nil {
+				useType(*col.CompositeType)
+			}
+		}
+	}
+	writeSection(&sb, "Types")
+	for _, enum := range export.Enums {
+		if filtered && !usedTypes[enum.Name] {
+			continue
+		}
+		labels := make([]string, len(enum.Labels))
+		for i, label := range enum.Labels {
+			labels[i] = QuoteLiteral(label)
+		}
+		sb.WriteString(fmt.Sprintf("DO $$ BEGIN\n    CREATE TYPE %s AS ENUM (%s);\nEXCEPTION WHEN duplicate_object THEN NULL;\nEND $$;\n",
+			QuoteIdentifier(enum.Name), strings.Join(labels, ", ")))
+	}
+	for _, name := range OrderByDependencies(compositeNames, compositeDeps) {
+		if filtered && !usedTypes[name] {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("DO $$ BEGIN\n    CREATE TYPE %s AS (%s);\nEXCEPTION WHEN duplicate_object THEN NULL;\nEND $$;\n",
+			QuoteIdentifier(name), strings.Join(composites[name].Attributes, ", ")))
+	}
+
+	// sequences
+	writeSection(&sb, "Sequences")
+	sequences := make([]ExportSequence, 0, len(export.Sequences))
+	for _, seq := range export.Sequences {
+		if filtered && !sequenceUsedBy(seq, orderedTables, tableColumns) {
+			continue
+		}
+		sequences = append(sequences, seq)
+		cycle := "NO CYCLE"
+		if seq.Cycle {
+			cycle = "CYCLE"
+		}
+		sb.WriteString(fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s AS %s INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d CACHE %d %s;\n",
+			QuoteIdentifier(seq.Name), seq.DataType, seq.IncrementBy, seq.MinValue, seq.MaxValue, seq.StartValue, seq.CacheSize, cycle))
+	}
+
+	// functions are only exported with the whole schema
+	if !filtered {
+		writeSection(&sb, "Functions")
+		for _, function := range export.Functions {
+			sb.WriteString(strings.TrimSpace(function.Definition))
+			sb.WriteString(";\n\n")
+		}
+	}
+
+	// tables, the partitions of a partitioned table follow it
+	partitionKeys := make(map[string]string, len(export.PartitionKeys))
+	for _, key := range export.PartitionKeys {
+		partitionKeys[key.TableName] = key.Definition
+	}
+	writeSection(&sb, "Tables")
+	for _, table := range orderedTables {
+		sb.WriteString(renderExportTable(table, tableColumns[table], partitionKeys[table]))
+		sb.WriteString("\n")
+	}
+	for _, partition := range orderExportPartitions(export.Partitions, selected) {
+		sb.WriteString(renderExportPartition(partition, partitionKeys[partition.Name]))
+	}
+	for _, seq := range sequences {
+		if seq.OwnedByTable != nil && seq.OwnedByColumn != nil && selected[*seq.OwnedByTable] {
+			sb.WriteString(fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s;\n",
+				QuoteIdentifier(seq.Name), QuoteIdentifier(*seq.OwnedByTable), QuoteIdentifier(*seq.OwnedByColumn)))
+		}
+	}
+
+	// constraints, foreign keys last so every referenced key exists
+	writeSection(&sb, "Constraints")
+	foreignKeys := make([]ExportConstraint, 0)
+	for _, table := range orderedTables {
+		for _, constraint := range export.Constraints {
+			if constraint.TableName != table {
+				continue
+			}
+			if constraint.ConstraintType == "f" {
+				foreignKeys = append(foreignKeys, constraint)
+				continue
+			}
+			sb.WriteString(renderExportConstraint(constraint))
+		}
+	}
+	writeSection(&sb, "Foreign Keys")
+	for _, fk := range foreignKeys {
+		if fk.ReferencedTable != nil && !selected[*fk.ReferencedTable] {
+			sb.WriteString(fmt.Sprintf("-- skipped %s: references %s which is not part of the export\n", fk.ConstraintName, *fk.ReferencedTable))
+			continue
+		}
+		sb.WriteString(renderExportConstraint(fk))
+	}
+
+	// indexes
+	writeSection(&sb, "Indexes")
+	views := make(map[string]bool)
+	for _, view := range export.Views {
+		views[view.Name] = true
+	}
+	for _, index := range export.Indexes {
+		if selected[index.TableName] {
+			sb.WriteString(ensureIfNotExists(index.Definition, "INDEX"))
+			sb.WriteString(";\n")
+		}
+	}
+
+	// views are only exported with the whole schema
+	if !filtered {
+		writeSection(&sb, "Views")
+		viewNames := make([]string, len(export.Views))
+		viewsByName := make(map[string]ExportView)
+		for i, view := range export.Views {
+			viewNames[i] = view.Name
+			viewsByName[view.Name] = view
+		}
+		viewDeps := make(map[string][]string)
+		for _, dep := range export.ViewDeps {
+			viewDeps[dep.ViewName] = append(viewDeps[dep.ViewName], dep.DependsOn)
+		}
+		for _, name := range OrderByDependencies(viewNames, viewDeps) {
+			view := viewsByName[name]
+			definition := strings.TrimSuffix(strings.TrimSpace(view.Definition), ";")
+			if view.Materialized {
+				sb.WriteString(fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS\n%s;\n", QuoteIdentifier(name), definition))
+			} else {
+				sb.WriteString(fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\n%s;\n", QuoteIdentifier(name), definition))
+			}
+		}
+		for _, index := range export.Indexes {
+			if views[index.TableName] {
+				sb.WriteString(ensureIfNotExists(index.Definition, "INDEX"))
+				sb.WriteString(";\n")
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func writeSection(sb *strings.Builder, title string) {
+	sb.WriteString(fmt.Sprintf("\n-- %s\n", title))
+}
+
+func renderExportTable(table string, columns []ExportColumn, partitionKey string) string {
+	columnDefs := make([]string, 0, len(columns))
+	for _, col := range columns {
+		def := fmt.Sprintf("    %s %s", QuoteIdentifier(col.ColumnName), col.DataType)
+		switch {
+		case col.Identity == "a":
+			def += " GENERATED ALWAYS AS IDENTITY"
+		case col.Identity == "d":
+			def += " GENERATED BY DEFAULT AS IDENTITY"
+		case col.Generated == "s" && col.ColumnDefault != nil:
+			def += fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", *col.ColumnDefault)
+		case col.ColumnDefault != nil:
+			def += " DEFAULT " + *col.ColumnDefault
+		}
+		if col.NotNull {
+			def += " NOT NULL"
+		}
+		columnDefs = append(columnDefs, def)
+	}
+	partitionBy := ""
+	if partitionKey != "" {
+		partitionBy = " PARTITION BY " + partitionKey
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n)%s;\n", QuoteIdentifier(table), strings.Join(columnDefs, ",\n"), partitionBy)
+}
+
+func renderExportPartition(partition ExportPartition, partitionKey string) string {
+	partitionBy := ""
+	if partitionKey != "" {
+		partitionBy = " PARTITION BY " + partitionKey
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s %s%s;\n",
+		QuoteIdentifier(partition.Name), QuoteIdentifier(partition.ParentName), partition.Bound, partitionBy)
+}
+
+// orderExportPartitions returns the partitions of the selected tables, including the partitions
+// of their sub-partitioned partitions, each after its parent
+func orderExportPartitions(partitions []ExportPartition, selected map[string]bool) []ExportPartition {
+	names := make([]string, len(partitions))
+	byName := make(map[string]ExportPartition, len(partitions))
+	deps := make(map[string][]string, len(partitions))
+	for i, partition := range partitions {
+		names[i] = partition.Name
+		byName[partition.Name] = partition
+		deps[partition.Name] = []string{partition.ParentName}
+	}
+	included := make(map[string]bool, len(selected))
+	for table := range selected {
+		included[table] = true
+	}
+	ordered := make([]ExportPartition, 0, len(partitions))
+	for _, name := range OrderByDependencies(names, deps) {
+		partition := byName[name]
+		if included[partition.ParentName] {
+			included[name] = true
+			ordered = append(ordered, partition)
+		}
+	}
+	return ordered
+}
+
+// constraints have no IF NOT EXISTS form so they are guarded by a catalog lookup
+func renderExportConstraint(constraint ExportConstraint) string {
+	return fmt.Sprintf(`DO $$ BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = %s AND conrelid = %s::regclass) THEN
+        ALTER TABLE %s ADD CONSTRAINT %s %s;
+    END IF;
+END $$;
+`,
+		QuoteLiteral(constraint.ConstraintName), QuoteLiteral(QuoteIdentifier(constraint.TableName)),
+		QuoteIdentifier(constraint.TableName), QuoteIdentifier(constraint.ConstraintName), constraint.Definition)
+}
+
+// ensureIfNotExists turns "CREATE [UNIQUE] INDEX name" into "CREATE [UNIQUE] INDEX IF NOT EXISTS name"
+func ensureIfNotExists(definition, object string) string {
+	keyword := object + " "
+	i := strings.Index(definition, keyword)
+	if i < 0 || strings.HasPrefix(definition[i+len(keyword):], "IF NOT EXISTS") {
+		return definition
+	}
+	return definition[:i+len(keyword)] + "IF NOT EXISTS " + definition[i+len(keyword):]
+}
+
+func sequenceUsedBy(seq ExportSequence, tables []string, tableColumns map[string][]ExportColumn) bool {
+	for _, table := range tables {
+		if seq.OwnedByTable != nil && *seq.OwnedByTable == table {
+			return true
+		}
+		for _, col := range tableColumns[table] {
+			if col.ColumnDefault != nil && strings.Contains(*col.ColumnDefault, seq.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OrderByDependencies sorts names so that every name comes after the names it depends on.
+// names keep their original order when possible and cycles are broken in that order
+func OrderByDependencies(names []string, deps map[string][]string) []string {
+	ordered := make([]string, 0, len(names))
+	state := make(map[string]int) // 0 unvisited, 1 visiting, 2 done
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	var visit func(name string)
+	visit = func(name string) {
+		if state[name] != 0 {
+			return
+		}
+		state[name] = 1
+		dependencies := slices.Clone(deps[name])
+		slices.Sort(dependencies)
+		for _, dep := range dependencies {
+			if known[dep] {
+				visit(dep)
+			}
+		}
+		state[name] = 2
+		ordered = append(ordered, name)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+	return ordered
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// DefaultSchema is the schema used when a request doesn't name one
+	DefaultSchema = "public"
+	// InternalSchema holds the platform bookkeeping inside every project database
+	InternalSchema = "_dbhs"
+
+	// UserSchemasFilter is the condition on a pg_namespace n that leaves out the system and platform schemas
+	UserSchemasFilter = `n.nspname NOT IN ('information_schema', '` + InternalSchema + `') AND n.nspname NOT LIKE 'pg\_%'`
+
+	listSchemasQuery = `
+		SELECT n.nspname
+		FROM pg_namespace n
+		WHERE ` + UserSchemasFilter + `
+		ORDER BY n.nspname;`
+
+	schemaExistsQuery = `SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1);`
+)
+
+// ListSchemas returns the schemas of a database that belong to the user,
+// system and platform schemas are left out
+func ListSchemas(ctx context.Context, db Querier) ([]string, error) {
+	var schemas []string
+	if err := pgxscan.Select(ctx, db, &schemas, listSchemasQuery); err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+// SchemaExists reports whether a user schema with the given name exists
+func SchemaExists(ctx context.Context, db Querier, schema string) (bool, error) {
+	if IsReservedSchema(schema) {
+		return false, nil
+	}
+	var exists bool
+	if err := db.QueryRow(ctx, schemaExistsQuery, schema).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check schema: %w", err)
+	}
+	return exists, nil
+}
+
+// IsReservedSchema reports whether a schema belongs to postgres or to the platform
+func IsReservedSchema(schema string) bool {
+	return schema == InternalSchema || schema == "information_schema" || strings.HasPrefix(schema, "pg_")
+}
+
+// SchemaOrDefault returns the schema or the default one when it is empty
+func SchemaOrDefault(schema string) string {
+	if schema == "" {
+		return DefaultSchema
+	}
+	return schema
+}
+
+// QualifiedName returns the quoted schema qualified name of a relation
+func QualifiedName(schema, name string) string {
+	return pgx.Identifier{SchemaOrDefault(schema), name}.Sanitize()
+}
+
+// SetSearchPath scopes the unqualified names of the rest of the transaction to a schema.
+// public stays on the path so references to shared tables and types keep resolving
+func SetSearchPath(ctx context.Context, tx pgx.Tx, schema string) error {
+	schema = SchemaOrDefault(schema)
+	path := QuoteIdentifier(schema)
+	if schema != DefaultSchema {
+		path += ", " + QuoteIdentifier(DefaultSchema)
+	}
+	if _, err := tx.Exec(ctx, "SET LOCAL search_path TO "+path); err != nil {
+		return fmt.Errorf("failed to set search path: %w", err)
+	}
+	return nil
+}
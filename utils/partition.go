@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+const (
+	RangePartition = "RANGE"
+	ListPartition  = "LIST"
+	HashPartition  = "HASH"
+)
+
+// PartitionSpec is the partition key of a partitioned table
+type PartitionSpec struct {
+	Strategy string   `db:"strategy" json:"Strategy"`
+	Columns  []string `db:"columns" json:"Columns"`
+	// Definition is the key as postgres prints it, it is only read from the database
+	Definition string `db:"definition" json:"Definition,omitempty"`
+}
+
+// PartitionBound is the FOR VALUES clause of a partition. From and To bound a range partition,
+// In lists the values of a list partition and Modulus and Remainder place a hash partition.
+// MINVALUE and MAXVALUE are accepted as range bounds
+type PartitionBound struct {
+	From      []string `json:"from,omitempty"`
+	To        []string `json:"to,omitempty"`
+	In        []string `json:"in,omitempty"`
+	Modulus   int      `json:"modulus,omitempty"`
+	Remainder int      `json:"remainder,omitempty"`
+	IsDefault bool     `json:"default,omitempty"`
+}
+
+// Partition is a partition attached to a partitioned table
+type Partition struct {
+	ParentName    string `db:"parent_name" json:"ParentName"`
+	SchemaName    string `db:"schema_name" json:"SchemaName"`
+	Name          string `db:"name" json:"Name"`
+	Bound         string `db:"bound" json:"Bound"`
+	IsPartitioned bool   `db:"is_partitioned" json:"IsPartitioned"`
+	EstimatedRows int64  `db:"estimated_rows" json:"EstimatedRows"`
+}
+
+// PartitionError is returned when a partition key or bound can't be turned into DDL
+type PartitionError struct {
+	Reason string
+}
+
+func (e *PartitionError) Error() string {
+	return "invalid partitioning: " + e.Reason
+}
+
+// partitionKeysQuery reads the key of every partitioned table of a schema, expression keys
+// have no column and are only described by the definition
+const partitionKeysQuery = `
+		SELECT c.relname AS table_name,
+			CASE pt.partstrat WHEN 'r' THEN 'RANGE' WHEN 'l' THEN 'LIST' ELSE 'HASH' END AS strategy,
+			COALESCE((
+				SELECT array_agg(a.attname::text ORDER BY k.ord)
+				FROM unnest(pt.partattrs::int2[]) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+			), '{}') AS columns,
+			pg_get_partkeydef(c.oid) AS definition
+		FROM pg_partitioned_table pt
+		JOIN pg_class c ON c.oid = pt.partrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND ($2 = '' OR c.relname = $2)`
+
+// partitionsQuery reads the direct partitions of the partitioned tables of a schema,
+// a partition may live in another schema than its parent
+const partitionsQuery = `
+		SELECT parent.relname AS parent_name, n.nspname AS schema_name, c.relname AS name,
+			pg_get_expr(c.relpartbound, c.oid) AS bound,
+			c.relkind = 'p' AS is_partitioned,
+			GREATEST(c.reltuples, 0)::bigint AS estimated_rows
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class parent ON parent.oid = i.inhparent
+		JOIN pg_namespace pn ON pn.oid = parent.relnamespace
+		WHERE c.relispartition AND pn.nspname = $1 AND ($2 = '' OR parent.relname = $2)
+		ORDER BY parent.relname, c.relname`
+
+type partitionKey struct {
+	TableName string `db:"table_name"`
+	PartitionSpec
+}
+
+// GetPartitionKeys returns the partition keys of a schema keyed by table name, an empty table
+// name reads every partitioned table of the schema
+func GetPartitionKeys(ctx context.Context, db Querier, schema, tableName string) (map[string]*PartitionSpec, error) {
+	var keys []partitionKey
+	if err := pgxscan.Select(ctx, db, &keys, partitionKeysQuery, schema, tableName); err != nil {
+		return nil, fmt.Errorf("failed to query partition keys: %w", err)
+	}
+	specs := make(map[string]*PartitionSpec, len(keys))
+	for _, key := range keys {
+		spec := key.PartitionSpec
+		specs[key.TableName] = &spec
+	}
+	return specs, nil
+}
+
+// GetPartitions returns the partitions of the partitioned tables of a schema, an empty table
+// name reads the partitions of every partitioned table of the schema
+func GetPartitions(ctx context.Context, db Querier, schema, tableName string) ([]Partition, error) {
+	var partitions []Partition
+	if err := pgxscan.Select(ctx, db, &partitions, partitionsQuery, schema, tableName); err != nil {
+		return nil, fmt.Errorf("failed to query partitions: %w", err)
+	}
+	return partitions, nil
+}
+
+// ValidatePartitionSpec checks the partition key of a table definition, the key columns must
+// be columns of the table
+func ValidatePartitionSpec(spec *PartitionSpec, columns []TableColumn) error {
+	if spec == nil {
+		return nil
+	}
+	// the definition is only read from the database, the key is built from the columns
+	spec.Definition = ""
+	spec.Strategy = strings.ToUpper(strings.TrimSpace(spec.Strategy))
+	if !slices.Contains([]string{RangePartition, ListPartition, HashPartition}, spec.Strategy) {
+		return &PartitionError{Reason: fmt.Sprintf("unknown strategy %q, use RANGE, LIST or HASH", spec.Strategy)}
+	}
+	if len(spec.Columns) == 0 {
+		return &PartitionError{Reason: "the partition key needs at least one column"}
+	}
+	if spec.Strategy == ListPartition && len(spec.Columns) > 1 {
+		return &PartitionError{Reason: "a list partition key has a single column"}
+	}
+	for _, column := range spec.Columns {
+		if !slices.ContainsFunc(columns, func(col TableColumn) bool { return col.ColumnName == column }) {
+			return &PartitionError{Reason: fmt.Sprintf("the partition key column %s is not a column of the table", column)}
+		}
+	}
+	return nil
+}
+
+// PartitionByClause returns the PARTITION BY clause of a partition key, a key read from the
+// database keeps its definition so expression keys are preserved
+func PartitionByClause(spec *PartitionSpec) string {
+	if spec.Definition != "" {
+		return "PARTITION BY " + spec.Definition
+	}
+	return fmt.Sprintf("PARTITION BY %s (%s)", spec.Strategy, QuoteColumns(spec.Columns))
+}
+
+func samePartitionSpec(a, b *PartitionSpec) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return strings.EqualFold(a.Strategy, b.Strategy) && slices.Equal(a.Columns, b.Columns)
+}
+
+// CreatePartitionStatement creates a partition of a table with the given FOR VALUES clause,
+// the partition is partitioned itself when a partition key is given
+func CreatePartitionStatement(partition, parent, bound string, spec *PartitionSpec) string {
+	stmt := fmt.Sprintf("CREATE TABLE %s PARTITION OF %s %s", partition, parent, bound)
+	if spec != nil {
+		stmt += " " + PartitionByClause(spec)
+	}
+	return stmt
+}
+
+// generateCreatePartitionStatement recreates a partition read from the database, names are
+// only qualified when the partition lives in another schema than its parent
+func generateCreatePartitionStatement(schema string, partition *Partition, spec *PartitionSpec) string {
+	name := QuoteIdentifier(partition.Name)
+	if partition.SchemaName != schema {
+		name = QualifiedName(partition.SchemaName, partition.Name)
+	}
+	return CreatePartitionStatement(name, QuoteIdentifier(partition.ParentName), partition.Bound, spec) + ";"
+}
+
+// orderPartitions puts the partitions of a sub-partitioned partition after that partition
+func orderPartitions(partitions []Partition) []Partition {
+	names := make([]string, len(partitions))
+	byName := make(map[string]Partition, len(partitions))
+	deps := make(map[string][]string, len(partitions))
+	for i, partition := range partitions {
+		names[i] = partition.Name
+		byName[partition.Name] = partition
+		deps[partition.Name] = []string{partition.ParentName}
+	}
+	ordered := make([]Partition, 0, len(partitions))
+	for _, name := range OrderByDependencies(names, deps) {
+		ordered = append(ordered, byName[name])
+	}
+	return ordered
+}
+
+// FormatPartitionBound returns the FOR VALUES clause of a partition bound
+func FormatPartitionBound(bound *PartitionBound) (string, error) {
+	if bound == nil {
+		return "", &PartitionError{Reason: "the partition bound is required"}
+	}
+	kinds := 0
+	for _, set := range []bool{bound.IsDefault, len(bound.From) > 0 || len(bound.To) > 0, len(bound.In) > 0, bound.Modulus > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return "", &PartitionError{Reason: "the bound needs exactly one of default, from/to, in or modulus/remainder"}
+	}
+
+	switch {
+	case bound.IsDefault:
+		return "DEFAULT", nil
+	case len(bound.In) > 0:
+		return fmt.Sprintf("FOR VALUES IN (%s)", boundValues(bound.In)), nil
+	case bound.Modulus > 0:
+		if bound.Remainder < 0 || bound.Remainder >= bound.Modulus {
+			return "", &PartitionError{Reason: "the remainder must be between 0 and the modulus"}
+		}
+		return fmt.Sprintf("FOR VALUES WITH (MODULUS %d, REMAINDER %d)", bound.Modulus, bound.Remainder), nil
+	}
+	if len(bound.From) == 0 || len(bound.From) != len(bound.To) {
+		return "", &PartitionError{Reason: "a range bound needs as many from values as to values"}
+	}
+	return fmt.Sprintf("FOR VALUES FROM (%s) TO (%s)", boundValues(bound.From), boundValues(bound.To)), nil
+}
+
+// boundValues quotes the values of a bound as literals so postgres casts them to the key
+// type, NULL, MINVALUE and MAXVALUE are kept as keywords
+func boundValues(values []string) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		switch strings.ToUpper(value) {
+		case "MINVALUE", "MAXVALUE", "NULL":
+			formatted[i] = strings.ToUpper(value)
+		default:
+			formatted[i] = QuoteLiteral(value)
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
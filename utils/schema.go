@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
@@ -13,6 +14,7 @@ type TableColumn struct {
 	TableName              string  `db:"table_name" json:"TableName"`
 	ColumnName             string  `db:"column_name" json:"ColumnName"`
 	DataType               string  `db:"data_type" json:"DataType"`
+	UdtName                string  `db:"udt_name" json:"UdtName,omitempty"`
 	IsNullable             bool    `db:"is_nullable" json:"IsNullable"`
 	ColumnDefault          *string `db:"column_default" json:"ColumnDefault"`
 	CharacterMaximumLength *int    `db:"character_maximum_length" json:"CharacterMaximumLength"`
@@ -21,16 +23,23 @@ type TableColumn struct {
 	OrdinalPosition        int     `db:"ordinal_position" json:"OrdinalPosition"`
 }
 
-// ConstraintInfo represents database constraints
+// ConstraintInfo represents database constraints, a constraint spans every column of Columns.
+// ColumnName and ForeignColumnName hold the first column of the lists
 type ConstraintInfo struct {
-	TableName         string  `db:"table_name" json:"TableName"`
-	ConstraintName    string  `db:"constraint_name" json:"ConstraintName"`
-	ConstraintType    string  `db:"constraint_type" json:"ConstraintType"`
-	ColumnName        *string `db:"column_name" json:"ColumnName"`
-	ForeignTableName  *string `db:"foreign_table_name" json:"ForeignTableName"`
-	ForeignColumnName *string `db:"foreign_column_name" json:"ForeignColumnName"`
-	CheckClause       *string `db:"check_clause" json:"CheckClause"`
-	OrdinalPosition   *int    `db:"ordinal_position" json:"OrdinalPosition"`
+	TableName         string   `db:"table_name" json:"TableName"`
+	ConstraintName    string   `db:"constraint_name" json:"ConstraintName"`
+	ConstraintType    string   `db:"constraint_type" json:"ConstraintType"`
+	ColumnName        *string  `db:"column_name" json:"ColumnName"`
+	Columns           []string `db:"columns" json:"Columns,omitempty"`
+	ForeignSchemaName *string  `db:"foreign_schema_name" json:"ForeignSchemaName,omitempty"`
+	ForeignTableName  *string  `db:"foreign_table_name" json:"ForeignTableName"`
+	ForeignColumnName *string  `db:"foreign_column_name" json:"ForeignColumnName"`
+	ForeignColumns    []string `db:"foreign_columns" json:"ForeignColumns,omitempty"`
+	// OnDelete and OnUpdate are the referential actions of a foreign key, NO ACTION when empty
+	OnDelete        string  `db:"on_delete" json:"OnDelete,omitempty"`
+	OnUpdate        string  `db:"on_update" json:"OnUpdate,omitempty"`
+	CheckClause     *string `db:"check_clause" json:"CheckClause"`
+	OrdinalPosition *int    `db:"ordinal_position" json:"OrdinalPosition"`
 }
 
 // IndexInfo represents database indexes
@@ -44,10 +53,14 @@ type IndexInfo struct {
 }
 
 type Table struct {
+	SchemaName  string           `db:"schema_name" json:"SchemaName,omitempty"`
 	TableName   string           `db:"table_name" json:"TableName"`
 	Columns     []TableColumn    `db:"columns" json:"Columns"`
 	Constraints []ConstraintInfo `db:"constraints" json:"Constraints"`
 	Indexes     []IndexInfo      `db:"indexes" json:"Indexes"`
+	// Partition is the partition key of a partitioned table, Partitions its attached partitions
+	Partition  *PartitionSpec `db:"-" json:"Partition,omitempty"`
+	Partitions []Partition    `db:"-" json:"Partitions,omitempty"`
 }
 
 type RenameRelation struct {
@@ -55,6 +68,40 @@ type RenameRelation struct {
 	NewName string `json:"newName"`
 }
 
+// constraintColumnsQuery reads one row per constraint, the key columns are listed in the order
+// of the constraint so composite keys keep the pairing of their columns
+const constraintColumnsQuery = `
+		SELECT
+			rel.relname AS table_name,
+			con.conname AS constraint_name,
+			CASE con.contype WHEN 'p' THEN 'PRIMARY KEY' WHEN 'u' THEN 'UNIQUE' WHEN 'f' THEN 'FOREIGN KEY' ELSE 'CHECK' END AS constraint_type,
+			cols.names[1] AS column_name,
+			COALESCE(cols.names, '{}') AS columns,
+			NULLIF(fns.nspname, n.nspname) AS foreign_schema_name,
+			frel.relname AS foreign_table_name,
+			fcols.names[1] AS foreign_column_name,
+			COALESCE(fcols.names, '{}') AS foreign_columns,
+			CASE WHEN con.contype <> 'f' THEN '' WHEN con.confdeltype = 'r' THEN 'RESTRICT' WHEN con.confdeltype = 'c' THEN 'CASCADE'
+				WHEN con.confdeltype = 'n' THEN 'SET NULL' WHEN con.confdeltype = 'd' THEN 'SET DEFAULT' ELSE 'NO ACTION' END AS on_delete,
+			CASE WHEN con.contype <> 'f' THEN '' WHEN con.confupdtype = 'r' THEN 'RESTRICT' WHEN con.confupdtype = 'c' THEN 'CASCADE'
+				WHEN con.confupdtype = 'n' THEN 'SET NULL' WHEN con.confupdtype = 'd' THEN 'SET DEFAULT' ELSE 'NO ACTION' END AS on_update,
+			CASE WHEN con.contype = 'c' THEN pg_get_expr(con.conbin, con.conrelid) END AS check_clause
+		FROM pg_constraint con
+		JOIN pg_class rel ON rel.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = rel.relnamespace
+		LEFT JOIN pg_class frel ON frel.oid = con.confrelid
+		LEFT JOIN pg_namespace fns ON fns.oid = frel.relnamespace
+		LEFT JOIN LATERAL (
+			SELECT array_agg(a.attname::text ORDER BY k.ord) AS names
+			FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		) cols ON true
+		LEFT JOIN LATERAL (
+			SELECT array_agg(a.attname::text ORDER BY k.ord) AS names
+			FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+		) fcols ON true`
+
 const (
 	// Query to get all tables and their columns with detailed information
 	getTablesAndColumnsQuery = `
@@ -62,6 +109,7 @@ const (
 			t.table_name AS table_name,
 			c.column_name AS column_name,
 			c.data_type AS data_type,
+			c.udt_name AS udt_name,
 			c.is_nullable = 'YES' AS is_nullable,
 			c.column_default AS column_default,
 			c.character_maximum_length AS character_maximum_length,
@@ -74,41 +122,20 @@ const (
 			information_schema.columns c ON t.table_name = c.table_name 
 			AND t.table_schema = c.table_schema
 		WHERE 
-			t.table_schema = 'public' 
+			t.table_schema = $1 
 			AND t.table_type = 'BASE TABLE'
+			-- partitions are listed with their partitioned table
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_class pc JOIN pg_namespace pn ON pn.oid = pc.relnamespace
+				WHERE pn.nspname = t.table_schema AND pc.relname = t.table_name AND pc.relispartition
+			)
 		ORDER BY 
 			t.table_name, c.ordinal_position;`
 
 	// Query to get all constraints (PRIMARY KEY, FOREIGN KEY, UNIQUE, CHECK)
-	getConstraintsQuery = `
-		SELECT 
-			tc.table_name AS table_name,
-			tc.constraint_name AS constraint_name,
-			tc.constraint_type AS constraint_type,
-			kcu.column_name AS column_name,
-			ccu.table_name AS foreign_table_name,
-			ccu.column_name AS foreign_column_name,
-			cc.check_clause AS check_clause,
-			kcu.ordinal_position AS ordinal_position
-		FROM 
-			information_schema.table_constraints tc
-		LEFT JOIN 
-			information_schema.key_column_usage kcu 
-			ON tc.constraint_name = kcu.constraint_name 
-			AND tc.table_schema = kcu.table_schema
-		LEFT JOIN 
-			information_schema.constraint_column_usage ccu 
-			ON tc.constraint_name = ccu.constraint_name 
-			AND tc.table_schema = ccu.table_schema
-		LEFT JOIN 
-			information_schema.check_constraints cc 
-			ON tc.constraint_name = cc.constraint_name 
-			AND tc.table_schema = cc.constraint_schema
-		WHERE 
-			tc.table_schema = 'public'
-			AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY', 'UNIQUE', 'CHECK')
-		ORDER BY 
-			tc.table_name, tc.constraint_type, kcu.ordinal_position;`
+	getConstraintsQuery = constraintColumnsQuery + `
+		WHERE n.nspname = $1 AND con.contype IN ('p', 'u', 'f', 'c')
+		ORDER BY rel.relname, constraint_type, con.conname;`
 
 	// Query to get all indexes (excluding those created by constraints)
 	getIndexesQuery = `
@@ -130,15 +157,15 @@ const (
 			AND i.oid = ix.indexrelid
 			AND a.attrelid = t.oid
 			AND a.attnum = ANY(ix.indkey)
-			AND t.relkind = 'r'
+			AND t.relkind IN ('r', 'p')
 			AND am.oid = i.relam
-			AND t.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = 'public')
+			AND t.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = $1)
 			AND NOT ix.indisprimary  -- Exclude primary key indexes (handled by constraints)
 			AND NOT EXISTS (
 				SELECT 1 FROM information_schema.table_constraints tc
 				WHERE tc.table_name = t.relname 
 				AND tc.constraint_type IN ('UNIQUE', 'FOREIGN KEY')
-				AND tc.table_schema = 'public'
+				AND tc.table_schema = $1
 			)
 		ORDER BY 
 			t.relname, i.relname;`
@@ -149,6 +176,7 @@ const (
 			t.table_name AS table_name,
 			c.column_name AS column_name,
 			c.data_type AS data_type,
+			c.udt_name AS udt_name,
 			c.is_nullable = 'YES' AS is_nullable,
 			c.column_default AS column_default,
 			c.character_maximum_length AS character_maximum_length,
@@ -161,43 +189,16 @@ const (
 			information_schema.columns c ON t.table_name = c.table_name 
 			AND t.table_schema = c.table_schema
 		WHERE 
-			t.table_schema = 'public' 
+			t.table_schema = $2 
 			AND t.table_type = 'BASE TABLE'
 			AND t.table_name = $1
 		ORDER BY 
 			t.table_name, c.ordinal_position;`
 	
 	// Query to get constraints for a specific table
-	getTableConstraintsQuery = `
-		SELECT 
-			tc.table_name AS table_name,
-			tc.constraint_name AS constraint_name,
-			tc.constraint_type AS constraint_type,
-			kcu.column_name AS column_name,
-			ccu.table_name AS foreign_table_name,
-			ccu.column_name AS foreign_column_name,
-			cc.check_clause AS check_clause,
-			kcu.ordinal_position AS ordinal_position
-		FROM 
-			information_schema.table_constraints tc
-		LEFT JOIN 
-			information_schema.key_column_usage kcu 
-			ON tc.constraint_name = kcu.constraint_name 
-			AND tc.table_schema = kcu.table_schema
-		LEFT JOIN 
-			information_schema.constraint_column_usage ccu 
-			ON tc.constraint_name = ccu.constraint_name 
-			AND tc.table_schema = ccu.table_schema
-		LEFT JOIN 
-			information_schema.check_constraints cc 
-			ON tc.constraint_name = cc.constraint_name 
-			AND tc.table_schema = cc.constraint_schema
-		WHERE 
-			tc.table_schema = 'public'
-			AND tc.table_name = $1
-			AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY', 'UNIQUE', 'CHECK')
-		ORDER BY 
-			tc.table_name, tc.constraint_type, kcu.ordinal_position;`
+	getTableConstraintsQuery = constraintColumnsQuery + `
+		WHERE n.nspname = $2 AND rel.relname = $1 AND con.contype IN ('p', 'u', 'f', 'c')
+		ORDER BY constraint_type, con.conname;`
 
 	// Query to get indexes for a specific table
 	getTableIndexesQuery = `
@@ -219,9 +220,9 @@ const (
 			AND i.oid = ix.indexrelid
 			AND a.attrelid = t.oid
 			AND a.attnum = ANY(ix.indkey)
-			AND t.relkind = 'r'
+			AND t.relkind IN ('r', 'p')
 			AND am.oid = i.relam
-			AND t.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = 'public')
+			AND t.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = $2)
 			AND t.relname = $1
 		ORDER BY 
 			t.relname, i.relname;`
@@ -258,8 +259,13 @@ the schema format for the tables is:
 	}
 */
 func GetTables(ctx context.Context, db Querier) (map[string]*Table, error) {
+	return GetSchemaTables(ctx, db, DefaultSchema)
+}
+
+// GetSchemaTables returns the tables of a schema keyed by table name
+func GetSchemaTables(ctx context.Context, db Querier, schema string) (map[string]*Table, error) {
 	// Get all tables and columns
-	columnsRows, err := db.Query(ctx, getTablesAndColumnsQuery)
+	columnsRows, err := db.Query(ctx, getTablesAndColumnsQuery, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query table columns: %w", err)
 	}
@@ -277,7 +283,7 @@ func GetTables(ctx context.Context, db Querier) (map[string]*Table, error) {
 	}
 
 	// Get all constraints
-	constraints, err := GetConstraints(ctx, db)
+	constraints, err := GetConstraints(ctx, db, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get constraints: %w", err)
 	}
@@ -288,7 +294,7 @@ func GetTables(ctx context.Context, db Querier) (map[string]*Table, error) {
 	}
 
 	// Get all indexes
-	indexes, err := GetIndexes(ctx, db)
+	indexes, err := GetIndexes(ctx, db, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get indexes: %w", err)
 	}
@@ -298,22 +304,41 @@ func GetTables(ctx context.Context, db Querier) (map[string]*Table, error) {
 		tableIndexes[index.TableName] = append(tableIndexes[index.TableName], index)
 	}
 
+	// Get the partition keys and the partitions of the partitioned tables
+	partitionKeys, err := GetPartitionKeys(ctx, db, schema, "")
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := GetPartitions(ctx, db, schema, "")
+	if err != nil {
+		return nil, err
+	}
+
+	tablePartitions := make(map[string][]Partition)
+	for _, partition := range partitions {
+		tablePartitions[partition.ParentName] = append(tablePartitions[partition.ParentName], partition)
+	}
+
 	tablesMap := make(map[string]*Table)
 	for tableName, columns := range tableColumns {
 		tablesMap[tableName] = &Table{
+			SchemaName:  schema,
 			TableName:   tableName,
 			Columns:     columns,
 			Constraints: tableConstraints[tableName],
 			Indexes:     tableIndexes[tableName],
+			Partition:   partitionKeys[tableName],
+			Partitions:  tablePartitions[tableName],
 		}
 	}
 
 	return tablesMap, nil
 }
 
-func GetTableSchema(ctx context.Context, tableName string, db Querier) (*Table, error) {
+func GetTableSchema(ctx context.Context, schema, tableName string, db Querier) (*Table, error) {
 	// Get the table schema
-	schemaRows, err := db.Query(ctx, getTableSchemaQuery, tableName)
+	schemaRows, err := db.Query(ctx, getTableSchemaQuery, tableName, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query table schema: %w", err)
 	}
@@ -330,14 +355,15 @@ func GetTableSchema(ctx context.Context, tableName string, db Querier) (*Table,
 	}
 
 	return &Table{
-		TableName: tableName,
-		Columns:   columns,
+		SchemaName: schema,
+		TableName:  tableName,
+		Columns:    columns,
 	}, nil
 }
 
-func GetTableConstraints(ctx context.Context, tableName string, db Querier) ([]ConstraintInfo, error) {
+func GetTableConstraints(ctx context.Context, schema, tableName string, db Querier) ([]ConstraintInfo, error) {
 	// Get the table constraints
-	constraintsRows, err := db.Query(ctx, getTableConstraintsQuery, tableName)
+	constraintsRows, err := db.Query(ctx, getTableConstraintsQuery, tableName, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query table constraints: %w", err)
 	}
@@ -349,21 +375,12 @@ func GetTableConstraints(ctx context.Context, tableName string, db Querier) ([]C
 		return nil, fmt.Errorf("failed to scan table constraints: %w", err)
 	}
 
-	for i := range len(constraints) {
-		if constraints[i].ConstraintType == "CHECK" {
-			constraints[i].ColumnName = &strings.Split(*constraints[i].CheckClause, " ")[0]
-			if strings.Contains(*constraints[i].CheckClause, "IS NOT NULL") {
-				constraints[i].ConstraintType = "NOT NULL"
-			}
-		}
-	}
-
 	return constraints, nil
 }
 
-func GetTableIndexes(ctx context.Context, tableName string, db Querier) ([]IndexInfo, error) {
+func GetTableIndexes(ctx context.Context, schema, tableName string, db Querier) ([]IndexInfo, error) {
 	// Get the table indexes
-	indexesRows, err := db.Query(ctx, getTableIndexesQuery, tableName)
+	indexesRows, err := db.Query(ctx, getTableIndexesQuery, tableName, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query table indexes: %w", err)
 	}
@@ -379,35 +396,57 @@ func GetTableIndexes(ctx context.Context, tableName string, db Querier) ([]Index
 }
 
 func GetTable(ctx context.Context, tableName string, db Querier) (*Table, error) {
+	return GetSchemaTable(ctx, DefaultSchema, tableName, db)
+}
+
+// GetSchemaTable returns the columns, constraints and indexes of a table in a schema
+func GetSchemaTable(ctx context.Context, schema, tableName string, db Querier) (*Table, error) {
 	// Get the table schema
-	tableSchema, err := GetTableSchema(ctx, tableName, db)
+	tableSchema, err := GetTableSchema(ctx, schema, tableName, db)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the table constraints
-	constraints, err := GetTableConstraints(ctx, tableName, db)
+	constraints, err := GetTableConstraints(ctx, schema, tableName, db)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the table indexes
-	indexes, err := GetTableIndexes(ctx, tableName, db)
+	indexes, err := GetTableIndexes(ctx, schema, tableName, db)
 	if err != nil {
 		return nil, err
 	}
 
+	// Get the partition key and the partitions when the table is partitioned
+	partitionKeys, err := GetPartitionKeys(ctx, db, schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []Partition
+	if partitionKeys[tableName] != nil {
+		partitions, err = GetPartitions(ctx, db, schema, tableName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Table{
+		SchemaName:  schema,
 		TableName:   tableName,
 		Columns:     tableSchema.Columns,
 		Constraints: constraints,
 		Indexes:     indexes,
+		Partition:   partitionKeys[tableName],
+		Partitions:  partitions,
 	}, nil
 }
 
-func GetConstraints(ctx context.Context, db Querier) ([]ConstraintInfo, error) {
+func GetConstraints(ctx context.Context, db Querier, schema string) ([]ConstraintInfo, error) {
 	// Get all constraints
-	constraintsRows, err := db.Query(ctx, getConstraintsQuery)
+	constraintsRows, err := db.Query(ctx, getConstraintsQuery, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query constraints: %w", err)
 	}
@@ -422,9 +461,9 @@ func GetConstraints(ctx context.Context, db Querier) ([]ConstraintInfo, error) {
 	return constraints, nil
 }
 
-func GetIndexes(ctx context.Context, db Querier) ([]IndexInfo, error) {
+func GetIndexes(ctx context.Context, db Querier, schema string) ([]IndexInfo, error) {
 	// Get all indexes
-	indexesRows, err := db.Query(ctx, getIndexesQuery)
+	indexesRows, err := db.Query(ctx, getIndexesQuery, schema)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query indexes: %w", err)
 	}
@@ -445,8 +484,15 @@ func ExtractDatabaseSchema(ctx context.Context, db Querier) (string, error) {
 	ddlStatements.WriteString("-- Database Schema DDL Export\n")
 	ddlStatements.WriteString("-- Generated automatically\n\n")
 
+	// Types come first so the columns can use them
+	types, err := generateCreateTypeStatements(ctx, db, DefaultSchema)
+	if err != nil {
+		return "", err
+	}
+	ddlStatements.WriteString(types)
+
 	// Get all tables and columns
-	columnsRows, err := db.Query(ctx, getTablesAndColumnsQuery)
+	columnsRows, err := db.Query(ctx, getTablesAndColumnsQuery, DefaultSchema)
 	if err != nil {
 		return "", fmt.Errorf("failed to query table columns: %w", err)
 	}
@@ -459,7 +505,7 @@ func ExtractDatabaseSchema(ctx context.Context, db Querier) (string, error) {
 	}
 
 	// Get all constraints
-	constraintsRows, err := db.Query(ctx, getConstraintsQuery)
+	constraintsRows, err := db.Query(ctx, getConstraintsQuery, DefaultSchema)
 	if err != nil {
 		return "", fmt.Errorf("failed to query constraints: %w", err)
 	}
@@ -472,7 +518,7 @@ func ExtractDatabaseSchema(ctx context.Context, db Querier) (string, error) {
 	}
 
 	// Get all indexes
-	indexesRows, err := db.Query(ctx, getIndexesQuery)
+	indexesRows, err := db.Query(ctx, getIndexesQuery, DefaultSchema)
 	if err != nil {
 		return "", fmt.Errorf("failed to query indexes: %w", err)
 	}
@@ -501,9 +547,14 @@ func ExtractDatabaseSchema(ctx context.Context, db Querier) (string, error) {
 		tableIndexes[index.TableName] = append(tableIndexes[index.TableName], index)
 	}
 
+	partitionKeys, err := GetPartitionKeys(ctx, db, DefaultSchema, "")
+	if err != nil {
+		return "", err
+	}
+
 	// Generate CREATE TABLE statements
 	for tableName, cols := range tableColumns {
-		ddlStatements.WriteString(generateCreateTableStatement(tableName, cols, tableConstraints[tableName]))
+		ddlStatements.WriteString(generateCreateTableStatement(tableName, cols, tableConstraints[tableName], partitionKeys[tableName]))
 		ddlStatements.WriteString("\n")
 
 		// Add indexes for this table
@@ -516,14 +567,68 @@ func ExtractDatabaseSchema(ctx context.Context, db Querier) (string, error) {
 		ddlStatements.WriteString("\n")
 	}
 
+	// Partitions are created once their partitioned tables exist
+	partitions, err := GetPartitions(ctx, db, DefaultSchema, "")
+	if err != nil {
+		return "", err
+	}
+	for _, partition := range orderPartitions(partitions) {
+		ddlStatements.WriteString(generateCreatePartitionStatement(DefaultSchema, &partition, partitionKeys[partition.Name]))
+		ddlStatements.WriteString("\n")
+	}
+
 	return ddlStatements.String(), nil
 }
 
+// generateCreateTypeStatements creates the CREATE TYPE statements of the enum and composite types of a schema
+func generateCreateTypeStatements(ctx context.Context, db Querier, schema string) (string, error) {
+	var enums []ExportEnum
+	if err := pgxscan.Select(ctx, db, &enums, exportEnumsQuery, schema); err != nil {
+		return "", fmt.Errorf("failed to query enum types: %w", err)
+	}
+	composites, err := GetCompositeTypes(ctx, db, schema)
+	if err != nil {
+		return "", err
+	}
+
+	var stmt strings.Builder
+	for _, enum := range enums {
+		labels := make([]string, len(enum.Labels))
+		for i, label := range enum.Labels {
+			labels[i] = QuoteLiteral(label)
+		}
+		stmt.WriteString(fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);\n", QuoteIdentifier(enum.Name), strings.Join(labels, ", ")))
+	}
+
+	names := make([]string, len(composites))
+	byName := make(map[string]ExportComposite, len(composites))
+	deps := make(map[string][]string, len(composites))
+	for i, composite := range composites {
+		names[i] = composite.Name
+		byName[composite.Name] = composite
+		deps[composite.Name] = composite.DependsOn
+	}
+	for _, name := range OrderByDependencies(names, deps) {
+		stmt.WriteString(fmt.Sprintf("CREATE TYPE %s AS (%s);\n", QuoteIdentifier(name), strings.Join(byName[name].Attributes, ", ")))
+	}
+
+	if stmt.Len() > 0 {
+		stmt.WriteString("\n")
+	}
+	return stmt.String(), nil
+}
+
 func GenerateCreateTableDDL(table *Table) (string, error) {
 	var ddlStatements strings.Builder
 	ddlStatements.WriteString("-- Database Schema DDL Export\n")
 	ddlStatements.WriteString("-- Generated automatically\n\n")
-	ddlStatements.WriteString(generateCreateTableStatement(table.TableName, table.Columns, table.Constraints))
+	if err := ValidateConstraints(MergeConstraints(table.TableName, table.Constraints)); err != nil {
+		return "", err
+	}
+	if err := ValidatePartitionSpec(table.Partition, table.Columns); err != nil {
+		return "", err
+	}
+	ddlStatements.WriteString(generateCreateTableStatement(table.TableName, table.Columns, table.Constraints, table.Partition))
 	ddlStatements.WriteString("\n")
 	// Add indexes for this table
 	ddlStatements.WriteString("\n-- Indexes\n")
@@ -534,8 +639,8 @@ func GenerateCreateTableDDL(table *Table) (string, error) {
 	return ddlStatements.String(), nil
 }
 
-// generateCreateTableStatement creates a CREATE TABLE DDL statement
-func generateCreateTableStatement(tableName string, columns []TableColumn, constraints []ConstraintInfo) string {
+// generateCreateTableStatement creates a CREATE TABLE DDL statement, partitioned when a partition key is given
+func generateCreateTableStatement(tableName string, columns []TableColumn, constraints []ConstraintInfo, partition *PartitionSpec) string {
 	var stmt strings.Builder
 	stmt.WriteString(fmt.Sprintf("CREATE TABLE \"%s\" (\n", tableName))
 
@@ -555,56 +660,17 @@ func generateCreateTableStatement(tableName string, columns []TableColumn, const
 		columnDefs = append(columnDefs, columnDef)
 	}
 
-	// Group constraints by type
-	primaryKeys := make([]string, 0)
-	uniqueConstraints := make(map[string][]string)
-	foreignKeys := make([]ConstraintInfo, 0)
-	checkConstraints := make([]ConstraintInfo, 0)
-
-	for _, constraint := range constraints {
-		switch constraint.ConstraintType {
-		case "PRIMARY KEY":
-			if constraint.ColumnName != nil {
-				primaryKeys = append(primaryKeys, *constraint.ColumnName)
-			}
-		case "UNIQUE":
-			if constraint.ColumnName != nil {
-				uniqueConstraints[constraint.ConstraintName] = append(uniqueConstraints[constraint.ConstraintName], *constraint.ColumnName)
-			}
-		case "FOREIGN KEY":
-			foreignKeys = append(foreignKeys, constraint)
-		case "CHECK":
-			checkConstraints = append(checkConstraints, constraint)
-		}
-	}
-
-	// Add PRIMARY KEY constraint
-	if len(primaryKeys) > 0 {
-		columnDefs = append(columnDefs, fmt.Sprintf("    PRIMARY KEY (\"%s\")", strings.Join(primaryKeys, "\", \"")))
-	}
-
-	// Add UNIQUE constraints
-	for constraintName, cols := range uniqueConstraints {
-		columnDefs = append(columnDefs, fmt.Sprintf("    CONSTRAINT \"%s\" UNIQUE (\"%s\")", constraintName, strings.Join(cols, "\", \"")))
-	}
-
-	// Add FOREIGN KEY constraints
-	for _, fk := range foreignKeys {
-		if fk.ColumnName != nil && fk.ForeignTableName != nil && fk.ForeignColumnName != nil {
-			columnDefs = append(columnDefs, fmt.Sprintf("    CONSTRAINT \"%s\" FOREIGN KEY (\"%s\") REFERENCES \"%s\" (\"%s\")",
-				fk.ConstraintName, *fk.ColumnName, *fk.ForeignTableName, *fk.ForeignColumnName))
-		}
-	}
-
-	// Add CHECK constraints
-	for _, check := range checkConstraints {
-		if check.CheckClause != nil {
-			columnDefs = append(columnDefs, fmt.Sprintf("    CONSTRAINT \"%s\" CHECK (%s)", check.ConstraintName, *check.CheckClause))
-		}
+	// Add the PRIMARY KEY, UNIQUE, FOREIGN KEY and CHECK constraints
+	for _, constraint := range MergeConstraints(tableName, constraints) {
+		columnDefs = append(columnDefs, "    "+FormatConstraint(&constraint))
 	}
 
 	stmt.WriteString(strings.Join(columnDefs, ",\n"))
-	stmt.WriteString("\n);")
+	stmt.WriteString("\n)")
+	if partition != nil {
+		stmt.WriteString(" " + PartitionByClause(partition))
+	}
+	stmt.WriteString(";")
 
 	return stmt.String()
 }
@@ -625,6 +691,18 @@ func formatDataType(col TableColumn) string {
 	dataType := strings.ToUpper(col.DataType)
 
 	switch dataType {
+	case "USER-DEFINED":
+		// enum and composite types are named by their udt, which is case sensitive
+		if col.UdtName != "" {
+			return QuoteIdentifier(col.UdtName)
+		}
+		return dataType
+	case "ARRAY":
+		// the udt of an array type is its element type prefixed with an underscore
+		if col.UdtName != "" {
+			return strings.TrimPrefix(col.UdtName, "_") + "[]"
+		}
+		return dataType
 	case "CHARACTER VARYING", "VARCHAR":
 		if col.CharacterMaximumLength != nil {
 			return fmt.Sprintf("VARCHAR(%d)", *col.CharacterMaximumLength)
@@ -647,25 +725,13 @@ func formatDataType(col TableColumn) string {
 	}
 }
 
-func formatConstraint(constraint *ConstraintInfo) string {
-	if constraint == nil {
-		return ""
-	}
-
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("CONSTRAINT \"%s\"", constraint.ConstraintName))
-	switch constraint.ConstraintType {
-	case "PRIMARY KEY":
-		sb.WriteString(fmt.Sprintf(" PRIMARY KEY (\"%s\")", *constraint.ColumnName))
-	case "UNIQUE":
-		sb.WriteString(fmt.Sprintf(" UNIQUE (\"%s\")", *constraint.ColumnName))
-	case "FOREIGN KEY":
-		sb.WriteString(fmt.Sprintf(" FOREIGN KEY (\"%s\") REFERENCES \"%s\" (\"%s\")",
-			*constraint.ColumnName, *constraint.ForeignTableName, *constraint.ForeignColumnName))
-	case "CHECK":
-		sb.WriteString(fmt.Sprintf(" CHECK (%s)", *constraint.CheckClause))
-	}
-	return sb.String()
+// sameDataType compares the types of two columns the way they are written in the DDL,
+// so a user defined type read from the catalog matches the plain type name given by a client
+func sameDataType(a, b TableColumn) bool {
+	normalize := func(col TableColumn) string {
+		return strings.ToLower(strings.ReplaceAll(formatDataType(col), `"`, ""))
+	}
+	return normalize(a) == normalize(b)
 }
 
 // function that compares two tables schema and returns the DDL statements to update the schema to turn old -> new
@@ -676,6 +742,10 @@ func CompareTableSchemas(oldTable, newTable *Table, renames []RenameRelation) (s
 	var ddlStatements strings.Builder
 	ddlStatements.WriteString(fmt.Sprintf("-- Comparing table schema for %s\n", oldTable.TableName))
 	ddlStatements.WriteString("-- Generated automatically\n\n")
+	// the partition key is fixed when the table is created, a schema without one keeps the current key
+	if newTable.Partition != nil && !samePartitionSpec(oldTable.Partition, newTable.Partition) {
+		return "", &PartitionError{Reason: "the partition key of an existing table can't be changed"}
+	}
 	// Compare names
 	if oldTable.TableName != newTable.TableName {
 		ddlStatements.WriteString(fmt.Sprintf("ALTER TABLE \"%s\" RENAME TO \"%s\";\n",
@@ -729,7 +799,7 @@ func CompareTableSchemas(oldTable, newTable *Table, renames []RenameRelation) (s
 		// check for changes in column properties
 		oldColumn := oldColumns[colName]
 		// type changes
-		if oldColumn.DataType != newCol.DataType {
+		if !sameDataType(*oldColumn, *newCol) {
 			ddlStatements.WriteString(fmt.Sprintf("ALTER TABLE \"%s\" ALTER COLUMN \"%s\" TYPE %s;\n",
 				newTable.TableName, colName, formatDataType(*newCol)))
 		}
@@ -794,31 +864,47 @@ func CompareTableSchemas(oldTable, newTable *Table, renames []RenameRelation) (s
 		}
 	}
 
-	// Compare constraints
+	// Compare constraints, a constraint whose definition changed is dropped and added again
+	newMerged := MergeConstraints(newTable.TableName, newTable.Constraints)
+	if err := ValidateConstraints(newMerged); err != nil {
+		return "", err
+	}
+	tableSchema := SchemaOrDefault(oldTable.SchemaName)
 	oldConstraints := make(map[string]*ConstraintInfo)
-	for _, constraint := range oldTable.Constraints {
+	for _, constraint := range MergeConstraints(oldTable.TableName, oldTable.Constraints) {
 		oldConstraints[constraint.ConstraintName] = &constraint
 	}
 	newConstraints := make(map[string]*ConstraintInfo)
-	for _, constraint := range newTable.Constraints {
+	addedConstraints := make([]*ConstraintInfo, 0)
+	for _, constraint := range newMerged {
+		if constraint.ForeignSchemaName != nil && *constraint.ForeignSchemaName == tableSchema {
+			constraint.ForeignSchemaName = nil
+		}
 		newConstraints[constraint.ConstraintName] = &constraint
+		if oldConstraint, exists := oldConstraints[constraint.ConstraintName]; !exists || !sameConstraint(oldConstraint, &constraint) {
+			addedConstraints = append(addedConstraints, &constraint)
+		}
 	}
 
-	// drop constraints that are in old but not in new add executed with IF EXISTS to avoid errors
+	// drop constraints that are in old but not in new or that changed, executed with IF EXISTS to avoid errors
+	// foreign keys are dropped first so the keys they reference can be dropped after them
+	droppedConstraints := make([]*ConstraintInfo, 0)
 	for constraintName, oldConstraint := range oldConstraints {
-		if _, exists := newConstraints[constraintName]; !exists {
-			ddlStatements.WriteString(fmt.Sprintf("ALTER TABLE \"%s\" DROP CONSTRAINT IF EXISTS \"%s\";\n",
-				newTable.TableName, oldConstraint.ConstraintName))
-			continue
+		if newConstraint, exists := newConstraints[constraintName]; !exists || !sameConstraint(oldConstraint, newConstraint) {
+			droppedConstraints = append(droppedConstraints, oldConstraint)
 		}
 	}
+	sortConstraints(droppedConstraints, true)
+	for _, oldConstraint := range droppedConstraints {
+		ddlStatements.WriteString(fmt.Sprintf("ALTER TABLE \"%s\" DROP CONSTRAINT IF EXISTS \"%s\";\n",
+			newTable.TableName, oldConstraint.ConstraintName))
+	}
 
-	// add constraints that are in new but not in old 
-	for constraintName, newConstraint := range newConstraints {
-		if _, exists := oldConstraints[constraintName]; !exists {
-			ddlStatements.WriteString(fmt.Sprintf("ALTER TABLE \"%s\" ADD %s;\n",
-				newTable.TableName, formatConstraint(newConstraint)))
-		}
+	// add constraints that are in new but not in old, keys come before the foreign keys that may reference them
+	sortConstraints(addedConstraints, false)
+	for _, newConstraint := range addedConstraints {
+		ddlStatements.WriteString(fmt.Sprintf("ALTER TABLE \"%s\" ADD %s;\n",
+			newTable.TableName, FormatConstraint(newConstraint)))
 	}
 
 	// compare indexes
@@ -842,6 +928,7 @@ func CompareTableSchemas(oldTable, newTable *Table, renames []RenameRelation) (s
 	for indexName, newIndex := range newIndexes {
 		if _, exists := oldIndexes[indexName]; !exists {
 			ddlStatements.WriteString(generateCreateIndexStatement(newIndex))
+			ddlStatements.WriteString("\n")
 		}
 	}
 
@@ -850,4 +937,203 @@ func CompareTableSchemas(oldTable, newTable *Table, renames []RenameRelation) (s
 		return "", fmt.Errorf("no changes detected between old and new table schemas")
 	}
 	return ddlStatements.String(), nil
-}
\ No newline at end of file
+}
+// SchemaChange describes a single operation of a schema update that can lose data or
+// weaken the table guarantees (dropped columns, dropped constraints and narrowing type changes)
+type SchemaChange struct {
+	Operation  string `json:"operation"`
+	Column     string `json:"column,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	OldType    string `json:"oldType,omitempty"`
+	NewType    string `json:"newType,omitempty"`
+	Detail     string `json:"detail"`
+}
+
+const (
+	DropColumnOperation     = "DROP COLUMN"
+	DropConstraintOperation = "DROP CONSTRAINT"
+	NarrowTypeOperation     = "NARROW TYPE"
+)
+
+// integer and floating point types ordered by their storage size
+var numericTypeRanks = map[string]int{
+	"SMALLINT":         1,
+	"INTEGER":          2,
+	"BIGINT":           3,
+	"REAL":             4,
+	"DOUBLE PRECISION": 5,
+	"NUMERIC":          6,
+}
+
+// DestructiveChanges lists the operations of old -> new that CompareTableSchemas would generate
+// and that may discard data or relax constraints
+func DestructiveChanges(oldTable, newTable *Table, renames []RenameRelation) []SchemaChange {
+	changes := make([]SchemaChange, 0)
+
+	renamedColumns := make(map[string]string)
+	for _, rename := range renames {
+		renamedColumns[rename.OldName] = rename.NewName
+	}
+
+	newColumns := make(map[string]TableColumn)
+	for _, col := range newTable.Columns {
+		newColumns[col.ColumnName] = col
+	}
+
+	for _, oldCol := range oldTable.Columns {
+		name := oldCol.ColumnName
+		if newName, renamed := renamedColumns[name]; renamed {
+			name = newName
+		}
+		newCol, exists := newColumns[name]
+		if !exists {
+			changes = append(changes, SchemaChange{
+				Operation: DropColumnOperation,
+				Column:    oldCol.ColumnName,
+				OldType:   formatDataType(oldCol),
+				Detail:    fmt.Sprintf("column %s and all of its values will be removed", oldCol.ColumnName),
+			})
+			continue
+		}
+		if IsNarrowingTypeChange(oldCol, newCol) {
+			changes = append(changes, SchemaChange{
+				Operation: NarrowTypeOperation,
+				Column:    oldCol.ColumnName,
+				OldType:   formatDataType(oldCol),
+				NewType:   formatDataType(newCol),
+				Detail:    fmt.Sprintf("existing values of %s may be truncated or fail to convert", oldCol.ColumnName),
+			})
+		}
+	}
+
+	newConstraints := make(map[string]bool)
+	for _, constraint := range newTable.Constraints {
+		newConstraints[constraint.ConstraintName] = true
+	}
+	droppedConstraints := make(map[string]bool)
+	for _, constraint := range oldTable.Constraints {
+		if newConstraints[constraint.ConstraintName] || droppedConstraints[constraint.ConstraintName] {
+			continue
+		}
+		droppedConstraints[constraint.ConstraintName] = true
+		changes = append(changes, SchemaChange{
+			Operation:  DropConstraintOperation,
+			Constraint: constraint.ConstraintName,
+			Detail:     fmt.Sprintf("%s constraint %s will no longer be enforced", constraint.ConstraintType, constraint.ConstraintName),
+		})
+	}
+
+	return changes
+}
+
+// IsNarrowingTypeChange reports whether converting a column from old to new type can lose
+// information or reject existing values
+func IsNarrowingTypeChange(oldCol, newCol TableColumn) bool {
+	oldType := CanonicalTypeName(oldCol.DataType)
+	newType := CanonicalTypeName(newCol.DataType)
+
+	// every type can be represented as text
+	if newType == "TEXT" {
+		return false
+	}
+
+	if isCharacterType(oldType) && isCharacterType(newType) {
+		if newCol.CharacterMaximumLength == nil {
+			return false
+		}
+		return oldType == "TEXT" || oldCol.CharacterMaximumLength == nil ||
+			*newCol.CharacterMaximumLength < *oldCol.CharacterMaximumLength
+	}
+
+	oldRank, oldNumeric := numericTypeRanks[oldType]
+	newRank, newNumeric := numericTypeRanks[newType]
+	if oldNumeric && newNumeric {
+		if oldType == "NUMERIC" && newType == "NUMERIC" {
+			return isSmaller(newCol.NumericPrecision, oldCol.NumericPrecision) ||
+				isSmaller(newCol.NumericScale, oldCol.NumericScale)
+		}
+		// floating point and arbitrary precision values can't be stored in integers without rounding
+		if oldRank > numericTypeRanks["BIGINT"] && newRank <= numericTypeRanks["BIGINT"] {
+			return true
+		}
+		// an unbounded numeric can't be represented exactly by a float
+		if oldType == "NUMERIC" {
+			return true
+		}
+		if newType == "NUMERIC" {
+			return newCol.NumericPrecision != nil
+		}
+		// the mantissa of the float is too short for every value of the integer
+		if lossyFloatConversions[[2]string{oldType, newType}] {
+			return true
+		}
+		return newRank < oldRank
+	}
+
+	// conversion between unrelated type families
+	return oldType != newType
+}
+
+func isCharacterType(dataType string) bool {
+	switch dataType {
+	case "TEXT", "CHARACTER VARYING", "CHARACTER":
+		return true
+	}
+	return false
+}
+
+// lossyFloatConversions are the integer to float conversions whose mantissa is too short to hold
+// every value of the integer
+var lossyFloatConversions = map[[2]string]bool{
+	{"INTEGER", "REAL"}:            true,
+	{"BIGINT", "REAL"}:             true,
+	{"BIGINT", "DOUBLE PRECISION"}: true,
+}
+
+// typeAliases maps the aliases of the built-in types to the names information_schema reports
+var typeAliases = map[string]string{
+	"INT":         "INTEGER",
+	"INT4":        "INTEGER",
+	"SERIAL":      "INTEGER",
+	"SERIAL4":     "INTEGER",
+	"INT2":        "SMALLINT",
+	"SMALLSERIAL": "SMALLINT",
+	"SERIAL2":     "SMALLINT",
+	"INT8":        "BIGINT",
+	"BIGSERIAL":   "BIGINT",
+	"SERIAL8":     "BIGINT",
+	"FLOAT4":      "REAL",
+	"FLOAT8":      "DOUBLE PRECISION",
+	"FLOAT":       "DOUBLE PRECISION",
+	"DECIMAL":     "NUMERIC",
+	"VARCHAR":     "CHARACTER VARYING",
+	"CHAR":        "CHARACTER",
+	"BPCHAR":      "CHARACTER",
+	"BOOL":        "BOOLEAN",
+	"TIMESTAMPTZ": "TIMESTAMP WITH TIME ZONE",
+	"TIMESTAMP":   "TIMESTAMP WITHOUT TIME ZONE",
+	"TIMETZ":      "TIME WITH TIME ZONE",
+	"TIME":        "TIME WITHOUT TIME ZONE",
+	"VARBIT":      "BIT VARYING",
+}
+
+// typeModifiers matches the length, precision or scale of a type name, such as (20) or (10, 2)
+var typeModifiers = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// CanonicalTypeName returns the upper case name information_schema reports for a type, without its
+// modifiers, so aliases such as int4 and INTEGER or timestamptz and timestamp with time zone compare equal
+func CanonicalTypeName(dataType string) string {
+	name := strings.Join(strings.Fields(strings.ToUpper(typeModifiers.ReplaceAllString(dataType, ""))), " ")
+	if canonical, ok := typeAliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// isSmaller reports whether a limit is tighter than b, nil means unlimited
+func isSmaller(a, b *int) bool {
+	if a == nil {
+		return false
+	}
+	return b == nil || *a < *b
+}
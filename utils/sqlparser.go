@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	pgparser "github.com/pganalyze/pg_query_go/v6/parser"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// UnsafeFunctions administrate the server, access its files or run the SQL text they are given, they
+// are never run on behalf of a project whatever schema they are qualified with
+var UnsafeFunctions = []string{
+	// server and session administration
+	"pg_terminate_backend", "pg_cancel_backend", "pg_reload_conf", "pg_rotate_logfile",
+	"pg_promote", "pg_switch_wal", "pg_create_restore_point", "set_config", "pg_sleep",
+	"pg_sleep_for", "pg_sleep_until", "pg_stat_reset", "pg_stat_reset_shared",
+	"pg_stat_reset_single_table_counters", "pg_stat_reset_single_function_counters", "pg_stat_reset_slru",
+	"pg_stat_reset_replication_slot", "pg_stat_reset_subscription_stats", "pg_stat_statements_reset",
+	// replication slots hold back the WAL of the whole server
+	"pg_create_physical_replication_slot", "pg_create_logical_replication_slot", "pg_drop_replication_slot",
+	"pg_copy_physical_replication_slot", "pg_copy_logical_replication_slot", "pg_replication_slot_advance",
+	"pg_logical_slot_get_changes", "pg_logical_slot_peek_changes", "pg_logical_slot_get_binary_changes",
+	"pg_logical_slot_peek_binary_changes", "pg_logical_emit_message",
+	// server file access
+	"pg_read_file", "pg_read_binary_file", "pg_ls_dir", "pg_stat_file", "pg_file_write",
+	"pg_ls_logdir", "pg_ls_waldir", "pg_ls_tmpdir", "pg_ls_archive_statusdir", "pg_ls_logicalsnapdir",
+	"pg_ls_logicalmapdir", "pg_ls_replslotdir", "lo_import", "lo_export",
+	// functions running the SQL text they are given would escape the checks of the statement
+	"query_to_xml", "query_to_xml_and_xmlschema", "query_to_xmlschema", "cursor_to_xml",
+	"dblink", "dblink_exec", "dblink_open", "dblink_send_query", "dblink_connect", "dblink_connect_u",
+}
+
+// ParsedStatement is a single statement of a SQL script parsed with the PostgreSQL grammar
+type ParsedStatement struct {
+	SQL    string
+	Node   *pg_query.Node
+	Offset int // byte offset of the statement in the script
+	Line   int
+	Column int
+}
+
+// SQLSyntaxError is returned when a script is rejected by the PostgreSQL parser
+type SQLSyntaxError struct {
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+func (e *SQLSyntaxError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("syntax error: %s", e.Message)
+	}
+	return fmt.Sprintf("syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ParseSQLStatements splits a script into its statements using the real PostgreSQL parser,
+// so semicolons inside strings, dollar quoted bodies and comments are handled correctly
+func ParseSQLStatements(script string) ([]ParsedStatement, error) {
+	tree, err := pg_query.Parse(script)
+	if err != nil {
+		var parseErr *pgparser.Error
+		if errors.As(err, &parseErr) {
+			syntaxErr := &SQLSyntaxError{Message: parseErr.Message}
+			if parseErr.Cursorpos > 0 {
+				// the cursor position is counted in characters starting at 1
+				syntaxErr.Line, syntaxErr.Column = linePosition(script, byteOffset(script, parseErr.Cursorpos-1))
+			}
+			return nil, syntaxErr
+		}
+		return nil, err
+	}
+
+	statements := make([]ParsedStatement, 0, len(tree.Stmts))
+	for _, raw := range tree.Stmts {
+		start := int(raw.StmtLocation)
+		end := len(script)
+		if raw.StmtLen > 0 {
+			end = start + int(raw.StmtLen)
+		}
+		text := strings.TrimSpace(script[start:end])
+		// the statement location includes the leading whitespace and comments
+		offset := start + strings.Index(script[start:end], text)
+		line, column := linePosition(script, offset)
+		statements = append(statements, ParsedStatement{
+			SQL:    text,
+			Node:   raw.Stmt,
+			Offset: offset,
+			Line:   line,
+			Column: column,
+		})
+	}
+	return statements, nil
+}
+
+// ScriptPosition returns the line and column in the script of a character position of the statement,
+// counted from 1 like the positions of the errors reported by PostgreSQL
+func (s ParsedStatement) ScriptPosition(script string, position int) (int, int) {
+	return linePosition(script, s.Offset+byteOffset(s.SQL, position-1))
+}
+
+// byteOffset converts a character position into a byte offset
+func byteOffset(s string, chars int) int {
+	offset := 0
+	for i := 0; i < chars && offset < len(s); i++ {
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// linePosition returns the 1-based line and column of a byte offset
+func linePosition(s string, offset int) (int, int) {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	before := s[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+	return line, column
+}
+
+// WalkParseTree visits every message of a parse tree depth first until visit returns false
+func WalkParseTree(message protoreflect.Message, visit func(protoreflect.ProtoMessage) bool) bool {
+	if !visit(message.Interface()) {
+		return false
+	}
+	keepWalking := true
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if field.Message() == nil || field.IsMap() {
+			return true
+		}
+		if field.IsList() {
+			list := value.List()
+			for i := 0; i < list.Len() && keepWalking; i++ {
+				keepWalking = WalkParseTree(list.Get(i).Message(), visit)
+			}
+			return keepWalking
+		}
+		keepWalking = WalkParseTree(value.Message(), visit)
+		return keepWalking
+	})
+	return keepWalking
+}
+
+// InnerNode returns the statement or expression wrapped by a pg_query.Node
+func InnerNode(node *pg_query.Node) protoreflect.ProtoMessage {
+	message := node.ProtoReflect()
+	field := message.WhichOneof(message.Descriptor().Oneofs().ByName("node"))
+	if field == nil {
+		return nil
+	}
+	return message.Get(field).Message().Interface()
+}
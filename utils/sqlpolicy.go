@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtectedSchemas can't be referenced by the SQL a project runs with the privileges of the platform,
+// the platform keeps its own objects in the internal schema. an unqualified pg_ relation is in pg_catalog
+var ProtectedSchemas = []string{InternalSchema, "pg_catalog", "pg_toast", "information_schema"}
+
+// SafeSettings are the settings the SQL of a project can change, in a statement or in the SET
+// clause of a function
+var SafeSettings = []string{
+	"search_path", "statement_timeout", "lock_timeout", "idle_in_transaction_session_timeout",
+	"client_min_messages", "timezone", "datestyle", "check_function_bodies", "constraints", "transaction",
+}
+
+// FunctionLanguages are the languages of the functions a project can create, their bodies can be
+// checked like any other statement
+var FunctionLanguages = []string{"sql", "plpgsql"}
+
+// CheckUnsafeReferences rejects a statement or an expression calling an unsafe function or referencing
+// the internal schema, for the SQL of a project the platform runs without a policy of its own
+func CheckUnsafeReferences(node *pg_query.Node) error {
+	var err error
+	WalkParseTree(node.ProtoReflect(), func(message protoreflect.ProtoMessage) bool {
+		switch n := message.(type) {
+		case *pg_query.RangeVar:
+			err = checkSchema(n.Schemaname, InternalSchema)
+		case *pg_query.FuncCall:
+			err = checkFunctionCall(n.Funcname)
+		case *pg_query.TypeName:
+			err = checkQualifiedName(n.Names, InternalSchema)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// CheckReferences rejects a statement referencing a protected schema, calling an unsafe function,
+// changing a setting other than the safe ones or changing the owner of an object
+func CheckReferences(node *pg_query.Node) error {
+	var err error
+	WalkParseTree(node.ProtoReflect(), func(message protoreflect.ProtoMessage) bool {
+		switch n := message.(type) {
+		case *pg_query.RangeVar:
+			schema := n.Schemaname
+			if schema == "" && strings.HasPrefix(strings.ToLower(n.Relname), "pg_") {
+				schema = "pg_catalog"
+			}
+			err = checkSchema(schema, ProtectedSchemas...)
+		case *pg_query.FuncCall:
+			err = checkFunctionCall(n.Funcname)
+		case *pg_query.CreateTrigStmt:
+			err = checkFunctionCall(n.Funcname)
+		case *pg_query.TypeName:
+			// the built-in types are qualified with pg_catalog by the parser
+			err = checkQualifiedName(n.Names, InternalSchema)
+		case *pg_query.ObjectWithArgs:
+			err = checkQualifiedName(n.Objname, ProtectedSchemas...)
+		case *pg_query.List:
+			// the qualified names of the objects of DROP and COMMENT statements
+			err = checkQualifiedName(n.Items, ProtectedSchemas...)
+		case *pg_query.CreateFunctionStmt:
+			err = checkQualifiedName(n.Funcname, ProtectedSchemas...)
+		case *pg_query.CreateEnumStmt:
+			err = checkQualifiedName(n.TypeName, ProtectedSchemas...)
+		case *pg_query.CreateDomainStmt:
+			err = checkQualifiedName(n.Domainname, ProtectedSchemas...)
+		case *pg_query.CreateRangeStmt:
+			err = checkQualifiedName(n.TypeName, ProtectedSchemas...)
+		case *pg_query.VariableSetStmt:
+			// the SET clauses of functions are checked too
+			if n.Name != "" && !slices.Contains(SafeSettings, strings.ToLower(n.Name)) {
+				err = fmt.Errorf("setting %s can't be changed", n.Name)
+			}
+		case *pg_query.CreateSchemaStmt:
+			err = checkSchema(n.Schemaname, ProtectedSchemas...)
+			if n.Authrole != nil {
+				err = errors.New("the owner of a schema can't be set")
+			}
+		case *pg_query.AlterObjectSchemaStmt:
+			err = checkSchema(n.Newschema, ProtectedSchemas...)
+		case *pg_query.RenameStmt:
+			if n.RenameType == pg_query.ObjectType_OBJECT_SCHEMA {
+				err = errors.Join(checkSchema(n.Subname, ProtectedSchemas...), checkSchema(n.Newname, ProtectedSchemas...))
+			}
+		case *pg_query.DropStmt:
+			if n.RemoveType == pg_query.ObjectType_OBJECT_SCHEMA {
+				for _, object := range n.Objects {
+					if err = checkSchema(object.GetString_().GetSval(), ProtectedSchemas...); err != nil {
+						break
+					}
+				}
+			}
+		case *pg_query.AlterTableCmd:
+			if n.Subtype == pg_query.AlterTableType_AT_ChangeOwner {
+				err = errors.New("the owner of a table can't be changed")
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// checkSchema rejects a schema that is one of the given schemas
+func checkSchema(schema string, schemas ...string) error {
+	if slices.Contains(schemas, strings.ToLower(schema)) {
+		return fmt.Errorf("schema %s can't be referenced", schema)
+	}
+	return nil
+}
+
+// checkQualifiedName rejects a qualified name in one of the given schemas
+func checkQualifiedName(names []*pg_query.Node, schemas ...string) error {
+	if len(names) < 2 {
+		return nil
+	}
+	return checkSchema(names[0].GetString_().GetSval(), schemas...)
+}
+
+// checkFunctionCall rejects the unsafe functions and the functions of the internal schema, the
+// built-in functions called with a special syntax such as EXTRACT are qualified with pg_catalog
+func checkFunctionCall(funcname []*pg_query.Node) error {
+	if len(funcname) == 0 {
+		return nil
+	}
+	function := strings.ToLower(funcname[len(funcname)-1].GetString_().GetSval())
+	if slices.Contains(UnsafeFunctions, function) {
+		return fmt.Errorf("function %s is not allowed", function)
+	}
+	return checkQualifiedName(funcname, InternalSchema)
+}
+
+// CheckFunctionBody checks the statements of the body of a function with checkStatement. a function
+// created on the admin connection could otherwise run what its creator can't, so only SQL and
+// PL/pgSQL functions without dynamic SQL that run with the privileges of their caller are allowed
+func CheckFunctionBody(stmt *pg_query.CreateFunctionStmt, sql string, checkStatement func(ParsedStatement) error) error {
+	language, body := "sql", ""
+	for _, option := range stmt.Options {
+		def := option.GetDefElem()
+		if def == nil {
+			continue
+		}
+		switch def.Defname {
+		case "language":
+			language = strings.ToLower(def.Arg.GetString_().GetSval())
+		case "as":
+			if items := def.Arg.GetList().GetItems(); len(items) > 0 {
+				body = items[0].GetString_().GetSval()
+			}
+		case "security":
+			if def.Arg.GetBoolean().GetBoolval() {
+				return errors.New("SECURITY DEFINER functions can't be created")
+			}
+		}
+	}
+	if !slices.Contains(FunctionLanguages, language) {
+		return fmt.Errorf("functions in %s can't be created", language)
+	}
+
+	checker := bodyChecker{checkStatement: checkStatement}
+	if language == "sql" {
+		// a BEGIN ATOMIC body is part of the parse tree and checked with the statement
+		if body == "" {
+			return nil
+		}
+		return checker.checkStatements(body)
+	}
+
+	tree, err := pg_query.ParsePlPgSqlToJSON(sql)
+	if err != nil {
+		return fmt.Errorf("function body: %w", err)
+	}
+	var functions []any
+	if err := json.Unmarshal([]byte(tree), &functions); err != nil {
+		return err
+	}
+	return checker.checkPlPgSQL(functions)
+}
+
+// bodyChecker checks the statements and expressions run by the body of a function
+type bodyChecker struct {
+	checkStatement func(ParsedStatement) error
+}
+
+// checkPlPgSQL walks the JSON tree of a PL/pgSQL function, rejects its dynamic SQL and checks the
+// statements and expressions it runs
+func (c bodyChecker) checkPlPgSQL(tree any) error {
+	switch n := tree.(type) {
+	case []any:
+		for _, item := range n {
+			if err := c.checkPlPgSQL(item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		for key, value := range n {
+			if strings.HasPrefix(key, "PLpgSQL_stmt_dyn") || key == "dynquery" {
+				return errors.New("functions can't run dynamic SQL (EXECUTE)")
+			}
+			if key == "PLpgSQL_expr" {
+				if err := c.checkPlPgSQLExpr(value); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := c.checkPlPgSQL(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// the parse modes of the expressions of a PL/pgSQL function
+const (
+	plpgsqlStatement  = 0
+	plpgsqlTypeName   = 1
+	plpgsqlExpression = 2
+)
+
+// checkPlPgSQLExpr checks an expression of a PL/pgSQL function. a statement is checked as it is, an
+// expression or the value of an assignment is checked as a SELECT of it
+func (c bodyChecker) checkPlPgSQLExpr(expr any) error {
+	fields, _ := expr.(map[string]any)
+	query, _ := fields["query"].(string)
+	mode, _ := fields["parseMode"].(float64)
+	switch mode {
+	case plpgsqlStatement:
+	case plpgsqlTypeName:
+		query = "SELECT NULL::" + query
+	case plpgsqlExpression:
+		query = "SELECT " + query
+	default:
+		// an assignment target := value
+		_, value, found := strings.Cut(query, ":=")
+		if !found {
+			_, value, _ = strings.Cut(query, "=")
+		}
+		query = "SELECT " + value
+	}
+	return c.checkStatements(query)
+}
+
+// checkStatements checks the statements run by the body of a function
+func (c bodyChecker) checkStatements(body string) error {
+	statements, err := ParseSQLStatements(body)
+	if err != nil {
+		return fmt.Errorf("function body: %w", err)
+	}
+	for _, statement := range statements {
+		if err := c.checkStatement(statement); err != nil {
+			return fmt.Errorf("function body: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// resolves a type name the same way a column definition would, NULL when the type doesn't exist
+const resolveTypeQuery = `SELECT to_regtype($1) IS NOT NULL`
+
+// serial types are only a shorthand of CREATE TABLE, they aren't registered in the catalog
+var serialTypes = map[string]bool{
+	"SMALLSERIAL": true, "SERIAL": true, "BIGSERIAL": true,
+	"SERIAL2": true, "SERIAL4": true, "SERIAL8": true,
+}
+
+// UnknownTypeError is returned when a column uses a type that doesn't exist in the project database
+type UnknownTypeError struct {
+	Column string
+	Type   string
+}
+
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("column %s has unknown type %s", e.Column, e.Type)
+}
+
+// TypeExists resolves a type name against the built-in types and the types of the database visible
+// on the search path. a type name that can't be parsed doesn't exist
+func TypeExists(ctx context.Context, db Querier, dataType string) (bool, error) {
+	if strings.TrimSpace(dataType) == "" {
+		return false, nil
+	}
+	if serialTypes[strings.ToUpper(dataType)] {
+		return true, nil
+	}
+
+	var exists bool
+	if err := db.QueryRow(ctx, resolveTypeQuery, dataType).Scan(&exists); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "42")) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to resolve type %s: %w", dataType, err)
+	}
+	return exists, nil
+}
+
+// ValidateColumnTypes checks that the type of every column exists, the types are checked
+// in the form the DDL generators write them
+func ValidateColumnTypes(ctx context.Context, db Querier, columns []TableColumn) error {
+	checked := make(map[string]bool)
+	for _, col := range columns {
+		dataType := formatDataType(col)
+		if checked[dataType] {
+			continue
+		}
+		exists, err := TypeExists(ctx, db, dataType)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return &UnknownTypeError{Column: col.ColumnName, Type: col.DataType}
+		}
+		checked[dataType] = true
+	}
+	return nil
+}
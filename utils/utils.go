@@ -37,6 +37,34 @@ func ResponseHandler(w http.ResponseWriter, r *http.Request, simulatedError api.
 	}
 }
 
+// ParseBoolParameter reads an optional boolean query parameter, a missing parameter is false
+func ParseBoolParameter(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// DatabaseError turns the errors of a statement caused by the request into client errors. conflicts
+// maps the error codes answered with 409 to their message, an empty message keeps the database one.
+// data, syntax and prerequisite errors are answered with 400, the others with message and 500
+func DatabaseError(err error, message string, conflicts map[string]string) api.ApiError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if conflict, ok := conflicts[pgErr.Code]; ok {
+			if conflict == "" {
+				conflict = pgErr.Message
+			}
+			return *api.NewApiError(conflict, http.StatusConflict, errors.New(err.Error()))
+		}
+		if pgErr.Code == "55000" || strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "42") {
+			return *api.NewApiError(pgErr.Message, http.StatusBadRequest, errors.New(err.Error()))
+		}
+	}
+	return *api.NewApiError(message, http.StatusInternalServerError, errors.New(err.Error()))
+}
+
 func GenerateOID() string {
 	return uuid.New().String() // 36 character
 }
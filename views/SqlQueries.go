@@ -0,0 +1,51 @@
+package views
+
+import "DBHS/utils"
+
+const (
+	// an empty $1 lists the views of every user schema
+	SELECT_VIEWS = `
+		SELECT c.relname AS name, n.nspname AS schema, (c.relkind = 'm') AS materialized,
+			pg_get_viewdef(c.oid, true) AS definition,
+			CASE WHEN c.relkind = 'm' THEN c.relispopulated END AS populated
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('v', 'm') AND ` + utils.UserSchemasFilter + ` AND ($1 = '' OR n.nspname = $1)
+		ORDER BY n.nspname, c.relname`
+
+	SELECT_VIEW = `
+		SELECT c.relname AS name, n.nspname AS schema, (c.relkind = 'm') AS materialized,
+			pg_get_viewdef(c.oid, true) AS definition,
+			CASE WHEN c.relkind = 'm' THEN c.relispopulated END AS populated
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('v', 'm') AND n.nspname = $1 AND c.relname = $2`
+
+	// indexes of a materialized view are recreated when its definition changes
+	SELECT_VIEW_INDEXES = `SELECT indexdef FROM pg_indexes WHERE schemaname = $1 AND tablename = $2`
+
+	CREATE_VIEW              = `CREATE VIEW %s AS %s`
+	REPLACE_VIEW             = `CREATE OR REPLACE VIEW %s AS %s`
+	CREATE_MATERIALIZED_VIEW = `CREATE MATERIALIZED VIEW %s AS %s WITH %s`
+	DROP_VIEW                = `DROP VIEW %s %s`
+	DROP_MATERIALIZED_VIEW   = `DROP MATERIALIZED VIEW %s %s`
+	REFRESH_VIEW             = `REFRESH MATERIALIZED VIEW %s%s`
+
+	// refresh schedules live in the service database so the worker doesn't have to visit every project
+	SELECT_PROJECT_SCHEDULES = `
+		SELECT schema_name, view_name, schedule, concurrently, last_refreshed_at, last_error
+		FROM "view_refresh_schedules" WHERE project_id = $1`
+
+	SELECT_SCHEDULES = `
+		SELECT s.id, s.schema_name, s.view_name, s.schedule, s.concurrently, s.last_refreshed_at, s.created_at,
+			p.oid AS project_oid, p.owner_id
+		FROM "view_refresh_schedules" s JOIN "projects" p ON p.id = s.project_id`
+
+	UPSERT_SCHEDULE = `
+		INSERT INTO "view_refresh_schedules" (project_id, schema_name, view_name, schedule, concurrently)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (project_id, schema_name, view_name)
+		DO UPDATE SET schedule = EXCLUDED.schedule, concurrently = EXCLUDED.concurrently`
+
+	DELETE_SCHEDULE = `DELETE FROM "view_refresh_schedules" WHERE project_id = $1 AND schema_name = $2 AND view_name = $3`
+
+	UPDATE_SCHEDULE_RUN = `UPDATE "view_refresh_schedules" SET last_refreshed_at = now(), last_error = $2 WHERE id = $1`
+)
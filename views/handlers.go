@@ -0,0 +1,291 @@
+package views
+
+import (
+	"DBHS/config"
+	"DBHS/response"
+	"DBHS/tables"
+	"DBHS/utils"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ListViewsHandler godoc
+// @Summary List views
+// @Description List the views and materialized views of the project database with their definitions and refresh schedules
+// @Tags views
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param schema query string false "Only list the views of this schema"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=[]View} "Views retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/views [get]
+func ListViewsHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		views, apiErr := ListViews(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"))
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "Views retrieved successfully", views)
+	}
+}
+
+// CreateViewHandler godoc
+// @Summary Create a view
+// @Description Create a view or a materialized view. The definition must be a single SELECT statement, unqualified names in it resolve in the schema of the view. Materialized views can be given a cron refresh schedule
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param view body CreateViewRequest true "View definition"
+// @Security BearerAuth
+// @Success 201 {object} response.SuccessResponse "View created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid definition or schedule"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project not found"
+// @Failure 409 {object} response.ErrorResponse "A relation with the same name already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/views [post]
+func CreateViewHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid := mux.Vars(r)["project_id"]
+		if projectOid == "" {
+			response.BadRequest(w, r, "Project Id is required", nil)
+			return
+		}
+
+		var request CreateViewRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+
+		if apiErr := CreateView(r.Context(), config.DB, projectOid, &request); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to create view:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.Created(w, r, "View created successfully", map[string]string{
+			"schema": request.Schema,
+			"name":   request.Name,
+		})
+	}
+}
+
+// GetViewHandler godoc
+// @Summary Get a view
+// @Description Get the definition and refresh schedule of a view
+// @Tags views
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param view_name path string true "View name"
+// @Param schema query string false "Schema of the view, defaults to public"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=View} "View retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or view not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/views/{view_name} [get]
+func GetViewHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, viewName, ok := viewVariables(w, r)
+		if !ok {
+			return
+		}
+
+		view, apiErr := GetProjectView(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"), viewName)
+		if apiErr.Error() != nil {
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "View retrieved successfully", view)
+	}
+}
+
+// UpdateViewHandler godoc
+// @Summary Update a view
+// @Description Replace the definition of a view and/or the refresh schedule of a materialized view. Materialized views are recreated with their indexes, an empty schedule removes the scheduled refresh
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param view_name path string true "View name"
+// @Param schema query string false "Schema of the view, defaults to public"
+// @Param view body UpdateViewRequest true "New definition and schedule"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "View updated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid definition or schedule"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or view not found"
+// @Failure 409 {object} response.ErrorResponse "Other objects depend on the view"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/views/{view_name} [put]
+func UpdateViewHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, viewName, ok := viewVariables(w, r)
+		if !ok {
+			return
+		}
+
+		var request UpdateViewRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.BadRequest(w, r, "Invalid request body", err)
+			return
+		}
+		if request.Definition == "" && request.Refresh == nil {
+			response.BadRequest(w, r, "A definition or a refresh schedule is required", nil)
+			return
+		}
+
+		if apiErr := UpdateView(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"), viewName, &request); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to update view:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "View updated successfully", nil)
+	}
+}
+
+// DropViewHandler godoc
+// @Summary Drop a view
+// @Description Drop a view or materialized view together with its refresh schedule
+// @Tags views
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param view_name path string true "View name"
+// @Param schema query string false "Schema of the view, defaults to public"
+// @Param cascade query bool false "Drop the objects that depend on the view as well"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "View dropped successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or view not found"
+// @Failure 409 {object} response.ErrorResponse "Other objects depend on the view"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/views/{view_name} [delete]
+func DropViewHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, viewName, ok := viewVariables(w, r)
+		if !ok {
+			return
+		}
+
+		cascade, err := utils.ParseBoolParameter(r, "cascade")
+		if err != nil {
+			response.BadRequest(w, r, "cascade must be a boolean", err)
+			return
+		}
+
+		if apiErr := DropView(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"), viewName, cascade); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to drop view:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "View dropped successfully", nil)
+	}
+}
+
+// RefreshViewHandler godoc
+// @Summary Refresh a materialized view
+// @Description Refresh the data of a materialized view. A concurrent refresh doesn't lock out readers but needs a unique index on the view and a populated view
+// @Tags views
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param view_name path string true "View name"
+// @Param schema query string false "Schema of the view, defaults to public"
+// @Param concurrently query bool false "Refresh without blocking reads"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse "View refreshed successfully"
+// @Failure 400 {object} response.ErrorResponse "Not a materialized view or concurrent refresh not possible"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or view not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/views/{view_name}/refresh [post]
+func RefreshViewHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, viewName, ok := viewVariables(w, r)
+		if !ok {
+			return
+		}
+
+		concurrently, err := utils.ParseBoolParameter(r, "concurrently")
+		if err != nil {
+			response.BadRequest(w, r, "concurrently must be a boolean", err)
+			return
+		}
+
+		if apiErr := RefreshView(r.Context(), config.DB, projectOid, r.URL.Query().Get("schema"), viewName, concurrently); apiErr.Error() != nil {
+			app.ErrorLog.Println("Failed to refresh view:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "View refreshed successfully", nil)
+	}
+}
+
+// ReadViewHandler godoc
+// @Summary Read the rows of a view
+// @Description Read the rows of a view or materialized view with the same pagination, ordering and filters as the tables API
+// @Tags views
+// @Produce json
+// @Param project_id path string true "Project ID"
+// @Param view_name path string true "View name"
+// @Param schema query string false "Schema of the view, defaults to public"
+// @Param page query int true "Page number"
+// @Param limit query int true "Rows per page"
+// @Param order query string false "Sort order example: ?order=id:asc&order=name:desc"
+// @Param filter query string false "Filter condition example: ?filter=id:gt:2"
+// @Security BearerAuth
+// @Success 200 {object} response.SuccessResponse{data=tables.Data} "View read successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid parameters or unpopulated materialized view"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Project or view not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /projects/{project_id}/views/{view_name}/rows [get]
+func ReadViewHandler(app *config.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectOid, viewName, ok := viewVariables(w, r)
+		if !ok {
+			return
+		}
+
+		parameters := r.URL.Query()
+		if parameters["page"] == nil || parameters["limit"] == nil {
+			response.BadRequest(w, r, "Page and Limit are required", nil)
+			return
+		}
+		if err := tables.CheckForNonNegativeNumber(parameters["page"][0]); err != nil {
+			response.BadRequest(w, r, "enter a valid page number", nil)
+			return
+		}
+		if err := tables.CheckForNonNegativeNumber(parameters["limit"][0]); err != nil {
+			response.BadRequest(w, r, "enter a valid limit number", nil)
+			return
+		}
+
+		data, apiErr := ReadView(r.Context(), config.DB, projectOid, parameters.Get("schema"), viewName, parameters)
+		if apiErr.Error() != nil {
+			app.ErrorLog.Println("Could not read view:", apiErr.Error())
+			utils.ResponseHandler(w, r, apiErr)
+			return
+		}
+
+		response.OK(w, r, "View read successfully", data)
+	}
+}
@@ -0,0 +1,62 @@
+package views
+
+import "time"
+
+// View is a view or materialized view of a project database
+type View struct {
+	Name         string `json:"name" db:"name"`
+	Schema       string `json:"schema" db:"schema"`
+	Materialized bool   `json:"materialized" db:"materialized"`
+	Definition   string `json:"definition" db:"definition"`
+	// Populated is only set for materialized views, an unpopulated view can't be read until it is refreshed
+	Populated *bool            `json:"populated,omitempty" db:"populated"`
+	Refresh   *RefreshSchedule `json:"refresh,omitempty" db:"-"`
+}
+
+// RefreshSchedule is the scheduled refresh of a materialized view, kept in the service database
+type RefreshSchedule struct {
+	Schedule        string     `json:"schedule" db:"schedule"`
+	Concurrently    bool       `json:"concurrently" db:"concurrently"`
+	LastRefreshedAt *time.Time `json:"last_refreshed_at" db:"last_refreshed_at"`
+	LastError       *string    `json:"last_error,omitempty" db:"last_error"`
+}
+
+// ScheduledRefresh is a refresh schedule together with the project it belongs to
+type ScheduledRefresh struct {
+	ID              int64      `db:"id"`
+	SchemaName      string     `db:"schema_name"`
+	ViewName        string     `db:"view_name"`
+	Schedule        string     `db:"schedule"`
+	Concurrently    bool       `db:"concurrently"`
+	LastRefreshedAt *time.Time `db:"last_refreshed_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	ProjectOid      string     `db:"project_oid"`
+	OwnerID         int64      `db:"owner_id"`
+}
+
+// viewKey identifies a view of a project database
+type viewKey struct {
+	schema string
+	name   string
+}
+
+type ScheduleRequest struct {
+	// Schedule is a standard 5 field cron expression, an empty schedule removes the scheduled refresh
+	Schedule     string `json:"schedule"`
+	Concurrently bool   `json:"concurrently"`
+}
+
+type CreateViewRequest struct {
+	Name         string `json:"name"`
+	Schema       string `json:"schema"`
+	Definition   string `json:"definition"`
+	Materialized bool   `json:"materialized"`
+	// WithData populates a materialized view when it is created, it defaults to true
+	WithData *bool            `json:"with_data,omitempty"`
+	Refresh  *ScheduleRequest `json:"refresh,omitempty"`
+}
+
+type UpdateViewRequest struct {
+	Definition string           `json:"definition,omitempty"`
+	Refresh    *ScheduleRequest `json:"refresh,omitempty"`
+}
@@ -0,0 +1,87 @@
+package views
+
+import (
+	"DBHS/utils"
+	"context"
+	"errors"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+func GetViews(ctx context.Context, db utils.Querier, schema string) ([]View, error) {
+	var views []View
+	if err := pgxscan.Select(ctx, db, &views, SELECT_VIEWS, schema); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// GetView returns nil when the view doesn't exist
+func GetView(ctx context.Context, db utils.Querier, schema, name string) (*View, error) {
+	var view View
+	if err := pgxscan.Get(ctx, db, &view, SELECT_VIEW, schema, name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &view, nil
+}
+
+func GetViewIndexes(ctx context.Context, db utils.Querier, schema, name string) ([]string, error) {
+	var indexes []string
+	if err := pgxscan.Select(ctx, db, &indexes, SELECT_VIEW_INDEXES, schema, name); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
+
+// GetProjectSchedules returns the refresh schedules of a project keyed by schema and view name
+func GetProjectSchedules(ctx context.Context, db utils.Querier, projectID int64) (map[viewKey]*RefreshSchedule, error) {
+	rows, err := db.Query(ctx, SELECT_PROJECT_SCHEDULES, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make(map[viewKey]*RefreshSchedule)
+	for rows.Next() {
+		var schema, name string
+		schedule := &RefreshSchedule{}
+		if err := rows.Scan(&schema, &name, &schedule.Schedule, &schedule.Concurrently, &schedule.LastRefreshedAt, &schedule.LastError); err != nil {
+			return nil, err
+		}
+		schedules[viewKey{schema, name}] = schedule
+	}
+	return schedules, rows.Err()
+}
+
+func GetSchedules(ctx context.Context, db utils.Querier) ([]ScheduledRefresh, error) {
+	var schedules []ScheduledRefresh
+	if err := pgxscan.Select(ctx, db, &schedules, SELECT_SCHEDULES); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func UpsertSchedule(ctx context.Context, db utils.Querier, projectID int64, schema, name string, schedule *ScheduleRequest) error {
+	_, err := db.Exec(ctx, UPSERT_SCHEDULE, projectID, schema, name, schedule.Schedule, schedule.Concurrently)
+	return err
+}
+
+func DeleteSchedule(ctx context.Context, db utils.Querier, projectID int64, schema, name string) error {
+	_, err := db.Exec(ctx, DELETE_SCHEDULE, projectID, schema, name)
+	return err
+}
+
+// RecordScheduledRun stores the outcome of a scheduled refresh, a nil error clears the last error
+func RecordScheduledRun(ctx context.Context, db utils.Querier, id int64, runErr error) error {
+	var lastError *string
+	if runErr != nil {
+		message := runErr.Error()
+		lastError = &message
+	}
+	_, err := db.Exec(ctx, UPDATE_SCHEDULE_RUN, id, lastError)
+	return err
+}
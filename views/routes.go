@@ -0,0 +1,31 @@
+package views
+
+import (
+	"DBHS/config"
+	"DBHS/middleware"
+	"net/http"
+)
+
+func DefineURLs() {
+	router := config.Router.PathPrefix("/api/projects/{project_id}/views").Subrouter()
+	router.Use(middleware.JwtAuthMiddleware, middleware.CheckOwnership)
+
+	router.Handle("", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:  ListViewsHandler(config.App),
+		http.MethodPost: CreateViewHandler(config.App),
+	}))
+
+	router.Handle("/{view_name}", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet:    GetViewHandler(config.App),
+		http.MethodPut:    UpdateViewHandler(config.App),
+		http.MethodDelete: DropViewHandler(config.App),
+	}))
+
+	router.Handle("/{view_name}/refresh", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodPost: RefreshViewHandler(config.App),
+	}))
+
+	router.Handle("/{view_name}/rows", middleware.Route(map[string]http.HandlerFunc{
+		http.MethodGet: ReadViewHandler(config.App),
+	}))
+}
@@ -0,0 +1,328 @@
+package views
+
+import (
+	"DBHS/config"
+	"DBHS/tables"
+	"DBHS/utils"
+	api "DBHS/utils/apiError"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	duplicateTableCode   = "42P07"
+	dependentObjectsCode = "2BP01"
+)
+
+// conflicts are the errors of the view statements answered with 409
+var conflicts = map[string]string{
+	duplicateTableCode:   "A relation with the same name already exists",
+	dependentObjectsCode: "Other objects depend on the view, use cascade to drop them",
+}
+
+func ListViews(ctx context.Context, db *pgxpool.Pool, projectOid string, schema string) ([]View, api.ApiError) {
+	projectID, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	views, err := GetViews(ctx, userDb, schema)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	schedules, err := GetProjectSchedules(ctx, db, projectID)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if views == nil {
+		views = make([]View, 0)
+	}
+	for i := range views {
+		views[i].Refresh = schedules[viewKey{views[i].Schema, views[i].Name}]
+	}
+	return views, api.ApiError{}
+}
+
+func GetProjectView(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string) (*View, api.ApiError) {
+	projectID, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	view, apiErr := findView(ctx, userDb, schema, name)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+
+	if view.Materialized {
+		schedules, err := GetProjectSchedules(ctx, db, projectID)
+		if err != nil {
+			return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		}
+		view.Refresh = schedules[viewKey{view.Schema, view.Name}]
+	}
+	return view, api.ApiError{}
+}
+
+func CreateView(ctx context.Context, db *pgxpool.Pool, projectOid string, request *CreateViewRequest) api.ApiError {
+	request.Schema = utils.SchemaOrDefault(request.Schema)
+	if request.Name == "" {
+		return *api.NewApiError("View name is required", 400, errors.New("view name is required"))
+	}
+	if utils.IsReservedSchema(request.Schema) {
+		return *api.NewApiError("Schema is reserved", 400, errors.New("schema "+request.Schema+" is reserved"))
+	}
+
+	definition, err := ValidateViewDefinition(request.Definition)
+	if err != nil {
+		return *api.NewApiError(err.Error(), 400, err)
+	}
+
+	if request.Refresh != nil && request.Refresh.Schedule != "" {
+		if !request.Materialized {
+			return *api.NewApiError(ErrNotMaterialized.Error(), 400, ErrNotMaterialized)
+		}
+		if _, err := ParseSchedule(request.Refresh.Schedule); err != nil {
+			return *api.NewApiError(err.Error(), 400, err)
+		}
+	}
+
+	projectID, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	// unqualified names of the definition resolve in the schema of the view
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, request.Schema); err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if _, err := tx.Exec(ctx, GenerateCreateViewQuery(request, definition)); err != nil {
+		return utils.DatabaseError(err, "Failed to create view", conflicts)
+	}
+
+	scheduled := request.Refresh != nil && request.Refresh.Schedule != ""
+	if scheduled {
+		if err := UpsertSchedule(ctx, db, projectID, request.Schema, request.Name, request.Refresh); err != nil {
+			return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		if scheduled {
+			DeleteSchedule(ctx, db, projectID, request.Schema, request.Name)
+		}
+		return utils.DatabaseError(err, "Failed to create view", conflicts)
+	}
+
+	config.App.InfoLog.Printf("View %s created in project %s", utils.QualifiedName(request.Schema, request.Name), projectOid)
+	return api.ApiError{}
+}
+
+// UpdateView replaces the definition and/or the refresh schedule of a view.
+// materialized views can't be replaced in place so they are recreated together with their indexes
+func UpdateView(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string, request *UpdateViewRequest) api.ApiError {
+	var definition string
+	if request.Definition != "" {
+		var err error
+		if definition, err = ValidateViewDefinition(request.Definition); err != nil {
+			return *api.NewApiError(err.Error(), 400, err)
+		}
+	}
+	if request.Refresh != nil && request.Refresh.Schedule != "" {
+		if _, err := ParseSchedule(request.Refresh.Schedule); err != nil {
+			return *api.NewApiError(err.Error(), 400, err)
+		}
+	}
+
+	projectID, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	view, apiErr := findView(ctx, userDb, schema, name)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	if request.Refresh != nil && !view.Materialized {
+		return *api.NewApiError(ErrNotMaterialized.Error(), 400, ErrNotMaterialized)
+	}
+
+	if definition != "" {
+		if apiErr := replaceDefinition(ctx, userDb, view, definition); apiErr.Error() != nil {
+			return apiErr
+		}
+	}
+
+	if request.Refresh != nil {
+		var err error
+		if request.Refresh.Schedule == "" {
+			err = DeleteSchedule(ctx, db, projectID, view.Schema, view.Name)
+		} else {
+			err = UpsertSchedule(ctx, db, projectID, view.Schema, view.Name, request.Refresh)
+		}
+		if err != nil {
+			return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+		}
+	}
+
+	config.App.InfoLog.Printf("View %s updated in project %s", utils.QualifiedName(view.Schema, view.Name), projectOid)
+	return api.ApiError{}
+}
+
+func replaceDefinition(ctx context.Context, userDb *pgxpool.Pool, view *View, definition string) api.ApiError {
+	tx, err := userDb.Begin(ctx)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, view.Schema); err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if !view.Materialized {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(REPLACE_VIEW, utils.QualifiedName(view.Schema, view.Name), definition)); err != nil {
+			return utils.DatabaseError(err, "Failed to update view", conflicts)
+		}
+		return commit(ctx, tx, "Failed to update view")
+	}
+
+	indexes, err := GetViewIndexes(ctx, tx, view.Schema, view.Name)
+	if err != nil {
+		return *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	if _, err := tx.Exec(ctx, GenerateDropViewQuery(view, false)); err != nil {
+		return utils.DatabaseError(err, "Failed to update view", conflicts)
+	}
+
+	// keep the populated state of the view, an unpopulated view stays unpopulated
+	withData := view.Populated == nil || *view.Populated
+	create := &CreateViewRequest{Name: view.Name, Schema: view.Schema, Materialized: true, WithData: &withData}
+	if _, err := tx.Exec(ctx, GenerateCreateViewQuery(create, definition)); err != nil {
+		return utils.DatabaseError(err, "Failed to update view", conflicts)
+	}
+
+	for _, index := range indexes {
+		if _, err := tx.Exec(ctx, index); err != nil {
+			return utils.DatabaseError(err, "Failed to recreate the indexes of the view", conflicts)
+		}
+	}
+
+	return commit(ctx, tx, "Failed to update view")
+}
+
+func DropView(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string, cascade bool) api.ApiError {
+	projectID, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	view, apiErr := findView(ctx, userDb, schema, name)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+
+	if _, err := userDb.Exec(ctx, GenerateDropViewQuery(view, cascade)); err != nil {
+		return utils.DatabaseError(err, "Failed to drop view", conflicts)
+	}
+
+	if err := DeleteSchedule(ctx, db, projectID, view.Schema, view.Name); err != nil {
+		config.App.ErrorLog.Println("Failed to delete the refresh schedule of a dropped view:", err)
+	}
+
+	config.App.InfoLog.Printf("View %s dropped in project %s", utils.QualifiedName(view.Schema, view.Name), projectOid)
+	return api.ApiError{}
+}
+
+// RefreshView refreshes a materialized view, a concurrent refresh doesn't block readers
+// but needs a unique index on the view and a populated view
+func RefreshView(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string, concurrently bool) api.ApiError {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	defer userDb.Close()
+
+	view, apiErr := findView(ctx, userDb, schema, name)
+	if apiErr.Error() != nil {
+		return apiErr
+	}
+	if !view.Materialized {
+		return *api.NewApiError(ErrNotMaterialized.Error(), 400, ErrNotMaterialized)
+	}
+
+	if _, err := userDb.Exec(ctx, GenerateRefreshViewQuery(view.Schema, view.Name, concurrently)); err != nil {
+		return utils.DatabaseError(err, "Failed to refresh view", conflicts)
+	}
+	return api.ApiError{}
+}
+
+// ReadView reads the rows of a view with the pagination, ordering and filters of the tables API
+func ReadView(ctx context.Context, db *pgxpool.Pool, projectOid string, schema, name string, parameters map[string][]string) (*tables.Data, api.ApiError) {
+	_, userDb, apiErr := utils.ProjectDatabase(ctx, db, projectOid)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+	defer userDb.Close()
+
+	view, apiErr := findView(ctx, userDb, schema, name)
+	if apiErr.Error() != nil {
+		return nil, apiErr
+	}
+
+	tx, err := userDb.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	defer tx.Rollback(ctx)
+
+	if err := utils.SetSearchPath(ctx, tx, view.Schema); err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+
+	data, err := tables.ReadTableData(ctx, view.Name, parameters, tx)
+	if err != nil {
+		return nil, utils.DatabaseError(err, "Could not read view", conflicts)
+	}
+	if data.Rows == nil {
+		data.Rows = make([]map[string]interface{}, 0)
+	}
+	return data, api.ApiError{}
+}
+
+func findView(ctx context.Context, userDb *pgxpool.Pool, schema, name string) (*View, api.ApiError) {
+	view, err := GetView(ctx, userDb, utils.SchemaOrDefault(schema), name)
+	if err != nil {
+		return nil, *api.NewApiError("Internal server error", 500, errors.New(err.Error()))
+	}
+	if view == nil {
+		return nil, *api.NewApiError("View not found", 404, errors.New("view "+name+" does not exist"))
+	}
+	return view, api.ApiError{}
+}
+
+func commit(ctx context.Context, tx pgx.Tx, message string) api.ApiError {
+	if err := tx.Commit(ctx); err != nil {
+		return utils.DatabaseError(err, message, conflicts)
+	}
+	return api.ApiError{}
+}
@@ -0,0 +1,108 @@
+package views
+
+import (
+	"DBHS/response"
+	"DBHS/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrNotSelect       = errors.New("a view definition must be a single SELECT statement")
+	ErrSelectInto      = errors.New("SELECT INTO is not allowed in a view definition")
+	ErrNotMaterialized = errors.New("only materialized views can be refreshed")
+)
+
+// ValidateViewDefinition parses a view definition and returns the statement without its
+// trailing semicolon, only a single SELECT (including VALUES and set operations) is accepted.
+// views are created and refreshed on the admin connection, so the unsafe functions and the
+// internal schema can't be referenced
+func ValidateViewDefinition(definition string) (string, error) {
+	statements, err := utils.ParseSQLStatements(definition)
+	if err != nil {
+		return "", err
+	}
+	if len(statements) != 1 {
+		return "", ErrNotSelect
+	}
+	selectStmt := statements[0].Node.GetSelectStmt()
+	if selectStmt == nil {
+		return "", ErrNotSelect
+	}
+	if selectStmt.IntoClause != nil {
+		return "", ErrSelectInto
+	}
+	if err := utils.CheckUnsafeReferences(statements[0].Node); err != nil {
+		return "", err
+	}
+	return statements[0].SQL, nil
+}
+
+// ParseSchedule parses a standard 5 field cron expression
+func ParseSchedule(schedule string) (cron.Schedule, error) {
+	parsed, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh schedule: %w", err)
+	}
+	return parsed, nil
+}
+
+// IsRefreshDue reports whether a scheduled refresh should run at now, a view that was never
+// refreshed counts from the time its schedule was created
+func IsRefreshDue(schedule cron.Schedule, lastRefreshedAt *time.Time, createdAt time.Time, now time.Time) bool {
+	last := createdAt
+	if lastRefreshedAt != nil {
+		last = *lastRefreshedAt
+	}
+	return !schedule.Next(last).After(now)
+}
+
+// CREATE [MATERIALIZED] VIEW "schema"."name" AS definition [WITH [NO] DATA]
+func GenerateCreateViewQuery(request *CreateViewRequest, definition string) string {
+	name := utils.QualifiedName(request.Schema, request.Name)
+	if !request.Materialized {
+		return fmt.Sprintf(CREATE_VIEW, name, definition)
+	}
+	data := "DATA"
+	if request.WithData != nil && !*request.WithData {
+		data = "NO DATA"
+	}
+	return fmt.Sprintf(CREATE_MATERIALIZED_VIEW, name, definition, data)
+}
+
+// DROP [MATERIALIZED] VIEW "schema"."name" RESTRICT|CASCADE
+func GenerateDropViewQuery(view *View, cascade bool) string {
+	behavior := "RESTRICT"
+	if cascade {
+		behavior = "CASCADE"
+	}
+	if view.Materialized {
+		return fmt.Sprintf(DROP_MATERIALIZED_VIEW, utils.QualifiedName(view.Schema, view.Name), behavior)
+	}
+	return fmt.Sprintf(DROP_VIEW, utils.QualifiedName(view.Schema, view.Name), behavior)
+}
+
+// REFRESH MATERIALIZED VIEW [CONCURRENTLY] "schema"."name"
+func GenerateRefreshViewQuery(schema, name string, concurrently bool) string {
+	option := ""
+	if concurrently {
+		option = "CONCURRENTLY "
+	}
+	return fmt.Sprintf(REFRESH_VIEW, option, utils.QualifiedName(schema, name))
+}
+
+// viewVariables reads the project and view of the url, it writes the error response when one is missing
+func viewVariables(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	urlVariables := mux.Vars(r)
+	projectOid, viewName := urlVariables["project_id"], urlVariables["view_name"]
+	if projectOid == "" || viewName == "" {
+		response.BadRequest(w, r, "Project Id and view name are required", nil)
+		return "", "", false
+	}
+	return projectOid, viewName, true
+}
@@ -0,0 +1,42 @@
+package workers
+
+import (
+	"DBHS/config"
+	"DBHS/tables"
+	"context"
+	"sync"
+)
+
+// maintaining guards against a slow run overlapping the run of the next hour
+var maintaining sync.Mutex
+
+// MaintainPartitionPolicies creates the upcoming time range partitions and drops the expired ones
+// of every table with a partition policy, it runs every hour and records the outcome on the policy
+func MaintainPartitionPolicies(app *config.Application) {
+	if !maintaining.TryLock() {
+		app.InfoLog.Println("Previous partition maintenance run is still in progress, skipping")
+		return
+	}
+	defer maintaining.Unlock()
+
+	policies, err := tables.GetPartitionPolicies(context.Background(), config.DB)
+	if err != nil {
+		app.ErrorLog.Println("Failed to read partition policies:", err)
+		return
+	}
+
+	for _, policy := range policies {
+		ctx := context.WithValue(context.Background(), "user-id", policy.OwnerID)
+		maintenance, err := tables.RunPartitionPolicy(ctx, &policy, config.DB)
+		if err != nil {
+			app.ErrorLog.Println("Partition maintenance of table", policy.TableName, "in project", policy.ProjectOid, "failed:", err)
+		}
+		if len(maintenance.Created) > 0 || len(maintenance.Dropped) > 0 {
+			app.InfoLog.Println("Partitions of table", policy.TableName, "in project", policy.ProjectOid, "created:", maintenance.Created, "dropped:", maintenance.Dropped)
+		}
+
+		if err := tables.RecordPartitionRun(ctx, policy.ID, err, config.DB); err != nil {
+			app.ErrorLog.Println("Failed to record the partition maintenance of table", policy.TableName, ":", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"DBHS/config"
+	"DBHS/tables"
+	"context"
+	"sync"
+)
+
+// purging guards against a slow run overlapping the next run
+var purging sync.Mutex
+
+// PurgeExpiredRows deletes the expired rows of every table with a ttl. the runs that purged rows
+// or failed are recorded with the number of rows and batches, runs older than 30 days are removed
+func PurgeExpiredRows(app *config.Application) {
+	if !purging.TryLock() {
+		app.InfoLog.Println("Previous ttl purge run is still in progress, skipping")
+		return
+	}
+	defer purging.Unlock()
+
+	ttls, err := tables.GetTableTTLs(context.Background(), config.DB)
+	if err != nil {
+		app.ErrorLog.Println("Failed to read table ttls:", err)
+		return
+	}
+
+	var total int64
+	for _, ttl := range ttls {
+		ctx := context.WithValue(context.Background(), "user-id", ttl.OwnerID)
+		run, err := tables.PurgeTableTTL(ctx, &ttl, config.DB)
+		if err != nil {
+			app.ErrorLog.Println("Purging expired rows of table", ttl.TableName, "in project", ttl.ProjectOid, "failed:", err)
+			message := err.Error()
+			run.Error = &message
+		}
+		if run.RowsPurged == 0 && run.Error == nil {
+			continue
+		}
+		total += run.RowsPurged
+
+		if err := tables.InsertTTLRun(ctx, ttl.TableID, run, config.DB); err != nil {
+			app.ErrorLog.Println("Failed to record the ttl purge of table", ttl.TableName, ":", err)
+		}
+	}
+	if total > 0 {
+		app.InfoLog.Println("Purged", total, "expired rows of the tables with a ttl")
+	}
+
+	if err := tables.DeleteOldTTLRuns(context.Background(), config.DB); err != nil {
+		app.ErrorLog.Println(err)
+	}
+}
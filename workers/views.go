@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"DBHS/config"
+	"DBHS/views"
+	"context"
+	"sync"
+	"time"
+)
+
+// refreshing guards against a slow run overlapping the run of the next minute
+var refreshing sync.Mutex
+
+// RefreshScheduledViews refreshes the materialized views whose refresh schedule is due,
+// it runs every minute and records the outcome of every refresh on its schedule
+func RefreshScheduledViews(app *config.Application) {
+	if !refreshing.TryLock() {
+		app.InfoLog.Println("Previous view refresh run is still in progress, skipping")
+		return
+	}
+	defer refreshing.Unlock()
+
+	schedules, err := views.GetSchedules(context.Background(), config.DB)
+	if err != nil {
+		app.ErrorLog.Println("Failed to read view refresh schedules:", err)
+		return
+	}
+
+	now := time.Now()
+	for _, scheduled := range schedules {
+		schedule, err := views.ParseSchedule(scheduled.Schedule)
+		if err != nil {
+			app.ErrorLog.Println("Invalid refresh schedule of view", scheduled.ViewName, ":", err)
+			continue
+		}
+		if !views.IsRefreshDue(schedule, scheduled.LastRefreshedAt, scheduled.CreatedAt, now) {
+			continue
+		}
+
+		ctx := context.WithValue(context.Background(), "user-id", scheduled.OwnerID)
+		apiErr := views.RefreshView(ctx, config.DB, scheduled.ProjectOid, scheduled.SchemaName, scheduled.ViewName, scheduled.Concurrently)
+		if apiErr.Error() != nil {
+			app.ErrorLog.Println("Scheduled refresh of view", scheduled.ViewName, "in project", scheduled.ProjectOid, "failed:", apiErr.Error())
+		}
+
+		if err := views.RecordScheduledRun(ctx, config.DB, scheduled.ID, apiErr.Error()); err != nil {
+			app.ErrorLog.Println("Failed to record the refresh of view", scheduled.ViewName, ":", err)
+		}
+	}
+}